@@ -101,6 +101,21 @@ func (c *ZeroTwelveUpgradeCommand) Run(args []string) int {
 		}
 	}
 
+	if parsedWith := sources.ClassifyFiles(); len(parsedWith) > 0 {
+		var legacyCount int
+		for _, kind := range parsedWith {
+			if kind == configupgrade.FileParseHCL1Fallback {
+				legacyCount++
+			}
+		}
+		if legacyCount > 0 {
+			c.Ui.Output(fmt.Sprintf(
+				"%d of %d configuration files use the legacy syntax and will be rewritten.",
+				legacyCount, len(parsedWith),
+			))
+		}
+	}
+
 	if !force {
 		// We'll check first if this directory already looks upgraded, so we
 		// don't waste the user's time dealing with an interactive prompt
@@ -0,0 +1,50 @@
+package cliconfig
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform/svchost"
+	svcauth "github.com/hashicorp/terraform/svchost/auth"
+	"github.com/hashicorp/terraform/tfdiags"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// These declarations exist only to make the build fail if one of the
+// signatures documented as this package's stable API, in the package doc
+// comment in cliconfig.go, changes shape. They don't run anything at runtime; a
+// signature change here is the signal, not a test failure message.
+var (
+	_ func() (*Config, tfdiags.Diagnostics) = LoadConfig
+	_ func() *Config                        = EnvConfig
+
+	_ func(*Config, *Config) (*Config, tfdiags.Diagnostics) = (*Config).Merge
+	_ func(*Config) tfdiags.Diagnostics                     = (*Config).Validate
+	_ func(*Config, ...PolicyCheck) tfdiags.Diagnostics     = (*Config).CheckPolicies
+	_ func(*Config, []string) (*CredentialsSource, error)   = (*Config).CredentialsSource
+
+	_ func(*Config) tfdiags.Diagnostics = PolicyCheck(DenyPlaintextTokens)
+	_ func(...string) PolicyCheck       = RequireMirrorForHosts
+	_ func(*Config) tfdiags.Diagnostics = PolicyCheck(ForbidDevOverrides)
+
+	_ func(*CredentialsSource, svchost.Hostname, string) (svcauth.HostCredentials, error) = (*CredentialsSource).ForHost
+	_ func(*CredentialsSource, svchost.Hostname) (cty.Value, bool)                        = (*CredentialsSource).RawValueForHost
+	_ func(*CredentialsSource, svchost.Hostname, svcauth.HostCredentialsWritable) error   = (*CredentialsSource).StoreForHost
+	_ func(*CredentialsSource, svchost.Hostname) error                                    = (*CredentialsSource).ForgetForHost
+	_ func(*CredentialsSource, svchost.Hostname) CredentialsLocation                      = (*CredentialsSource).HostCredentialsLocation
+	_ func(*CredentialsSource) (string, error)                                            = (*CredentialsSource).CredentialsFilePath
+	_ func(*CredentialsSource) string                                                     = (*CredentialsSource).CredentialsHelperType
+)
+
+// TestAPIStability_nilConfig confirms that Validate and DeepCopy tolerate a
+// nil receiver, so that a caller holding a possibly-nil *Config doesn't need
+// a nil check before every call.
+func TestAPIStability_nilConfig(t *testing.T) {
+	var c *Config
+
+	if diags := c.Validate(); diags.HasErrors() {
+		t.Errorf("Validate on a nil *Config returned errors: %s", diags.Err())
+	}
+	if got := c.DeepCopy(); got != nil {
+		t.Errorf("DeepCopy on a nil *Config returned non-nil: %#v", got)
+	}
+}
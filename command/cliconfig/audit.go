@@ -0,0 +1,58 @@
+package cliconfig
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform/svchost"
+	svcauth "github.com/hashicorp/terraform/svchost/auth"
+)
+
+// auditLogRecord is the JSON shape of a single line appended to the file
+// configured by an "audit" block: one record per credentials helper
+// invocation, deliberately carrying nothing about the credential itself.
+type auditLogRecord struct {
+	Time       string `json:"time"`
+	Host       string `json:"host"`
+	Verb       string `json:"verb"`
+	DurationMS int64  `json:"duration_ms"`
+	Success    bool   `json:"success"`
+}
+
+// newAuditLogFunc returns an svcauth.AuditLogFunc that appends one JSON
+// record per call to the file at path, guarded by a mutex since the
+// returned function may be shared across concurrently-running helpers.
+//
+// Opening and writing are best-effort: a problem creating or appending to
+// the file is silently ignored rather than surfaced as an error, since a
+// broken audit log shouldn't be allowed to block the credentials helper
+// invocation it's trying to record.
+func newAuditLogFunc(path string) svcauth.AuditLogFunc {
+	var mu sync.Mutex
+	return func(host svchost.Hostname, verb string, duration time.Duration, success bool) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		enc, err := json.Marshal(auditLogRecord{
+			Time:       time.Now().UTC().Format(time.RFC3339),
+			Host:       host.ForDisplay(),
+			Verb:       verb,
+			DurationMS: duration.Milliseconds(),
+			Success:    success,
+		})
+		if err != nil {
+			return
+		}
+
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			return
+		}
+		defer f.Close()
+
+		enc = append(enc, '\n')
+		f.Write(enc)
+	}
+}
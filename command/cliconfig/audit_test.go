@@ -0,0 +1,54 @@
+package cliconfig
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform/svchost"
+)
+
+func TestNewAuditLogFunc(t *testing.T) {
+	dir, err := ioutil.TempDir("", "terraform-cliconfig-audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "audit.jsonl")
+
+	log := newAuditLogFunc(path)
+	log(svchost.Hostname("example.com"), "get", 12*time.Millisecond, true)
+	log(svchost.Hostname("example.com"), "store", 5*time.Millisecond, false)
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines; want 2\n%s", len(lines), content)
+	}
+
+	if got, want := lines[0], `"host":"example.com"`; !strings.Contains(got, want) {
+		t.Errorf("line 1 %q does not contain %q", got, want)
+	}
+	if got, want := lines[0], `"verb":"get"`; !strings.Contains(got, want) {
+		t.Errorf("line 1 %q does not contain %q", got, want)
+	}
+	if got, want := lines[0], `"success":true`; !strings.Contains(got, want) {
+		t.Errorf("line 1 %q does not contain %q", got, want)
+	}
+	if got, want := lines[1], `"verb":"store"`; !strings.Contains(got, want) {
+		t.Errorf("line 2 %q does not contain %q", got, want)
+	}
+	if got, want := lines[1], `"success":false`; !strings.Contains(got, want) {
+		t.Errorf("line 2 %q does not contain %q", got, want)
+	}
+
+	for i, line := range lines {
+		if strings.Contains(line, "token") || strings.Contains(line, "secret") {
+			t.Errorf("line %d unexpectedly mentions a credential: %s", i, line)
+		}
+	}
+}
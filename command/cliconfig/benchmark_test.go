@@ -0,0 +1,61 @@
+package cliconfig
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// benchConfigDir writes n small, distinct CLI config files into a fresh
+// temporary directory for a benchmark to load repeatedly, returning the
+// directory path. The directory is removed via b.Cleanup when the benchmark
+// finishes.
+func benchConfigDir(b *testing.B, n int) string {
+	b.Helper()
+
+	dir, err := ioutil.TempDir("", "terraform-cliconfig-bench")
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { os.RemoveAll(dir) })
+
+	for i := 0; i < n; i++ {
+		name := filepath.Join(dir, fmt.Sprintf("%04d.tfrc", i))
+		src := fmt.Sprintf(`
+plugin_cache_dir = "/tmp/cache-%d"
+
+host "example-%d.com" {
+  services = {
+    "modules.v1" = "https://example-%d.com/modules/"
+  }
+}
+
+credentials "example-%d.com" {
+  token = "s3kr1t-%d"
+}
+`, i, i, i, i, i)
+		if err := ioutil.WriteFile(name, []byte(src), 0644); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+func benchmarkLoadConfigDir(b *testing.B, n int) {
+	dir := benchConfigDir(b, n)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, diags := loadConfigDir(dir)
+		if diags.HasErrors() {
+			b.Fatalf("unexpected error loading %d config files: %s", n, diags.Err())
+		}
+	}
+}
+
+func BenchmarkLoadConfigDir1(b *testing.B)    { benchmarkLoadConfigDir(b, 1) }
+func BenchmarkLoadConfigDir100(b *testing.B)  { benchmarkLoadConfigDir(b, 100) }
+func BenchmarkLoadConfigDir1000(b *testing.B) { benchmarkLoadConfigDir(b, 1000) }
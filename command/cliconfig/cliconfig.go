@@ -6,6 +6,26 @@
 // variables. The CLI config is not the same thing as a Terraform configuration
 // written in the Terraform language; the logic for those lives in the top-level
 // directory "configs".
+//
+// # Stable API
+//
+// Most of this package's exported surface exists only for Terraform's own
+// commands to call. The following subset is maintained with external callers
+// in mind too -- for example, a wrapper tool that wants to honor the same CLI
+// config a user already has set up for Terraform itself -- and changes to it
+// are made with backward compatibility in mind:
+//
+//   - LoadConfig, LoadConfigFile, and EnvConfig, for obtaining a *Config
+//   - Config, ConfigHost, and ConfigCredentialsHelper, and the exported fields
+//     of each
+//   - (*Config).Merge, (*Config).Validate, and (*Config).CheckPolicies
+//   - PolicyCheck, and the built-in checks DenyPlaintextTokens,
+//     RequireMirrorForHosts, and ForbidDevOverrides
+//   - (*Config).CredentialsSource and the methods of *CredentialsSource
+//
+// Everything else -- including any unexported identifier, and any exported
+// identifier not listed above -- may change shape between releases without
+// that being considered a breaking change.
 package cliconfig
 
 import (
@@ -14,8 +34,15 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
 
+	goversion "github.com/hashicorp/go-version"
 	"github.com/hashicorp/hcl"
+	homedir "github.com/mitchellh/go-homedir"
+	"golang.org/x/crypto/openpgp"
 
 	"github.com/hashicorp/terraform/svchost"
 	"github.com/hashicorp/terraform/tfdiags"
@@ -23,13 +50,101 @@ import (
 
 const pluginCacheDirEnvVar = "TF_PLUGIN_CACHE_DIR"
 
+// pluginTLSVerifyDisableEnvVar must be set, in addition to
+// DisablePluginTLSVerify, before PluginTLSVerifyDisabled will report TLS
+// verification as disabled. See DisablePluginTLSVerify for why both are
+// required.
+const pluginTLSVerifyDisableEnvVar = "TF_DISABLE_PLUGIN_TLS_VERIFY"
+
+// These limits guard against pathological inputs -- a config directory
+// that's accidentally pointed at something huge, or populated by
+// automation that went wrong -- rather than against any normal, hand
+// edited configuration, which will always be orders of magnitude smaller.
+const (
+	// maxConfigFileSize is the largest individual CLI config file we're
+	// willing to read. Legitimate CLI config files are at most a few
+	// kilobytes.
+	maxConfigFileSize = 1 << 20 // 1MiB
+
+	// maxConfigDirFiles is the largest number of matching files we're
+	// willing to read out of a single CLI config directory.
+	maxConfigDirFiles = 1000
+
+	// maxCredentialsBlocks is the largest number of "credentials" blocks
+	// we're willing to act on from a single merged configuration.
+	maxCredentialsBlocks = 1000
+)
+
+// punycodePrefix is the ASCII Compatible Encoding prefix that svchost uses
+// to recognize a label as already being in Punycode form. We only need it
+// here to recognize that specific mistake so NormalizeHostname can explain
+// it more helpfully than svchost's own error message does.
+const punycodePrefix = "xn--"
+
+// defaultCredentialsHost is the special key under which a
+// `credentials "*" { ... }` block is recorded in Config.Credentials. It's
+// not a hostname at all, so it's exempted from NormalizeHostname wherever
+// the Credentials map's keys are otherwise treated as hostnames.
+const defaultCredentialsHost = "*"
+
+// NormalizeHostname validates and normalizes a user-specified service
+// hostname from a "host" or "credentials" block, using the same rules as
+// svchost.ForComparison but with diagnostics worded for the mistakes that
+// are most common in a CLI configuration file: giving a hostname that's
+// already Punycode-encoded instead of the Unicode form Terraform expects,
+// or including a port number on a hostname that's meant to identify a
+// service provider rather than a particular network endpoint.
+//
+// Both loadConfigFile and credentialsSource use this, so that the Hosts
+// and Credentials maps end up keyed consistently by the same normalized
+// form no matter how a hostname was capitalized or Unicode-normalized by
+// whoever wrote the configuration.
+func NormalizeHostname(given string) (svchost.Hostname, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+
+	host, err := svchost.ForComparison(given)
+	if err != nil {
+		if strings.Contains(strings.ToLower(given), punycodePrefix) {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Invalid hostname",
+				fmt.Sprintf("The host %q is already given in Punycode form. Service hostnames must be written in Unicode; Terraform converts them to Punycode automatically wherever that's needed.", given),
+			))
+			return "", diags
+		}
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Invalid hostname",
+			fmt.Sprintf("The host %q is not a valid hostname: %s.", given, err),
+		))
+		return "", diags
+	}
+
+	if strings.Contains(string(host), ":") {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Warning,
+			"Hostname includes a port number",
+			fmt.Sprintf("The host %q includes a port number. Terraform treats it as a different host than the same name without a port, which is rarely what's intended for a service hostname.", given),
+		))
+	}
+
+	return host, diags
+}
+
 // Config is the structure of the configuration for the Terraform CLI.
 //
 // This is not the configuration for Terraform itself. That is in the
 // "config" package.
 type Config struct {
-	Providers    map[string]string
-	Provisioners map[string]string
+	Providers map[string]string
+
+	// Vars is the set of name/value pairs declared in a "vars" block, which
+	// other string settings in the same file may reference by writing
+	// "${var.NAME}". This exists purely to reduce duplication within a
+	// single CLI config file, such as across several "host" or
+	// "credentials" blocks that otherwise repeat the same values; it has no
+	// effect once the file has been loaded and is not merged across files.
+	Vars map[string]string `hcl:"vars"`
 
 	DisableCheckpoint          bool `hcl:"disable_checkpoint"`
 	DisableCheckpointSignature bool `hcl:"disable_checkpoint_signature"`
@@ -38,10 +153,182 @@ type Config struct {
 	// avoid repeatedly re-downloading over the Internet.
 	PluginCacheDir string `hcl:"plugin_cache_dir"`
 
+	// PluginCacheStrictChecksum, when set, causes the provider installer to
+	// re-verify a cached plugin's checksum against the one signed by the
+	// registry before reusing it, rejecting (and re-downloading) any cache
+	// entry that no longer matches. This guards against a shared cache
+	// directory being tampered with or corrupted between runs, at the cost
+	// of hashing every cached plugin on each use.
+	PluginCacheStrictChecksum bool `hcl:"plugin_cache_strict_checksum"`
+
+	// RateLimit, if set to a positive value, caps the number of requests
+	// per second the services client will send to any single host. This
+	// is useful when a large "terraform init" run against a self-hosted
+	// registry would otherwise trip rate-limiting rules such as a WAF.
+	RateLimit float64 `hcl:"rate_limit"`
+
 	Hosts map[string]*ConfigHost `hcl:"host"`
 
+	// Credentials is keyed by hostname, with one special case:
+	// defaultCredentialsHost ("*") configures a fallback used for any host
+	// with no "credentials" block of its own and no credentials helper that
+	// claims it, rather than a literal hostname. This is useful in
+	// environments where a single token is valid for every registry host,
+	// such as one fronted by a shared auth proxy; it's deliberately lower
+	// precedence than everything else, so that a more specific entry set up
+	// later is never shadowed by it.
 	Credentials        map[string]map[string]interface{}   `hcl:"credentials"`
 	CredentialsHelpers map[string]*ConfigCredentialsHelper `hcl:"credentials_helper"`
+
+	Diagnostics *ConfigDiagnostics `hcl:"diagnostics"`
+
+	Registry *ConfigRegistry `hcl:"registry"`
+
+	// Audit, if set, causes every credentials helper invocation (host,
+	// verb, duration, and whether it succeeded -- never the credential
+	// itself) to be appended to the file it names, to satisfy compliance
+	// requirements around who accessed which host's credentials and when.
+	Audit *ConfigAudit `hcl:"audit"`
+
+	// ProvisionerInstallation controls how Terraform finds provisioner
+	// plugins and allows overriding individual provisioners with local
+	// binaries during development.
+	ProvisionerInstallation *ProvisionerInstallation `hcl:"provisioner_installation"`
+
+	// Functions is keyed by function name and registers a user-defined
+	// function implemented as a WebAssembly module for use in Terraform
+	// expressions; see ConfigFunction and functions.go for more.
+	Functions map[string]*ConfigFunction `hcl:"function"`
+
+	// TrustedKeys lists ASCII-armored OpenPGP public keys trusted to sign
+	// configuration fragments loaded from a "system directory" -- the
+	// ".d" drop-in directory alongside the main file, and ConfigDir --
+	// rather than from the main file itself. Once any of these sources
+	// has contributed a non-empty TrustedKeys, every fragment loaded from
+	// a system directory afterward must carry a valid detached signature,
+	// in a sibling file named "<fragment>.sig", from one of the keys that
+	// were already trusted before that fragment was loaded; an unsigned
+	// or invalidly signed fragment is rejected with a diagnostic rather
+	// than silently skipped.
+	//
+	// This exists for centrally pushed configuration: it lets a package
+	// or provisioning script contribute fragments to a machine it doesn't
+	// otherwise control, in a way the machine's own CLI config can verify
+	// came from that package rather than from anything else with write
+	// access to the drop-in directory.
+	TrustedKeys []string `hcl:"trusted_keys"`
+
+	// ExternalPrograms allowlists the absolute paths of programs the
+	// "external" function, in a configuration that has separately opted in
+	// to that function via lang.Scope.EnableExternalFunction, is permitted
+	// to run. A call naming any other program fails, so enabling the
+	// function in a module's "terraform" block doesn't by itself grant it
+	// license to run anything the module's author likes; the operator
+	// running Terraform must also allowlist that specific program here.
+	//
+	// Like TrustedKeys, this accumulates across every file and drop-in
+	// fragment contributing to the final Config rather than the last one
+	// overriding the rest, since a narrower allowlist from one source
+	// should never silently widen into a broader one just because another
+	// source happened to be merged in afterward -- only the union of what
+	// every source explicitly allowed is ever permitted.
+	ExternalPrograms []string `hcl:"external_programs"`
+
+	// RequiredCLIVersion, if set, is a version constraint string that the
+	// running Terraform CLI must satisfy in order to use this file. It
+	// exists so that a newer Terraform can introduce new top-level
+	// settings or block types without an older installation encountering
+	// them at all: if the constraint isn't met, loadConfigFile skips the
+	// whole file -- after emitting a warning -- rather than attempting to
+	// decode content it might misinterpret.
+	RequiredCLIVersion string `hcl:"required_cli_version"`
+
+	// DisablePluginTLSVerify, if set, disables TLS certificate verification
+	// for plugin and registry downloads. This is meant only as a temporary
+	// aid for debugging an interception proxy, and is deliberately made
+	// awkward to turn on: PluginTLSVerifyDisabled also requires the
+	// TF_DISABLE_PLUGIN_TLS_VERIFY environment variable to be set before it
+	// will report verification as disabled, so that neither this setting
+	// left behind in a shared config file nor the environment variable set
+	// in a shared shell profile is enough on its own to silently weaken
+	// security; and Validate emits a warning whenever both are present, so
+	// the reduced protection is never silent even when intentional.
+	DisablePluginTLSVerify bool `hcl:"disable_plugin_tls_verify"`
+
+	// CABundlePath, if set, names a file of PEM-encoded certificates to
+	// trust, in addition to the system's own trusted root certificates,
+	// when verifying the TLS certificate presented by a plugin or registry
+	// host. This is for environments that terminate outbound HTTPS at a
+	// corporate proxy or otherwise sit a private certificate authority in
+	// front of those hosts; see TLSConfig for how it's used.
+	CABundlePath string `hcl:"ca_bundle_path"`
+
+	// Features is a map of experimental or otherwise opt-in behaviors,
+	// keyed by a short feature name, that don't yet (or won't ever)
+	// warrant a setting of their own. It exists so that an opt-in can be
+	// rolled out, tested, and eventually removed again without each one
+	// needing its own new top-level setting and its own entry in Merge
+	// and Validate; see FeatureEnabled for how a caller elsewhere in
+	// Terraform checks one.
+	Features map[string]bool `hcl:"features"`
+
+	// sourceFiles records the paths of the files that contributed to this
+	// Config, in the order they were merged in. It's populated by
+	// LoadConfig and consulted by Snapshot; it's not set on a Config
+	// that was constructed directly or decoded from a single file.
+	sourceFiles []string
+
+	// envInfluences records the values of snapshotEnvVars as observed when
+	// this Config was loaded -- by LoadConfig, from the live process
+	// environment, or by LoadConfigFromSnapshot, from a previously
+	// captured one -- so that EnvInfluences can report accurately
+	// regardless of which one produced the receiver. It's nil for a
+	// Config built or decoded by other means, in which case EnvInfluences
+	// falls back to the current process environment.
+	envInfluences map[string]string
+
+	// credentialsSources records, for each hostname with a "credentials"
+	// block in this Config, the path of the file that block came from.
+	// It's populated alongside Credentials by loadConfigFile and consulted
+	// by Merge in order to report which two files are in conflict when the
+	// same hostname is configured more than once.
+	credentialsSources map[string]string
+
+	// legacyProvisionerOverrides records entries found in an old-style
+	// top-level "provisioners" map, which was replaced by the
+	// provisioner_installation block's dev_overrides. Config no longer
+	// decodes "provisioners" into an exported field, since otherwise it
+	// would silently shadow dev_overrides for anyone who hadn't yet
+	// migrated; instead loadConfigFile decodes it separately into this
+	// field purely so that Validate and LegacyOverridesInUse can warn
+	// about it.
+	legacyProvisionerOverrides map[string]string
+
+	// hostPositions, credentialsPositions, and credentialsHelperPositions
+	// record, for each key in the correspondingly-named map (Hosts,
+	// Credentials, and CredentialsHelpers respectively), the source
+	// position of the block that produced it. They're populated by
+	// loadConfigFile from the parsed AST, before hcl.DecodeObject has
+	// thrown that information away, so that Validate can report a
+	// problem with a specific block at a useful location instead of a
+	// sourceless one. A Config assembled directly, such as in a test, has
+	// no entries in these maps, and Validate falls back to reporting
+	// without a location in that case.
+	hostPositions              map[string]string
+	credentialsPositions       map[string]string
+	credentialsHelperPositions map[string]string
+}
+
+// ConfigDiagnostics is the structure of the "diagnostics" nested block
+// within the CLI configuration, which lets an operator centrally manage
+// known, coded diagnostics that Terraform would otherwise print as-is.
+type ConfigDiagnostics struct {
+	// Suppress lists the codes of warnings that should be dropped entirely.
+	Suppress []string `hcl:"suppress"`
+
+	// PromoteToError lists the codes of warnings that should instead be
+	// reported as errors.
+	PromoteToError []string `hcl:"promote_to_error"`
 }
 
 // ConfigHost is the structure of the "host" nested block within the CLI
@@ -49,12 +336,207 @@ type Config struct {
 // discovery behavior for a particular hostname.
 type ConfigHost struct {
 	Services map[string]interface{} `hcl:"services"`
+
+	// PinServiceVersions maps a bare service name (e.g. "providers") to
+	// the version Terraform should accept for it (e.g. "v1") when talking
+	// to this host, regardless of which version its discovery document
+	// actually advertises the service under. This is for working around a
+	// self-hosted registry that's upgraded to a newer, wire-compatible
+	// service version before this Terraform release knows to ask for it
+	// by name; unlike Services, it leaves live discovery in place for
+	// everything else, rather than replacing the host's entire discovery
+	// document with a static one.
+	PinServiceVersions map[string]string `hcl:"pin_service_version"`
+
+	// MinVersion, if set, is the earliest Terraform CLI version that
+	// understands this host block; an older CLI skips it, with a warning,
+	// rather than acting on a "services" map it might not interpret
+	// correctly.
+	MinVersion string `hcl:"min_version"`
 }
 
 // ConfigCredentialsHelper is the structure of the "credentials_helper"
 // nested block within the CLI configuration.
 type ConfigCredentialsHelper struct {
 	Args []string `hcl:"args"`
+
+	// Env is a set of additional environment variables to set in the
+	// helper program's environment, beyond whatever it already inherits
+	// from Terraform's own process environment (or, if EnvAllowlist is
+	// set, whatever subset of that environment survives the allowlist).
+	Env map[string]string `hcl:"env"`
+
+	// EnvAllowlist, if non-empty, restricts the environment variables the
+	// helper program inherits from Terraform's own process environment to
+	// just the names listed here, rather than passing through the full
+	// environment as it does by default. This is useful in locked-down
+	// environments where the ambient environment may carry secrets that
+	// shouldn't reach an externally-invoked helper program. Env is always
+	// passed through regardless of this setting.
+	EnvAllowlist []string `hcl:"env_allowlist"`
+
+	// Hosts, if non-empty, restricts this credentials_helper block to
+	// serving only hostnames matching one of its patterns, so that several
+	// credentials_helper blocks can route different hosts to different
+	// helpers -- for example, a "vault" helper for internal hosts and the
+	// default local credentials file for everything else.
+	//
+	// A pattern is either a literal hostname or, for a pattern starting
+	// with "*.", a wildcard matching any number of subdomain labels under
+	// the part following the dot (but not that part alone), following the
+	// same convention as a TLS wildcard certificate.
+	//
+	// A credentials_helper block with no Hosts is a catch-all, matching any
+	// hostname not matched by one of the others; at most one block may omit
+	// Hosts, since two catch-alls would be ambiguous.
+	Hosts []string `hcl:"hosts"`
+
+	// Timeout, in seconds, bounds how long a single invocation of the
+	// helper program is allowed to run before it's killed and treated as
+	// a failure. A zero value means to use the source's own default,
+	// currently 30 seconds; see svchost/auth.HelperProgramOptions.Timeout.
+	Timeout int `hcl:"timeout"`
+
+	// MinVersion, if set, is the earliest Terraform CLI version that
+	// understands this credentials_helper block; see ConfigHost.MinVersion.
+	MinVersion string `hcl:"min_version"`
+}
+
+// credentialsHelperNamePattern matches the identifier syntax required for
+// a "credentials_helper" block's label, which doubles as the name of the
+// plugin Terraform searches for via plugin/discovery. It intentionally
+// matches the same rules as a provider or provisioner type name.
+var credentialsHelperNamePattern = regexp.MustCompile(`(?i)\A[A-Z][A-Z0-9_-]*\z`)
+
+// ConfigRegistry is the structure of the "registry" nested block within
+// the CLI configuration, which lets an operator tune the retry and
+// timeout behavior of the module and provider registry client -- useful
+// when working against a self-hosted registry reached over a slow or
+// unreliable network.
+//
+// A zero value for any field means "use the client's own default" rather
+// than literally zero, except for Retries where zero means disabled.
+type ConfigRegistry struct {
+	// Retries is how many additional attempts the registry client makes
+	// after a request fails with a transient error (a network error or a
+	// 5xx response).
+	Retries int `hcl:"retries"`
+
+	// Timeout, in seconds, bounds how long a single registry request,
+	// including any retries, is allowed to take.
+	Timeout int `hcl:"timeout"`
+
+	// BackoffMax, in seconds, caps the exponential backoff delay between
+	// retries.
+	BackoffMax int `hcl:"backoff_max"`
+
+	// MinVersion, if set, is the earliest Terraform CLI version that
+	// understands this registry block; see ConfigHost.MinVersion.
+	MinVersion string `hcl:"min_version"`
+}
+
+// ConfigAudit is the structure of the "audit" nested block within the CLI
+// configuration, which enables a structured audit log of credentials
+// helper activity.
+type ConfigAudit struct {
+	// Path is the file to append one JSON record to for every credentials
+	// helper invocation. The file is created if it doesn't already exist;
+	// Terraform never truncates or rotates it, so an operator relying on
+	// this for compliance is responsible for archiving it themselves.
+	Path string `hcl:"path"`
+
+	// MinVersion, if set, is the earliest Terraform CLI version that
+	// understands this audit block; see ConfigHost.MinVersion.
+	MinVersion string `hcl:"min_version"`
+}
+
+// ProvisionerInstallation is the structure of the "provisioner_installation"
+// block within the CLI configuration, which controls how Terraform finds
+// provisioner plugins on the local filesystem and allows overriding a
+// particular provisioner with a local binary during development.
+//
+// This mirrors, for provisioners, the search-path and override behavior
+// that the provider installer already gets from GlobalPluginDirs and the
+// legacy Providers map; it's expressed as its own typed block rather than
+// a bare top-level map because provisioners have no version constraints to
+// reason about, so nothing more elaborate than this is needed.
+type ProvisionerInstallation struct {
+	// SearchPaths lists additional directories to search for provisioner
+	// plugins, beyond the usual default locations.
+	SearchPaths []string `hcl:"search_paths"`
+
+	// DevOverrides maps a provisioner name to the path of a local binary to
+	// use in place of any discovered provisioner plugin of that name. This
+	// is intended only for provisioner development, since an override
+	// bypasses the usual plugin discovery entirely.
+	DevOverrides map[string]string `hcl:"dev_overrides"`
+}
+
+// ProvisionerSearchPaths returns the additional directories that should be
+// searched for provisioner plugins, as configured by any
+// "provisioner_installation" block. It returns nil if c is nil or no such
+// block is present.
+func (c *Config) ProvisionerSearchPaths() []string {
+	if c == nil || c.ProvisionerInstallation == nil {
+		return nil
+	}
+	return c.ProvisionerInstallation.SearchPaths
+}
+
+// ProvisionerDevOverrides returns the provisioner-name-to-local-binary-path
+// overrides configured by any "provisioner_installation" block's
+// dev_overrides. It returns nil if c is nil or no such block is present.
+func (c *Config) ProvisionerDevOverrides() map[string]string {
+	if c == nil || c.ProvisionerInstallation == nil {
+		return nil
+	}
+	return c.ProvisionerInstallation.DevOverrides
+}
+
+// LegacyPluginOverride describes a single entry found in one of the CLI
+// config's legacy plugin path override mechanisms: the bare top-level
+// "providers" map, or an old-style top-level "provisioners" map that
+// predates the provisioner_installation block's dev_overrides.
+type LegacyPluginOverride struct {
+	// Kind is the kind of plugin the override applies to: "provider" or
+	// "provisioner".
+	Kind string
+
+	// Name is the plugin name the override applies to.
+	Name string
+
+	// Path is the local binary path the override points at.
+	Path string
+}
+
+// LegacyOverridesInUse reports every entry found in a deprecated plugin
+// path override mechanism, so that callers can print precise guidance
+// about what to migrate and where to move it. It returns an empty slice
+// if no legacy overrides are in use.
+//
+// The result is sorted by Kind and then Name so that it's stable for
+// display and for comparison in tests.
+func (c *Config) LegacyOverridesInUse() []LegacyPluginOverride {
+	var ret []LegacyPluginOverride
+	if c == nil {
+		return ret
+	}
+
+	for name, path := range c.Providers {
+		ret = append(ret, LegacyPluginOverride{Kind: "provider", Name: name, Path: path})
+	}
+	for name, path := range c.legacyProvisionerOverrides {
+		ret = append(ret, LegacyPluginOverride{Kind: "provisioner", Name: name, Path: path})
+	}
+
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Kind != ret[j].Kind {
+			return ret[i].Kind < ret[j].Kind
+		}
+		return ret[i].Name < ret[j].Name
+	})
+
+	return ret
 }
 
 // BuiltinConfig is the built-in defaults for the configuration. These
@@ -79,43 +561,174 @@ func ConfigDir() (string, error) {
 // and from the environment, returning a merged configuration along with any
 // diagnostics (errors and warnings) encountered along the way.
 func LoadConfig() (*Config, tfdiags.Diagnostics) {
+	return LoadConfigWithObserver(nil)
+}
+
+// LoadConfigPhaseObserver is an optional hook for LoadConfigWithObserver,
+// called once per named phase of loading the CLI config with how long that
+// phase took. It lets a caller that cares where terraform init is spending
+// its time during config loading -- for example to feed its own tracing or
+// metrics system -- find out, without this package needing to take on a
+// dependency on any particular tracing library itself.
+//
+// The set of phase names isn't a compatibility promise: it may gain, lose,
+// or rename phases between releases as the implementation of LoadConfig
+// changes.
+type LoadConfigPhaseObserver func(phase string, d time.Duration)
+
+// LoadConfigWithObserver is LoadConfig with an optional
+// LoadConfigPhaseObserver. Passing a nil observer is equivalent to calling
+// LoadConfig directly.
+func LoadConfigWithObserver(observe LoadConfigPhaseObserver) (*Config, tfdiags.Diagnostics) {
 	var diags tfdiags.Diagnostics
 	configVal := BuiltinConfig // copy
 	config := &configVal
 
-	if mainFilename, err := cliConfigFile(); err == nil {
-		if _, err := os.Stat(mainFilename); err == nil {
-			mainConfig, mainDiags := loadConfigFile(mainFilename)
-			diags = diags.Append(mainDiags)
-			config = config.Merge(mainConfig)
+	// We share a single snapshot of the process environment across every
+	// file LoadConfig reads, rather than letting each one re-read it via
+	// os.ExpandEnv, since a config directory can hold many files and a CI
+	// environment can have thousands of variables.
+	env := newEnvCache()
+
+	observePhase(observe, "main file", func() {
+		if mainFilename, err := cliConfigFile(); err == nil {
+			if _, err := os.Stat(mainFilename); err == nil {
+				mainConfig, mainDiags := loadConfigFileWithEnv(mainFilename, env)
+				diags = diags.Append(mainDiags)
+				mainConfig.sourceFiles = []string{mainFilename}
+				var mergeDiags tfdiags.Diagnostics
+				config, mergeDiags = config.Merge(mainConfig)
+				diags = diags.Append(mergeDiags)
+			}
+
+			// A "<mainFilename>.d" directory alongside the main config file is a
+			// drop-in directory, in the same spirit as systemd's ".d" drop-ins:
+			// it lets a package or provisioning script contribute fragments of
+			// configuration without having to parse and edit the user's main
+			// file. Its files are merged after the main file, in lexicographic
+			// order, the same way loadConfigDirWithEnv merges the files within
+			// any one config directory.
+			dropInDir := mainFilename + ".d"
+			if info, err := os.Stat(dropInDir); err == nil && info.IsDir() {
+				dropInConfig, dropInDiags := loadConfigDirWithEnv(dropInDir, env, config.TrustedKeys)
+				diags = diags.Append(dropInDiags)
+				var mergeDiags tfdiags.Diagnostics
+				config, mergeDiags = config.Merge(dropInConfig)
+				diags = diags.Append(mergeDiags)
+			}
 		}
-	}
+	})
 
-	if configDir, err := ConfigDir(); err == nil {
-		if info, err := os.Stat(configDir); err == nil && info.IsDir() {
-			dirConfig, dirDiags := loadConfigDir(configDir)
-			diags = diags.Append(dirDiags)
-			config = config.Merge(dirConfig)
+	observePhase(observe, "config dir", func() {
+		if configDir, err := ConfigDir(); err == nil {
+			if info, err := os.Stat(configDir); err == nil && info.IsDir() {
+				dirConfig, dirDiags := loadConfigDirWithEnv(configDir, env, config.TrustedKeys)
+				diags = diags.Append(dirDiags)
+				var mergeDiags tfdiags.Diagnostics
+				config, mergeDiags = config.Merge(dirConfig)
+				diags = diags.Append(mergeDiags)
+			}
 		}
-	}
+	})
 
-	if envConfig := EnvConfig(); envConfig != nil {
-		// envConfig takes precedence
-		config = envConfig.Merge(config)
-	}
+	observePhase(observe, "env config", func() {
+		if envConfig := EnvConfig(); envConfig != nil {
+			// envConfig takes precedence
+			var mergeDiags tfdiags.Diagnostics
+			config, mergeDiags = envConfig.Merge(config)
+			diags = diags.Append(mergeDiags)
+		}
+
+		config.envInfluences = captureEnvInfluences(os.LookupEnv)
+	})
+
+	observePhase(observe, "validate", func() {
+		diags = diags.Append(config.Validate())
+		diags = diags.Append(legacyConfigFileDiagnostics())
+
+		if config.Diagnostics != nil {
+			diags = tfdiags.FilterDiagnostics(diags, config.Diagnostics.Suppress, config.Diagnostics.PromoteToError)
+		}
 
-	diags = diags.Append(config.Validate())
+		diags = tfdiags.ScrubSecrets(diags, config.knownSecrets())
+	})
 
 	return config, diags
 }
 
+// observePhase runs f, and if observe is non-nil calls it with phase and how
+// long f took to run.
+func observePhase(observe LoadConfigPhaseObserver, phase string, f func()) {
+	if observe == nil {
+		f()
+		return
+	}
+
+	start := time.Now()
+	f()
+	observe(phase, time.Since(start))
+}
+
+// LoadConfigFile reads and decodes a single CLI configuration file at the
+// given path, without merging it with any other file or with any of the
+// other sources LoadConfig also consults, such as ConfigDir or environment
+// variables.
+//
+// Most callers should use LoadConfig instead, which assembles the CLI config
+// the same way Terraform itself does. LoadConfigFile exists for tooling that
+// needs to decode or validate one file in isolation -- for example, a
+// pipeline that renders a candidate CLI config file and wants to validate it
+// before deploying it anywhere.
+func LoadConfigFile(path string) (*Config, tfdiags.Diagnostics) {
+	return loadConfigFile(path)
+}
+
+// knownSecrets returns every credential-shaped string value configured in
+// the receiver, for use with tfdiags.ScrubSecrets so that a diagnostic
+// which happens to embed one of them -- for example, by quoting the raw
+// output of a failed credentials helper -- doesn't leak it back to the
+// user.
+func (c *Config) knownSecrets() []string {
+	if c == nil {
+		return nil
+	}
+
+	var secrets []string
+	for _, creds := range c.Credentials {
+		for _, v := range creds {
+			if s, ok := v.(string); ok {
+				secrets = append(secrets, s)
+			}
+		}
+	}
+	for _, helper := range c.CredentialsHelpers {
+		for _, v := range helper.Env {
+			secrets = append(secrets, v)
+		}
+	}
+	return secrets
+}
+
 // loadConfigFile loads the CLI configuration from ".terraformrc" files.
 func loadConfigFile(path string) (*Config, tfdiags.Diagnostics) {
+	return loadConfigFileWithEnv(path, newEnvCache())
+}
+
+// loadConfigFileWithEnv is loadConfigFile with an injectable envCache, so
+// that loadConfigDirWithEnv can share one snapshot of the environment
+// across every file in a config directory instead of each file taking its
+// own.
+func loadConfigFileWithEnv(path string, env *envCache) (*Config, tfdiags.Diagnostics) {
 	var diags tfdiags.Diagnostics
 	result := &Config{}
 
 	log.Printf("Loading CLI configuration from %s", path)
 
+	if info, err := os.Stat(path); err == nil && info.Size() > maxConfigFileSize {
+		diags = diags.Append(fmt.Errorf("Error reading %s: file is %d bytes, which is larger than the %d byte limit for a CLI config file", path, info.Size(), int64(maxConfigFileSize)))
+		return result, diags
+	}
+
 	// Read the HCL file and prepare for parsing
 	d, err := ioutil.ReadFile(path)
 	if err != nil {
@@ -136,22 +749,256 @@ func loadConfigFile(path string) (*Config, tfdiags.Diagnostics) {
 		return result, diags
 	}
 
-	// Replace all env vars
+	// A file whose required_cli_version this running CLI doesn't satisfy is
+	// meant for a different Terraform release; skip the rest of it entirely
+	// rather than risk misinterpreting content it might not understand.
+	ok, verDiags := checkRequiredCLIVersion(path, result.RequiredCLIVersion)
+	diags = diags.Append(verDiags)
+	if !ok {
+		return &Config{}, diags
+	}
+
+	// Capture the source position of each "host", "credentials", and
+	// "credentials_helper" block now, while it's still available from the
+	// parsed AST, so that Validate can later report a problem with one of
+	// these blocks at a useful location instead of a sourceless one.
+	hostPositions := blockPositions(obj, "host")
+	credentialsPositions := blockPositions(obj, "credentials")
+	result.credentialsHelperPositions = blockPositions(obj, "credentials_helper")
+
+	diags = diags.Append(warnUnknownTopLevelAttributes(path, obj))
+
+	// Drop any block whose min_version this running CLI doesn't satisfy,
+	// before its content is normalized, expanded, or otherwise acted on.
+	diags = diags.Append(applyVersionGates(path, result))
+
+	// Resolve the "vars" block, if any, against itself before using it to
+	// expand "${var.NAME}" references elsewhere in the file.
+	if len(result.Vars) > 0 {
+		resolved, err := resolveVars(result.Vars)
+		if err != nil {
+			diags = diags.Append(fmt.Errorf("Error in vars block of %s: %s", path, err))
+			return result, diags
+		}
+		result.Vars = resolved
+	}
+
+	// Replace all var and env var references
 	for k, v := range result.Providers {
-		result.Providers[k] = os.ExpandEnv(v)
+		result.Providers[k] = env.expand(expandConfigVars(v, result.Vars))
 	}
-	for k, v := range result.Provisioners {
-		result.Provisioners[k] = os.ExpandEnv(v)
+	if result.ProvisionerInstallation != nil {
+		for k, v := range result.ProvisionerInstallation.DevOverrides {
+			result.ProvisionerInstallation.DevOverrides[k] = env.expand(expandConfigVars(v, result.Vars))
+		}
+		for i, p := range result.ProvisionerInstallation.SearchPaths {
+			result.ProvisionerInstallation.SearchPaths[i] = env.expand(expandConfigVars(p, result.Vars))
+		}
+	}
+	for _, fn := range result.Functions {
+		fn.WASMFile = env.expand(expandConfigVars(fn.WASMFile, result.Vars))
+	}
+	for _, hostConfig := range result.Hosts {
+		for k, v := range hostConfig.Services {
+			if s, ok := v.(string); ok {
+				hostConfig.Services[k] = expandConfigVars(s, result.Vars)
+			}
+		}
+	}
+	for _, creds := range result.Credentials {
+		for k, v := range creds {
+			if s, ok := v.(string); ok {
+				creds[k] = expandConfigVars(s, result.Vars)
+			}
+		}
 	}
 
 	if result.PluginCacheDir != "" {
-		result.PluginCacheDir = os.ExpandEnv(result.PluginCacheDir)
+		result.PluginCacheDir = env.expand(expandConfigVars(result.PluginCacheDir, result.Vars))
+
+		// The vendored HCL v1 decoder used above has no concept of function
+		// calls, so unlike the main Terraform language we can't offer a real
+		// pathexpand() function here. Instead we expand a leading "~" as a
+		// special case, since that's the only function call a CLI config
+		// commonly needs to express a path setting like this one.
+		if expanded, err := homedir.Expand(result.PluginCacheDir); err == nil {
+			result.PluginCacheDir = expanded
+		} else {
+			diags = diags.Append(fmt.Errorf("Error expanding plugin_cache_dir %q: %s", result.PluginCacheDir, err))
+		}
+	}
+
+	// The top-level "provisioners" map was replaced by the
+	// provisioner_installation block's dev_overrides, and so is no longer
+	// decoded into an exported field. We still decode it here, separately,
+	// purely so that Validate and LegacyOverridesInUse can tell the user
+	// about it and point them at its replacement, rather than the entries
+	// just silently doing nothing.
+	var legacy struct {
+		Provisioners map[string]string `hcl:"provisioners"`
+	}
+	if err := hcl.DecodeObject(&legacy, obj); err == nil && len(legacy.Provisioners) > 0 {
+		result.legacyProvisionerOverrides = make(map[string]string, len(legacy.Provisioners))
+		for k, v := range legacy.Provisioners {
+			result.legacyProvisionerOverrides[k] = env.expand(v)
+		}
+	}
+
+	// Normalize the Hosts and Credentials maps so they're keyed by the same
+	// comparison form that svchost.ForComparison would produce, regardless
+	// of how the hostname was capitalized or Unicode-normalized in the
+	// file. This lets later merging and duplicate-detection logic rely on
+	// plain map lookups instead of re-normalizing on every access. A host
+	// that fails to normalize is left under its original key so that
+	// Validate can still report it using the text the user wrote; Validate
+	// is also where the resulting diagnostics actually get surfaced.
+	if len(result.Hosts) > 0 {
+		normalized := make(map[string]*ConfigHost, len(result.Hosts))
+		for givenHost, hostConfig := range result.Hosts {
+			host, hostDiags := NormalizeHostname(givenHost)
+			key := givenHost
+			if !hostDiags.HasErrors() {
+				key = string(host)
+			}
+			normalized[key] = hostConfig
+			if pos, ok := hostPositions[givenHost]; ok {
+				result.setHostPosition(key, pos)
+			}
+		}
+		result.Hosts = normalized
+	}
+	if len(result.Credentials) > 0 {
+		normalized := make(map[string]map[string]interface{}, len(result.Credentials))
+		for givenHost, creds := range result.Credentials {
+			key := givenHost
+			if givenHost != defaultCredentialsHost {
+				host, hostDiags := NormalizeHostname(givenHost)
+				if !hostDiags.HasErrors() {
+					key = string(host)
+				}
+			}
+			normalized[key] = creds
+			if pos, ok := credentialsPositions[givenHost]; ok {
+				result.setCredentialsPosition(key, pos)
+			}
+		}
+		result.Credentials = normalized
+	}
+
+	for host := range result.Credentials {
+		result.setCredentialsSource(host, path)
 	}
 
 	return result, diags
 }
 
+// setCredentialsSource records, lazily allocating credentialsSources if
+// needed, which file a "credentials" block for the given host came from.
+// An empty path is a no-op, so that merging in a Config with no source
+// tracking of its own (for example, one built directly in a test, or the
+// env-derived Config, neither of which come from a file) doesn't leave
+// behind meaningless empty-string entries.
+func (c *Config) setCredentialsSource(host, path string) {
+	if path == "" {
+		return
+	}
+	if c.credentialsSources == nil {
+		c.credentialsSources = make(map[string]string)
+	}
+	c.credentialsSources[host] = path
+}
+
+// setHostPosition, setCredentialsPosition, and setCredentialsHelperPosition
+// record, lazily allocating the relevant map if needed, the source
+// position of the "host", "credentials", or "credentials_helper" block
+// (respectively) that produced the given key. An empty position is a
+// no-op, so that merging in a Config with no position tracking of its own
+// (for example, one built directly in a test) doesn't leave behind
+// meaningless empty-string entries.
+func (c *Config) setHostPosition(host, pos string) {
+	if pos == "" {
+		return
+	}
+	if c.hostPositions == nil {
+		c.hostPositions = make(map[string]string)
+	}
+	c.hostPositions[host] = pos
+}
+
+func (c *Config) setCredentialsPosition(host, pos string) {
+	if pos == "" {
+		return
+	}
+	if c.credentialsPositions == nil {
+		c.credentialsPositions = make(map[string]string)
+	}
+	c.credentialsPositions[host] = pos
+}
+
+func (c *Config) setCredentialsHelperPosition(name, pos string) {
+	if pos == "" {
+		return
+	}
+	if c.credentialsHelperPositions == nil {
+		c.credentialsHelperPositions = make(map[string]string)
+	}
+	c.credentialsHelperPositions[name] = pos
+}
+
+// configFileFormat identifies which of our recognized CLI config file
+// formats the file at the given path is in, based on its base name rather
+// than its full path, so that it works the same whether given a bare
+// filename or one joined with a directory. Matching is case-insensitive,
+// both because Windows filesystems are generally case-insensitive anyway
+// and because it's easy for a user to end up with an unexpectedly-cased
+// extension (e.g. from a file manager that capitalizes extensions) and not
+// realize why Terraform is silently ignoring the file.
+//
+// The result is "tfrc" for "*.tfrc" files and for the classic
+// ".terraformrc"/"terraform.rc" config file names, "tfrc.json" for
+// "*.tfrc.json" files, and "" if the name doesn't match any recognized
+// pattern.
+//
+// If override is non-empty it's returned verbatim instead of being
+// detected, so that a caller who already knows the format (for example,
+// because the user specified it explicitly) can force it without needing
+// a separate code path.
+func configFileFormat(path string, override string) string {
+	if override != "" {
+		return override
+	}
+
+	name := strings.ToLower(filepath.Base(path))
+	switch name {
+	case ".terraformrc", "terraform.rc":
+		return "tfrc"
+	}
+
+	switch {
+	case strings.HasSuffix(name, ".tfrc.json"):
+		return "tfrc.json"
+	case strings.HasSuffix(name, ".tfrc"):
+		return "tfrc"
+	default:
+		return ""
+	}
+}
+
 func loadConfigDir(path string) (*Config, tfdiags.Diagnostics) {
+	return loadConfigDirWithEnv(path, newEnvCache(), nil)
+}
+
+// loadConfigDirWithEnv is loadConfigDir with an injectable envCache, shared
+// across every file in the directory instead of each one snapshotting the
+// environment for itself, and with trustedKeys, the TrustedKeys accumulated
+// by the Config so far.
+//
+// If trustedKeys is non-empty, every file in the directory must carry a
+// valid detached signature from one of those keys, in a sibling file named
+// "<name>.sig", or it's rejected with a diagnostic instead of being loaded.
+// If trustedKeys is empty, signatures are neither required nor checked,
+// preserving the directory's previous unsigned-fragment behavior exactly.
+func loadConfigDirWithEnv(path string, env *envCache, trustedKeys []string) (*Config, tfdiags.Diagnostics) {
 	var diags tfdiags.Diagnostics
 	result := &Config{}
 
@@ -161,20 +1008,65 @@ func loadConfigDir(path string) (*Config, tfdiags.Diagnostics) {
 		return result, diags
 	}
 
+	var keyring openpgp.EntityList
+	if len(trustedKeys) > 0 {
+		var keyDiags tfdiags.Diagnostics
+		keyring, keyDiags = parseTrustedKeys(trustedKeys)
+		diags = diags.Append(keyDiags)
+	}
+
+	filesRead := 0
 	for _, entry := range entries {
 		name := entry.Name()
-		// Ignoring errors here because it is used only to indicate pattern
-		// syntax errors, and our patterns are hard-coded here.
-		hclMatched, _ := filepath.Match("*.tfrc", name)
-		jsonMatched, _ := filepath.Match("*.tfrc.json", name)
-		if !(hclMatched || jsonMatched) {
+
+		if strings.HasPrefix(name, ".") {
+			// Dotfiles -- editor swap files, OS metadata like .DS_Store,
+			// a stray ".terraformrc" that belongs at the top level rather
+			// than in this directory, etc -- are assumed to be unrelated
+			// to Terraform, so we skip them even if they happen to match
+			// one of our config file name patterns.
+			continue
+		}
+		if configFileFormat(name, "") == "" {
 			continue
 		}
 
 		filePath := filepath.Join(path, name)
-		fileConfig, fileDiags := loadConfigFile(filePath)
+
+		// entry's own info comes from an Lstat, so a symlink won't yet
+		// have told us anything about what it points at. Stat through it
+		// here, which also means a broken or cyclic symlink surfaces as
+		// an ordinary stat error below rather than being passed on to
+		// loadConfigFile to fail in a more confusing way.
+		info, err := os.Stat(filePath)
+		if err != nil {
+			diags = diags.Append(tfdiags.SimpleWarning(fmt.Sprintf("Skipping unreadable item %s in CLI config directory: %s", filePath, err)))
+			continue
+		}
+		if info.IsDir() {
+			// A directory -- or a symlink to one -- can't be a config file.
+			continue
+		}
+
+		if filesRead >= maxConfigDirFiles {
+			diags = diags.Append(fmt.Errorf("Error reading %s: found more than %d config files; ignoring %s and any remaining files", path, maxConfigDirFiles, filePath))
+			break
+		}
+		filesRead++
+
+		if len(trustedKeys) > 0 {
+			if err := verifyConfigFragmentSignature(filePath, keyring); err != nil {
+				diags = diags.Append(fmt.Errorf("Rejecting %s: %s", filePath, err))
+				continue
+			}
+		}
+
+		fileConfig, fileDiags := loadConfigFileWithEnv(filePath, env)
 		diags = diags.Append(fileDiags)
-		result = result.Merge(fileConfig)
+		fileConfig.sourceFiles = []string{filePath}
+		var mergeDiags tfdiags.Diagnostics
+		result, mergeDiags = result.Merge(fileConfig)
+		diags = diags.Append(mergeDiags)
 	}
 
 	return result, diags
@@ -185,9 +1077,18 @@ func loadConfigDir(path string) (*Config, tfdiags.Diagnostics) {
 // Any values specified in this config should override those set in the
 // configuration file.
 func EnvConfig() *Config {
+	return envConfig(os.Getenv)
+}
+
+// envConfig is the shared implementation behind EnvConfig. It's factored
+// out with an injectable lookup function so that Snapshot and
+// LoadConfigFromSnapshot can reconstruct the env-derived portion of a
+// Config from a captured set of variables, rather than only from the
+// current process environment.
+func envConfig(getenv func(string) string) *Config {
 	config := &Config{}
 
-	if envPluginCacheDir := os.Getenv(pluginCacheDirEnvVar); envPluginCacheDir != "" {
+	if envPluginCacheDir := getenv(pluginCacheDirEnvVar); envPluginCacheDir != "" {
 		// No Expandenv here, because expanding environment variables inside
 		// an environment variable would be strange and seems unnecessary.
 		// (User can expand variables into the value while setting it using
@@ -212,45 +1113,300 @@ func (c *Config) Validate() tfdiags.Diagnostics {
 	}
 
 	// FIXME: Right now our config parsing doesn't retain enough information
-	// to give proper source references to any errors. We should improve
-	// on this when we change the CLI config parser to use HCL2.
+	// to give proper source references to any errors, except for "host",
+	// "credentials", and "credentials_helper" blocks, whose positions
+	// loadConfigFile captures separately (see hostPositions and friends)
+	// because hcl.DecodeObject doesn't preserve them. We should improve on
+	// the remaining cases when we change the CLI config parser to use
+	// HCL2.
 
-	// Check that all "host" blocks have valid hostnames.
-	for givenHost := range c.Hosts {
-		_, err := svchost.ForComparison(givenHost)
-		if err != nil {
-			diags = diags.Append(
-				fmt.Errorf("The host %q block has an invalid hostname: %s", givenHost, err),
-			)
+	// Check that all "host" blocks have valid hostnames. By the time we get
+	// here loadConfigFile has already normalized any host it could, so a
+	// key that still fails here is one it already flagged via diagnostics;
+	// we re-check it so that a Config built or decoded by other means (for
+	// example, directly in a test) is still validated.
+	for givenHost, hostConfig := range c.Hosts {
+		_, hostDiags := NormalizeHostname(givenHost)
+		diags = diags.Append(positioned(hostDiags, c.hostPositions[givenHost]))
+
+		// Each pinned version must at least parse as a version, since a
+		// typo here would otherwise silently never match anything and so
+		// never actually pin whatever service it was meant to.
+		for svc, ver := range hostConfig.PinServiceVersions {
+			if _, err := goversion.NewVersion(ver); err != nil {
+				var pinDiags tfdiags.Diagnostics
+				pinDiags = pinDiags.Append(fmt.Errorf("Host %q has an invalid pin_service_version for %q: %s", givenHost, svc, err))
+				diags = diags.Append(positioned(pinDiags, c.hostPositions[givenHost]))
+			}
 		}
 	}
 
-	// Check that all "credentials" blocks have valid hostnames.
+	// Check that all "credentials" blocks have valid hostnames, except for
+	// the special defaultCredentialsHost key, which isn't a hostname at all.
 	for givenHost := range c.Credentials {
-		_, err := svchost.ForComparison(givenHost)
-		if err != nil {
+		if givenHost == defaultCredentialsHost {
+			continue
+		}
+		_, hostDiags := NormalizeHostname(givenHost)
+		diags = diags.Append(positioned(hostDiags, c.credentialsPositions[givenHost]))
+	}
+
+	if len(c.Credentials) > maxCredentialsBlocks {
+		diags = diags.Append(
+			fmt.Errorf("Too many \"credentials\" blocks: found %d, but only %d are supported", len(c.Credentials), maxCredentialsBlocks),
+		)
+	}
+
+	// Multiple "credentials_helper" blocks are allowed as long as each one
+	// other than at most one catch-all routes to a disjoint set of hosts;
+	// we can't detect every possible overlap between wildcard patterns, but
+	// we can and do catch the unambiguous cases: more than one catch-all
+	// block, or the same pattern repeated across blocks.
+	catchAlls := make([]string, 0, 1)
+	seenPatterns := make(map[string]string, len(c.CredentialsHelpers))
+	for _, name := range sortedCredentialsHelperNames(c.CredentialsHelpers) {
+		helper := c.CredentialsHelpers[name]
+
+		if len(helper.Hosts) == 0 {
+			catchAlls = append(catchAlls, name)
+			continue
+		}
+
+		for _, pattern := range helper.Hosts {
+			if err := validateCredentialsHelperHostPattern(pattern); err != nil {
+				diags = diags.Append(c.credentialsHelperError(name, fmt.Sprintf("Invalid hosts pattern %q: %s.", pattern, err)))
+				continue
+			}
+			if otherName, ok := seenPatterns[pattern]; ok && otherName != name {
+				diags = diags.Append(c.credentialsHelperError(name, fmt.Sprintf("The hosts pattern %q is also claimed by credentials_helper %q.", pattern, otherName)))
+				continue
+			}
+			seenPatterns[pattern] = name
+		}
+	}
+	if len(catchAlls) > 1 {
+		sort.Strings(catchAlls)
+		diags = diags.Append(
+			fmt.Errorf("No more than one credentials_helper block may omit \"hosts\", since each is a catch-all for every host not claimed elsewhere, but found: %s", strings.Join(catchAlls, ", ")),
+		)
+	}
+
+	// Each "credentials_helper" block's label doubles as the name of the
+	// plugin Terraform will search for, so it's constrained to the same
+	// identifier syntax as a provider or provisioner type name.
+	for name, helper := range c.CredentialsHelpers {
+		if !credentialsHelperNamePattern.MatchString(name) {
+			diags = diags.Append(c.credentialsHelperError(name, fmt.Sprintf("The credentials_helper name %q is invalid: names must start with a letter and contain only letters, digits, dashes, and underscores.", name)))
+		}
+
+		for _, envName := range helper.EnvAllowlist {
+			if envName == "" {
+				diags = diags.Append(c.credentialsHelperError(name, fmt.Sprintf("The credentials_helper %q env_allowlist includes an empty variable name.", name)))
+			}
+		}
+
+		if helper.Timeout < 0 {
+			diags = diags.Append(c.credentialsHelperError(name, fmt.Sprintf("The credentials_helper %q \"timeout\" value must not be negative.", name)))
+		}
+	}
+
+	if c.RateLimit < 0 {
+		diags = diags.Append(
+			fmt.Errorf("The \"rate_limit\" value must not be negative"),
+		)
+	}
+
+	// Check that any "registry" block has sane values. Negative durations
+	// or retry counts don't make sense and most likely indicate a typo.
+	if c.Registry != nil {
+		if c.Registry.Retries < 0 {
+			diags = diags.Append(
+				fmt.Errorf("The \"registry\" block's \"retries\" value must not be negative"),
+			)
+		}
+		if c.Registry.Timeout < 0 {
+			diags = diags.Append(
+				fmt.Errorf("The \"registry\" block's \"timeout\" value must not be negative"),
+			)
+		}
+		if c.Registry.BackoffMax < 0 {
 			diags = diags.Append(
-				fmt.Errorf("The credentials %q block has an invalid hostname: %s", givenHost, err),
+				fmt.Errorf("The \"registry\" block's \"backoff_max\" value must not be negative"),
 			)
 		}
 	}
 
-	// Should have zero or one "credentials_helper" blocks
-	if len(c.CredentialsHelpers) > 1 {
+	// An "audit" block with no path wouldn't know where to write, so we
+	// require one rather than silently discarding every record.
+	if c.Audit != nil && c.Audit.Path == "" {
 		diags = diags.Append(
-			fmt.Errorf("No more than one credentials_helper block may be specified"),
+			fmt.Errorf("The \"audit\" block's \"path\" value must not be empty"),
 		)
 	}
 
+	// Each "function" block's label becomes the name callers use in an
+	// expression, so it's constrained to the same syntax as a built-in
+	// function name, and its wasm_file can't be left pointing at nothing.
+	for name, fn := range c.Functions {
+		if !functionNamePattern.MatchString(name) {
+			diags = diags.Append(
+				fmt.Errorf("The function name %q is invalid: names must start with a lowercase letter and contain only lowercase letters, digits, and underscores.", name),
+			)
+		}
+		if fn.WASMFile == "" {
+			diags = diags.Append(
+				fmt.Errorf("The function %q block's \"wasm_file\" value must not be empty.", name),
+			)
+		}
+	}
+
+	// Each "external_programs" entry must be an absolute path, in the same
+	// way as a credentials helper's executable, so that the external
+	// function can never be tricked into running a different program of
+	// the same name found earlier on PATH.
+	for _, path := range c.ExternalPrograms {
+		if !filepath.IsAbs(path) {
+			diags = diags.Append(
+				fmt.Errorf("The \"external_programs\" entry %q is invalid: it must be an absolute path.", path),
+			)
+		}
+	}
+
+	// Disabling the checkpoint signature without disabling checkpoint
+	// altogether means we still send the same telemetry but can no longer
+	// verify that the response actually came from HashiCorp, which is
+	// unlikely to be intentional.
+	if c.DisableCheckpointSignature && !c.DisableCheckpoint {
+		diags = diags.Append(tfdiags.WithCode(
+			tfdiags.SimpleWarning("disable_checkpoint_signature is set without disable_checkpoint; checkpoint responses will not be verified"),
+			"CLICONFIG_W001",
+		))
+	}
+
+	// Resolve ca_bundle_path eagerly so that a missing file or a file with
+	// no usable certificates in it is reported here, at config load time,
+	// rather than surfacing later as a confusing TLS handshake failure from
+	// whichever command happens to make the first plugin or registry
+	// request.
+	if _, err := c.TLSConfig(); err != nil {
+		diags = diags.Append(err)
+	}
+
+	// Disabling TLS verification for plugin and registry downloads removes
+	// protection against a tampered or impersonated download, so we warn
+	// every time it's in effect rather than only when it's first
+	// configured, as a standing reminder that it should be turned back off
+	// once whatever it's being used to debug is resolved.
+	if c.PluginTLSVerifyDisabled() {
+		diags = diags.Append(tfdiags.WithCode(
+			tfdiags.SimpleWarning("disable_plugin_tls_verify is set and TF_DISABLE_PLUGIN_TLS_VERIFY is non-empty; TLS certificate verification is disabled for plugin and registry downloads"),
+			"CLICONFIG_W007",
+		))
+	}
+
+	// Warn about any legacy plugin path overrides still in use, and point
+	// at their replacement, so that users migrate away from them before
+	// they're removed for good.
+	for _, o := range c.LegacyOverridesInUse() {
+		switch o.Kind {
+		case "provider":
+			diags = diags.Append(tfdiags.WithCode(
+				tfdiags.SimpleWarning(fmt.Sprintf("The top-level \"providers\" override for %q is deprecated; specify provider plugin locations using the normal plugin search paths instead", o.Name)),
+				"CLICONFIG_W003",
+			))
+		case "provisioner":
+			diags = diags.Append(tfdiags.WithCode(
+				tfdiags.SimpleWarning(fmt.Sprintf("The top-level \"provisioners\" override for %q is deprecated and no longer has any effect; move it into a \"provisioner_installation\" block's \"dev_overrides\" instead", o.Name)),
+				"CLICONFIG_W004",
+			))
+		}
+	}
+
 	return diags
 }
 
+// sortedCredentialsHelperNames returns the labels of the given
+// "credentials_helper" blocks in a deterministic order, so that validation
+// and host-routing logic -- which otherwise has no ordering to go on, since
+// the position-tracking in credentialsHelperPositions spans however many
+// files were merged to build this Config -- can behave the same way from
+// one run to the next.
+func sortedCredentialsHelperNames(helpers map[string]*ConfigCredentialsHelper) []string {
+	names := make([]string, 0, len(helpers))
+	for name := range helpers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// credentialsHelperError builds a diagnostic about the named
+// "credentials_helper" block, prefixed with that block's source position
+// when known.
+func (c *Config) credentialsHelperError(name, detail string) error {
+	if pos, ok := c.credentialsHelperPositions[name]; ok {
+		detail = fmt.Sprintf("%s: %s", pos, detail)
+	}
+	return fmt.Errorf("%s", detail)
+}
+
+// validateCredentialsHelperHostPattern checks that pattern is either a bare
+// hostname or a "*." wildcard followed by one, returning a descriptive
+// error if not.
+func validateCredentialsHelperHostPattern(pattern string) error {
+	if pattern == "" {
+		return fmt.Errorf("pattern must not be empty")
+	}
+
+	suffix := strings.TrimPrefix(pattern, "*.")
+	if suffix == pattern {
+		// No wildcard prefix, so the whole pattern must be a plain hostname.
+		if strings.Contains(pattern, "*") {
+			return fmt.Errorf("a wildcard is only allowed as a \"*.\" prefix")
+		}
+		return nil
+	}
+
+	if suffix == "" {
+		return fmt.Errorf("a \"*.\" wildcard must be followed by a hostname")
+	}
+	if strings.Contains(suffix, "*") {
+		return fmt.Errorf("only one \"*.\" wildcard is allowed, at the start of the pattern")
+	}
+	return nil
+}
+
+// positioned re-wraps each diagnostic in diags so that its detail message
+// is prefixed with the given source position, leaving diags unchanged if
+// pos is empty (as it will be for a Config that didn't come from
+// loadConfigFile, or whose relevant block loadConfigFile couldn't find a
+// position for).
+func positioned(diags tfdiags.Diagnostics, pos string) tfdiags.Diagnostics {
+	if pos == "" {
+		return diags
+	}
+
+	var ret tfdiags.Diagnostics
+	for _, diag := range diags {
+		desc := diag.Description()
+		ret = ret.Append(tfdiags.Sourceless(
+			diag.Severity(),
+			desc.Summary,
+			fmt.Sprintf("%s: %s", pos, desc.Detail),
+		))
+	}
+	return ret
+}
+
 // Merge merges two configurations and returns a third entirely
-// new configuration with the two merged.
-func (c1 *Config) Merge(c2 *Config) *Config {
+// new configuration with the two merged, along with any diagnostics
+// produced while doing so -- currently just a warning when the same host
+// has a "credentials" block in both configurations, since c2 silently
+// wins in that case.
+func (c1 *Config) Merge(c2 *Config) (*Config, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
 	var result Config
-	result.Providers = make(map[string]string)
-	result.Provisioners = make(map[string]string)
+	result.Providers = make(map[string]string, len(c1.Providers)+len(c2.Providers))
 	for k, v := range c1.Providers {
 		result.Providers[k] = v
 	}
@@ -260,15 +1416,6 @@ func (c1 *Config) Merge(c2 *Config) *Config {
 		}
 		result.Providers[k] = v
 	}
-	for k, v := range c1.Provisioners {
-		result.Provisioners[k] = v
-	}
-	for k, v := range c2.Provisioners {
-		if v1, ok := c1.Provisioners[k]; ok {
-			log.Printf("[INFO] Local %s provisioner configuration '%s' overrides '%s'", k, v, v1)
-		}
-		result.Provisioners[k] = v
-	}
 	result.DisableCheckpoint = c1.DisableCheckpoint || c2.DisableCheckpoint
 	result.DisableCheckpointSignature = c1.DisableCheckpointSignature || c2.DisableCheckpointSignature
 
@@ -277,40 +1424,157 @@ func (c1 *Config) Merge(c2 *Config) *Config {
 		result.PluginCacheDir = c2.PluginCacheDir
 	}
 
+	result.PluginCacheStrictChecksum = c1.PluginCacheStrictChecksum || c2.PluginCacheStrictChecksum
+	result.DisablePluginTLSVerify = c1.DisablePluginTLSVerify || c2.DisablePluginTLSVerify
+
+	result.CABundlePath = c1.CABundlePath
+	if result.CABundlePath == "" {
+		result.CABundlePath = c2.CABundlePath
+	}
+
+	result.RateLimit = c1.RateLimit
+	if result.RateLimit == 0 {
+		result.RateLimit = c2.RateLimit
+	}
+
+	if (len(c1.Features) + len(c2.Features)) > 0 {
+		result.Features = make(map[string]bool, len(c1.Features)+len(c2.Features))
+		for k, v := range c1.Features {
+			result.Features[k] = v
+		}
+		for k, v := range c2.Features {
+			result.Features[k] = v
+		}
+	}
+
 	if (len(c1.Hosts) + len(c2.Hosts)) > 0 {
-		result.Hosts = make(map[string]*ConfigHost)
+		result.Hosts = make(map[string]*ConfigHost, len(c1.Hosts)+len(c2.Hosts))
 		for name, host := range c1.Hosts {
 			result.Hosts[name] = host
+			result.setHostPosition(name, c1.hostPositions[name])
 		}
 		for name, host := range c2.Hosts {
 			result.Hosts[name] = host
+			result.setHostPosition(name, c2.hostPositions[name])
 		}
 	}
 
 	if (len(c1.Credentials) + len(c2.Credentials)) > 0 {
-		result.Credentials = make(map[string]map[string]interface{})
+		result.Credentials = make(map[string]map[string]interface{}, len(c1.Credentials)+len(c2.Credentials))
 		for host, creds := range c1.Credentials {
 			result.Credentials[host] = creds
+			result.setCredentialsSource(host, c1.credentialsSources[host])
+			result.setCredentialsPosition(host, c1.credentialsPositions[host])
 		}
 		for host, creds := range c2.Credentials {
+			if _, exists := c1.Credentials[host]; exists {
+				oldFile, newFile := c1.credentialsSources[host], c2.credentialsSources[host]
+				if oldFile != "" && newFile != "" && oldFile != newFile {
+					diags = diags.Append(tfdiags.WithCode(
+						tfdiags.SimpleWarning(fmt.Sprintf(
+							"Host %q has \"credentials\" blocks in both %s and %s; the one in %s will be used",
+							host, oldFile, newFile, newFile,
+						)),
+						"CLICONFIG_W002",
+					))
+				}
+			}
+
 			// We just clobber an entry from the other file right now. Will
 			// improve on this later using the more-robust merging behavior
 			// built in to HCL2.
 			result.Credentials[host] = creds
+			result.setCredentialsSource(host, c2.credentialsSources[host])
+			result.setCredentialsPosition(host, c2.credentialsPositions[host])
 		}
 	}
 
 	if (len(c1.CredentialsHelpers) + len(c2.CredentialsHelpers)) > 0 {
-		result.CredentialsHelpers = make(map[string]*ConfigCredentialsHelper)
+		result.CredentialsHelpers = make(map[string]*ConfigCredentialsHelper, len(c1.CredentialsHelpers)+len(c2.CredentialsHelpers))
 		for name, helper := range c1.CredentialsHelpers {
 			result.CredentialsHelpers[name] = helper
+			result.setCredentialsHelperPosition(name, c1.credentialsHelperPositions[name])
 		}
 		for name, helper := range c2.CredentialsHelpers {
 			result.CredentialsHelpers[name] = helper
+			result.setCredentialsHelperPosition(name, c2.credentialsHelperPositions[name])
 		}
 	}
 
-	return &result
+	result.Diagnostics = c1.Diagnostics
+	if c2.Diagnostics != nil {
+		result.Diagnostics = c2.Diagnostics
+	}
+
+	result.Registry = c1.Registry
+	if c2.Registry != nil {
+		result.Registry = c2.Registry
+	}
+
+	result.Audit = c1.Audit
+	if c2.Audit != nil {
+		result.Audit = c2.Audit
+	}
+
+	result.ProvisionerInstallation = c1.ProvisionerInstallation
+	if c2.ProvisionerInstallation != nil {
+		result.ProvisionerInstallation = c2.ProvisionerInstallation
+	}
+
+	if (len(c1.Functions) + len(c2.Functions)) > 0 {
+		result.Functions = make(map[string]*ConfigFunction, len(c1.Functions)+len(c2.Functions))
+		for name, fn := range c1.Functions {
+			result.Functions[name] = fn
+		}
+		for name, fn := range c2.Functions {
+			result.Functions[name] = fn
+		}
+	}
+
+	if (len(c1.legacyProvisionerOverrides) + len(c2.legacyProvisionerOverrides)) > 0 {
+		result.legacyProvisionerOverrides = make(map[string]string, len(c1.legacyProvisionerOverrides)+len(c2.legacyProvisionerOverrides))
+		for k, v := range c1.legacyProvisionerOverrides {
+			result.legacyProvisionerOverrides[k] = v
+		}
+		for k, v := range c2.legacyProvisionerOverrides {
+			result.legacyProvisionerOverrides[k] = v
+		}
+	}
+
+	result.sourceFiles = append(result.sourceFiles, c1.sourceFiles...)
+	result.sourceFiles = append(result.sourceFiles, c2.sourceFiles...)
+
+	// TrustedKeys accumulates rather than overriding, since it's an
+	// allowlist: a key trusted by either file being merged should remain
+	// trusted in the result, so that a system directory loaded later can
+	// still be verified against a key contributed by the main file or by
+	// a system directory loaded earlier.
+	result.TrustedKeys = append(result.TrustedKeys, c1.TrustedKeys...)
+	result.TrustedKeys = append(result.TrustedKeys, c2.TrustedKeys...)
+
+	// ExternalPrograms accumulates for the same reason as TrustedKeys: it's
+	// an allowlist, so a program allowed by either file being merged should
+	// remain allowed in the result.
+	result.ExternalPrograms = append(result.ExternalPrograms, c1.ExternalPrograms...)
+	result.ExternalPrograms = append(result.ExternalPrograms, c2.ExternalPrograms...)
+
+	return &result, diags
+}
+
+// FeatureEnabled reports whether the named entry in a "features" block is
+// set, falling back to def if the CLI config doesn't mention name at all.
+//
+// This is the sanctioned way for an opt-in, experimental behavior elsewhere
+// in Terraform to consult the CLI config, rather than inventing its own
+// ad-hoc setting or environment variable; see Features for more on why.
+func (c *Config) FeatureEnabled(name string, def bool) bool {
+	if c == nil {
+		return def
+	}
+	if v, ok := c.Features[name]; ok {
+		return v
+	}
+	return def
 }
 
 func cliConfigFile() (string, error) {
@@ -1,12 +1,21 @@
 package cliconfig
 
 import (
+	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"reflect"
+	"sort"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/davecgh/go-spew/spew"
+	homedir "github.com/mitchellh/go-homedir"
+
+	"github.com/hashicorp/terraform/registry"
+	"github.com/hashicorp/terraform/tfdiags"
 )
 
 // This is the directory where our test fixtures are.
@@ -44,8 +53,10 @@ func TestLoadConfig_env(t *testing.T) {
 			"aws":    "hello",
 			"google": "bar",
 		},
-		Provisioners: map[string]string{
-			"local": "hello",
+		ProvisionerInstallation: &ProvisionerInstallation{
+			DevOverrides: map[string]string{
+				"local": "hello",
+			},
 		},
 	}
 
@@ -54,6 +65,60 @@ func TestLoadConfig_env(t *testing.T) {
 	}
 }
 
+func TestLoadConfig_pluginCacheDirTilde(t *testing.T) {
+	home, err := homedir.Dir()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, diags := loadConfigFile(filepath.Join(fixtureDir, "config-plugin-cache-dir-tilde"))
+	if diags != nil {
+		t.Fatalf("err: %s", diags)
+	}
+
+	want := filepath.Join(home, ".terraform.d", "plugin-cache")
+	if c.PluginCacheDir != want {
+		t.Fatalf("wrong PluginCacheDir\ngot:  %s\nwant: %s", c.PluginCacheDir, want)
+	}
+}
+
+func TestLoadConfig_vars(t *testing.T) {
+	c, diags := loadConfigFile(filepath.Join(fixtureDir, "config-vars"))
+	if diags != nil {
+		t.Fatalf("err: %s", diags)
+	}
+
+	if got, want := c.Providers["aws"], "example-corp.example.com"; got != want {
+		t.Errorf("wrong providers.aws\ngot:  %s\nwant: %s", got, want)
+	}
+
+	hostConfig, ok := c.Hosts["${var.hostname}"]
+	if !ok {
+		t.Fatal("missing host block (note: block labels are not expanded)")
+	}
+	if got, want := hostConfig.Services["modules.v1"], "https://example-corp.example.com/modules/"; got != want {
+		t.Errorf("wrong host service URL\ngot:  %s\nwant: %s", got, want)
+	}
+
+	creds, ok := c.Credentials["${var.hostname}"]
+	if !ok {
+		t.Fatal("missing credentials block (note: block labels are not expanded)")
+	}
+	if got, want := creds["token"], "example-corp-token"; got != want {
+		t.Errorf("wrong credentials token\ngot:  %s\nwant: %s", got, want)
+	}
+}
+
+func TestLoadConfig_varsCycle(t *testing.T) {
+	_, diags := loadConfigFile(filepath.Join(fixtureDir, "config-vars-cycle"))
+	if diags == nil {
+		t.Fatal("succeeded; want error")
+	}
+	if !strings.Contains(diags.Err().Error(), "cycle in vars block") {
+		t.Fatalf("wrong error: %s", diags.Err())
+	}
+}
+
 func TestLoadConfig_hosts(t *testing.T) {
 	got, diags := loadConfigFile(filepath.Join(fixtureDir, "hosts"))
 	if len(diags) != 0 {
@@ -68,6 +133,9 @@ func TestLoadConfig_hosts(t *testing.T) {
 				},
 			},
 		},
+		hostPositions: map[string]string{
+			"example.com": "2:6",
+		},
 	}
 
 	if !reflect.DeepEqual(got, want) {
@@ -96,6 +164,17 @@ func TestLoadConfig_credentials(t *testing.T) {
 				Args: []string{"bar", "baz"},
 			},
 		},
+		credentialsSources: map[string]string{
+			"example.com": filepath.Join(fixtureDir, "credentials"),
+			"example.net": filepath.Join(fixtureDir, "credentials"),
+		},
+		credentialsPositions: map[string]string{
+			"example.com": "2:13",
+			"example.net": "6:13",
+		},
+		credentialsHelperPositions: map[string]string{
+			"foo": "15:20",
+		},
 	}
 
 	if !reflect.DeepEqual(got, want) {
@@ -103,6 +182,655 @@ func TestLoadConfig_credentials(t *testing.T) {
 	}
 }
 
+func TestLoadConfigDir_caseInsensitive(t *testing.T) {
+	dir, err := ioutil.TempDir("", "terraform-cliconfig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "a.TFRC"), []byte(`providers { aws = "foo" }`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "b.Tfrc.JSON"), []byte(`{"providers":{"google":"bar"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, diags := loadConfigDir(dir)
+	if len(diags) != 0 {
+		t.Fatalf("%s", diags.Err())
+	}
+
+	want := &Config{
+		Providers: map[string]string{
+			"aws":    "foo",
+			"google": "bar",
+		},
+	}
+
+	if !reflect.DeepEqual(got.Providers, want.Providers) {
+		t.Errorf("wrong result\ngot:  %swant: %s", spew.Sdump(got.Providers), spew.Sdump(want.Providers))
+	}
+}
+
+func TestLoadConfig_hostsAndCredentialsWithPort(t *testing.T) {
+	dir, err := ioutil.TempDir("", "terraform-cliconfig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	src := `
+host "registry.internal:8443" {
+  services = {
+    "modules.v1" = "https://registry.internal:8443/v1/modules/"
+  }
+}
+
+credentials "registry.internal:8443" {
+  token = "self-hosted-token"
+}
+`
+	if err := ioutil.WriteFile(filepath.Join(dir, "a.tfrc"), []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, diags := loadConfigDir(dir)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+
+	if _, ok := got.Hosts["registry.internal:8443"]; !ok {
+		t.Fatalf("missing host block for port-scoped hostname\n%s", spew.Sdump(got.Hosts))
+	}
+	if _, ok := got.Credentials["registry.internal:8443"]; !ok {
+		t.Fatalf("missing credentials block for port-scoped hostname\n%s", spew.Sdump(got.Credentials))
+	}
+}
+
+func TestLoadConfigDir_credentialsHostCaseInsensitive(t *testing.T) {
+	dir, err := ioutil.TempDir("", "terraform-cliconfig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "a.tfrc"), []byte(`credentials "EXAMPLE.com" { token = "a" }`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "b.tfrc"), []byte(`credentials "example.com" { token = "b" }`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, diags := loadConfigDir(dir)
+
+	// The two files configure what normalizes to the same host, so we
+	// expect the same "credentials block appears twice" warning that
+	// loading two differently-named files for the identical hostname
+	// would produce.
+	if !diags.HasErrors() && len(diags) == 0 {
+		t.Fatalf("expected a warning about the duplicate host, got none")
+	}
+
+	if len(got.Credentials) != 1 {
+		t.Fatalf("wrong number of distinct credentials hosts: got %d, want 1\n%s", len(got.Credentials), spew.Sdump(got.Credentials))
+	}
+}
+
+func TestLoadConfig_registry(t *testing.T) {
+	dir, err := ioutil.TempDir("", "terraform-cliconfig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	src := `
+registry {
+  retries     = 3
+  timeout     = 5
+  backoff_max = 20
+}
+`
+	if err := ioutil.WriteFile(filepath.Join(dir, "a.tfrc"), []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, diags := loadConfigDir(dir)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+
+	want := &ConfigRegistry{
+		Retries:    3,
+		Timeout:    5,
+		BackoffMax: 20,
+	}
+	if !reflect.DeepEqual(got.Registry, want) {
+		t.Errorf("wrong result\ngot:  %swant: %s", spew.Sdump(got.Registry), spew.Sdump(want))
+	}
+
+	retry := got.RegistryRetryConfig()
+	if retry.Retries != 3 {
+		t.Errorf("wrong Retries: got %d, want 3", retry.Retries)
+	}
+	if retry.Timeout != 5*time.Second {
+		t.Errorf("wrong Timeout: got %s, want 5s", retry.Timeout)
+	}
+	if retry.BackoffMax != 20*time.Second {
+		t.Errorf("wrong BackoffMax: got %s, want 20s", retry.BackoffMax)
+	}
+}
+
+func TestLoadConfig_provisionerInstallation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "terraform-cliconfig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	src := `
+provisioner_installation {
+  search_paths = ["/opt/terraform/provisioners"]
+
+  dev_overrides {
+    local = "/home/user/go/bin/terraform-provisioner-local"
+  }
+}
+`
+	if err := ioutil.WriteFile(filepath.Join(dir, "a.tfrc"), []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, diags := loadConfigDir(dir)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+
+	want := &ProvisionerInstallation{
+		SearchPaths: []string{"/opt/terraform/provisioners"},
+		DevOverrides: map[string]string{
+			"local": "/home/user/go/bin/terraform-provisioner-local",
+		},
+	}
+	if !reflect.DeepEqual(got.ProvisionerInstallation, want) {
+		t.Errorf("wrong result\ngot:  %swant: %s", spew.Sdump(got.ProvisionerInstallation), spew.Sdump(want))
+	}
+
+	if !reflect.DeepEqual(got.ProvisionerSearchPaths(), want.SearchPaths) {
+		t.Errorf("wrong ProvisionerSearchPaths result: %#v", got.ProvisionerSearchPaths())
+	}
+	if !reflect.DeepEqual(got.ProvisionerDevOverrides(), want.DevOverrides) {
+		t.Errorf("wrong ProvisionerDevOverrides result: %#v", got.ProvisionerDevOverrides())
+	}
+}
+
+func TestLoadConfig_legacyProvisionerOverride(t *testing.T) {
+	dir, err := ioutil.TempDir("", "terraform-cliconfig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	src := `
+provisioners {
+  local = "/path/to/terraform-provisioner-local"
+}
+`
+	if err := ioutil.WriteFile(filepath.Join(dir, "a.tfrc"), []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, diags := loadConfigDir(dir)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+
+	want := []LegacyPluginOverride{
+		{Kind: "provisioner", Name: "local", Path: "/path/to/terraform-provisioner-local"},
+	}
+	if !reflect.DeepEqual(got.LegacyOverridesInUse(), want) {
+		t.Errorf("wrong result\ngot:  %swant: %s", spew.Sdump(got.LegacyOverridesInUse()), spew.Sdump(want))
+	}
+
+	validateDiags := got.Validate()
+	if len(validateDiags) != 1 {
+		t.Fatalf("wrong number of diagnostics %d; want 1\n%s", len(validateDiags), validateDiags.Err())
+	}
+}
+
+func TestConfig_LegacyOverridesInUse(t *testing.T) {
+	c := &Config{
+		Providers: map[string]string{
+			"mock": "/path/to/terraform-provider-mock",
+		},
+	}
+	c.legacyProvisionerOverrides = map[string]string{
+		"local": "/path/to/terraform-provisioner-local",
+	}
+
+	want := []LegacyPluginOverride{
+		{Kind: "provider", Name: "mock", Path: "/path/to/terraform-provider-mock"},
+		{Kind: "provisioner", Name: "local", Path: "/path/to/terraform-provisioner-local"},
+	}
+	if got := c.LegacyOverridesInUse(); !reflect.DeepEqual(got, want) {
+		t.Errorf("wrong result\ngot:  %swant: %s", spew.Sdump(got), spew.Sdump(want))
+	}
+
+	var nilConfig *Config
+	if got := nilConfig.LegacyOverridesInUse(); len(got) != 0 {
+		t.Errorf("wrong result for nil Config: %#v", got)
+	}
+}
+
+func TestConfig_ProvisionerSearchPaths_nilWhenUnset(t *testing.T) {
+	var c *Config
+	if got := c.ProvisionerSearchPaths(); got != nil {
+		t.Errorf("wrong result for nil Config: %#v", got)
+	}
+	if got := c.ProvisionerDevOverrides(); got != nil {
+		t.Errorf("wrong result for nil Config: %#v", got)
+	}
+
+	c = &Config{}
+	if got := c.ProvisionerSearchPaths(); got != nil {
+		t.Errorf("wrong result for empty Config: %#v", got)
+	}
+	if got := c.ProvisionerDevOverrides(); got != nil {
+		t.Errorf("wrong result for empty Config: %#v", got)
+	}
+}
+
+func TestConfig_RegistryRetryConfig_defaultsWhenUnset(t *testing.T) {
+	var c *Config
+	got := c.RegistryRetryConfig()
+	want := registry.DefaultRetryConfig()
+	if got != want {
+		t.Errorf("wrong result: got %#v, want %#v", got, want)
+	}
+
+	c = &Config{}
+	got = c.RegistryRetryConfig()
+	if got != want {
+		t.Errorf("wrong result for empty Config: got %#v, want %#v", got, want)
+	}
+}
+
+func TestConfig_PluginTLSVerifyDisabled(t *testing.T) {
+	defer os.Setenv(pluginTLSVerifyDisableEnvVar, os.Getenv(pluginTLSVerifyDisableEnvVar))
+
+	tests := []struct {
+		Name       string
+		ConfigFlag bool
+		EnvSet     bool
+		Want       bool
+	}{
+		{"neither set", false, false, false},
+		{"config set without env var", true, false, false},
+		{"env var set without config", false, true, false},
+		{"both set", true, true, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			if test.EnvSet {
+				os.Setenv(pluginTLSVerifyDisableEnvVar, "1")
+			} else {
+				os.Unsetenv(pluginTLSVerifyDisableEnvVar)
+			}
+
+			c := &Config{DisablePluginTLSVerify: test.ConfigFlag}
+			if got := c.PluginTLSVerifyDisabled(); got != test.Want {
+				t.Errorf("wrong result: got %v, want %v", got, test.Want)
+			}
+		})
+	}
+}
+
+func TestConfigValidate_pluginTLSVerifyDisabled(t *testing.T) {
+	defer os.Setenv(pluginTLSVerifyDisableEnvVar, os.Getenv(pluginTLSVerifyDisableEnvVar))
+
+	os.Unsetenv(pluginTLSVerifyDisableEnvVar)
+	c := &Config{DisablePluginTLSVerify: true}
+	if diags := c.Validate(); len(diags) != 0 {
+		t.Errorf("expected no diagnostics with the env var unset, got: %s", diags.Err())
+	}
+
+	os.Setenv(pluginTLSVerifyDisableEnvVar, "1")
+	diags := c.Validate()
+	if len(diags) != 1 {
+		t.Fatalf("wrong number of diagnostics %d; want 1\n%s", len(diags), diags.Err())
+	}
+	if got, want := diags[0].Description().Summary, "TLS certificate verification is disabled"; !strings.Contains(got, want) {
+		t.Errorf("wrong diagnostic summary %q; want it to contain %q", got, want)
+	}
+}
+
+func TestLoadConfig_rateLimit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "terraform-cliconfig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "a.tfrc"), []byte(`rate_limit = 4.5`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, diags := loadConfigDir(dir)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+
+	if got.RateLimit != 4.5 {
+		t.Errorf("wrong RateLimit: got %v, want 4.5", got.RateLimit)
+	}
+}
+
+func TestConfig_Merge_rateLimit(t *testing.T) {
+	c1 := &Config{RateLimit: 2}
+	c2 := &Config{RateLimit: 5}
+
+	merged, diags := c1.Merge(c2)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+	if merged.RateLimit != 2 {
+		t.Errorf("wrong RateLimit: got %v, want 2 (c1 should win when both are set)", merged.RateLimit)
+	}
+
+	c3 := &Config{}
+	merged, diags = c3.Merge(c2)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+	if merged.RateLimit != 5 {
+		t.Errorf("wrong RateLimit: got %v, want 5 (falls back to c2 when c1 is unset)", merged.RateLimit)
+	}
+}
+
+func TestConfig_Validate_rateLimit(t *testing.T) {
+	c := &Config{RateLimit: -1}
+	if diags := c.Validate(); !diags.HasErrors() {
+		t.Fatal("expected an error for a negative rate_limit")
+	}
+}
+
+func TestLoadConfigFile_tooLarge(t *testing.T) {
+	dir, err := ioutil.TempDir("", "terraform-cliconfig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "huge.tfrc")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Truncate(maxConfigFileSize + 1); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	_, diags := loadConfigFile(path)
+	if !diags.HasErrors() {
+		t.Fatal("expected an error for an oversized config file")
+	}
+}
+
+func TestLoadConfigDir_tooManyFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "terraform-cliconfig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for i := 0; i < maxConfigDirFiles+1; i++ {
+		name := fmt.Sprintf("%04d.tfrc", i)
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(``), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, diags := loadConfigDir(dir)
+	if !diags.HasErrors() {
+		t.Fatal("expected an error when a config directory has too many files")
+	}
+}
+
+func TestLoadConfig_dropInDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "terraform-cliconfig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	mainFilename := filepath.Join(dir, "terraformrc")
+	mainSrc := `
+providers = {
+  aws = "main-aws"
+  do  = "main-do"
+}
+`
+	if err := ioutil.WriteFile(mainFilename, []byte(mainSrc), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	dropInDir := mainFilename + ".d"
+	if err := os.Mkdir(dropInDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	// Lexicographically later files are merged later, so 20-override.tfrc
+	// should win over both the main file and 10-extra.tfrc.
+	extraSrc := `
+providers = {
+  google = "extra-google"
+}
+`
+	overrideSrc := `
+providers = {
+  aws = "override-aws"
+}
+`
+	if err := ioutil.WriteFile(filepath.Join(dropInDir, "10-extra.tfrc"), []byte(extraSrc), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dropInDir, "20-override.tfrc"), []byte(overrideSrc), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.Unsetenv("TF_CLI_CONFIG_FILE")
+	os.Setenv("TF_CLI_CONFIG_FILE", mainFilename)
+	defer os.Unsetenv("HOME")
+	os.Setenv("HOME", dir)
+
+	c, diags := LoadConfig()
+	if diags.HasErrors() {
+		t.Fatalf("unexpected diagnostics: %s", diags.Err())
+	}
+
+	expected := map[string]string{
+		"aws":    "override-aws",
+		"do":     "main-do",
+		"google": "extra-google",
+	}
+	if !reflect.DeepEqual(c.Providers, expected) {
+		t.Fatalf("wrong providers\ngot:  %#v\nwant: %#v", c.Providers, expected)
+	}
+}
+
+func TestLoadConfig_noDropInDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "terraform-cliconfig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	mainFilename := filepath.Join(dir, "terraformrc")
+	if err := ioutil.WriteFile(mainFilename, []byte(`providers = { aws = "main-aws" }`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.Unsetenv("TF_CLI_CONFIG_FILE")
+	os.Setenv("TF_CLI_CONFIG_FILE", mainFilename)
+	defer os.Unsetenv("HOME")
+	os.Setenv("HOME", dir)
+
+	c, diags := LoadConfig()
+	if diags.HasErrors() {
+		t.Fatalf("unexpected diagnostics: %s", diags.Err())
+	}
+
+	if got, want := c.Providers["aws"], "main-aws"; got != want {
+		t.Fatalf("wrong aws provider: got %q, want %q", got, want)
+	}
+}
+
+func TestLoadConfigWithObserver(t *testing.T) {
+	dir, err := ioutil.TempDir("", "terraform-cliconfig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	mainFilename := filepath.Join(dir, "terraformrc")
+	if err := ioutil.WriteFile(mainFilename, []byte(`providers = { aws = "main-aws" }`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.Unsetenv("TF_CLI_CONFIG_FILE")
+	os.Setenv("TF_CLI_CONFIG_FILE", mainFilename)
+	defer os.Unsetenv("HOME")
+	os.Setenv("HOME", dir)
+
+	var phases []string
+	_, diags := LoadConfigWithObserver(func(phase string, d time.Duration) {
+		phases = append(phases, phase)
+	})
+	if diags.HasErrors() {
+		t.Fatalf("unexpected diagnostics: %s", diags.Err())
+	}
+
+	wantPhases := []string{"main file", "config dir", "env config", "validate"}
+	if !reflect.DeepEqual(phases, wantPhases) {
+		t.Fatalf("wrong phases\ngot:  %#v\nwant: %#v", phases, wantPhases)
+	}
+}
+
+func TestConfigValidate_tooManyCredentialsBlocks(t *testing.T) {
+	creds := make(map[string]map[string]interface{}, maxCredentialsBlocks+1)
+	for i := 0; i < maxCredentialsBlocks+1; i++ {
+		creds[fmt.Sprintf("example%d.com", i)] = map[string]interface{}{"token": "x"}
+	}
+	c := &Config{Credentials: creds}
+
+	diags := c.Validate()
+	if !diags.HasErrors() {
+		t.Fatal("expected an error for too many credentials blocks")
+	}
+}
+
+func TestLoadConfigDir_symlinksAndDotfiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "terraform-cliconfig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	realFile := filepath.Join(dir, "real.tfrc")
+	if err := ioutil.WriteFile(realFile, []byte(`providers { aws = "foo" }`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(realFile, filepath.Join(dir, "linked.tfrc")); err != nil {
+		t.Skipf("can't create symlinks in this environment: %s", err)
+	}
+	if err := os.Symlink(filepath.Join(dir, "does-not-exist"), filepath.Join(dir, "broken.tfrc")); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, ".hidden.tfrc"), []byte(`providers { google = "should not load" }`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, diags := loadConfigDir(dir)
+
+	want := map[string]string{"aws": "foo"}
+	if !reflect.DeepEqual(got.Providers, want) {
+		t.Errorf("wrong providers\ngot:  %swant: %s", spew.Sdump(got.Providers), spew.Sdump(want))
+	}
+
+	foundBrokenWarning := false
+	for _, diag := range diags {
+		if diag.Severity() == tfdiags.Warning {
+			foundBrokenWarning = true
+		}
+	}
+	if !foundBrokenWarning {
+		t.Errorf("expected a warning diagnostic for the broken symlink, got: %s", spew.Sdump(diags))
+	}
+}
+
+func TestConfigFileFormat(t *testing.T) {
+	tests := []struct {
+		path     string
+		override string
+		want     string
+	}{
+		{"foo.tfrc", "", "tfrc"},
+		{"foo.TFRC", "", "tfrc"},
+		{"/home/user/foo.tfrc", "", "tfrc"},
+		{"foo.tfrc.json", "", "tfrc.json"},
+		{"foo.Tfrc.JSON", "", "tfrc.json"},
+		{".terraformrc", "", "tfrc"},
+		{"/home/user/.terraformrc", "", "tfrc"},
+		{"terraform.rc", "", "tfrc"},
+		{`C:\Users\user\AppData\Roaming\terraform.rc`, "", ""}, // base name detection only; not a path separator this platform understands
+		{"foo.txt", "", ""},
+		{"foo.txt", "tfrc.json", "tfrc.json"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			if got := configFileFormat(test.path, test.override); got != test.want {
+				t.Errorf("configFileFormat(%q, %q) = %q; want %q", test.path, test.override, got, test.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeHostname(t *testing.T) {
+	tests := []struct {
+		given       string
+		want        string
+		wantErr     bool
+		wantWarning bool
+	}{
+		{"example.com", "example.com", false, false},
+		{"EXAMPLE.COM", "example.com", false, false},
+		{"example.com:443", "example.com", false, false},
+		{"example.com:8443", "example.com:8443", false, true},
+		{"example..com", "", true, false},
+		{"xn--caf-dma.com", "", true, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.given, func(t *testing.T) {
+			got, diags := NormalizeHostname(test.given)
+			if diags.HasErrors() != test.wantErr {
+				t.Fatalf("NormalizeHostname(%q) diags = %s; wantErr = %v", test.given, diags.Err(), test.wantErr)
+			}
+			if test.wantErr {
+				return
+			}
+			if string(got) != test.want {
+				t.Errorf("NormalizeHostname(%q) = %q; want %q", test.given, got, test.want)
+			}
+			if gotWarning := len(diags) > 0; gotWarning != test.wantWarning {
+				t.Errorf("NormalizeHostname(%q) produced %d diags; wantWarning = %v", test.given, len(diags), test.wantWarning)
+			}
+		})
+	}
+}
+
 func TestConfigValidate(t *testing.T) {
 	tests := map[string]struct {
 		Config    *Config
@@ -152,6 +880,16 @@ func TestConfigValidate(t *testing.T) {
 			},
 			1, // credentials block has invalid hostname
 		},
+		"default credentials block": {
+			&Config{
+				Credentials: map[string]map[string]interface{}{
+					defaultCredentialsHost: map[string]interface{}{
+						"token": "foo",
+					},
+				},
+			},
+			0,
+		},
 		"credentials helper good": {
 			&Config{
 				CredentialsHelpers: map[string]*ConfigCredentialsHelper{
@@ -169,6 +907,130 @@ func TestConfigValidate(t *testing.T) {
 			},
 			1, // no more than one credentials_helper block allowed
 		},
+		"credentials helper with bad name": {
+			&Config{
+				CredentialsHelpers: map[string]*ConfigCredentialsHelper{
+					"-foo": {},
+				},
+			},
+			1, // credentials_helper name is invalid
+		},
+		"credentials helper with empty env_allowlist entry": {
+			&Config{
+				CredentialsHelpers: map[string]*ConfigCredentialsHelper{
+					"foo": {
+						EnvAllowlist: []string{"HOME", ""},
+					},
+				},
+			},
+			1, // env_allowlist includes an empty variable name
+		},
+		"credentials helper with negative timeout": {
+			&Config{
+				CredentialsHelpers: map[string]*ConfigCredentialsHelper{
+					"foo": {
+						Timeout: -1,
+					},
+				},
+			},
+			1, // timeout must not be negative
+		},
+		"audit block with empty path": {
+			&Config{
+				Audit: &ConfigAudit{},
+			},
+			1, // path must not be empty
+		},
+		"audit block with path": {
+			&Config{
+				Audit: &ConfigAudit{Path: "audit.jsonl"},
+			},
+			0,
+		},
+		"credentials helper with hosts, routed to different helpers": {
+			&Config{
+				CredentialsHelpers: map[string]*ConfigCredentialsHelper{
+					"vault": {
+						Hosts: []string{"*.corp.example.com"},
+					},
+					"foo": {},
+				},
+			},
+			0,
+		},
+		"credentials helper with two catch-alls": {
+			&Config{
+				CredentialsHelpers: map[string]*ConfigCredentialsHelper{
+					"foo": {},
+					"bar": {},
+				},
+			},
+			1, // no more than one credentials_helper block may omit "hosts"
+		},
+		"credentials helper with overlapping host patterns": {
+			&Config{
+				CredentialsHelpers: map[string]*ConfigCredentialsHelper{
+					"vault": {
+						Hosts: []string{"registry.corp.example.com"},
+					},
+					"other": {
+						Hosts: []string{"registry.corp.example.com"},
+					},
+				},
+			},
+			1, // the hosts pattern is also claimed by another credentials_helper
+		},
+		"credentials helper with invalid host pattern": {
+			&Config{
+				CredentialsHelpers: map[string]*ConfigCredentialsHelper{
+					"vault": {
+						Hosts: []string{"*.*.example.com"},
+					},
+				},
+			},
+			1, // invalid hosts pattern
+		},
+		"checkpoint signature disabled without checkpoint disabled": {
+			&Config{
+				DisableCheckpointSignature: true,
+			},
+			1, // CLICONFIG_W001
+		},
+		"checkpoint signature disabled along with checkpoint": {
+			&Config{
+				DisableCheckpoint:          true,
+				DisableCheckpointSignature: true,
+			},
+			0,
+		},
+		"legacy provider override in use": {
+			&Config{
+				Providers: map[string]string{
+					"mock": "/path/to/terraform-provider-mock",
+				},
+			},
+			1, // CLICONFIG_W003
+		},
+		"host with valid pin_service_version": {
+			&Config{
+				Hosts: map[string]*ConfigHost{
+					"example.com": {
+						PinServiceVersions: map[string]string{"modules": "v1"},
+					},
+				},
+			},
+			0,
+		},
+		"host with invalid pin_service_version": {
+			&Config{
+				Hosts: map[string]*ConfigHost{
+					"example.com": {
+						PinServiceVersions: map[string]string{"modules": "not-a-version"},
+					},
+				},
+			},
+			1, // pin_service_version does not parse as a version
+		},
 	}
 
 	for name, test := range tests {
@@ -184,15 +1046,99 @@ func TestConfigValidate(t *testing.T) {
 	}
 }
 
+func TestConfigValidate_hostPosition(t *testing.T) {
+	config, diags := loadConfigFile(filepath.Join(fixtureDir, "config-bad-host-position"))
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors loading fixture: %s", diags.Err())
+	}
+
+	diags = config.Validate()
+	if !diags.HasErrors() {
+		t.Fatal("Validate succeeded; want an invalid hostname error")
+	}
+
+	got := diags.Err().Error()
+	if want := "2:6: The host"; !strings.Contains(got, want) {
+		t.Fatalf("diagnostic is missing its source position\ngot:  %s\nwant substring: %s", got, want)
+	}
+}
+
+func TestLoadConfig_unknownAttribute(t *testing.T) {
+	_, diags := loadConfigFile(filepath.Join(fixtureDir, "config-unknown-attr"))
+	if len(diags) != 1 {
+		t.Fatalf("wrong number of diagnostics %d; want 1\n%s", len(diags), diags.Err())
+	}
+
+	got := diags[0].Description().Summary
+	if want := `Did you mean "plugin_cache_dir"?`; !strings.Contains(got, want) {
+		t.Fatalf("wrong diagnostic\ngot:  %s\nwant substring: %s", got, want)
+	}
+}
+
+func TestConfig_knownSecrets(t *testing.T) {
+	config := &Config{
+		Credentials: map[string]map[string]interface{}{
+			"example.com": {
+				"token": "s3kr1t",
+				"other": 123, // not a string, so not a secret
+			},
+		},
+		CredentialsHelpers: map[string]*ConfigCredentialsHelper{
+			"foo": {
+				Env: map[string]string{"VAULT_TOKEN": "also-s3kr1t"},
+			},
+		},
+	}
+
+	got := config.knownSecrets()
+	sort.Strings(got)
+	want := []string{"also-s3kr1t", "s3kr1t"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("wrong result\ngot:  %#v\nwant: %#v", got, want)
+	}
+
+	if got := (*Config)(nil).knownSecrets(); got != nil {
+		t.Errorf("got %#v for a nil Config; want nil", got)
+	}
+}
+
+func TestConfigValidate_scrubsSecrets(t *testing.T) {
+	config := &Config{
+		Credentials: map[string]map[string]interface{}{
+			"example.com": {
+				"token": "s3kr1t",
+			},
+		},
+	}
+
+	// Simulate a diagnostic that leaks the configured token verbatim, as
+	// might happen if a credentials helper echoed back raw request output
+	// that happened to include it.
+	var diags tfdiags.Diagnostics
+	diags = diags.Append(tfdiags.Sourceless(
+		tfdiags.Error,
+		"Failed to fetch module",
+		"The server responded: Authorization: Bearer s3kr1t was rejected.",
+	))
+
+	diags = tfdiags.ScrubSecrets(diags, config.knownSecrets())
+
+	got := diags.Err().Error()
+	if strings.Contains(got, "s3kr1t") {
+		t.Fatalf("diagnostic leaks a known secret: %s", got)
+	}
+}
+
 func TestConfig_Merge(t *testing.T) {
 	c1 := &Config{
 		Providers: map[string]string{
 			"foo": "bar",
 			"bar": "blah",
 		},
-		Provisioners: map[string]string{
-			"local":  "local",
-			"remote": "bad",
+		ProvisionerInstallation: &ProvisionerInstallation{
+			DevOverrides: map[string]string{
+				"local": "local",
+			},
 		},
 		Hosts: map[string]*ConfigHost{
 			"example.com": {
@@ -216,8 +1162,10 @@ func TestConfig_Merge(t *testing.T) {
 			"bar": "baz",
 			"baz": "what",
 		},
-		Provisioners: map[string]string{
-			"remote": "remote",
+		ProvisionerInstallation: &ProvisionerInstallation{
+			DevOverrides: map[string]string{
+				"remote": "remote",
+			},
 		},
 		Hosts: map[string]*ConfigHost{
 			"example.net": {
@@ -242,9 +1190,10 @@ func TestConfig_Merge(t *testing.T) {
 			"bar": "baz",
 			"baz": "what",
 		},
-		Provisioners: map[string]string{
-			"local":  "local",
-			"remote": "remote",
+		ProvisionerInstallation: &ProvisionerInstallation{
+			DevOverrides: map[string]string{
+				"remote": "remote",
+			},
 		},
 		Hosts: map[string]*ConfigHost{
 			"example.com": {
@@ -272,12 +1221,66 @@ func TestConfig_Merge(t *testing.T) {
 		},
 	}
 
-	actual := c1.Merge(c2)
+	actual, diags := c1.Merge(c2)
+	if len(diags) != 0 {
+		t.Fatalf("unexpected diags: %s", diags.Err())
+	}
 	if !reflect.DeepEqual(actual, expected) {
 		t.Fatalf("bad: %#v", actual)
 	}
 }
 
+func TestConfig_Merge_features(t *testing.T) {
+	c1 := &Config{
+		Features: map[string]bool{
+			"foo": true,
+			"bar": false,
+		},
+	}
+	c2 := &Config{
+		Features: map[string]bool{
+			"bar": true,
+			"baz": true,
+		},
+	}
+
+	merged, diags := c1.Merge(c2)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+
+	want := map[string]bool{
+		"foo": true,
+		"bar": true, // c2 overrides c1 for a key set in both
+		"baz": true,
+	}
+	if !reflect.DeepEqual(merged.Features, want) {
+		t.Errorf("wrong result\ngot:  %#v\nwant: %#v", merged.Features, want)
+	}
+}
+
+func TestConfig_FeatureEnabled(t *testing.T) {
+	c := &Config{
+		Features: map[string]bool{
+			"on":  true,
+			"off": false,
+		},
+	}
+
+	if !c.FeatureEnabled("on", false) {
+		t.Error("expected \"on\" to be enabled")
+	}
+	if c.FeatureEnabled("off", true) {
+		t.Error("expected \"off\" to be disabled")
+	}
+	if !c.FeatureEnabled("unmentioned", true) {
+		t.Error("expected the default to be used for a feature not mentioned in Features")
+	}
+	if (*Config)(nil).FeatureEnabled("on", true) != true {
+		t.Error("expected the default to be used for a nil Config")
+	}
+}
+
 func TestConfig_Merge_disableCheckpoint(t *testing.T) {
 	c1 := &Config{
 		DisableCheckpoint: true,
@@ -287,11 +1290,13 @@ func TestConfig_Merge_disableCheckpoint(t *testing.T) {
 
 	expected := &Config{
 		Providers:         map[string]string{},
-		Provisioners:      map[string]string{},
 		DisableCheckpoint: true,
 	}
 
-	actual := c1.Merge(c2)
+	actual, diags := c1.Merge(c2)
+	if len(diags) != 0 {
+		t.Fatalf("unexpected diags: %s", diags.Err())
+	}
 	if !reflect.DeepEqual(actual, expected) {
 		t.Fatalf("bad: %#v", actual)
 	}
@@ -306,12 +1311,70 @@ func TestConfig_Merge_disableCheckpointSignature(t *testing.T) {
 
 	expected := &Config{
 		Providers:                  map[string]string{},
-		Provisioners:               map[string]string{},
 		DisableCheckpointSignature: true,
 	}
 
-	actual := c1.Merge(c2)
+	actual, diags := c1.Merge(c2)
+	if len(diags) != 0 {
+		t.Fatalf("unexpected diags: %s", diags.Err())
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("bad: %#v", actual)
+	}
+}
+
+func TestConfig_Merge_diagnostics(t *testing.T) {
+	c1 := &Config{
+		Diagnostics: &ConfigDiagnostics{
+			Suppress: []string{"CLICONFIG_W001"},
+		},
+	}
+
+	c2 := &Config{
+		Diagnostics: &ConfigDiagnostics{
+			PromoteToError: []string{"CLICONFIG_W002"},
+		},
+	}
+
+	expected := &Config{
+		Providers: map[string]string{},
+		Diagnostics: &ConfigDiagnostics{
+			PromoteToError: []string{"CLICONFIG_W002"},
+		},
+	}
+
+	actual, diags := c1.Merge(c2)
+	if len(diags) != 0 {
+		t.Fatalf("unexpected diags: %s", diags.Err())
+	}
 	if !reflect.DeepEqual(actual, expected) {
 		t.Fatalf("bad: %#v", actual)
 	}
 }
+
+func TestConfigDiagnostics_suppressAndPromote(t *testing.T) {
+	c := &Config{
+		DisableCheckpointSignature: true,
+		Diagnostics: &ConfigDiagnostics{
+			Suppress: []string{"CLICONFIG_W001"},
+		},
+	}
+
+	diags := c.Validate()
+	if len(diags) != 1 {
+		t.Fatalf("expected one diagnostic before filtering, got %d", len(diags))
+	}
+
+	filtered := tfdiags.FilterDiagnostics(diags, c.Diagnostics.Suppress, c.Diagnostics.PromoteToError)
+	if len(filtered) != 0 {
+		t.Fatalf("expected CLICONFIG_W001 to be suppressed, got %d diagnostics", len(filtered))
+	}
+
+	c.Diagnostics.Suppress = nil
+	c.Diagnostics.PromoteToError = []string{"CLICONFIG_W001"}
+	diags = c.Validate()
+	filtered = tfdiags.FilterDiagnostics(diags, c.Diagnostics.Suppress, c.Diagnostics.PromoteToError)
+	if len(filtered) != 1 || filtered[0].Severity() != tfdiags.Error {
+		t.Fatalf("expected CLICONFIG_W001 to be promoted to an error, got %#v", filtered)
+	}
+}
@@ -18,6 +18,12 @@ var (
 
 const CSIDL_APPDATA = 26
 
+// longPathBufferSize is used in place of syscall.MAX_PATH when asking
+// Windows APIs to fill in a path, since syscall.MAX_PATH (260) is too
+// small to hold paths enabled by long path support. This is the same
+// limit Windows itself uses for "extended-length" paths.
+const longPathBufferSize = 32767
+
 func configFile() (string, error) {
 	dir, err := homeDir()
 	if err != nil {
@@ -37,7 +43,15 @@ func configDir() (string, error) {
 }
 
 func homeDir() (string, error) {
-	b := make([]uint16, syscall.MAX_PATH)
+	// %APPDATA% is normally set for every interactive Windows user session,
+	// so we prefer reading it directly over the SHGetFolderPathW call
+	// below: it's simpler, and it respects any environment-level override
+	// of the roaming profile location.
+	if appData := os.Getenv("APPDATA"); appData != "" {
+		return appData, nil
+	}
+
+	b := make([]uint16, longPathBufferSize)
 
 	// See: http://msdn.microsoft.com/en-us/library/windows/desktop/bb762181(v=vs.85).aspx
 	r, _, err := getFolderPath.Call(0, CSIDL_APPDATA, 0, 0, uintptr(unsafe.Pointer(&b[0])))
@@ -0,0 +1,136 @@
+package cliconfig
+
+// DeepCopy returns a new Config that contains equivalent data to the
+// receiver but shares no top-level map or slice in common with it, so that
+// neither the receiver nor the copy is affected by changes made to the
+// other's maps after this method returns.
+//
+// Like Merge, DeepCopy copies each map down to its own keys and values but
+// doesn't clone the likes of *ConfigHost and *ConfigCredentialsHelper that
+// those maps point to, since nothing in this package ever mutates one of
+// those in place once it's been loaded.
+func (c *Config) DeepCopy() *Config {
+	if c == nil {
+		return nil
+	}
+
+	result := *c
+
+	if c.Providers != nil {
+		result.Providers = make(map[string]string, len(c.Providers))
+		for k, v := range c.Providers {
+			result.Providers[k] = v
+		}
+	}
+	if c.Vars != nil {
+		result.Vars = make(map[string]string, len(c.Vars))
+		for k, v := range c.Vars {
+			result.Vars[k] = v
+		}
+	}
+	if c.Hosts != nil {
+		result.Hosts = make(map[string]*ConfigHost, len(c.Hosts))
+		for k, v := range c.Hosts {
+			result.Hosts[k] = v
+		}
+	}
+	if c.Credentials != nil {
+		result.Credentials = make(map[string]map[string]interface{}, len(c.Credentials))
+		for k, v := range c.Credentials {
+			result.Credentials[k] = v
+		}
+	}
+	if c.CredentialsHelpers != nil {
+		result.CredentialsHelpers = make(map[string]*ConfigCredentialsHelper, len(c.CredentialsHelpers))
+		for k, v := range c.CredentialsHelpers {
+			result.CredentialsHelpers[k] = v
+		}
+	}
+	if c.Functions != nil {
+		result.Functions = make(map[string]*ConfigFunction, len(c.Functions))
+		for k, v := range c.Functions {
+			result.Functions[k] = v
+		}
+	}
+	if c.Features != nil {
+		result.Features = make(map[string]bool, len(c.Features))
+		for k, v := range c.Features {
+			result.Features[k] = v
+		}
+	}
+	if c.TrustedKeys != nil {
+		result.TrustedKeys = append([]string(nil), c.TrustedKeys...)
+	}
+	if c.ExternalPrograms != nil {
+		result.ExternalPrograms = append([]string(nil), c.ExternalPrograms...)
+	}
+	if c.sourceFiles != nil {
+		result.sourceFiles = append([]string(nil), c.sourceFiles...)
+	}
+	if c.envInfluences != nil {
+		result.envInfluences = make(map[string]string, len(c.envInfluences))
+		for k, v := range c.envInfluences {
+			result.envInfluences[k] = v
+		}
+	}
+	if c.credentialsSources != nil {
+		result.credentialsSources = make(map[string]string, len(c.credentialsSources))
+		for k, v := range c.credentialsSources {
+			result.credentialsSources[k] = v
+		}
+	}
+	if c.legacyProvisionerOverrides != nil {
+		result.legacyProvisionerOverrides = make(map[string]string, len(c.legacyProvisionerOverrides))
+		for k, v := range c.legacyProvisionerOverrides {
+			result.legacyProvisionerOverrides[k] = v
+		}
+	}
+	if c.hostPositions != nil {
+		result.hostPositions = make(map[string]string, len(c.hostPositions))
+		for k, v := range c.hostPositions {
+			result.hostPositions[k] = v
+		}
+	}
+	if c.credentialsPositions != nil {
+		result.credentialsPositions = make(map[string]string, len(c.credentialsPositions))
+		for k, v := range c.credentialsPositions {
+			result.credentialsPositions[k] = v
+		}
+	}
+	if c.credentialsHelperPositions != nil {
+		result.credentialsHelperPositions = make(map[string]string, len(c.credentialsHelperPositions))
+		for k, v := range c.credentialsHelperPositions {
+			result.credentialsHelperPositions[k] = v
+		}
+	}
+
+	return &result
+}
+
+// ConfigOption is a function that applies some override to a Config, for
+// use with Config.With. An option must only modify the *Config it's given,
+// which With guarantees is always a private copy rather than one that's
+// shared with any other caller.
+type ConfigOption func(*Config)
+
+// With returns a copy of the receiver with each of the given options
+// applied to it in order, leaving the receiver itself untouched.
+//
+// This is the supported way to customize a Config once it's in use: nothing
+// in this package mutates a Config's fields in place after it's returned
+// from LoadConfig, so a long-running process can load one Config at startup
+// and share it by reference across goroutines, with each goroutine calling
+// With to derive whatever per-request overrides it needs -- such as a
+// different PluginCacheDir -- without racing against concurrent readers of
+// the shared Config or affecting any other goroutine's view of it.
+//
+//	perRequest := sharedConfig.With(func(c *Config) {
+//		c.PluginCacheDir = "/tmp/request-specific-cache"
+//	})
+func (c *Config) With(opts ...ConfigOption) *Config {
+	result := c.DeepCopy()
+	for _, opt := range opts {
+		opt(result)
+	}
+	return result
+}
@@ -0,0 +1,102 @@
+package cliconfig
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestConfigDeepCopyIndependence(t *testing.T) {
+	orig := &Config{
+		Providers: map[string]string{"foo": "/path/to/foo"},
+		Hosts: map[string]*ConfigHost{
+			"example.com": {Services: map[string]interface{}{"modules.v1": "https://example.com/modules/"}},
+		},
+		Credentials: map[string]map[string]interface{}{
+			"example.com": {"token": "s3kr1t"},
+		},
+	}
+
+	got := orig.DeepCopy()
+
+	got.Providers["foo"] = "/different/path"
+	got.Providers["bar"] = "/path/to/bar"
+	got.Credentials["other.example.com"] = map[string]interface{}{"token": "other"}
+
+	if orig.Providers["foo"] != "/path/to/foo" {
+		t.Errorf("mutating the copy's Providers affected the original: %#v", orig.Providers)
+	}
+	if _, ok := orig.Providers["bar"]; ok {
+		t.Errorf("adding a key to the copy's Providers affected the original: %#v", orig.Providers)
+	}
+	if _, ok := orig.Credentials["other.example.com"]; ok {
+		t.Errorf("adding a key to the copy's Credentials affected the original: %#v", orig.Credentials)
+	}
+
+	// Map values that this package never mutates in place, like *ConfigHost,
+	// are intentionally shared rather than cloned.
+	if got.Hosts["example.com"] != orig.Hosts["example.com"] {
+		t.Errorf("expected the copy to share the same *ConfigHost as the original")
+	}
+}
+
+func TestConfigDeepCopyNil(t *testing.T) {
+	var c *Config
+	if got := c.DeepCopy(); got != nil {
+		t.Errorf("DeepCopy of a nil *Config returned %#v; want nil", got)
+	}
+}
+
+func TestConfigWith(t *testing.T) {
+	shared := &Config{
+		Providers:      map[string]string{"foo": "/path/to/foo"},
+		PluginCacheDir: "/shared/cache",
+	}
+
+	derived := shared.With(func(c *Config) {
+		c.PluginCacheDir = "/request/cache"
+		c.Providers["foo"] = "/request/path/to/foo"
+	})
+
+	if shared.PluginCacheDir != "/shared/cache" {
+		t.Errorf("With mutated the receiver's PluginCacheDir: %s", shared.PluginCacheDir)
+	}
+	if shared.Providers["foo"] != "/path/to/foo" {
+		t.Errorf("With mutated the receiver's Providers: %#v", shared.Providers)
+	}
+	if derived.PluginCacheDir != "/request/cache" {
+		t.Errorf("wrong PluginCacheDir on derived Config: %s", derived.PluginCacheDir)
+	}
+	if derived.Providers["foo"] != "/request/path/to/foo" {
+		t.Errorf("wrong Providers on derived Config: %#v", derived.Providers)
+	}
+}
+
+// TestConfigWithConcurrent exercises the scenario With exists for: many
+// goroutines deriving their own overrides from one shared Config at once.
+func TestConfigWithConcurrent(t *testing.T) {
+	shared := &Config{PluginCacheDir: "/shared/cache"}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			// Concurrent reads of the shared Config, happening alongside
+			// other goroutines deriving their own copies below.
+			_ = shared.PluginCacheDir
+
+			derived := shared.With(func(c *Config) {
+				c.RateLimit = float64(i)
+			})
+			if derived.RateLimit != float64(i) {
+				t.Errorf("goroutine %d: wrong RateLimit %v", i, derived.RateLimit)
+			}
+			if shared.RateLimit != 0 {
+				t.Errorf("goroutine %d: With mutated the shared Config's RateLimit", i)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -1,21 +1,24 @@
 package cliconfig
 
 import (
-	"bytes"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
-	"log"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/zclconf/go-cty/cty"
 	ctyjson "github.com/zclconf/go-cty/cty/json"
 
 	"github.com/hashicorp/terraform/configs/hcl2shim"
 	pluginDiscovery "github.com/hashicorp/terraform/plugin/discovery"
+	"github.com/hashicorp/terraform/registry"
 	"github.com/hashicorp/terraform/svchost"
 	svcauth "github.com/hashicorp/terraform/svchost/auth"
+	"github.com/hashicorp/terraform/svchost/disco"
 )
 
 // credentialsConfigFile returns the path for the special configuration file
@@ -32,7 +35,11 @@ func credentialsConfigFile() (string, error) {
 // CredentialsSource creates and returns a service credentials source whose
 // behavior depends on which "credentials" and "credentials_helper" blocks,
 // if any, are present in the receiving config.
-func (c *Config) CredentialsSource(helperPlugins pluginDiscovery.PluginMetaSet) (*CredentialsSource, error) {
+//
+// searchDirs gives the directories to search for the "credentials_helper"
+// plugin, if one is configured; see plugin/discovery.FindCredentialsHelper
+// for the naming convention it expects.
+func (c *Config) CredentialsSource(searchDirs []string) (*CredentialsSource, error) {
 	credentialsFilePath, err := credentialsConfigFile()
 	if err != nil {
 		// If we managed to load a Config object at all then we would already
@@ -40,28 +47,181 @@ func (c *Config) CredentialsSource(helperPlugins pluginDiscovery.PluginMetaSet)
 		return nil, fmt.Errorf("can't locate credentials file: %s", err)
 	}
 
-	var helper svcauth.CredentialsSource
-	var helperType string
-	for givenType, givenConfig := range c.CredentialsHelpers {
-		available := helperPlugins.WithName(givenType)
-		if available.Count() == 0 {
-			log.Printf("[ERROR] Unable to find credentials helper %q; ignoring", helperType)
-			break
+	// We build one routedCredentialsHelper per "credentials_helper" block,
+	// in a deterministic order with the catch-all (if any) last, so that
+	// ForHost can pick the first one whose Hosts patterns match a given
+	// host -- or fall through to the catch-all if none of the more
+	// specific ones do.
+	var auditLog svcauth.AuditLogFunc
+	if c.Audit != nil && c.Audit.Path != "" {
+		auditLog = newAuditLogFunc(c.Audit.Path)
+	}
+
+	var helpers []routedCredentialsHelper
+	var catchAll *routedCredentialsHelper
+	for _, givenType := range sortedCredentialsHelperNames(c.CredentialsHelpers) {
+		givenConfig := c.CredentialsHelpers[givenType]
+
+		selected, err := pluginDiscovery.FindCredentialsHelper(givenType, searchDirs)
+		if err != nil {
+			return nil, err
+		}
+
+		helperEnv := buildHelperEnv(givenConfig.Env, givenConfig.EnvAllowlist)
+		helperOpts := svcauth.HelperProgramOptions{
+			Env:     helperEnv,
+			Timeout: time.Duration(givenConfig.Timeout) * time.Second,
+		}
+		var helperSource svcauth.CredentialsSource = svcauth.HelperProgramCredentialsSourceWithOptions(selected.Path, helperOpts, givenConfig.Args...)
+		if auditLog != nil {
+			// Wrapped before caching, so only real helper invocations are
+			// recorded and a cache hit doesn't generate a spurious entry.
+			helperSource = svcauth.AuditingCredentialsSource(helperSource, auditLog)
+		}
+		// Cached because the helper is an external program that may be
+		// slow or expensive to run, and commands that touch many modules
+		// or providers would otherwise invoke it once per host lookup.
+		cached, _ := svcauth.CachingCredentialsSource(helperSource, 0)
+
+		routed := routedCredentialsHelper{
+			helperType: givenType,
+			hosts:      givenConfig.Hosts,
+			source:     cached,
 		}
+		if len(givenConfig.Hosts) == 0 {
+			// We assume Validate already rejected a config with more than
+			// one catch-all, so the last one we see wins if it somehow
+			// didn't.
+			catchAll = &routed
+			continue
+		}
+		helpers = append(helpers, routed)
+	}
+	if catchAll != nil {
+		helpers = append(helpers, *catchAll)
+	}
+
+	return c.credentialsSource(helpers, credentialsFilePath), nil
+}
+
+// routedCredentialsHelper pairs a configured credentials helper with the
+// host patterns (from its "credentials_helper" block's "hosts" attribute)
+// that route to it. A nil or empty hosts means this entry is a catch-all.
+type routedCredentialsHelper struct {
+	helperType string
+	hosts      []string
+	source     svcauth.CredentialsSource
+}
 
-		selected := available.Newest()
+// matches reports whether host is routed to this helper, either because
+// one of its hosts patterns matches or because it has none and so is the
+// catch-all.
+func (r routedCredentialsHelper) matches(host svchost.Hostname) bool {
+	if len(r.hosts) == 0 {
+		return true
+	}
+	for _, pattern := range r.hosts {
+		if credentialsHelperHostPatternMatches(pattern, host) {
+			return true
+		}
+	}
+	return false
+}
 
-		helperSource := svcauth.HelperProgramCredentialsSource(selected.Path, givenConfig.Args...)
-		helper = svcauth.CachingCredentialsSource(helperSource) // cached because external operation may be slow/expensive
-		helperType = givenType
+// credentialsHelperHostPatternMatches reports whether host matches pattern,
+// using the same "*.example.com" wildcard convention as a TLS wildcard
+// certificate: a leading "*." matches one or more subdomain labels under
+// the given suffix, but not the suffix by itself.
+func credentialsHelperHostPatternMatches(pattern string, host svchost.Hostname) bool {
+	wildcard := false
+	suffix := strings.TrimPrefix(pattern, "*.")
+	if suffix != pattern {
+		wildcard = true
+	}
 
-		// There should only be zero or one "credentials_helper" blocks. We
-		// assume that the config was validated earlier and so we don't check
-		// for extras here.
-		break
+	// Normalize the non-wildcard part the same way NormalizeHostname would,
+	// so that a pattern written with different capitalization or Unicode
+	// normalization than host still matches it. We fall back to the raw
+	// suffix on error, since an invalid hostname here was already reported
+	// by Validate.
+	normSuffix, err := svchost.ForComparison(suffix)
+	if err != nil {
+		normSuffix = svchost.Hostname(suffix)
 	}
 
-	return c.credentialsSource(helperType, helper, credentialsFilePath), nil
+	if !wildcard {
+		return host == normSuffix
+	}
+	return strings.HasSuffix(string(host), "."+string(normSuffix))
+}
+
+// ServicesClient returns a *disco.Disco configured from the receiver: any
+// "host" blocks are registered as forced overrides so that service
+// discovery for those hosts never touches the network, and the
+// credentials source built by CredentialsSource is attached so that
+// outgoing discovery and service requests carry whatever credentials are
+// configured for their host.
+//
+// This is the constructor the CLI entrypoint uses, exposed here so that
+// any other caller that needs a service discovery client gets the same
+// host-override and credentials handling without having to reassemble it.
+func (c *Config) ServicesClient(searchDirs []string) (*disco.Disco, error) {
+	credsSrc, err := c.CredentialsSource(searchDirs)
+	if err != nil {
+		return nil, err
+	}
+
+	services := disco.NewWithCredentialsSource(credsSrc)
+	services.SetRateLimit(c.RateLimit)
+
+	for givenHost, hostConfig := range c.Hosts {
+		host, hostDiags := NormalizeHostname(givenHost)
+		if hostDiags.HasErrors() {
+			// We expect the config was already validated by the time we
+			// get here, so we'll just ignore invalid hostnames.
+			continue
+		}
+		services.ForceHostServices(host, hostConfig.Services)
+		if len(hostConfig.PinServiceVersions) > 0 {
+			services.SetServiceVersionPins(host, hostConfig.PinServiceVersions)
+		}
+	}
+
+	return services, nil
+}
+
+// RegistryRetryConfig returns the retry and timeout settings to use for
+// the Terraform Registry client, based on the "registry" block in the CLI
+// configuration if one was given, or registry.DefaultRetryConfig()
+// otherwise.
+func (c *Config) RegistryRetryConfig() registry.RetryConfig {
+	if c == nil || c.Registry == nil {
+		return registry.DefaultRetryConfig()
+	}
+
+	ret := registry.DefaultRetryConfig()
+	if c.Registry.Retries > 0 {
+		ret.Retries = c.Registry.Retries
+	}
+	if c.Registry.Timeout > 0 {
+		ret.Timeout = time.Duration(c.Registry.Timeout) * time.Second
+	}
+	if c.Registry.BackoffMax > 0 {
+		ret.BackoffMax = time.Duration(c.Registry.BackoffMax) * time.Second
+	}
+	return ret
+}
+
+// PluginTLSVerifyDisabled reports whether TLS certificate verification
+// should be disabled for plugin and registry downloads. This requires both
+// the "disable_plugin_tls_verify" config setting and the
+// TF_DISABLE_PLUGIN_TLS_VERIFY environment variable to be set; see
+// Config.DisablePluginTLSVerify for why.
+func (c *Config) PluginTLSVerifyDisabled() bool {
+	if c == nil || !c.DisablePluginTLSVerify {
+		return false
+	}
+	return os.Getenv(pluginTLSVerifyDisableEnvVar) != ""
 }
 
 // EmptyCredentialsSourceForTests constructs a CredentialsSource with
@@ -72,27 +232,40 @@ func (c *Config) CredentialsSource(helperPlugins pluginDiscovery.PluginMetaSet)
 // be used in normal application code.
 func EmptyCredentialsSourceForTests(credentialsFilePath string) *CredentialsSource {
 	cfg := &Config{}
-	return cfg.credentialsSource("", nil, credentialsFilePath)
+	return cfg.credentialsSource(nil, credentialsFilePath)
 }
 
 // credentialsSource is an internal factory for the credentials source which
 // allows overriding the credentials file path, which allows setting it to
 // a temporary file location when testing.
-func (c *Config) credentialsSource(helperType string, helper svcauth.CredentialsSource, credentialsFilePath string) *CredentialsSource {
+func (c *Config) credentialsSource(helpers []routedCredentialsHelper, credentialsFilePath string) *CredentialsSource {
 	configured := map[svchost.Hostname]cty.Value{}
+	servicesFilter := map[svchost.Hostname][]string{}
+	commandSources := map[svchost.Hostname]svcauth.CredentialsSource{}
+	var defaultCredentials *processedCredentialsBlock
 	for userHost, creds := range c.Credentials {
-		host, err := svchost.ForComparison(userHost)
-		if err != nil {
+		if userHost == defaultCredentialsHost {
+			block := processCredentialsBlock(creds)
+			defaultCredentials = &block
+			continue
+		}
+
+		host, hostDiags := NormalizeHostname(userHost)
+		if hostDiags.HasErrors() {
 			// We expect the config was already validated by the time we get
 			// here, so we'll just ignore invalid hostnames.
 			continue
 		}
 
-		// For now our CLI config continues to use HCL 1.0, so we'll shim it
-		// over to HCL 2.0 types. In future we will hopefully migrate it to
-		// HCL 2.0 instead, and so it'll be a cty.Value already.
-		credsV := hcl2shim.HCL2ValueFromConfigValue(creds)
-		configured[host] = credsV
+		block := processCredentialsBlock(creds)
+		if block.services != nil {
+			servicesFilter[host] = block.services
+		}
+		if block.commandSource != nil {
+			commandSources[host] = block.commandSource
+			continue
+		}
+		configured[host] = block.value
 	}
 
 	writableLocal := readHostsInCredentialsFile(credentialsFilePath)
@@ -105,10 +278,155 @@ func (c *Config) credentialsSource(helperType string, helper svcauth.Credentials
 
 	return &CredentialsSource{
 		configured:          configured,
+		servicesFilter:      servicesFilter,
+		commandSources:      commandSources,
 		unwritable:          unwritableLocal,
 		credentialsFilePath: credentialsFilePath,
-		helper:              helper,
-		helperType:          helperType,
+		helpers:             helpers,
+		defaultCredentials:  defaultCredentials,
+	}
+}
+
+// processedCredentialsBlock holds the pieces extracted from one
+// "credentials" block's raw attributes: the same split between a services
+// filter, a token_command source, and a plain credentials value that
+// credentialsSource applies to a host-specific block also applies, unchanged,
+// to the defaultCredentialsHost fallback block.
+type processedCredentialsBlock struct {
+	// services is non-nil if the block had a "services" attribute, scoping
+	// it to only the listed services.
+	services []string
+
+	// commandSource is non-nil if the block had a "token_command"
+	// attribute, in which case value is unset; the token comes from running
+	// this source instead.
+	commandSource svcauth.CredentialsSource
+
+	// value is the cty.Value produced from the block's remaining
+	// attributes, valid only when commandSource is nil.
+	value cty.Value
+}
+
+func processCredentialsBlock(creds map[string]interface{}) processedCredentialsBlock {
+	var result processedCredentialsBlock
+
+	// A "services" attribute scopes the credentials to particular services
+	// on the host rather than the host as a whole. We pull it out here,
+	// rather than leaving it in the stored credentials value, since it's
+	// metadata about where the credentials apply rather than part of the
+	// credentials themselves.
+	if rawServices, ok := creds["services"]; ok {
+		creds = copyCredsWithoutKeys(creds, "services")
+		result.services = toStringSlice(rawServices)
+	}
+
+	// A "token_command" attribute is a lighter-weight alternative to a
+	// full credentials helper: rather than writing a helper program that
+	// implements the "get"/"store"/"forget" protocol, the user can give
+	// a command to run whose stdout is used directly as the token. Since
+	// this determines where the token comes from rather than being part
+	// of it, the block has no further "value" of its own.
+	if rawCommand, ok := creds["token_command"]; ok {
+		ttl := time.Duration(0)
+		if rawTTL, ok := creds["token_command_ttl_seconds"]; ok {
+			if seconds, ok := rawTTL.(int); ok && seconds > 0 {
+				ttl = time.Duration(seconds) * time.Second
+			}
+		}
+		result.commandSource = svcauth.CommandCredentialsSource(toStringSlice(rawCommand), ttl)
+		return result
+	}
+
+	// For now our CLI config continues to use HCL 1.0, so we'll shim it
+	// over to HCL 2.0 types. In future we will hopefully migrate it to
+	// HCL 2.0 instead, and so it'll be a cty.Value already.
+	result.value = hcl2shim.HCL2ValueFromConfigValue(creds)
+	return result
+}
+
+// copyCredsWithoutKeys returns a shallow copy of creds with the given keys
+// removed, leaving the original map (which may still be referenced
+// elsewhere, such as in c.Credentials) untouched.
+func copyCredsWithoutKeys(creds map[string]interface{}, remove ...string) map[string]interface{} {
+	copied := make(map[string]interface{}, len(creds))
+	for k, v := range creds {
+		skip := false
+		for _, r := range remove {
+			if k == r {
+				skip = true
+				break
+			}
+		}
+		if !skip {
+			copied[k] = v
+		}
+	}
+	return copied
+}
+
+// buildHelperEnv computes the environment to run a credentials helper
+// program in, given the "env" and "env_allowlist" attributes of its
+// "credentials_helper" block.
+//
+// With neither attribute set this returns nil, so that the helper inherits
+// this process's environment wholesale, matching the helper protocol's
+// long-standing default behavior. Otherwise it starts from either the full
+// inherited environment or, if allowlist is non-empty, just the variables
+// named in it, and then applies extra on top, so that an explicitly
+// configured variable always wins over an inherited one of the same name.
+func buildHelperEnv(extra map[string]string, allowlist []string) []string {
+	if len(extra) == 0 && len(allowlist) == 0 {
+		return nil
+	}
+
+	var env []string
+	if len(allowlist) == 0 {
+		env = os.Environ()
+	} else {
+		allowed := make(map[string]struct{}, len(allowlist))
+		for _, name := range allowlist {
+			allowed[name] = struct{}{}
+		}
+		for _, kv := range os.Environ() {
+			name := kv
+			if idx := strings.IndexByte(kv, '='); idx >= 0 {
+				name = kv[:idx]
+			}
+			if _, ok := allowed[name]; ok {
+				env = append(env, kv)
+			}
+		}
+	}
+
+	names := make([]string, 0, len(extra))
+	for name := range extra {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		env = append(env, fmt.Sprintf("%s=%s", name, extra[name]))
+	}
+
+	return env
+}
+
+// toStringSlice converts a "services" attribute value, which HCL may decode
+// as either []string or []interface{} depending on context, into a plain
+// []string.
+func toStringSlice(raw interface{}) []string {
+	switch v := raw.(type) {
+	case []string:
+		return v
+	case []interface{}:
+		ret := make([]string, 0, len(v))
+		for _, e := range v {
+			if s, ok := e.(string); ok {
+				ret = append(ret, s)
+			}
+		}
+		return ret
+	default:
+		return nil
 	}
 }
 
@@ -121,6 +439,18 @@ type CredentialsSource struct {
 	// any writes to the special credentials.tfrc.json file.
 	configured map[svchost.Hostname]cty.Value
 
+	// servicesFilter records, for hosts whose "credentials" block included a
+	// "services" attribute, the set of services those credentials are
+	// scoped to. Hosts with no entry here have unscoped credentials that
+	// apply to any service.
+	servicesFilter map[svchost.Hostname][]string
+
+	// commandSources holds, for each host whose "credentials" block included
+	// a "token_command", the source that runs that command to obtain a
+	// token. These hosts have no entry in "configured" since their token
+	// isn't a static value.
+	commandSources map[svchost.Hostname]svcauth.CredentialsSource
+
 	// unwritable describes any credentials explicitly configured in the
 	// CLI config in any file other than credentials.tfrc.json. We cannot update
 	// these automatically because only credentials.tfrc.json is subject to
@@ -136,34 +466,99 @@ type CredentialsSource struct {
 	// testing.)
 	credentialsFilePath string
 
-	// helper is the credentials source representing the configured credentials
-	// helper, if any. When this is non-nil, it will be consulted for any
-	// hostnames not explicitly represented in "configured". Any writes to
-	// the credentials store will also be sent to a configured helper instead
-	// of the credentials.tfrc.json file.
-	helper svcauth.CredentialsSource
+	// helpers holds the credentials sources representing any configured
+	// "credentials_helper" blocks, each paired with the host patterns that
+	// route to it. For a hostname not explicitly represented in
+	// "configured", helperForHost picks the first entry whose patterns
+	// match (with a catch-all, if any, always last). Any writes to the
+	// credentials store for a host routed to a helper are sent to that
+	// helper instead of the credentials.tfrc.json file.
+	helpers []routedCredentialsHelper
+
+	// defaultCredentials, if non-nil, came from a `credentials "*" { ... }`
+	// block: a fallback used only for a host with no entry of its own in
+	// "configured", "commandSources", or "helpers". It's deliberately
+	// consulted last of all, so that it can never shadow a more specific
+	// source of credentials for a host.
+	defaultCredentials *processedCredentialsBlock
+}
 
-	// helperType is the name of the type of credentials helper that is
-	// referenced in "helper", or the empty string if "helper" is nil.
-	helperType string
+// helperForHost returns the routedCredentialsHelper that serves host, or
+// nil if none of the configured helpers claim it.
+func (s *CredentialsSource) helperForHost(host svchost.Hostname) *routedCredentialsHelper {
+	for i, helper := range s.helpers {
+		if helper.matches(host) {
+			return &s.helpers[i]
+		}
+	}
+	return nil
 }
 
 // Assertion that credentialsSource implements CredentialsSource
 var _ svcauth.CredentialsSource = (*CredentialsSource)(nil)
 
-func (s *CredentialsSource) ForHost(host svchost.Hostname) (svcauth.HostCredentials, error) {
+func (s *CredentialsSource) ForHost(host svchost.Hostname, service string) (svcauth.HostCredentials, error) {
+	if service != "" {
+		if allowed, scoped := s.servicesFilter[host]; scoped && !stringSliceContains(allowed, service) {
+			return nil, nil
+		}
+	}
+
+	if cmdSrc, ok := s.commandSources[host]; ok {
+		return cmdSrc.ForHost(host, service)
+	}
+
 	v, ok := s.configured[host]
 	if ok {
 		return svcauth.HostCredentialsFromObject(v), nil
 	}
 
-	if s.helper != nil {
-		return s.helper.ForHost(host)
+	if helper := s.helperForHost(host); helper != nil {
+		return helper.source.ForHost(host, service)
+	}
+
+	if d := s.defaultCredentials; d != nil {
+		if service != "" && d.services != nil && !stringSliceContains(d.services, service) {
+			return nil, nil
+		}
+		if d.commandSource != nil {
+			return d.commandSource.ForHost(host, service)
+		}
+		return svcauth.HostCredentialsFromObject(d.value), nil
 	}
 
 	return nil, nil
 }
 
+// RawValueForHost returns the cty.Value that was produced by converting the
+// "credentials" block configured for the given host, along with whether any
+// such block was found.
+//
+// This gives callers access to the typed representation of a host's raw
+// configured credentials attributes (beyond just the "token" that ForHost
+// extracts), regardless of whether the configuration was loaded from a
+// native-syntax ".tfrc" file or a JSON-syntax ".tfrc.json" file: both are
+// normalized to the same cty.Value shape by credentialsSource before being
+// stored here.
+func (s *CredentialsSource) RawValueForHost(host svchost.Hostname) (cty.Value, bool) {
+	if v, ok := s.configured[host]; ok {
+		return v, true
+	}
+	if d := s.defaultCredentials; d != nil && d.commandSource == nil {
+		return d.value, true
+	}
+	return cty.NilVal, false
+}
+
+func stringSliceContains(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
 func (s *CredentialsSource) StoreForHost(host svchost.Hostname, credentials svcauth.HostCredentialsWritable) error {
 	return s.updateHostCredentials(host, credentials)
 }
@@ -181,12 +576,18 @@ func (s *CredentialsSource) HostCredentialsLocation(host svchost.Hostname) Crede
 	if _, unwritable := s.unwritable[host]; unwritable {
 		return CredentialsInOtherFile
 	}
+	if _, viaCommand := s.commandSources[host]; viaCommand {
+		return CredentialsViaTokenCommand
+	}
 	if _, exists := s.configured[host]; exists {
 		return CredentialsInPrimaryFile
 	}
-	if s.helper != nil {
+	if s.helperForHost(host) != nil {
 		return CredentialsViaHelper
 	}
+	if s.defaultCredentials != nil {
+		return CredentialsViaDefault
+	}
 	return CredentialsNotAvailable
 }
 
@@ -204,29 +605,47 @@ func (s *CredentialsSource) CredentialsFilePath() (string, error) {
 	return s.credentialsFilePath, nil
 }
 
-// CredentialsHelperType returns the name of the configured credentials helper
-// type, or an empty string if no credentials helper is configured.
+// CredentialsHelperType returns the name of the configured credentials
+// helper type, or an empty string if no credentials helper is configured.
+//
+// When more than one credentials_helper block routes different hosts to
+// different helpers, there's no single answer to give here -- different
+// hosts use different helpers -- so this returns an empty string. Callers
+// that need the helper for a specific host should go through ForHost or
+// HostCredentialsLocation instead.
 func (s *CredentialsSource) CredentialsHelperType() string {
-	return s.helperType
+	if len(s.helpers) != 1 {
+		return ""
+	}
+	return s.helpers[0].helperType
 }
 
 func (s *CredentialsSource) updateHostCredentials(host svchost.Hostname, new svcauth.HostCredentialsWritable) error {
 	switch loc := s.HostCredentialsLocation(host); loc {
 	case CredentialsInOtherFile:
 		return ErrUnwritableHostCredentials(host)
-	case CredentialsInPrimaryFile, CredentialsNotAvailable:
+	case CredentialsViaTokenCommand:
+		return fmt.Errorf("cannot change credentials for %s: credentials are obtained by running a token_command", host.ForDisplay())
+	case CredentialsInPrimaryFile, CredentialsNotAvailable, CredentialsViaDefault:
 		// If the host already has credentials stored locally then we'll update
 		// them locally too, even if there's a credentials helper configured,
 		// because the user might be intentionally retaining this particular
 		// host locally for some reason, e.g. if the credentials helper is
 		// talking to some shared remote service like HashiCorp Vault.
+		//
+		// A host that's only covered by the "*" default block is handled the
+		// same way as one with nothing configured at all: writing to it
+		// creates a new host-specific entry rather than touching the shared
+		// default, since the default is meant to be edited directly in the
+		// CLI config, not through the credentials-updating API.
 		return s.updateLocalHostCredentials(host, new)
 	case CredentialsViaHelper:
-		// Delegate entirely to the helper, then.
+		// Delegate entirely to the matching helper, then.
+		helper := s.helperForHost(host)
 		if new == nil {
-			return s.helper.ForgetForHost(host)
+			return helper.source.ForgetForHost(host)
 		}
-		return s.helper.StoreForHost(host, new)
+		return helper.source.StoreForHost(host, new)
 	default:
 		// Should never happen because the above cases are exhaustive
 		return fmt.Errorf("invalid credentials location %#v", loc)
@@ -248,39 +667,41 @@ func (s *CredentialsSource) updateLocalHostCredentials(host svchost.Hostname, ne
 		return fmt.Errorf("cannot read %s: %s", filename, err)
 	}
 
-	var raw map[string]interface{}
-
+	// We decode and re-encode via orderedJSONObject, rather than going
+	// through a plain map[string]interface{}, so that any other hosts'
+	// entries and any unrelated top-level content a human added to this
+	// file keep their original position. encoding/json would otherwise
+	// re-sort every object's keys alphabetically on the way back out,
+	// turning what should be a surgical update into a full reformat of
+	// the file.
+	var raw *orderedJSONObject
 	if len(oldSrc) > 0 {
-		// When decoding we use a custom decoder so we can decode any numbers as
-		// json.Number and thus avoid losing any accuracy in our round-trip.
-		dec := json.NewDecoder(bytes.NewReader(oldSrc))
-		dec.UseNumber()
-		err = dec.Decode(&raw)
+		raw, err = decodeOrderedJSONObject(oldSrc)
 		if err != nil {
 			return fmt.Errorf("cannot read %s: %s", filename, err)
 		}
 	} else {
-		raw = make(map[string]interface{})
+		raw = newOrderedJSONObject()
 	}
 
-	rawCredsI, ok := raw["credentials"]
-	if !ok {
-		rawCredsI = make(map[string]interface{})
-		raw["credentials"] = rawCredsI
-	}
-	rawCredsMap, ok := rawCredsI.(map[string]interface{})
-	if !ok {
-		return fmt.Errorf("credentials file %s has invalid value for \"credentials\" property: must be a JSON object", filename)
+	var rawCreds *orderedJSONObject
+	if rawCredsSrc, ok := raw.get("credentials"); ok {
+		rawCreds, err = decodeOrderedJSONObject(rawCredsSrc)
+		if err != nil {
+			return fmt.Errorf("credentials file %s has invalid value for \"credentials\" property: must be a JSON object", filename)
+		}
+	} else {
+		rawCreds = newOrderedJSONObject()
 	}
 
 	// We use display-oriented hostnames in our file to mimick how a human user
 	// would write it, so we need to search for and remove any key that
 	// normalizes to our target hostname so we won't generate something invalid
 	// when the existing entry is slightly different.
-	for givenHost := range rawCredsMap {
+	for _, givenHost := range rawCreds.keys() {
 		canonHost, err := svchost.ForComparison(givenHost)
 		if err == nil && canonHost == host {
-			delete(rawCredsMap, givenHost)
+			rawCreds.delete(givenHost)
 		}
 	}
 
@@ -291,10 +712,18 @@ func (s *CredentialsSource) updateLocalHostCredentials(host svchost.Hostname, ne
 	// Domain Names specification.
 	if new != nil {
 		toStore := new.ToStore()
-		rawCredsMap[host.ForDisplay()] = ctyjson.SimpleJSONValue{
-			Value: toStore,
+		valueSrc, err := json.Marshal(ctyjson.SimpleJSONValue{Value: toStore})
+		if err != nil {
+			return fmt.Errorf("cannot serialize updated credentials for %s: %s", host.ForDisplay(), err)
 		}
+		rawCreds.set(host.ForDisplay(), valueSrc)
+	}
+
+	rawCredsSrc, err := json.Marshal(rawCreds)
+	if err != nil {
+		return fmt.Errorf("cannot serialize updated credentials file: %s", err)
 	}
+	raw.set("credentials", rawCredsSrc)
 
 	newSrc, err := json.MarshalIndent(raw, "", "  ")
 	if err != nil {
@@ -437,4 +866,14 @@ const (
 	// are available for the host but a helper program is available that may
 	// or may not have credentials for the host.
 	CredentialsViaHelper CredentialsLocation = 'H'
+
+	// CredentialsViaTokenCommand indicates that the host's "credentials"
+	// block configures a token_command, so its token is obtained by running
+	// that command rather than from a stored value.
+	CredentialsViaTokenCommand CredentialsLocation = 'C'
+
+	// CredentialsViaDefault indicates that no credentials are configured
+	// specifically for the host, no helper claims it, but the optional
+	// `credentials "*" { ... }` default block applies to it instead.
+	CredentialsViaDefault CredentialsLocation = 'D'
 )
@@ -1,6 +1,7 @@
 package cliconfig
 
 import (
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"os"
@@ -29,18 +30,22 @@ func TestCredentialsForHost(t *testing.T) {
 		// a credentials helper program, since we're only testing the logic
 		// for choosing when to delegate to the helper here. The logic for
 		// interacting with a helper program is tested in the svcauth package.
-		helper: svcauth.StaticCredentialsSource(map[svchost.Hostname]map[string]interface{}{
-			"from-helper.example.com": {
-				"token": "from-helper",
-			},
-
-			// This should be shadowed by the "configured" entry with the same
-			// hostname above.
-			"configured.example.com": {
-				"token": "incorrectly-from-helper",
+		helpers: []routedCredentialsHelper{
+			{
+				helperType: "fake",
+				source: svcauth.StaticCredentialsSource(map[svchost.Hostname]map[string]interface{}{
+					"from-helper.example.com": {
+						"token": "from-helper",
+					},
+
+					// This should be shadowed by the "configured" entry with the same
+					// hostname above.
+					"configured.example.com": {
+						"token": "incorrectly-from-helper",
+					},
+				}),
 			},
-		}),
-		helperType: "fake",
+		},
 	}
 
 	testReqAuthHeader := func(t *testing.T, creds svcauth.HostCredentials) string {
@@ -59,7 +64,7 @@ func TestCredentialsForHost(t *testing.T) {
 	}
 
 	t.Run("configured", func(t *testing.T) {
-		creds, err := credSrc.ForHost(svchost.Hostname("configured.example.com"))
+		creds, err := credSrc.ForHost(svchost.Hostname("configured.example.com"), "")
 		if err != nil {
 			t.Fatalf("unexpected error: %s", err)
 		}
@@ -68,7 +73,7 @@ func TestCredentialsForHost(t *testing.T) {
 		}
 	})
 	t.Run("from helper", func(t *testing.T) {
-		creds, err := credSrc.ForHost(svchost.Hostname("from-helper.example.com"))
+		creds, err := credSrc.ForHost(svchost.Hostname("from-helper.example.com"), "")
 		if err != nil {
 			t.Fatalf("unexpected error: %s", err)
 		}
@@ -77,7 +82,7 @@ func TestCredentialsForHost(t *testing.T) {
 		}
 	})
 	t.Run("not available", func(t *testing.T) {
-		creds, err := credSrc.ForHost(svchost.Hostname("unavailable.example.com"))
+		creds, err := credSrc.ForHost(svchost.Hostname("unavailable.example.com"), "")
 		if err != nil {
 			t.Fatalf("unexpected error: %s", err)
 		}
@@ -87,6 +92,413 @@ func TestCredentialsForHost(t *testing.T) {
 	})
 }
 
+func TestCredentialsHelperHostPatternMatches(t *testing.T) {
+	tests := []struct {
+		pattern string
+		host    svchost.Hostname
+		want    bool
+	}{
+		{"example.com", "example.com", true},
+		{"example.com", "other.example.com", false},
+		{"*.corp.example.com", "vault.corp.example.com", true},
+		{"*.corp.example.com", "a.b.corp.example.com", true},
+		{"*.corp.example.com", "corp.example.com", false},
+		{"*.corp.example.com", "example.com", false},
+		{"EXAMPLE.COM", "example.com", true},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%s~%s", test.pattern, test.host), func(t *testing.T) {
+			if got := credentialsHelperHostPatternMatches(test.pattern, test.host); got != test.want {
+				t.Errorf("credentialsHelperHostPatternMatches(%q, %q) = %v; want %v", test.pattern, test.host, got, test.want)
+			}
+		})
+	}
+}
+
+func TestCredentialsSourceHelperForHost(t *testing.T) {
+	vault := svcauth.StaticCredentialsSource(map[svchost.Hostname]map[string]interface{}{
+		"vault.corp.example.com": {"token": "from-vault"},
+	})
+	fallback := svcauth.StaticCredentialsSource(map[svchost.Hostname]map[string]interface{}{
+		"example.com": {"token": "from-fallback"},
+	})
+
+	credSrc := &CredentialsSource{
+		helpers: []routedCredentialsHelper{
+			{
+				helperType: "vault",
+				hosts:      []string{"*.corp.example.com"},
+				source:     vault,
+			},
+			{
+				helperType: "fallback",
+				source:     fallback,
+			},
+		},
+	}
+
+	t.Run("routed to vault", func(t *testing.T) {
+		got := credSrc.helperForHost(svchost.Hostname("vault.corp.example.com"))
+		if got == nil || got.helperType != "vault" {
+			t.Fatalf("wrong helper: %#v", got)
+		}
+	})
+	t.Run("routed to catch-all", func(t *testing.T) {
+		got := credSrc.helperForHost(svchost.Hostname("example.com"))
+		if got == nil || got.helperType != "fallback" {
+			t.Fatalf("wrong helper: %#v", got)
+		}
+	})
+	t.Run("CredentialsHelperType is ambiguous with more than one helper", func(t *testing.T) {
+		if got := credSrc.CredentialsHelperType(); got != "" {
+			t.Errorf("got %q, want empty string", got)
+		}
+	})
+}
+
+func TestCredentialsForHostServiceScoped(t *testing.T) {
+	credSrc := &CredentialsSource{
+		configured: map[svchost.Hostname]cty.Value{
+			"scoped.example.com": cty.ObjectVal(map[string]cty.Value{
+				"token": cty.StringVal("scoped"),
+			}),
+		},
+		servicesFilter: map[svchost.Hostname][]string{
+			"scoped.example.com": {"modules.v1"},
+		},
+	}
+
+	t.Run("matching service", func(t *testing.T) {
+		creds, err := credSrc.ForHost(svchost.Hostname("scoped.example.com"), "modules.v1")
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if creds == nil {
+			t.Fatalf("expected credentials for a matching service")
+		}
+	})
+	t.Run("unscoped request", func(t *testing.T) {
+		creds, err := credSrc.ForHost(svchost.Hostname("scoped.example.com"), "")
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if creds == nil {
+			t.Fatalf("expected credentials when the caller doesn't care about service scoping")
+		}
+	})
+	t.Run("non-matching service", func(t *testing.T) {
+		creds, err := credSrc.ForHost(svchost.Hostname("scoped.example.com"), "providers.v1")
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if creds != nil {
+			t.Errorf("expected no credentials for a service outside the configured scope, got %#v", creds)
+		}
+	})
+}
+
+func TestCredentialsSourceTokenCommand(t *testing.T) {
+	d, err := ioutil.TempDir("", "terraform-cliconfig-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(d)
+
+	cfg := &Config{
+		Credentials: map[string]map[string]interface{}{
+			"command.example.com": {
+				"token_command": []interface{}{"sh", "-c", "echo -n from-command"},
+			},
+		},
+	}
+	credSrc := cfg.credentialsSource(nil, filepath.Join(d, "credentials.tfrc.json"))
+
+	if got, want := credSrc.HostCredentialsLocation(svchost.Hostname("command.example.com")), CredentialsViaTokenCommand; got != want {
+		t.Fatalf("wrong location: got %q, want %q", got, want)
+	}
+
+	creds, err := credSrc.ForHost(svchost.Hostname("command.example.com"), "")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if creds == nil {
+		t.Fatal("expected credentials from token_command")
+	}
+	if got, want := creds.Token(), "from-command"; got != want {
+		t.Errorf("wrong token: got %q, want %q", got, want)
+	}
+
+	if err := credSrc.StoreForHost(svchost.Hostname("command.example.com"), svcauth.HostCredentialsToken("new")); err == nil {
+		t.Error("expected an error trying to store credentials for a token_command host")
+	}
+}
+
+func TestCredentialsSourceHostWithPort(t *testing.T) {
+	d, err := ioutil.TempDir("", "terraform-cliconfig-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(d)
+
+	// Many self-hosted registries don't run on the default HTTPS port, so
+	// credentials for them are configured against a hostname that includes
+	// a port number.
+	cfg := &Config{
+		Credentials: map[string]map[string]interface{}{
+			"registry.internal:8443": {
+				"token": "from-port-scoped-host",
+			},
+		},
+	}
+	credSrc := cfg.credentialsSource(nil, filepath.Join(d, "credentials.tfrc.json"))
+
+	host := svchost.Hostname("registry.internal:8443")
+	creds, err := credSrc.ForHost(host, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if creds == nil {
+		t.Fatal("expected credentials for the port-scoped host")
+	}
+	if got, want := creds.Token(), "from-port-scoped-host"; got != want {
+		t.Errorf("wrong token: got %q, want %q", got, want)
+	}
+
+	// A request for the same hostname without the port must not match,
+	// since the port is a significant part of the host identity.
+	if creds, err := credSrc.ForHost(svchost.Hostname("registry.internal"), ""); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if creds != nil {
+		t.Fatal("unexpectedly found credentials for the bare hostname")
+	}
+}
+
+func TestCredentialsSourceDefault(t *testing.T) {
+	d, err := ioutil.TempDir("", "terraform-cliconfig-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(d)
+
+	cfg := &Config{
+		Credentials: map[string]map[string]interface{}{
+			defaultCredentialsHost: {
+				"token": "from-default",
+			},
+			"configured.example.com": {
+				"token": "from-host-specific",
+			},
+		},
+	}
+	credSrc := cfg.credentialsSource(nil, filepath.Join(d, "credentials.tfrc.json"))
+
+	t.Run("falls back to the default for an unconfigured host", func(t *testing.T) {
+		if got, want := credSrc.HostCredentialsLocation(svchost.Hostname("other.example.com")), CredentialsViaDefault; got != want {
+			t.Fatalf("wrong location: got %q, want %q", got, want)
+		}
+		creds, err := credSrc.ForHost(svchost.Hostname("other.example.com"), "")
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if creds == nil {
+			t.Fatal("expected credentials from the default block")
+		}
+		if got, want := creds.Token(), "from-default"; got != want {
+			t.Errorf("wrong token: got %q, want %q", got, want)
+		}
+	})
+
+	t.Run("a host-specific block always wins over the default", func(t *testing.T) {
+		// This host's credentials came from the Config directly rather than
+		// from the credentials.tfrc.json file, so they're unwritable here --
+		// that's orthogonal to what we're testing, which is that the
+		// host-specific entry is found at all rather than falling through
+		// to the default.
+		if got, want := credSrc.HostCredentialsLocation(svchost.Hostname("configured.example.com")), CredentialsInOtherFile; got != want {
+			t.Fatalf("wrong location: got %q, want %q", got, want)
+		}
+		creds, err := credSrc.ForHost(svchost.Hostname("configured.example.com"), "")
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if got, want := creds.Token(), "from-host-specific"; got != want {
+			t.Errorf("wrong token: got %q, want %q", got, want)
+		}
+	})
+
+	t.Run("writing for a host covered only by the default creates a host-specific entry", func(t *testing.T) {
+		host := svchost.Hostname("new.example.com")
+		if err := credSrc.StoreForHost(host, svcauth.HostCredentialsToken("new-token")); err != nil {
+			t.Fatalf("unexpected error storing credentials: %s", err)
+		}
+		if got, want := credSrc.HostCredentialsLocation(host), CredentialsInPrimaryFile; got != want {
+			t.Fatalf("wrong location after storing: got %q, want %q", got, want)
+		}
+	})
+}
+
+func TestConfig_ServicesClient(t *testing.T) {
+	d, err := ioutil.TempDir("", "terraform-cliconfig-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(d)
+
+	// We can't exercise credentialsConfigFile's real home-directory lookup
+	// from a test, but ServicesClient only needs CredentialsSource to
+	// succeed, which it will as long as ConfigDir can resolve -- so this
+	// test just asserts on the parts that ServicesClient adds on top of
+	// CredentialsSource: forced host services and credential attachment.
+	cfg := &Config{
+		Hosts: map[string]*ConfigHost{
+			"forced.example.com": {
+				Services: map[string]interface{}{
+					"thingy.v1": "https://forced.example.com/thingy",
+				},
+			},
+		},
+		Credentials: map[string]map[string]interface{}{
+			"forced.example.com": {
+				"token": "forced-token",
+			},
+		},
+	}
+
+	services, err := cfg.ServicesClient(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	host, err := svchost.ForComparison("forced.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	discovered, err := services.Discover(host)
+	if err != nil {
+		t.Fatalf("unexpected discovery error: %s", err)
+	}
+	gotURL, err := discovered.ServiceURL("thingy.v1")
+	if err != nil {
+		t.Fatalf("unexpected service URL error: %s", err)
+	}
+	if got, want := gotURL.String(), "https://forced.example.com/thingy"; got != want {
+		t.Errorf("wrong service URL\ngot:  %s\nwant: %s", got, want)
+	}
+
+	creds, err := services.CredentialsForHost(host)
+	if err != nil {
+		t.Fatalf("unexpected credentials error: %s", err)
+	}
+	if creds == nil {
+		t.Fatal("expected credentials for the forced host")
+	}
+	if got, want := creds.Token(), "forced-token"; got != want {
+		t.Errorf("wrong token: got %q, want %q", got, want)
+	}
+}
+
+func TestConfig_ServicesClient_pinServiceVersion(t *testing.T) {
+	cfg := &Config{
+		Hosts: map[string]*ConfigHost{
+			"pinned.example.com": {
+				Services: map[string]interface{}{
+					"thingy.v2": "https://pinned.example.com/thingy",
+				},
+				PinServiceVersions: map[string]string{
+					"thingy": "v1",
+				},
+			},
+		},
+	}
+
+	services, err := cfg.ServicesClient(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	host, err := svchost.ForComparison("pinned.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	discovered, err := services.Discover(host)
+	if err != nil {
+		t.Fatalf("unexpected discovery error: %s", err)
+	}
+	gotURL, err := discovered.ServiceURL("thingy.v1")
+	if err != nil {
+		t.Fatalf("unexpected service URL error: %s", err)
+	}
+	if got, want := gotURL.String(), "https://pinned.example.com/thingy"; got != want {
+		t.Errorf("wrong service URL\ngot:  %s\nwant: %s", got, want)
+	}
+}
+
+// TestCredentialsSource_crossFormatEquivalence verifies that an equivalent
+// "credentials" block produces the exact same cty.Value, attribute-by-
+// attribute and type-by-type, regardless of whether it was written in
+// native HCL syntax (a ".tfrc" file) or JSON syntax (a ".tfrc.json" file).
+// Both syntaxes are parsed by the same HCL decoder and then normalized to
+// cty.Value by credentialsSource, so a regression that caused the two
+// syntaxes to diverge (for example in how a number or boolean attribute is
+// typed) would show up here as a RawValueForHost mismatch.
+func TestCredentialsSource_crossFormatEquivalence(t *testing.T) {
+	const nativeSrc = `
+credentials "example.com" {
+  token                 = "abc123"
+  expires_at            = "2030-01-01T00:00:00Z"
+  refresh_hint_seconds  = 3600
+}
+`
+	const jsonSrc = `
+{
+  "credentials": {
+    "example.com": {
+      "token": "abc123",
+      "expires_at": "2030-01-01T00:00:00Z",
+      "refresh_hint_seconds": 3600
+    }
+  }
+}
+`
+
+	loadRawValue := func(t *testing.T, filename, src string) cty.Value {
+		t.Helper()
+
+		d, err := ioutil.TempDir("", "terraform-cliconfig-test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(d)
+
+		path := filepath.Join(d, filename)
+		if err := ioutil.WriteFile(path, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		cfg, diags := loadConfigFile(path)
+		if diags.HasErrors() {
+			t.Fatalf("unexpected diagnostics loading %s: %s", filename, diags.Err())
+		}
+
+		credSrc := cfg.credentialsSource(nil, filepath.Join(d, "credentials.tfrc.json"))
+		v, ok := credSrc.RawValueForHost(svchost.Hostname("example.com"))
+		if !ok {
+			t.Fatalf("no credentials configured for example.com, loaded from %s", filename)
+		}
+		return v
+	}
+
+	nativeVal := loadRawValue(t, "native.tfrc", nativeSrc)
+	jsonVal := loadRawValue(t, "json.tfrc.json", jsonSrc)
+
+	if !nativeVal.RawEquals(jsonVal) {
+		t.Errorf("native and JSON config produced different typed credentials\nnative: %#v\njson:   %#v", nativeVal, jsonVal)
+	}
+}
+
 func TestCredentialsStoreForget(t *testing.T) {
 	d, err := ioutil.TempDir("", "terraform-cliconfig-test")
 	if err != nil {
@@ -109,7 +521,7 @@ func TestCredentialsStoreForget(t *testing.T) {
 	// We'll initially use a credentials source with no credentials helper at
 	// all, and thus with credentials stored in the credentials file.
 	credSrc := cfg.credentialsSource(
-		"", nil,
+		nil,
 		mockCredsFilename,
 	)
 
@@ -165,7 +577,7 @@ func TestCredentialsStoreForget(t *testing.T) {
 			t.Fatalf("unexpected error storing locally: %s", err)
 		}
 
-		creds, err := credSrc.ForHost(svchost.Hostname("stored-locally.example.com"))
+		creds, err := credSrc.ForHost(svchost.Hostname("stored-locally.example.com"), "")
 		if err != nil {
 			t.Fatalf("failed to read back stored-locally credentials: %s", err)
 		}
@@ -192,7 +604,7 @@ func TestCredentialsStoreForget(t *testing.T) {
 	}
 	mockHelper := &mockCredentialsHelper{current: make(map[svchost.Hostname]cty.Value)}
 	credSrc = cfg.credentialsSource(
-		"mock", mockHelper,
+		[]routedCredentialsHelper{{helperType: "mock", source: mockHelper}},
 		mockCredsFilename,
 	)
 	{
@@ -213,7 +625,7 @@ func TestCredentialsStoreForget(t *testing.T) {
 			t.Fatalf("unexpected error storing in helper: %s", err)
 		}
 
-		creds, err := credSrc.ForHost(svchost.Hostname("stored-in-helper.example.com"))
+		creds, err := credSrc.ForHost(svchost.Hostname("stored-in-helper.example.com"), "")
 		if err != nil {
 			t.Fatalf("failed to read back stored-in-helper credentials: %s", err)
 		}
@@ -242,7 +654,7 @@ func TestCredentialsStoreForget(t *testing.T) {
 			t.Fatalf("unexpected error storing locally again: %s", err)
 		}
 
-		creds, err := credSrc.ForHost(svchost.Hostname("stored-locally.example.com"))
+		creds, err := credSrc.ForHost(svchost.Hostname("stored-locally.example.com"), "")
 		if err != nil {
 			t.Fatalf("failed to read back stored-locally credentials: %s", err)
 		}
@@ -261,7 +673,7 @@ func TestCredentialsStoreForget(t *testing.T) {
 			t.Fatalf("unexpected error forgetting locally: %s", err)
 		}
 
-		creds, err := credSrc.ForHost(svchost.Hostname("stored-locally.example.com"))
+		creds, err := credSrc.ForHost(svchost.Hostname("stored-locally.example.com"), "")
 		if err != nil {
 			t.Fatalf("failed to read back stored-locally credentials: %s", err)
 		}
@@ -285,7 +697,7 @@ func TestCredentialsStoreForget(t *testing.T) {
 			t.Fatalf("unexpected error forgetting in helper: %s", err)
 		}
 
-		creds, err := credSrc.ForHost(svchost.Hostname("stored-in-helper.example.com"))
+		creds, err := credSrc.ForHost(svchost.Hostname("stored-in-helper.example.com"), "")
 		if err != nil {
 			t.Fatalf("failed to read back stored-in-helper credentials: %s", err)
 		}
@@ -328,7 +740,7 @@ type mockCredentialsHelper struct {
 // Assertion that mockCredentialsHelper implements svcauth.CredentialsSource
 var _ svcauth.CredentialsSource = (*mockCredentialsHelper)(nil)
 
-func (s *mockCredentialsHelper) ForHost(hostname svchost.Hostname) (svcauth.HostCredentials, error) {
+func (s *mockCredentialsHelper) ForHost(hostname svchost.Hostname, service string) (svcauth.HostCredentials, error) {
 	v, ok := s.current[hostname]
 	if !ok {
 		return nil, nil
@@ -353,3 +765,135 @@ func (s *mockCredentialsHelper) ForgetForHost(hostname svchost.Hostname) error {
 	delete(s.current, hostname)
 	return nil
 }
+
+func TestBuildHelperEnv(t *testing.T) {
+	defer os.Setenv("CLICONFIG_TEST_INHERITED", os.Getenv("CLICONFIG_TEST_INHERITED"))
+	os.Setenv("CLICONFIG_TEST_INHERITED", "from-environment")
+
+	t.Run("neither set", func(t *testing.T) {
+		if got := buildHelperEnv(nil, nil); got != nil {
+			t.Errorf("got %#v; want nil, so the helper inherits the full environment", got)
+		}
+	})
+
+	t.Run("extra only", func(t *testing.T) {
+		env := buildHelperEnv(map[string]string{"FOO": "bar"}, nil)
+		if !contains(env, "FOO=bar") {
+			t.Errorf("env does not contain FOO=bar: %#v", env)
+		}
+		if !contains(env, "CLICONFIG_TEST_INHERITED=from-environment") {
+			t.Errorf("env does not contain the inherited variable: %#v", env)
+		}
+	})
+
+	t.Run("allowlist only", func(t *testing.T) {
+		env := buildHelperEnv(nil, []string{"CLICONFIG_TEST_INHERITED"})
+		if got, want := env, []string{"CLICONFIG_TEST_INHERITED=from-environment"}; !cmp.Equal(got, want) {
+			t.Errorf("wrong env\ngot:  %#v\nwant: %#v", got, want)
+		}
+	})
+
+	t.Run("allowlist excludes everything not listed", func(t *testing.T) {
+		env := buildHelperEnv(nil, []string{"CLICONFIG_TEST_NOT_SET"})
+		if len(env) != 0 {
+			t.Errorf("got %#v; want no entries, since nothing matched the allowlist", env)
+		}
+	})
+
+	t.Run("extra overrides an allowed inherited value", func(t *testing.T) {
+		env := buildHelperEnv(
+			map[string]string{"CLICONFIG_TEST_INHERITED": "from-config"},
+			[]string{"CLICONFIG_TEST_INHERITED"},
+		)
+		if got, want := env, []string{"CLICONFIG_TEST_INHERITED=from-environment", "CLICONFIG_TEST_INHERITED=from-config"}; !cmp.Equal(got, want) {
+			t.Errorf("wrong env\ngot:  %#v\nwant: %#v", got, want)
+		}
+	})
+}
+
+func contains(haystack []string, needle string) bool {
+	for _, s := range haystack {
+		if s == needle {
+			return true
+		}
+	}
+	return false
+}
+
+func TestCredentialsStorePreservesUnrelatedContentOrder(t *testing.T) {
+	d, err := ioutil.TempDir("", "terraform-cliconfig-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(d)
+
+	mockCredsFilename := filepath.Join(d, "credentials.tfrc.json")
+
+	// A hand-maintained credentials file commonly has its hosts in whatever
+	// order a human added them in, plus possibly unrelated top-level content
+	// that some other tool added. A surgical update to one host's token
+	// shouldn't disturb any of that.
+	initial := `{
+  "credentials": {
+    "zzz.example.com": {"token": "zzz-token"},
+    "aaa.example.com": {"token": "aaa-token"}
+  },
+  "some_other_tool_setting": {"enabled": true}
+}`
+	if err := ioutil.WriteFile(mockCredsFilename, []byte(initial), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &Config{}
+	credSrc := cfg.credentialsSource(nil, mockCredsFilename)
+
+	if err := credSrc.StoreForHost(
+		svchost.Hostname("aaa.example.com"),
+		svcauth.HostCredentialsToken("aaa-token-updated"),
+	); err != nil {
+		t.Fatalf("unexpected error storing: %s", err)
+	}
+
+	raw, err := decodeOrderedJSONObject(readFile(t, mockCredsFilename))
+	if err != nil {
+		t.Fatalf("cannot parse updated credentials file: %s", err)
+	}
+
+	if got, want := raw.keys(), []string{"credentials", "some_other_tool_setting"}; !stringSlicesEqual(got, want) {
+		t.Errorf("wrong top-level key order\ngot:  %#v\nwant: %#v", got, want)
+	}
+
+	credsSrc, ok := raw.get("credentials")
+	if !ok {
+		t.Fatal("missing \"credentials\" key")
+	}
+	creds, err := decodeOrderedJSONObject(credsSrc)
+	if err != nil {
+		t.Fatalf("cannot parse \"credentials\" object: %s", err)
+	}
+	if got, want := creds.keys(), []string{"zzz.example.com", "aaa.example.com"}; !stringSlicesEqual(got, want) {
+		t.Errorf("wrong host order\ngot:  %#v\nwant: %#v", got, want)
+	}
+
+	hostCreds, err := credSrc.ForHost(svchost.Hostname("aaa.example.com"), "")
+	if err != nil {
+		t.Fatalf("failed to read back updated credentials: %s", err)
+	}
+	req, err := http.NewRequest("GET", "http://example.com/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hostCreds.PrepareRequest(req)
+	if got, want := req.Header.Get("Authorization"), "Bearer aaa-token-updated"; got != want {
+		t.Fatalf("wrong header value\ngot:  %s\nwant: %s", got, want)
+	}
+}
+
+func readFile(t *testing.T, filename string) []byte {
+	t.Helper()
+	src, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return src
+}
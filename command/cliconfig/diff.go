@@ -0,0 +1,303 @@
+package cliconfig
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// notSetValue is the string Change reports for a setting that's present on
+// one side of a Diff but absent on the other.
+const notSetValue = "(not set)"
+
+// redactedValue is the string Change reports in place of any value that
+// might carry a credential, regardless of whether it actually changed.
+const redactedValue = "(redacted)"
+
+// Change describes one setting that differs between two Configs, as
+// produced by Diff.
+type Change struct {
+	// Setting names the changed value in a short, human-readable form,
+	// such as "rate_limit" or "credentials_helper[vault].timeout".
+	Setting string
+
+	// Old and New are the string forms of the setting's value on each side
+	// of the comparison, or notSetValue if the setting was entirely absent
+	// on that side. Anything that might carry a credential -- currently a
+	// "credentials" block's value, or a "credentials_helper" block's "env"
+	// -- is always reported as redactedValue instead of its real value, so
+	// that a diff can be shared (for example, attached to an alert) without
+	// becoming a place secrets leak to.
+	Old, New string
+
+	// OldSource and NewSource identify, if known, the position
+	// ("line:column" within its file) of the label of the block that
+	// produced Old and New respectively. They're empty
+	// when Diff has no more precise provenance for the setting than the
+	// whole list of files that contributed to the Config; see
+	// Config.Snapshot.
+	OldSource, NewSource string
+}
+
+// Diff compares two Configs -- most commonly the configuration currently
+// effective on a workstation and a "blessed" baseline loaded from
+// elsewhere -- and returns a Change for each compared setting that differs
+// between them.
+//
+// The settings compared are disable_checkpoint,
+// disable_checkpoint_signature, plugin_cache_dir,
+// plugin_cache_strict_checksum, rate_limit, the number of trusted_keys, the
+// services of each "host" block, the presence of each "credentials" block,
+// each "credentials_helper" block, and the "registry" and "audit" blocks.
+// Other settings, such as features, ca_bundle_path or
+// provisioner_installation, are not compared.
+//
+// A nil Config is treated the same as an empty one, so that Diff can be
+// used to describe a Config in absolute terms by comparing it against nil.
+//
+// The result is sorted by Setting for stable output, and never includes
+// the literal value of anything that might be a credential; see Change.
+func Diff(a, b *Config) []Change {
+	if a == nil {
+		a = &Config{}
+	}
+	if b == nil {
+		b = &Config{}
+	}
+
+	var changes []Change
+	changes = append(changes, diffScalars(a, b)...)
+	changes = append(changes, diffTrustedKeys(a, b)...)
+	changes = append(changes, diffHosts(a, b)...)
+	changes = append(changes, diffCredentials(a, b)...)
+	changes = append(changes, diffCredentialsHelpers(a, b)...)
+	changes = append(changes, diffRegistry(a, b)...)
+	changes = append(changes, diffAudit(a, b)...)
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Setting < changes[j].Setting })
+	return changes
+}
+
+// scalarChange appends a Change for setting if old and new differ, using
+// the given source positions, which may be empty if unknown.
+func scalarChange(changes []Change, setting, old, new, oldSource, newSource string) []Change {
+	if old == new {
+		return changes
+	}
+	return append(changes, Change{
+		Setting:   setting,
+		Old:       old,
+		New:       new,
+		OldSource: oldSource,
+		NewSource: newSource,
+	})
+}
+
+func diffScalars(a, b *Config) []Change {
+	var changes []Change
+	changes = scalarChange(changes, "disable_checkpoint", strconv.FormatBool(a.DisableCheckpoint), strconv.FormatBool(b.DisableCheckpoint), "", "")
+	changes = scalarChange(changes, "disable_checkpoint_signature", strconv.FormatBool(a.DisableCheckpointSignature), strconv.FormatBool(b.DisableCheckpointSignature), "", "")
+	changes = scalarChange(changes, "plugin_cache_dir", a.PluginCacheDir, b.PluginCacheDir, "", "")
+	changes = scalarChange(changes, "plugin_cache_strict_checksum", strconv.FormatBool(a.PluginCacheStrictChecksum), strconv.FormatBool(b.PluginCacheStrictChecksum), "", "")
+	changes = scalarChange(changes, "rate_limit", strconv.FormatFloat(a.RateLimit, 'g', -1, 64), strconv.FormatFloat(b.RateLimit, 'g', -1, 64), "", "")
+	return changes
+}
+
+// diffTrustedKeys reports only whether the set of trusted keys changed, and
+// by how many keys, rather than their full content: a "trusted_keys" list
+// holds whole ASCII-armored public keys, which are too long to usefully
+// show inline in a diff.
+func diffTrustedKeys(a, b *Config) []Change {
+	old := trustedKeysSummary(a.TrustedKeys)
+	new := trustedKeysSummary(b.TrustedKeys)
+	return scalarChange(nil, "trusted_keys", old, new, "", "")
+}
+
+func trustedKeysSummary(keys []string) string {
+	if len(keys) == 0 {
+		return notSetValue
+	}
+	return fmt.Sprintf("(%d keys)", len(keys))
+}
+
+func diffHosts(a, b *Config) []Change {
+	var changes []Change
+	for _, name := range sortedHostNames(a.Hosts, b.Hosts) {
+		oldHost, oldOK := a.Hosts[name]
+		newHost, newOK := b.Hosts[name]
+
+		old, new := notSetValue, notSetValue
+		if oldOK {
+			old = fmt.Sprintf("%v", oldHost.Services)
+		}
+		if newOK {
+			new = fmt.Sprintf("%v", newHost.Services)
+		}
+
+		changes = scalarChange(changes, fmt.Sprintf("host[%s]", name), old, new, a.hostPositions[name], b.hostPositions[name])
+	}
+	return changes
+}
+
+func sortedHostNames(a, b map[string]*ConfigHost) []string {
+	seen := make(map[string]struct{}, len(a)+len(b))
+	for name := range a {
+		seen[name] = struct{}{}
+	}
+	for name := range b {
+		seen[name] = struct{}{}
+	}
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// diffCredentials reports which hosts' "credentials" blocks changed,
+// without ever showing the credentials themselves.
+func diffCredentials(a, b *Config) []Change {
+	var changes []Change
+	for _, host := range sortedCredentialsHosts(a.Credentials, b.Credentials) {
+		_, oldOK := a.Credentials[host]
+		_, newOK := b.Credentials[host]
+
+		old, new := notSetValue, notSetValue
+		if oldOK {
+			old = redactedValue
+		}
+		if newOK {
+			new = redactedValue
+		}
+		if old == new {
+			// Both sides have a block, and we can't compare their content
+			// without showing it, so we can only report a change here when
+			// one side has a block and the other doesn't.
+			continue
+		}
+
+		changes = append(changes, Change{
+			Setting:   fmt.Sprintf("credentials[%s]", host),
+			Old:       old,
+			New:       new,
+			OldSource: a.credentialsPositions[host],
+			NewSource: b.credentialsPositions[host],
+		})
+	}
+	return changes
+}
+
+func sortedCredentialsHosts(a, b map[string]map[string]interface{}) []string {
+	seen := make(map[string]struct{}, len(a)+len(b))
+	for host := range a {
+		seen[host] = struct{}{}
+	}
+	for host := range b {
+		seen[host] = struct{}{}
+	}
+	hosts := make([]string, 0, len(seen))
+	for host := range seen {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+	return hosts
+}
+
+func diffCredentialsHelpers(a, b *Config) []Change {
+	var changes []Change
+	for _, name := range sortedCredentialsHelperDiffNames(a.CredentialsHelpers, b.CredentialsHelpers) {
+		oldHelper, oldOK := a.CredentialsHelpers[name]
+		newHelper, newOK := b.CredentialsHelpers[name]
+		oldPos, newPos := a.credentialsHelperPositions[name], b.credentialsHelperPositions[name]
+
+		if !oldOK || !newOK {
+			old, new := notSetValue, notSetValue
+			if oldOK {
+				old = "(configured)"
+			}
+			if newOK {
+				new = "(configured)"
+			}
+			changes = append(changes, Change{
+				Setting: fmt.Sprintf("credentials_helper[%s]", name),
+				Old:     old, New: new,
+				OldSource: oldPos, NewSource: newPos,
+			})
+			continue
+		}
+
+		prefix := fmt.Sprintf("credentials_helper[%s]", name)
+		changes = scalarChange(changes, prefix+".args", strings.Join(oldHelper.Args, ","), strings.Join(newHelper.Args, ","), oldPos, newPos)
+		changes = scalarChange(changes, prefix+".hosts", strings.Join(oldHelper.Hosts, ","), strings.Join(newHelper.Hosts, ","), oldPos, newPos)
+		changes = scalarChange(changes, prefix+".env_allowlist", strings.Join(oldHelper.EnvAllowlist, ","), strings.Join(newHelper.EnvAllowlist, ","), oldPos, newPos)
+		changes = scalarChange(changes, prefix+".timeout", strconv.Itoa(oldHelper.Timeout), strconv.Itoa(newHelper.Timeout), oldPos, newPos)
+
+		// env holds arbitrary values, which may include secrets passed to
+		// the helper program, so we report only whether it changed.
+		if !stringMapsEqual(oldHelper.Env, newHelper.Env) {
+			changes = append(changes, Change{
+				Setting: prefix + ".env", Old: redactedValue, New: redactedValue,
+				OldSource: oldPos, NewSource: newPos,
+			})
+		}
+	}
+	return changes
+}
+
+func sortedCredentialsHelperDiffNames(a, b map[string]*ConfigCredentialsHelper) []string {
+	seen := make(map[string]struct{}, len(a)+len(b))
+	for name := range a {
+		seen[name] = struct{}{}
+	}
+	for name := range b {
+		seen[name] = struct{}{}
+	}
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func stringMapsEqual(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if bv, ok := b[k]; !ok || bv != v {
+			return false
+		}
+	}
+	return true
+}
+
+func diffRegistry(a, b *Config) []Change {
+	var oldRetries, oldTimeout, oldBackoff int
+	var newRetries, newTimeout, newBackoff int
+	if a.Registry != nil {
+		oldRetries, oldTimeout, oldBackoff = a.Registry.Retries, a.Registry.Timeout, a.Registry.BackoffMax
+	}
+	if b.Registry != nil {
+		newRetries, newTimeout, newBackoff = b.Registry.Retries, b.Registry.Timeout, b.Registry.BackoffMax
+	}
+
+	var changes []Change
+	changes = scalarChange(changes, "registry.retries", strconv.Itoa(oldRetries), strconv.Itoa(newRetries), "", "")
+	changes = scalarChange(changes, "registry.timeout", strconv.Itoa(oldTimeout), strconv.Itoa(newTimeout), "", "")
+	changes = scalarChange(changes, "registry.backoff_max", strconv.Itoa(oldBackoff), strconv.Itoa(newBackoff), "", "")
+	return changes
+}
+
+func diffAudit(a, b *Config) []Change {
+	var oldPath, newPath string
+	if a.Audit != nil {
+		oldPath = a.Audit.Path
+	}
+	if b.Audit != nil {
+		newPath = b.Audit.Path
+	}
+	return scalarChange(nil, "audit.path", oldPath, newPath, "", "")
+}
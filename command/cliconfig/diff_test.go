@@ -0,0 +1,182 @@
+package cliconfig
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	t.Run("no differences", func(t *testing.T) {
+		a := &Config{RateLimit: 5}
+		b := &Config{RateLimit: 5}
+		if got := Diff(a, b); len(got) != 0 {
+			t.Errorf("got %d changes; want 0: %#v", len(got), got)
+		}
+	})
+
+	t.Run("scalar setting changed", func(t *testing.T) {
+		a := &Config{RateLimit: 5, PluginCacheDir: "/tmp/old"}
+		b := &Config{RateLimit: 10, PluginCacheDir: "/tmp/old"}
+
+		got := Diff(a, b)
+		if len(got) != 1 {
+			t.Fatalf("got %d changes; want 1: %#v", len(got), got)
+		}
+		if got[0].Setting != "rate_limit" || got[0].Old != "5" || got[0].New != "10" {
+			t.Errorf("wrong change: %#v", got[0])
+		}
+	})
+
+	t.Run("credentials block added, never showing the value", func(t *testing.T) {
+		a := &Config{}
+		b := &Config{
+			Credentials: map[string]map[string]interface{}{
+				"example.com": {"token": "super-secret"},
+			},
+		}
+
+		got := Diff(a, b)
+		if len(got) != 1 {
+			t.Fatalf("got %d changes; want 1: %#v", len(got), got)
+		}
+		change := got[0]
+		if change.Setting != "credentials[example.com]" {
+			t.Errorf("wrong setting %q", change.Setting)
+		}
+		if change.Old != notSetValue || change.New != redactedValue {
+			t.Errorf("wrong values: old=%q new=%q", change.Old, change.New)
+		}
+
+		for _, c := range got {
+			if strings.Contains(c.Old, "super-secret") || strings.Contains(c.New, "super-secret") {
+				t.Errorf("credential value leaked into diff: %#v", c)
+			}
+		}
+	})
+
+	t.Run("credentials_helper env change is redacted but other fields are not", func(t *testing.T) {
+		a := &Config{
+			CredentialsHelpers: map[string]*ConfigCredentialsHelper{
+				"vault": {
+					Args:    []string{"--old"},
+					Env:     map[string]string{"VAULT_TOKEN": "old-secret"},
+					Timeout: 5,
+				},
+			},
+		}
+		b := &Config{
+			CredentialsHelpers: map[string]*ConfigCredentialsHelper{
+				"vault": {
+					Args:    []string{"--new"},
+					Env:     map[string]string{"VAULT_TOKEN": "new-secret"},
+					Timeout: 10,
+				},
+			},
+		}
+
+		got := Diff(a, b)
+
+		var sawArgs, sawEnv, sawTimeout bool
+		for _, c := range got {
+			switch c.Setting {
+			case "credentials_helper[vault].args":
+				sawArgs = true
+				if c.Old != "--old" || c.New != "--new" {
+					t.Errorf("wrong args change: %#v", c)
+				}
+			case "credentials_helper[vault].env":
+				sawEnv = true
+				if c.Old != redactedValue || c.New != redactedValue {
+					t.Errorf("env change not redacted: %#v", c)
+				}
+			case "credentials_helper[vault].timeout":
+				sawTimeout = true
+				if c.Old != "5" || c.New != "10" {
+					t.Errorf("wrong timeout change: %#v", c)
+				}
+			}
+			if strings.Contains(c.Old, "secret") || strings.Contains(c.New, "secret") {
+				t.Errorf("secret value leaked into diff: %#v", c)
+			}
+		}
+		if !sawArgs || !sawEnv || !sawTimeout {
+			t.Errorf("missing expected changes: args=%v env=%v timeout=%v", sawArgs, sawEnv, sawTimeout)
+		}
+	})
+
+	t.Run("registry settings", func(t *testing.T) {
+		a := &Config{}
+		b := &Config{Registry: &ConfigRegistry{Retries: 3, Timeout: 20, BackoffMax: 30}}
+
+		got := Diff(a, b)
+		want := map[string]struct{ old, new string }{
+			"registry.retries":     {"0", "3"},
+			"registry.timeout":     {"0", "20"},
+			"registry.backoff_max": {"0", "30"},
+		}
+		if len(got) != len(want) {
+			t.Fatalf("got %d changes; want %d: %#v", len(got), len(want), got)
+		}
+		for _, c := range got {
+			w, ok := want[c.Setting]
+			if !ok {
+				t.Errorf("unexpected setting %q", c.Setting)
+				continue
+			}
+			if c.Old != w.old || c.New != w.new {
+				t.Errorf("wrong values for %q: old=%q new=%q", c.Setting, c.Old, c.New)
+			}
+		}
+	})
+
+	t.Run("audit path", func(t *testing.T) {
+		a := &Config{}
+		b := &Config{Audit: &ConfigAudit{Path: "/var/log/tf-audit.jsonl"}}
+
+		got := Diff(a, b)
+		if len(got) != 1 || got[0].Setting != "audit.path" || got[0].New != "/var/log/tf-audit.jsonl" {
+			t.Fatalf("wrong changes: %#v", got)
+		}
+	})
+
+	t.Run("trusted_keys reports a count, not the keys", func(t *testing.T) {
+		a := &Config{}
+		b := &Config{TrustedKeys: []string{"key one", "key two"}}
+
+		got := Diff(a, b)
+		if len(got) != 1 || got[0].Setting != "trusted_keys" {
+			t.Fatalf("wrong changes: %#v", got)
+		}
+		if got[0].New != "(2 keys)" {
+			t.Errorf("wrong new value %q", got[0].New)
+		}
+		if strings.Contains(got[0].New, "key one") {
+			t.Errorf("full key content leaked into diff: %#v", got[0])
+		}
+	})
+
+	t.Run("host block position is reported as source", func(t *testing.T) {
+		b := &Config{
+			Hosts: map[string]*ConfigHost{
+				"example.com": {Services: map[string]interface{}{"modules.v1": "https://example.com/"}},
+			},
+		}
+		b.setHostPosition("example.com", "example.tfrc:4")
+
+		got := Diff(&Config{}, b)
+		if len(got) != 1 {
+			t.Fatalf("got %d changes; want 1: %#v", len(got), got)
+		}
+		if got[0].NewSource != "example.tfrc:4" {
+			t.Errorf("wrong new source %q", got[0].NewSource)
+		}
+	})
+
+	t.Run("nil Configs are treated as empty", func(t *testing.T) {
+		b := &Config{RateLimit: 5}
+		got := Diff(nil, b)
+		if len(got) != 1 || got[0].Setting != "rate_limit" {
+			t.Fatalf("wrong changes: %#v", got)
+		}
+	})
+}
@@ -0,0 +1,59 @@
+package cliconfig
+
+import (
+	"os"
+	"runtime"
+	"strings"
+)
+
+// envCache snapshots the process environment once and serves $VAR and
+// ${VAR} expansions from that snapshot rather than from a live os.Getenv
+// call every time. loadConfigDir builds one and shares it across every
+// file it loads, since re-reading the environment per file is wasted work
+// once a config directory holds more than a handful of files.
+//
+// Lookups are case-insensitive on Windows, matching the native environment
+// APIs that os.Getenv itself defers to there, so that a variable set by
+// some other Windows tool under a different letter case than Terraform's
+// documentation uses -- "tf_plugin_cache_dir" rather than
+// "TF_PLUGIN_CACHE_DIR", say -- is still honored.
+type envCache struct {
+	vars map[string]string
+}
+
+// envCacheKey normalizes a variable name the way envCache uses internally
+// for both storage and lookup.
+func envCacheKey(name string) string {
+	return envCacheKeyForGOOS(runtime.GOOS, name)
+}
+
+// envCacheKeyForGOOS is envCacheKey with an injectable GOOS, so its
+// Windows-specific behavior can be unit tested without actually running on
+// Windows.
+func envCacheKeyForGOOS(goos, name string) string {
+	if goos == "windows" {
+		return strings.ToUpper(name)
+	}
+	return name
+}
+
+// newEnvCache captures the current process environment for use by expand.
+func newEnvCache() *envCache {
+	environ := os.Environ()
+	vars := make(map[string]string, len(environ))
+	for _, kv := range environ {
+		for i := 0; i < len(kv); i++ {
+			if kv[i] == '=' {
+				vars[envCacheKey(kv[:i])] = kv[i+1:]
+				break
+			}
+		}
+	}
+	return &envCache{vars: vars}
+}
+
+// expand is a drop-in replacement for os.ExpandEnv that consults the
+// snapshot taken by newEnvCache instead of the live environment.
+func (e *envCache) expand(s string) string {
+	return os.Expand(s, func(name string) string { return e.vars[envCacheKey(name)] })
+}
@@ -0,0 +1,49 @@
+package cliconfig
+
+import (
+	"os"
+	"testing"
+)
+
+func TestEnvCacheExpand(t *testing.T) {
+	defer os.Unsetenv("CLICONFIG_ENVCACHE_TEST")
+	os.Setenv("CLICONFIG_ENVCACHE_TEST", "hello")
+
+	env := newEnvCache()
+
+	if got, want := env.expand("$CLICONFIG_ENVCACHE_TEST world"), "hello world"; got != want {
+		t.Errorf("wrong expansion\ngot:  %s\nwant: %s", got, want)
+	}
+	if got, want := env.expand("${CLICONFIG_ENVCACHE_TEST}!"), "hello!"; got != want {
+		t.Errorf("wrong expansion\ngot:  %s\nwant: %s", got, want)
+	}
+	if got, want := env.expand("$CLICONFIG_ENVCACHE_TEST_UNSET"), ""; got != want {
+		t.Errorf("wrong expansion of an unset variable\ngot:  %q\nwant: %q", got, want)
+	}
+
+	// The cache reflects the environment as it was when newEnvCache was
+	// called, not whatever it is when expand runs.
+	os.Setenv("CLICONFIG_ENVCACHE_TEST", "changed")
+	if got, want := env.expand("$CLICONFIG_ENVCACHE_TEST"), "hello"; got != want {
+		t.Errorf("expand used the live environment instead of the snapshot\ngot:  %s\nwant: %s", got, want)
+	}
+}
+
+func TestEnvCacheKeyForGOOS(t *testing.T) {
+	t.Run("windows normalizes to uppercase", func(t *testing.T) {
+		if got, want := envCacheKeyForGOOS("windows", "tf_plugin_cache_dir"), "TF_PLUGIN_CACHE_DIR"; got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+		if got, want := envCacheKeyForGOOS("windows", "TF_PLUGIN_CACHE_DIR"), "TF_PLUGIN_CACHE_DIR"; got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	})
+
+	t.Run("other platforms are left alone", func(t *testing.T) {
+		for _, goos := range []string{"linux", "darwin", "freebsd"} {
+			if got, want := envCacheKeyForGOOS(goos, "tf_plugin_cache_dir"), "tf_plugin_cache_dir"; got != want {
+				t.Errorf("%s: got %q, want %q", goos, got, want)
+			}
+		}
+	})
+}
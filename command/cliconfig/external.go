@@ -0,0 +1,14 @@
+package cliconfig
+
+// AllowedExternalPrograms returns the absolute paths allowlisted by every
+// "external_programs" entry in c, for use by a lang.Scope that has opted in
+// to the "external" function via EnableExternalFunction. It returns nil if
+// c is nil or no such entries are present.
+func (c *Config) AllowedExternalPrograms() []string {
+	if c == nil || len(c.ExternalPrograms) == 0 {
+		return nil
+	}
+	paths := make([]string, len(c.ExternalPrograms))
+	copy(paths, c.ExternalPrograms)
+	return paths
+}
@@ -0,0 +1,72 @@
+package cliconfig
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadConfig_externalPrograms(t *testing.T) {
+	dir, err := ioutil.TempDir("", "terraform-cliconfig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	src := `
+external_programs = ["/usr/local/bin/tf-helper"]
+`
+	if err := ioutil.WriteFile(filepath.Join(dir, "a.tfrc"), []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, diags := loadConfigDir(dir)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+
+	want := []string{"/usr/local/bin/tf-helper"}
+	if !reflect.DeepEqual(got.ExternalPrograms, want) {
+		t.Errorf("wrong result\ngot:  %#v\nwant: %#v", got.ExternalPrograms, want)
+	}
+	if !reflect.DeepEqual(got.AllowedExternalPrograms(), want) {
+		t.Errorf("wrong AllowedExternalPrograms result: %#v", got.AllowedExternalPrograms())
+	}
+}
+
+func TestConfigValidate_externalPrograms(t *testing.T) {
+	tests := map[string]struct {
+		paths   []string
+		wantErr bool
+	}{
+		"absolute path": {[]string{"/usr/local/bin/tf-helper"}, false},
+		"relative path": {[]string{"tf-helper"}, true},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := &Config{ExternalPrograms: test.paths}
+			diags := c.Validate()
+			if got := diags.HasErrors(); got != test.wantErr {
+				t.Errorf("wrong result\ngot:  %v\nwant: %v\ndiags: %s", got, test.wantErr, diags.Err())
+			}
+		})
+	}
+}
+
+func TestConfigMerge_externalPrograms(t *testing.T) {
+	c1 := &Config{ExternalPrograms: []string{"/usr/local/bin/a"}}
+	c2 := &Config{ExternalPrograms: []string{"/usr/local/bin/b"}}
+
+	got, diags := c1.Merge(c2)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+
+	want := []string{"/usr/local/bin/a", "/usr/local/bin/b"}
+	if !reflect.DeepEqual(got.ExternalPrograms, want) {
+		t.Errorf("wrong result\ngot:  %#v\nwant: %#v", got.ExternalPrograms, want)
+	}
+}
@@ -0,0 +1,112 @@
+package cliconfig
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Fingerprint returns a deterministic hash of the receiver's effective
+// settings, suitable for cheaply detecting whether the configuration has
+// changed -- for example, to decide whether a cache of something derived
+// from it, such as a discovery document or an installed plugin set, is
+// still valid.
+//
+// Fingerprint covers the same settings Diff compares between two Configs,
+// so two Configs that Diff would report any change between always produce
+// different fingerprints. It also covers the full content of trusted_keys
+// and of each "credentials" block, which Diff doesn't compare, so two
+// Configs that Diff reports as identical can still have different
+// fingerprints. It says nothing about unexported bookkeeping fields such as
+// sourceFiles.
+//
+// A secret value, such as a "credentials" block's token or a
+// "credentials_helper" block's "env", contributes to the fingerprint via
+// a hash of its own content rather than its literal value, so that the
+// fingerprint still changes whenever a secret does without the fingerprint
+// itself disclosing what the secret is.
+func (c *Config) Fingerprint() string {
+	h := sha256.New()
+	if c != nil {
+		for _, line := range c.fingerprintLines() {
+			fmt.Fprintln(h, line)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// fingerprintLines returns one canonical "setting=value" line per setting
+// Diff would compare, sorted by setting name so that the result -- and so
+// Fingerprint -- never depends on map iteration order.
+func (c *Config) fingerprintLines() []string {
+	values := map[string]string{
+		"disable_checkpoint":           strconv.FormatBool(c.DisableCheckpoint),
+		"disable_checkpoint_signature": strconv.FormatBool(c.DisableCheckpointSignature),
+		"plugin_cache_dir":             c.PluginCacheDir,
+		"plugin_cache_strict_checksum": strconv.FormatBool(c.PluginCacheStrictChecksum),
+		"rate_limit":                   strconv.FormatFloat(c.RateLimit, 'g', -1, 64),
+		"trusted_keys":                 strings.Join(c.TrustedKeys, "\x00"),
+		"audit.path":                   "",
+		"registry.retries":             "0",
+		"registry.timeout":             "0",
+		"registry.backoff_max":         "0",
+	}
+
+	if c.Audit != nil {
+		values["audit.path"] = c.Audit.Path
+	}
+	if c.Registry != nil {
+		values["registry.retries"] = strconv.Itoa(c.Registry.Retries)
+		values["registry.timeout"] = strconv.Itoa(c.Registry.Timeout)
+		values["registry.backoff_max"] = strconv.Itoa(c.Registry.BackoffMax)
+	}
+
+	for name, host := range c.Hosts {
+		values[fmt.Sprintf("host[%s]", name)] = fmt.Sprintf("%v", host.Services)
+	}
+
+	for host, creds := range c.Credentials {
+		values[fmt.Sprintf("credentials[%s]", host)] = contentHash(creds)
+	}
+
+	for name, helper := range c.CredentialsHelpers {
+		prefix := fmt.Sprintf("credentials_helper[%s]", name)
+		values[prefix+".args"] = strings.Join(helper.Args, "\x00")
+		values[prefix+".hosts"] = strings.Join(helper.Hosts, "\x00")
+		values[prefix+".env_allowlist"] = strings.Join(helper.EnvAllowlist, "\x00")
+		values[prefix+".timeout"] = strconv.Itoa(helper.Timeout)
+		values[prefix+".env"] = contentHash(helper.Env)
+	}
+
+	settings := make([]string, 0, len(values))
+	for setting := range values {
+		settings = append(settings, setting)
+	}
+	sort.Strings(settings)
+
+	lines := make([]string, len(settings))
+	for i, setting := range settings {
+		lines[i] = setting + "=" + values[setting]
+	}
+	return lines
+}
+
+// contentHash returns a hex-encoded SHA-256 hash of v's canonical JSON
+// encoding, for folding a value that might be a secret into a fingerprint
+// without the fingerprint's own inputs ever holding that value directly.
+func contentHash(v interface{}) string {
+	// encoding/json always sorts map keys, so this is deterministic
+	// regardless of v's own iteration order.
+	enc, err := json.Marshal(v)
+	if err != nil {
+		// Every value we pass here (map[string]interface{}, map[string]string)
+		// is always representable as JSON, so this should never happen.
+		enc = []byte(fmt.Sprintf("%v", v))
+	}
+	sum := sha256.Sum256(enc)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,76 @@
+package cliconfig
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConfigFingerprint(t *testing.T) {
+	t.Run("identical configs match", func(t *testing.T) {
+		a := &Config{RateLimit: 5, PluginCacheDir: "/tmp/cache"}
+		b := &Config{RateLimit: 5, PluginCacheDir: "/tmp/cache"}
+		if a.Fingerprint() != b.Fingerprint() {
+			t.Errorf("fingerprints differ for identical configs")
+		}
+	})
+
+	t.Run("differing configs don't match", func(t *testing.T) {
+		a := &Config{RateLimit: 5}
+		b := &Config{RateLimit: 10}
+		if a.Fingerprint() == b.Fingerprint() {
+			t.Errorf("fingerprints match for differing configs")
+		}
+	})
+
+	t.Run("independent of Hosts map iteration order", func(t *testing.T) {
+		a := &Config{
+			Hosts: map[string]*ConfigHost{
+				"one.example.com": {Services: map[string]interface{}{"modules.v1": "https://one.example.com/"}},
+				"two.example.com": {Services: map[string]interface{}{"modules.v1": "https://two.example.com/"}},
+			},
+		}
+		b := &Config{
+			Hosts: map[string]*ConfigHost{
+				"two.example.com": {Services: map[string]interface{}{"modules.v1": "https://two.example.com/"}},
+				"one.example.com": {Services: map[string]interface{}{"modules.v1": "https://one.example.com/"}},
+			},
+		}
+		if a.Fingerprint() != b.Fingerprint() {
+			t.Errorf("fingerprint depends on map iteration order")
+		}
+	})
+
+	t.Run("a changed secret changes the fingerprint without appearing in it", func(t *testing.T) {
+		a := &Config{
+			Credentials: map[string]map[string]interface{}{
+				"example.com": {"token": "super-secret-one"},
+			},
+		}
+		b := &Config{
+			Credentials: map[string]map[string]interface{}{
+				"example.com": {"token": "super-secret-two"},
+			},
+		}
+
+		fpA, fpB := a.Fingerprint(), b.Fingerprint()
+		if fpA == fpB {
+			t.Errorf("fingerprint did not change when the credential did")
+		}
+		if strings.Contains(fpA, "super-secret-one") || strings.Contains(fpB, "super-secret-two") {
+			t.Errorf("secret value leaked into fingerprint")
+		}
+
+		for _, line := range a.fingerprintLines() {
+			if strings.Contains(line, "super-secret-one") {
+				t.Errorf("secret value leaked into a fingerprint input line: %s", line)
+			}
+		}
+	})
+
+	t.Run("nil Config has a stable fingerprint", func(t *testing.T) {
+		var a, b *Config
+		if a.Fingerprint() != b.Fingerprint() {
+			t.Errorf("fingerprints differ for two nil configs")
+		}
+	})
+}
@@ -0,0 +1,46 @@
+package cliconfig
+
+import (
+	"regexp"
+)
+
+// functionNamePattern matches the identifier syntax required for a
+// "function" block's label, which doubles as the name an expression calls
+// it by, so it has to be a syntactically valid function name rather than an
+// arbitrary string.
+var functionNamePattern = regexp.MustCompile(`\A[a-z][a-z0-9_]*\z`)
+
+// ConfigFunction is the structure of one labeled "function" block within
+// the CLI configuration, which registers a user-defined function -- backed
+// by a WebAssembly module -- for use in Terraform expressions under the
+// block's label.
+//
+//	function "double" {
+//	  wasm_file = "${path.module}/double.wasm"
+//	}
+//
+// Loading and executing the named module is the responsibility of whatever
+// builds a lang.Scope's function table from a Config, not of this package;
+// see lang/funcs/wasm for the current state of that extension point.
+type ConfigFunction struct {
+	// WASMFile is the path to the compiled WebAssembly module that
+	// implements this function. A relative path is resolved the same way
+	// as ProvisionerInstallation's paths: relative to the current working
+	// directory at the time the CLI config is loaded, not to the
+	// configuration file that declared it.
+	WASMFile string `hcl:"wasm_file"`
+}
+
+// UserFunctionWASMPaths returns the configured WASM module path for each
+// "function" block in c, keyed by function name. It returns nil if c is nil
+// or no such blocks are present.
+func (c *Config) UserFunctionWASMPaths() map[string]string {
+	if c == nil || len(c.Functions) == 0 {
+		return nil
+	}
+	paths := make(map[string]string, len(c.Functions))
+	for name, fn := range c.Functions {
+		paths[name] = fn.WASMFile
+	}
+	return paths
+}
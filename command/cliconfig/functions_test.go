@@ -0,0 +1,102 @@
+package cliconfig
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+)
+
+func TestLoadConfig_functions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "terraform-cliconfig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	src := `
+function "double" {
+  wasm_file = "./double.wasm"
+}
+`
+	if err := ioutil.WriteFile(filepath.Join(dir, "a.tfrc"), []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, diags := loadConfigDir(dir)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+
+	want := map[string]*ConfigFunction{
+		"double": {WASMFile: "./double.wasm"},
+	}
+	if !reflect.DeepEqual(got.Functions, want) {
+		t.Errorf("wrong result\ngot:  %swant: %s", spew.Sdump(got.Functions), spew.Sdump(want))
+	}
+
+	wantPaths := map[string]string{"double": "./double.wasm"}
+	if !reflect.DeepEqual(got.UserFunctionWASMPaths(), wantPaths) {
+		t.Errorf("wrong UserFunctionWASMPaths result: %#v", got.UserFunctionWASMPaths())
+	}
+}
+
+func TestConfigValidate_functions(t *testing.T) {
+	tests := map[string]struct {
+		c       *Config
+		wantErr bool
+	}{
+		"valid": {
+			&Config{Functions: map[string]*ConfigFunction{
+				"double": {WASMFile: "./double.wasm"},
+			}},
+			false,
+		},
+		"invalid name": {
+			&Config{Functions: map[string]*ConfigFunction{
+				"Double": {WASMFile: "./double.wasm"},
+			}},
+			true,
+		},
+		"missing wasm_file": {
+			&Config{Functions: map[string]*ConfigFunction{
+				"double": {},
+			}},
+			true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			diags := test.c.Validate()
+			if got := diags.HasErrors(); got != test.wantErr {
+				t.Errorf("wrong result\ngot:  %v\nwant: %v\ndiags: %s", got, test.wantErr, diags.Err())
+			}
+		})
+	}
+}
+
+func TestConfigMerge_functions(t *testing.T) {
+	c1 := &Config{Functions: map[string]*ConfigFunction{
+		"double": {WASMFile: "./double.wasm"},
+	}}
+	c2 := &Config{Functions: map[string]*ConfigFunction{
+		"triple": {WASMFile: "./triple.wasm"},
+	}}
+
+	got, diags := c1.Merge(c2)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+
+	want := map[string]*ConfigFunction{
+		"double": {WASMFile: "./double.wasm"},
+		"triple": {WASMFile: "./triple.wasm"},
+	}
+	if !reflect.DeepEqual(got.Functions, want) {
+		t.Errorf("wrong result\ngot:  %swant: %s", spew.Sdump(got.Functions), spew.Sdump(want))
+	}
+}
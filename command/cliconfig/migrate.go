@@ -0,0 +1,114 @@
+package cliconfig
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+// legacyConfigFileName returns the CLI configuration filename used by the
+// platform other than the one this binary was built for: ".terraformrc" on
+// Windows, or "terraform.rc" everywhere else. ConfigFile never returns this
+// name itself, but a file using it can end up in the same directory as the
+// canonical one -- for example, if a user's home directory is shared
+// between a Windows machine and a Unix-like one, as is common under WSL.
+func legacyConfigFileName() string {
+	if runtime.GOOS == "windows" {
+		return ".terraformrc"
+	}
+	return "terraform.rc"
+}
+
+// DetectLegacyConfigFile looks, alongside the canonical CLI configuration
+// file for the current platform (as returned by ConfigFile), for a file
+// using the other platform's filename convention in the same directory.
+//
+// The canonical file always takes precedence: LoadConfig never reads the
+// legacy one. A non-empty, found result here is purely informational, so
+// that a caller can warn the user that the legacy file's content, if any,
+// is being silently ignored.
+func DetectLegacyConfigFile() (legacyPath string, found bool, err error) {
+	canonicalPath, err := ConfigFile()
+	if err != nil {
+		return "", false, err
+	}
+
+	legacyPath = filepath.Join(filepath.Dir(canonicalPath), legacyConfigFileName())
+	if _, err := os.Stat(legacyPath); err != nil {
+		if os.IsNotExist(err) {
+			return legacyPath, false, nil
+		}
+		return legacyPath, false, err
+	}
+
+	return legacyPath, true, nil
+}
+
+// legacyConfigFileDiagnostics returns a warning diagnostic if a legacy CLI
+// configuration file is present alongside the canonical one, or no
+// diagnostics at all if there's nothing to warn about or the check itself
+// failed. It's intentionally lenient about errors here, since this is only
+// an advisory check and LoadConfig has more important things to report if
+// something is wrong with the filesystem.
+func legacyConfigFileDiagnostics() tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+
+	legacyPath, found, err := DetectLegacyConfigFile()
+	if err != nil || !found {
+		return diags
+	}
+
+	canonicalPath, err := ConfigFile()
+	if err != nil {
+		return diags
+	}
+
+	diags = diags.Append(tfdiags.SimpleWarning(fmt.Sprintf(
+		"Both %s and %s exist; only %s is in effect, so any settings in %s are being ignored. Use MigrateLegacyLocations to consolidate them into %s.",
+		canonicalPath, legacyPath, canonicalPath, legacyPath, canonicalPath,
+	)))
+
+	return diags
+}
+
+// MigrateLegacyLocations consolidates a CLI configuration file using the
+// other platform's filename convention (see DetectLegacyConfigFile) into
+// the canonical location for the current platform.
+//
+// If something already exists at the canonical location, it's backed up
+// first by renaming it to add a ".bak" suffix, and backupPath is returned
+// so the caller can report where it went; otherwise backupPath is empty.
+// MigrateLegacyLocations does nothing, returning ("", nil), if there's no
+// legacy file to migrate.
+func MigrateLegacyLocations() (backupPath string, err error) {
+	legacyPath, found, err := DetectLegacyConfigFile()
+	if err != nil {
+		return "", err
+	}
+	if !found {
+		return "", nil
+	}
+
+	canonicalPath, err := ConfigFile()
+	if err != nil {
+		return "", err
+	}
+
+	if _, statErr := os.Stat(canonicalPath); statErr == nil {
+		backupPath = canonicalPath + ".bak"
+		if err := replaceFileAtomic(canonicalPath, backupPath); err != nil {
+			return "", fmt.Errorf("failed to back up existing %s to %s: %s", canonicalPath, backupPath, err)
+		}
+	} else if !os.IsNotExist(statErr) {
+		return "", statErr
+	}
+
+	if err := replaceFileAtomic(legacyPath, canonicalPath); err != nil {
+		return "", fmt.Errorf("failed to move %s to %s: %s", legacyPath, canonicalPath, err)
+	}
+
+	return backupPath, nil
+}
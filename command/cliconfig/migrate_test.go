@@ -0,0 +1,145 @@
+package cliconfig
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// withTempHome points HOME (and APPDATA, for a Windows build) at a fresh
+// temporary directory for the duration of a test, so that ConfigFile and
+// DetectLegacyConfigFile operate on a throwaway location instead of the
+// real user's home directory.
+func withTempHome(t *testing.T) string {
+	t.Helper()
+
+	dir, err := ioutil.TempDir("", "terraform-cliconfig-migrate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	for _, name := range []string{"HOME", "APPDATA"} {
+		old, hadOld := os.LookupEnv(name)
+		os.Setenv(name, dir)
+		name, old, hadOld := name, old, hadOld
+		t.Cleanup(func() {
+			if hadOld {
+				os.Setenv(name, old)
+			} else {
+				os.Unsetenv(name)
+			}
+		})
+	}
+
+	return dir
+}
+
+func TestDetectLegacyConfigFile(t *testing.T) {
+	dir := withTempHome(t)
+
+	t.Run("neither file present", func(t *testing.T) {
+		_, found, err := DetectLegacyConfigFile()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if found {
+			t.Error("got found=true; want false")
+		}
+	})
+
+	t.Run("legacy file present", func(t *testing.T) {
+		legacyPath := filepath.Join(dir, legacyConfigFileName())
+		if err := ioutil.WriteFile(legacyPath, []byte("providers {}\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		defer os.Remove(legacyPath)
+
+		gotPath, found, err := DetectLegacyConfigFile()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !found {
+			t.Fatal("got found=false; want true")
+		}
+		if gotPath != legacyPath {
+			t.Errorf("wrong legacy path\ngot:  %s\nwant: %s", gotPath, legacyPath)
+		}
+	})
+}
+
+func TestMigrateLegacyLocations(t *testing.T) {
+	dir := withTempHome(t)
+
+	t.Run("nothing to migrate", func(t *testing.T) {
+		backupPath, err := MigrateLegacyLocations()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if backupPath != "" {
+			t.Errorf("got backup path %q; want none", backupPath)
+		}
+	})
+
+	canonicalPath, err := ConfigFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacyPath := filepath.Join(dir, legacyConfigFileName())
+
+	t.Run("migrates without an existing canonical file", func(t *testing.T) {
+		if err := ioutil.WriteFile(legacyPath, []byte("providers { foo = \"bar\" }\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		backupPath, err := MigrateLegacyLocations()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if backupPath != "" {
+			t.Errorf("got backup path %q; want none, since there was nothing to back up", backupPath)
+		}
+		if _, err := os.Stat(legacyPath); !os.IsNotExist(err) {
+			t.Errorf("legacy file %s still exists after migration", legacyPath)
+		}
+		got, err := ioutil.ReadFile(canonicalPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != "providers { foo = \"bar\" }\n" {
+			t.Errorf("canonical file has wrong content: %s", got)
+		}
+	})
+
+	t.Run("backs up an existing canonical file before migrating another", func(t *testing.T) {
+		if err := ioutil.WriteFile(legacyPath, []byte("providers { baz = \"qux\" }\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		backupPath, err := MigrateLegacyLocations()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if backupPath == "" {
+			t.Fatal("got no backup path; want one, since a canonical file already existed")
+		}
+		defer os.Remove(backupPath)
+
+		backedUp, err := ioutil.ReadFile(backupPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(backedUp) != "providers { foo = \"bar\" }\n" {
+			t.Errorf("backup has wrong content: %s", backedUp)
+		}
+
+		got, err := ioutil.ReadFile(canonicalPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != "providers { baz = \"qux\" }\n" {
+			t.Errorf("canonical file has wrong content after migration: %s", got)
+		}
+	})
+}
@@ -0,0 +1,128 @@
+package cliconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// orderedJSONObject is a JSON object that remembers the order in which its
+// keys were read, so that code which edits one key in an existing JSON
+// document and writes it back out doesn't disturb the order of every other
+// key. encoding/json can't do this on its own: decoding into a plain
+// map[string]interface{} and re-encoding it re-sorts the keys alphabetically,
+// which would turn a one-line surgical edit to a human-maintained file like
+// credentials.tfrc.json into a full reformat.
+type orderedJSONObject struct {
+	order  []string
+	values map[string]json.RawMessage
+}
+
+func newOrderedJSONObject() *orderedJSONObject {
+	return &orderedJSONObject{
+		values: make(map[string]json.RawMessage),
+	}
+}
+
+// decodeOrderedJSONObject parses src, which must be a JSON object, into an
+// orderedJSONObject that preserves the original key order and leaves each
+// value that isn't otherwise modified encoded exactly as it was decoded,
+// rather than as whatever encoding/json would produce for an equivalent Go
+// value.
+func decodeOrderedJSONObject(src []byte) (*orderedJSONObject, error) {
+	dec := json.NewDecoder(bytes.NewReader(src))
+	dec.UseNumber()
+
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return nil, fmt.Errorf("not a JSON object")
+	}
+
+	obj := newOrderedJSONObject()
+	for dec.More() {
+		keyTok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, ok := keyTok.(string)
+		if !ok {
+			// Should never happen: the JSON grammar requires object keys
+			// to be strings.
+			return nil, fmt.Errorf("non-string JSON object key")
+		}
+
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, err
+		}
+		obj.set(key, value)
+	}
+	if _, err := dec.Token(); err != nil { // consume the closing '}'
+		return nil, err
+	}
+
+	return obj, nil
+}
+
+// keys returns the object's keys in their current order. The result must be
+// treated as read-only and is invalidated by a subsequent call to set or
+// delete.
+func (o *orderedJSONObject) keys() []string {
+	return o.order
+}
+
+// get returns the raw JSON encoding of the value for the given key, and
+// whether it was present at all.
+func (o *orderedJSONObject) get(key string) (json.RawMessage, bool) {
+	v, ok := o.values[key]
+	return v, ok
+}
+
+// set assigns value to key, preserving its existing position if key is
+// already present and otherwise appending it to the end.
+func (o *orderedJSONObject) set(key string, value json.RawMessage) {
+	if _, exists := o.values[key]; !exists {
+		o.order = append(o.order, key)
+	}
+	o.values[key] = value
+}
+
+// delete removes key, if present.
+func (o *orderedJSONObject) delete(key string) {
+	if _, exists := o.values[key]; !exists {
+		return
+	}
+	delete(o.values, key)
+	for i, k := range o.order {
+		if k == key {
+			o.order = append(o.order[:i], o.order[i+1:]...)
+			break
+		}
+	}
+}
+
+// MarshalJSON implements json.Marshaler, producing an object whose keys
+// appear in the order recorded by set, with each value reproduced from
+// whatever raw encoding was stored for it -- either its original encoding
+// from decodeOrderedJSONObject, or whatever was passed to set.
+func (o *orderedJSONObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range o.order {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		keySrc, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(keySrc)
+		buf.WriteByte(':')
+		buf.Write(o.values[key])
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,78 @@
+package cliconfig
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestOrderedJSONObjectRoundTrip(t *testing.T) {
+	const src = `{"b": 1, "a": {"z": true, "y": false}, "c": [1, 2, 3]}`
+
+	obj, err := decodeOrderedJSONObject([]byte(src))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if got, want := obj.keys(), []string{"b", "a", "c"}; !stringSlicesEqual(got, want) {
+		t.Errorf("wrong key order\ngot:  %#v\nwant: %#v", got, want)
+	}
+
+	got, err := json.Marshal(obj)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if string(got) != `{"b":1,"a":{"z":true,"y":false},"c":[1,2,3]}` {
+		t.Errorf("wrong re-encoded object: %s", got)
+	}
+}
+
+func TestOrderedJSONObjectSetPreservesPosition(t *testing.T) {
+	obj, err := decodeOrderedJSONObject([]byte(`{"a": 1, "b": 2, "c": 3}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	obj.set("b", json.RawMessage(`"updated"`))
+	obj.set("d", json.RawMessage(`4`))
+
+	if got, want := obj.keys(), []string{"a", "b", "c", "d"}; !stringSlicesEqual(got, want) {
+		t.Errorf("wrong key order after set\ngot:  %#v\nwant: %#v", got, want)
+	}
+
+	got, err := json.Marshal(obj)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if string(got) != `{"a":1,"b":"updated","c":3,"d":4}` {
+		t.Errorf("wrong re-encoded object: %s", got)
+	}
+}
+
+func TestOrderedJSONObjectDelete(t *testing.T) {
+	obj, err := decodeOrderedJSONObject([]byte(`{"a": 1, "b": 2, "c": 3}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	obj.delete("b")
+	obj.delete("not-present") // should be a no-op, not a panic
+
+	if got, want := obj.keys(), []string{"a", "c"}; !stringSlicesEqual(got, want) {
+		t.Errorf("wrong key order after delete\ngot:  %#v\nwant: %#v", got, want)
+	}
+	if _, ok := obj.get("b"); ok {
+		t.Errorf("deleted key %q is still present", "b")
+	}
+}
+
+func stringSlicesEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
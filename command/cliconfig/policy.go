@@ -0,0 +1,103 @@
+package cliconfig
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+// PolicyCheck is the extension point for validating a merged Config against
+// caller-defined policy, on top of the structural validation Validate
+// performs. It exists so that an organization can enforce its own rules --
+// for example by evaluating an OPA/Rego policy, or reading a rules file of
+// its own design -- without this package needing to take on a dependency on
+// any particular policy engine.
+//
+// A PolicyCheck reports violations as diagnostics rather than a plain error,
+// consistent with the rest of this package, so that violations from several
+// checks can be collected into one combined set before a caller decides
+// whether to fail.
+type PolicyCheck func(*Config) tfdiags.Diagnostics
+
+// CheckPolicies runs each of the given checks against the receiver in turn
+// and returns their combined diagnostics. A nil receiver is valid and
+// produces no diagnostics, without calling any of the checks, the same way
+// Validate treats a nil receiver as trivially valid.
+func (c *Config) CheckPolicies(checks ...PolicyCheck) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+	if c == nil {
+		return diags
+	}
+	for _, check := range checks {
+		diags = diags.Append(check(c))
+	}
+	return diags
+}
+
+// DenyPlaintextTokens is a PolicyCheck that flags any "credentials" block
+// configuring a literal "token" value, rather than obtaining the token from
+// a "token_command" or a credentials helper. It's meant for environments
+// where a plaintext token sitting in a CLI config file -- as opposed to one
+// fetched on demand -- is itself considered a policy violation, regardless
+// of the file's permissions.
+func DenyPlaintextTokens(c *Config) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+	for host, creds := range c.Credentials {
+		if _, ok := creds["token_command"]; ok {
+			continue
+		}
+		if _, ok := creds["token"]; ok {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Plaintext token not allowed",
+				fmt.Sprintf("The \"credentials\" block for %q configures a plaintext token. Policy requires using \"token_command\" or a credentials helper instead.", host),
+			))
+		}
+	}
+	return diags
+}
+
+// RequireMirrorForHosts returns a PolicyCheck that requires each of the
+// given hostnames to have a "host" block configuring at least one service
+// mirror in its "services" attribute. It's meant for hostnames designated
+// as production registries, where routing installs through an
+// organization-controlled mirror is a compliance requirement rather than
+// just a performance optimization.
+func RequireMirrorForHosts(hostnames ...string) PolicyCheck {
+	return func(c *Config) tfdiags.Diagnostics {
+		var diags tfdiags.Diagnostics
+		for _, given := range hostnames {
+			host, hostDiags := NormalizeHostname(given)
+			if hostDiags.HasErrors() {
+				diags = diags.Append(fmt.Errorf("invalid hostname %q in policy check: %s", given, hostDiags.Err()))
+				continue
+			}
+			hostConfig, ok := c.Hosts[string(host)]
+			if !ok || len(hostConfig.Services) == 0 {
+				diags = diags.Append(tfdiags.Sourceless(
+					tfdiags.Error,
+					"Missing required mirror",
+					fmt.Sprintf("Policy requires a service mirror to be configured for %q, via a \"host\" block with a non-empty \"services\" attribute.", given),
+				))
+			}
+		}
+		return diags
+	}
+}
+
+// ForbidDevOverrides is a PolicyCheck that flags any configured provisioner
+// dev_overrides. It's meant to be included only conditionally -- for
+// example, only once the caller has independently determined that it's
+// running on a CI machine rather than a developer's own workstation, where
+// dev_overrides are a common and legitimate tool.
+func ForbidDevOverrides(c *Config) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+	if c.ProvisionerInstallation != nil && len(c.ProvisionerInstallation.DevOverrides) > 0 {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Provisioner dev_overrides not allowed",
+			"Policy forbids provisioner_installation dev_overrides in this environment.",
+		))
+	}
+	return diags
+}
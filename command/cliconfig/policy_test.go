@@ -0,0 +1,141 @@
+package cliconfig
+
+import (
+	"testing"
+)
+
+func TestConfigCheckPolicies(t *testing.T) {
+	t.Run("nil config", func(t *testing.T) {
+		var c *Config
+		if diags := c.CheckPolicies(DenyPlaintextTokens); diags.HasErrors() {
+			t.Errorf("unexpected diagnostics for nil config: %s", diags.Err())
+		}
+	})
+
+	t.Run("combines diagnostics from multiple checks", func(t *testing.T) {
+		c := &Config{
+			Credentials: map[string]map[string]interface{}{
+				"example.com": {"token": "plaintext"},
+			},
+			ProvisionerInstallation: &ProvisionerInstallation{
+				DevOverrides: map[string]string{
+					"mock": "/path/to/terraform-provisioner-mock",
+				},
+			},
+		}
+		diags := c.CheckPolicies(DenyPlaintextTokens, ForbidDevOverrides)
+		if len(diags) != 2 {
+			t.Fatalf("got %d diagnostics, want 2: %s", len(diags), diags.Err())
+		}
+	})
+}
+
+func TestDenyPlaintextTokens(t *testing.T) {
+	tests := map[string]struct {
+		Config    *Config
+		WantError bool
+	}{
+		"plaintext token": {
+			&Config{
+				Credentials: map[string]map[string]interface{}{
+					"example.com": {"token": "abc123"},
+				},
+			},
+			true,
+		},
+		"token_command is fine": {
+			&Config{
+				Credentials: map[string]map[string]interface{}{
+					"example.com": {"token_command": []interface{}{"sh", "-c", "echo hi"}},
+				},
+			},
+			false,
+		},
+		"no credentials at all": {
+			&Config{},
+			false,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			diags := test.Config.CheckPolicies(DenyPlaintextTokens)
+			if got := diags.HasErrors(); got != test.WantError {
+				t.Errorf("CheckPolicies(DenyPlaintextTokens) HasErrors() = %v; want %v\ndiags: %s", got, test.WantError, diags.Err())
+			}
+		})
+	}
+}
+
+func TestRequireMirrorForHosts(t *testing.T) {
+	tests := map[string]struct {
+		Config    *Config
+		WantError bool
+	}{
+		"missing host block": {
+			&Config{},
+			true,
+		},
+		"host block with no services": {
+			&Config{
+				Hosts: map[string]*ConfigHost{
+					"registry.example.com": {},
+				},
+			},
+			true,
+		},
+		"host block with a mirror configured": {
+			&Config{
+				Hosts: map[string]*ConfigHost{
+					"registry.example.com": {
+						Services: map[string]interface{}{
+							"modules.v1": "https://mirror.example.com/",
+						},
+					},
+				},
+			},
+			false,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			check := RequireMirrorForHosts("registry.example.com")
+			diags := test.Config.CheckPolicies(check)
+			if got := diags.HasErrors(); got != test.WantError {
+				t.Errorf("CheckPolicies(RequireMirrorForHosts(...)) HasErrors() = %v; want %v\ndiags: %s", got, test.WantError, diags.Err())
+			}
+		})
+	}
+}
+
+func TestForbidDevOverrides(t *testing.T) {
+	tests := map[string]struct {
+		Config    *Config
+		WantError bool
+	}{
+		"no provisioner_installation block": {
+			&Config{},
+			false,
+		},
+		"dev_overrides configured": {
+			&Config{
+				ProvisionerInstallation: &ProvisionerInstallation{
+					DevOverrides: map[string]string{
+						"mock": "/path/to/terraform-provisioner-mock",
+					},
+				},
+			},
+			true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			diags := test.Config.CheckPolicies(ForbidDevOverrides)
+			if got := diags.HasErrors(); got != test.WantError {
+				t.Errorf("CheckPolicies(ForbidDevOverrides) HasErrors() = %v; want %v\ndiags: %s", got, test.WantError, diags.Err())
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package cliconfig
+
+import (
+	"github.com/hashicorp/hcl/hcl/ast"
+)
+
+// blockPositions returns, for each top-level labeled block of the given
+// type (for example "host" or "credentials_helper") found in the given
+// parsed file, the source position of that block's label, keyed by the
+// label itself.
+//
+// hcl.DecodeObject discards position information once it has populated a
+// target struct, so this exists purely to let loadConfigFile capture it
+// separately, from the same *ast.File the decoder consumes, while it's
+// still available. The result is nil if obj isn't in the shape we expect
+// or has no blocks of the given type.
+func blockPositions(obj *ast.File, blockType string) map[string]string {
+	root, ok := obj.Node.(*ast.ObjectList)
+	if !ok {
+		return nil
+	}
+
+	items := root.Filter(blockType).Items
+	if len(items) == 0 {
+		return nil
+	}
+
+	positions := make(map[string]string, len(items))
+	for _, item := range items {
+		if len(item.Keys) == 0 {
+			continue
+		}
+		label, ok := item.Keys[0].Token.Value().(string)
+		if !ok {
+			continue
+		}
+		positions[label] = item.Pos().String()
+	}
+	return positions
+}
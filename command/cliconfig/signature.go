@@ -0,0 +1,60 @@
+package cliconfig
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"golang.org/x/crypto/openpgp"
+
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+// configFragmentSigSuffix is appended to a config fragment's own filename to
+// find its detached signature -- "10-registry.tfrc.sig" alongside
+// "10-registry.tfrc", for example.
+const configFragmentSigSuffix = ".sig"
+
+// parseTrustedKeys parses each of the given ASCII-armored OpenPGP public
+// keys into a single keyring, for use verifying signed config fragments in
+// loadConfigDirWithEnv. A key that fails to parse produces a diagnostic of
+// its own but doesn't prevent the rest of the keyring from being used.
+func parseTrustedKeys(armored []string) (openpgp.EntityList, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+	var keyring openpgp.EntityList
+	for i, armor := range armored {
+		el, err := openpgp.ReadArmoredKeyRing(bytes.NewReader([]byte(armor)))
+		if err != nil {
+			diags = diags.Append(fmt.Errorf("invalid entry %d in \"trusted_keys\": %s", i, err))
+			continue
+		}
+		keyring = append(keyring, el...)
+	}
+	return keyring, diags
+}
+
+// verifyConfigFragmentSignature checks that filePath has a valid detached
+// signature from one of the keys in keyring, in a sibling file named
+// filePath+configFragmentSigSuffix.
+func verifyConfigFragmentSignature(filePath string, keyring openpgp.EntityList) error {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+
+	sigPath := filePath + configFragmentSigSuffix
+	sig, err := ioutil.ReadFile(sigPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("no signature found at %s", sigPath)
+		}
+		return err
+	}
+
+	_, err = openpgp.CheckDetachedSignature(keyring, bytes.NewReader(data), bytes.NewReader(sig))
+	if err != nil {
+		return fmt.Errorf("signature at %s is not valid for any trusted key: %s", sigPath, err)
+	}
+	return nil
+}
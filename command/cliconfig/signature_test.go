@@ -0,0 +1,178 @@
+package cliconfig
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+)
+
+// newTestKeyPair generates a throwaway OpenPGP key pair for use in these
+// tests, returning its ASCII-armored public key alongside the entity itself
+// so a test can sign fragments with it.
+func newTestKeyPair(t *testing.T) (armored string, entity *openpgp.Entity) {
+	t.Helper()
+	entity, err := openpgp.NewEntity("test", "", "test@example.com", nil)
+	if err != nil {
+		t.Fatalf("failed to generate test key: %s", err)
+	}
+
+	var buf bytes.Buffer
+	w, err := armor.Encode(&buf, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatalf("failed to armor-encode test key: %s", err)
+	}
+	if err := entity.Serialize(w); err != nil {
+		t.Fatalf("failed to serialize test key: %s", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("failed to close armor writer: %s", err)
+	}
+	return buf.String(), entity
+}
+
+// signTestFragment writes a detached signature of data, from signer, to
+// path+".sig".
+func signTestFragment(t *testing.T, path string, data []byte, signer *openpgp.Entity) {
+	t.Helper()
+	f, err := os.Create(path + configFragmentSigSuffix)
+	if err != nil {
+		t.Fatalf("failed to create signature file: %s", err)
+	}
+	defer f.Close()
+	if err := openpgp.DetachSign(f, signer, bytes.NewReader(data), nil); err != nil {
+		t.Fatalf("failed to sign test fragment: %s", err)
+	}
+}
+
+func TestLoadConfigDirWithEnv_signedFragments(t *testing.T) {
+	armoredPub, signer := newTestKeyPair(t)
+	_, otherSigner := newTestKeyPair(t)
+
+	writeFragment := func(dir, name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write fragment: %s", err)
+		}
+		return path
+	}
+
+	t.Run("valid signature from a trusted key is accepted", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "terraform-cliconfig-signed-test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+
+		content := `plugin_cache_dir = "/tmp/plugins"` + "\n"
+		path := writeFragment(dir, "10-cache.tfrc", content)
+		signTestFragment(t, path, []byte(content), signer)
+
+		got, diags := loadConfigDirWithEnv(dir, newEnvCache(), []string{armoredPub})
+		if diags.HasErrors() {
+			t.Fatalf("unexpected diagnostics: %s", diags.Err())
+		}
+		if got.PluginCacheDir != "/tmp/plugins" {
+			t.Errorf("fragment wasn't loaded: got PluginCacheDir %q", got.PluginCacheDir)
+		}
+	})
+
+	t.Run("missing signature is rejected", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "terraform-cliconfig-signed-test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+
+		content := `plugin_cache_dir = "/tmp/plugins"` + "\n"
+		writeFragment(dir, "10-cache.tfrc", content)
+
+		got, diags := loadConfigDirWithEnv(dir, newEnvCache(), []string{armoredPub})
+		if !diags.HasErrors() {
+			t.Fatal("expected an error for the missing signature")
+		}
+		if got.PluginCacheDir != "" {
+			t.Errorf("unsigned fragment should not have been loaded, got PluginCacheDir %q", got.PluginCacheDir)
+		}
+	})
+
+	t.Run("signature from an untrusted key is rejected", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "terraform-cliconfig-signed-test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+
+		content := `plugin_cache_dir = "/tmp/plugins"` + "\n"
+		path := writeFragment(dir, "10-cache.tfrc", content)
+		signTestFragment(t, path, []byte(content), otherSigner)
+
+		got, diags := loadConfigDirWithEnv(dir, newEnvCache(), []string{armoredPub})
+		if !diags.HasErrors() {
+			t.Fatal("expected an error for the untrusted signer")
+		}
+		if got.PluginCacheDir != "" {
+			t.Errorf("fragment signed by an untrusted key should not have been loaded, got PluginCacheDir %q", got.PluginCacheDir)
+		}
+	})
+
+	t.Run("tampered content with an otherwise-valid signature is rejected", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "terraform-cliconfig-signed-test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+
+		content := `plugin_cache_dir = "/tmp/plugins"` + "\n"
+		path := writeFragment(dir, "10-cache.tfrc", content)
+		signTestFragment(t, path, []byte(content), signer)
+
+		tampered := `plugin_cache_dir = "/tmp/evil"` + "\n"
+		if err := ioutil.WriteFile(path, []byte(tampered), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		got, diags := loadConfigDirWithEnv(dir, newEnvCache(), []string{armoredPub})
+		if !diags.HasErrors() {
+			t.Fatal("expected an error for the tampered fragment")
+		}
+		if got.PluginCacheDir != "" {
+			t.Errorf("tampered fragment should not have been loaded, got PluginCacheDir %q", got.PluginCacheDir)
+		}
+	})
+
+	t.Run("no trusted keys preserves the old unsigned behavior", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "terraform-cliconfig-signed-test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+
+		content := `plugin_cache_dir = "/tmp/plugins"` + "\n"
+		writeFragment(dir, "10-cache.tfrc", content)
+
+		got, diags := loadConfigDirWithEnv(dir, newEnvCache(), nil)
+		if diags.HasErrors() {
+			t.Fatalf("unexpected diagnostics: %s", diags.Err())
+		}
+		if got.PluginCacheDir != "/tmp/plugins" {
+			t.Errorf("unsigned fragment should still be loaded when no keys are trusted, got PluginCacheDir %q", got.PluginCacheDir)
+		}
+	})
+}
+
+func TestParseTrustedKeys_invalidKey(t *testing.T) {
+	armoredPub, _ := newTestKeyPair(t)
+
+	keyring, diags := parseTrustedKeys([]string{"not a valid key", armoredPub})
+	if !diags.HasErrors() {
+		t.Fatal("expected a diagnostic for the invalid key")
+	}
+	if len(keyring) != 1 {
+		t.Fatalf("expected the valid key to still be parsed, got %d entities", len(keyring))
+	}
+}
@@ -0,0 +1,296 @@
+package cliconfig
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+// snapshotEnvVars lists the environment variables that influence CLI
+// configuration loading, so that Snapshot can capture their values and
+// LoadConfigFromSnapshot can reconstruct their effect later without
+// depending on the environment of whatever machine is doing the
+// reproducing.
+var snapshotEnvVars = []string{
+	"TF_CLI_CONFIG_FILE",
+	"TERRAFORM_CONFIG",
+	pluginCacheDirEnvVar,
+}
+
+// captureEnvInfluences returns the values of snapshotEnvVars as reported
+// by the given lookup function (ordinarily os.LookupEnv), omitting any
+// that are unset, for use as a Config's envInfluences.
+func captureEnvInfluences(lookup func(string) (string, bool)) map[string]string {
+	influences := make(map[string]string, len(snapshotEnvVars))
+	for _, name := range snapshotEnvVars {
+		if v, ok := lookup(name); ok {
+			influences[name] = v
+		}
+	}
+	return influences
+}
+
+// EnvInfluence describes a single environment variable that can affect
+// CLI configuration loading, along with whatever value it had when the
+// Config it's reported against was loaded.
+type EnvInfluence struct {
+	Name string
+
+	// Value is the variable's value, or "" if it was unset. Check Set to
+	// distinguish an unset variable from one explicitly set to "".
+	Value string
+	Set   bool
+}
+
+// EnvInfluences returns the environment variables that LoadConfig and
+// LoadConfigFromSnapshot consult (the same set Snapshot captures), along
+// with whichever value each one had when the receiver was loaded, so that
+// tooling such as an interactive "doctor" command can explain why a
+// setting has the value it does without the user needing to already know
+// which environment variables are relevant.
+//
+// A Config that wasn't produced by LoadConfig or LoadConfigFromSnapshot --
+// for example, one built directly in a test -- has no recorded load-time
+// environment, so EnvInfluences falls back to reporting the current
+// process environment in that case.
+func (c *Config) EnvInfluences() []EnvInfluence {
+	lookup := os.LookupEnv
+	if c != nil && c.envInfluences != nil {
+		lookup = func(name string) (string, bool) {
+			v, ok := c.envInfluences[name]
+			return v, ok
+		}
+	}
+
+	influences := make([]EnvInfluence, 0, len(snapshotEnvVars))
+	for _, name := range snapshotEnvVars {
+		value, set := lookup(name)
+		influences = append(influences, EnvInfluence{
+			Name:  name,
+			Value: value,
+			Set:   set,
+		})
+	}
+	return influences
+}
+
+const snapshotManifestName = "manifest.json"
+const snapshotFilePrefix = "files/"
+
+type snapshotManifest struct {
+	// Files lists, in load order, the base names of the CLI config files
+	// captured in the "files/" prefix of the archive.
+	Files []string `json:"files"`
+
+	// Env captures the values of snapshotEnvVars at the time the snapshot
+	// was taken. A variable that was unset is omitted.
+	Env map[string]string `json:"env"`
+}
+
+// credentialsLinePattern matches lines that are likely to carry a secret
+// value inside a "credentials" or "credentials_helper" block, such as
+// `token = "..."`, so that Snapshot can redact them on request. This is a
+// best-effort textual redaction rather than a full HCL-aware one, since the
+// legacy CLI config parser doesn't retain enough position information to
+// safely rewrite just the affected nested blocks.
+var credentialsLinePattern = regexp.MustCompile(`(?i)^(\s*\S*(?:token|password|secret|key)\S*\s*=\s*).+$`)
+
+// Snapshot writes to w a self-contained zip archive containing every file
+// that contributed to the receiving Config (as tracked by LoadConfig) along
+// with the values of the environment variables that affect CLI config
+// loading, so that the exact effective configuration can be reproduced
+// later -- such as when attaching it to a bug report or a CI debugging
+// artifact -- using LoadConfigFromSnapshot.
+//
+// If redactSecrets is true, lines that look like they assign a credential
+// value are blanked out before being written to the archive.
+//
+// Snapshot only has something to capture for a Config returned by
+// LoadConfig; a Config built or decoded by other means has no known
+// source files and produces an archive containing only the manifest.
+func (c *Config) Snapshot(w io.Writer, redactSecrets bool) error {
+	zw := zip.NewWriter(w)
+
+	manifest := snapshotManifest{
+		Env: captureEnvInfluences(os.LookupEnv),
+	}
+
+	for _, path := range c.sourceFiles {
+		src, err := ioutil.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s for snapshot: %s", path, err)
+		}
+		if redactSecrets {
+			src = redactCredentials(src)
+		}
+
+		name := filepath.Base(path)
+		fw, err := zw.Create(snapshotFilePrefix + name)
+		if err != nil {
+			return fmt.Errorf("failed to add %s to snapshot: %s", path, err)
+		}
+		if _, err := fw.Write(src); err != nil {
+			return fmt.Errorf("failed to add %s to snapshot: %s", path, err)
+		}
+		manifest.Files = append(manifest.Files, name)
+	}
+
+	manifestSrc, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to build snapshot manifest: %s", err)
+	}
+	mw, err := zw.Create(snapshotManifestName)
+	if err != nil {
+		return fmt.Errorf("failed to add snapshot manifest: %s", err)
+	}
+	if _, err := mw.Write(manifestSrc); err != nil {
+		return fmt.Errorf("failed to add snapshot manifest: %s", err)
+	}
+
+	return zw.Close()
+}
+
+// LoadConfigFromSnapshot reconstructs the Config that produced a snapshot
+// written by Config.Snapshot, by extracting its captured files into a
+// temporary directory and then loading and merging them exactly as
+// LoadConfig would, layering in the captured environment variable values
+// in place of the current process environment.
+//
+// The returned Config behaves like one from LoadConfig except that its
+// source files are temporary extracted copies, so a second call to
+// Snapshot against it will still produce an equivalent archive.
+func LoadConfigFromSnapshot(r io.ReaderAt, size int64) (*Config, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		diags = diags.Append(fmt.Errorf("invalid CLI config snapshot: %s", err))
+		return &Config{}, diags
+	}
+
+	var manifest snapshotManifest
+	filesByName := make(map[string]*zip.File)
+	for _, f := range zr.File {
+		switch {
+		case f.Name == snapshotManifestName:
+			rc, err := f.Open()
+			if err != nil {
+				diags = diags.Append(fmt.Errorf("failed to open snapshot manifest: %s", err))
+				return &Config{}, diags
+			}
+			manifestSrc, err := ioutil.ReadAll(rc)
+			rc.Close()
+			if err != nil {
+				diags = diags.Append(fmt.Errorf("failed to read snapshot manifest: %s", err))
+				return &Config{}, diags
+			}
+			if err := json.Unmarshal(manifestSrc, &manifest); err != nil {
+				diags = diags.Append(fmt.Errorf("invalid snapshot manifest: %s", err))
+				return &Config{}, diags
+			}
+		default:
+			filesByName[f.Name] = f
+		}
+	}
+
+	tmpDir, err := ioutil.TempDir("", "terraform-cliconfig-snapshot")
+	if err != nil {
+		diags = diags.Append(fmt.Errorf("failed to create temporary directory for snapshot: %s", err))
+		return &Config{}, diags
+	}
+
+	config := &Config{}
+	sort.Strings(manifest.Files)
+	for _, name := range manifest.Files {
+		f, ok := filesByName[snapshotFilePrefix+name]
+		if !ok {
+			diags = diags.Append(fmt.Errorf("snapshot manifest refers to missing file %q", name))
+			continue
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			diags = diags.Append(fmt.Errorf("failed to open %s from snapshot: %s", name, err))
+			continue
+		}
+		src, err := ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			diags = diags.Append(fmt.Errorf("failed to read %s from snapshot: %s", name, err))
+			continue
+		}
+
+		extractedPath := filepath.Join(tmpDir, name)
+		if err := ioutil.WriteFile(extractedPath, src, 0600); err != nil {
+			diags = diags.Append(fmt.Errorf("failed to extract %s from snapshot: %s", name, err))
+			continue
+		}
+
+		fileConfig, fileDiags := loadConfigFile(extractedPath)
+		diags = diags.Append(fileDiags)
+		fileConfig.sourceFiles = []string{extractedPath}
+		var mergeDiags tfdiags.Diagnostics
+		config, mergeDiags = config.Merge(fileConfig)
+		diags = diags.Append(mergeDiags)
+	}
+
+	envCfg := envConfig(func(name string) string { return manifest.Env[name] })
+	var mergeDiags tfdiags.Diagnostics
+	config, mergeDiags = envCfg.Merge(config)
+	diags = diags.Append(mergeDiags)
+
+	config.envInfluences = manifest.Env
+
+	diags = diags.Append(config.Validate())
+
+	return config, diags
+}
+
+// redactCredentials returns a copy of src with the value of any line that
+// looks like it assigns a token, password, secret or key blanked out.
+func redactCredentials(src []byte) []byte {
+	lines := splitLinesPreservingTerminators(src)
+	for i, line := range lines {
+		trimmed, terminator := trimLineTerminator(line)
+		if credentialsLinePattern.Match(trimmed) {
+			lines[i] = append(credentialsLinePattern.ReplaceAll(trimmed, []byte("$1\"(redacted)\"")), terminator...)
+		}
+	}
+
+	var out []byte
+	for _, line := range lines {
+		out = append(out, line...)
+	}
+	return out
+}
+
+func splitLinesPreservingTerminators(src []byte) [][]byte {
+	var lines [][]byte
+	start := 0
+	for i, b := range src {
+		if b == '\n' {
+			lines = append(lines, src[start:i+1])
+			start = i + 1
+		}
+	}
+	if start < len(src) {
+		lines = append(lines, src[start:])
+	}
+	return lines
+}
+
+func trimLineTerminator(line []byte) (trimmed, terminator []byte) {
+	end := len(line)
+	for end > 0 && (line[end-1] == '\n' || line[end-1] == '\r') {
+		end--
+	}
+	return line[:end], line[end:]
+}
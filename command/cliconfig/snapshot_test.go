@@ -0,0 +1,105 @@
+package cliconfig
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConfigSnapshotRoundTrip(t *testing.T) {
+	c, diags := loadConfigFile(filepath.Join(fixtureDir, "credentials"))
+	if len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics: %s", diags.Err())
+	}
+	c.sourceFiles = []string{filepath.Join(fixtureDir, "credentials")}
+
+	var buf bytes.Buffer
+	if err := c.Snapshot(&buf, false); err != nil {
+		t.Fatalf("Snapshot failed: %s", err)
+	}
+
+	got, diags := LoadConfigFromSnapshot(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics: %s", diags.Err())
+	}
+
+	if got.Credentials["example.com"]["token"] != "foo the bar baz" {
+		t.Errorf("wrong restored token: %#v", got.Credentials["example.com"])
+	}
+	if len(got.CredentialsHelpers) != 1 {
+		t.Errorf("expected one credentials_helper to survive the round trip, got %#v", got.CredentialsHelpers)
+	}
+}
+
+func TestConfigSnapshotRedaction(t *testing.T) {
+	c, diags := loadConfigFile(filepath.Join(fixtureDir, "credentials"))
+	if len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics: %s", diags.Err())
+	}
+	c.sourceFiles = []string{filepath.Join(fixtureDir, "credentials")}
+
+	var buf bytes.Buffer
+	if err := c.Snapshot(&buf, true); err != nil {
+		t.Fatalf("Snapshot failed: %s", err)
+	}
+
+	if strings.Contains(buf.String(), "foo the bar baz") {
+		t.Errorf("expected the token value to be redacted from the snapshot archive")
+	}
+
+	got, diags := LoadConfigFromSnapshot(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics: %s", diags.Err())
+	}
+	if got.Credentials["example.com"]["token"] == "foo the bar baz" {
+		t.Errorf("expected the restored token to be redacted, not the original value")
+	}
+}
+
+func TestConfigEnvInfluences(t *testing.T) {
+	defer os.Unsetenv(pluginCacheDirEnvVar)
+	os.Setenv(pluginCacheDirEnvVar, "/tmp/plugin-cache")
+
+	t.Run("falls back to the live environment", func(t *testing.T) {
+		var c *Config
+		influences := c.EnvInfluences()
+
+		var found bool
+		for _, inf := range influences {
+			if inf.Name != pluginCacheDirEnvVar {
+				continue
+			}
+			found = true
+			if !inf.Set || inf.Value != "/tmp/plugin-cache" {
+				t.Errorf("wrong influence for %s: %#v", pluginCacheDirEnvVar, inf)
+			}
+		}
+		if !found {
+			t.Errorf("%s not present in EnvInfluences result", pluginCacheDirEnvVar)
+		}
+	})
+
+	t.Run("reports a snapshot's captured environment instead of the live one", func(t *testing.T) {
+		c := &Config{
+			envInfluences: map[string]string{
+				pluginCacheDirEnvVar: "/snapshot/plugin-cache",
+			},
+		}
+		influences := c.EnvInfluences()
+
+		for _, inf := range influences {
+			switch inf.Name {
+			case pluginCacheDirEnvVar:
+				if !inf.Set || inf.Value != "/snapshot/plugin-cache" {
+					t.Errorf("wrong influence for %s: %#v", pluginCacheDirEnvVar, inf)
+				}
+			case "TF_CLI_CONFIG_FILE", "TERRAFORM_CONFIG":
+				if inf.Set {
+					t.Errorf("expected %s to be unset in the captured environment, got %#v", inf.Name, inf)
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,105 @@
+// Package template renders a CLI configuration file from a shared template
+// plus a map of variables, and validates the result the same way
+// cliconfig.LoadConfigFile would before a caller ever writes it out or
+// hands it to Terraform.
+//
+// It exists to support fleet rollout tooling: an MDM or other
+// config-management pipeline that generates a per-host .terraformrc file
+// from one template, substituting details like the machine's name, team,
+// or region. The template itself uses the CLI config's own "${var.NAME}"
+// substitution syntax -- the same one a "vars" block expands within a
+// single CLI config file -- so Render's output is ordinary CLI config text
+// with no foreign templating syntax of its own to learn.
+package template
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform/command/cliconfig"
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+// varNamePattern matches the identifier syntax a "${var.NAME}" reference
+// requires, mirroring the pattern varRefPattern accepts in the cliconfig
+// package itself.
+var varNamePattern = regexp.MustCompile(`\A[A-Za-z0-9_-]+\z`)
+
+// Render substitutes each "${var.NAME}" reference in tmpl using vars, then
+// validates the result as a standalone CLI configuration file, returning
+// the rendered text along with any diagnostics produced by either step. If
+// validation fails, the returned text is empty, so that a caller can treat
+// a non-empty result as safe to write out.
+//
+// tmpl should not declare its own "vars" block; Render synthesizes one from
+// vars, so that the template's author doesn't need to keep an embedded vars
+// block in sync with whatever variables the calling pipeline supplies.
+func Render(tmpl string, vars map[string]string) (string, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+
+	varsBlock, varsDiags := synthesizeVarsBlock(vars)
+	diags = diags.Append(varsDiags)
+	if diags.HasErrors() {
+		return "", diags
+	}
+
+	rendered := varsBlock + "\n" + tmpl
+
+	f, err := ioutil.TempFile("", "terraform-cliconfig-template-*.tfrc")
+	if err != nil {
+		diags = diags.Append(fmt.Errorf("cannot create temporary file to validate rendered config: %s", err))
+		return "", diags
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.WriteString(rendered); err != nil {
+		f.Close()
+		diags = diags.Append(fmt.Errorf("cannot write temporary file to validate rendered config: %s", err))
+		return "", diags
+	}
+	if err := f.Close(); err != nil {
+		diags = diags.Append(fmt.Errorf("cannot write temporary file to validate rendered config: %s", err))
+		return "", diags
+	}
+
+	config, loadDiags := cliconfig.LoadConfigFile(f.Name())
+	diags = diags.Append(loadDiags)
+	diags = diags.Append(config.Validate())
+	if diags.HasErrors() {
+		return "", diags
+	}
+	return rendered, diags
+}
+
+// synthesizeVarsBlock renders vars as a CLI config "vars" block, with its
+// entries in a deterministic order so that Render's output doesn't vary
+// from one call to the next given the same input.
+func synthesizeVarsBlock(vars map[string]string) (string, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		if !varNamePattern.MatchString(name) {
+			diags = diags.Append(fmt.Errorf("invalid variable name %q: names may contain only letters, digits, dashes, and underscores", name))
+			continue
+		}
+		names = append(names, name)
+	}
+	if diags.HasErrors() {
+		return "", diags
+	}
+	sort.Strings(names)
+
+	var buf strings.Builder
+	buf.WriteString("vars {\n")
+	for _, name := range names {
+		fmt.Fprintf(&buf, "  %s = %s\n", name, strconv.Quote(vars[name]))
+	}
+	buf.WriteString("}\n")
+	return buf.String(), diags
+}
@@ -0,0 +1,76 @@
+package template
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	tmpl := `
+credentials "registry.example.com" {
+  token = "${var.team}-${var.machine_name}"
+}
+`
+	got, diags := Render(tmpl, map[string]string{
+		"machine_name": "host01",
+		"team":         "sre",
+	})
+	if diags.HasErrors() {
+		t.Fatalf("unexpected diagnostics: %s", diags.Err())
+	}
+
+	wantVarsBlock := "vars {\n  machine_name = \"host01\"\n  team = \"sre\"\n}\n"
+	if !strings.HasPrefix(got, wantVarsBlock) {
+		t.Errorf("rendered output doesn't start with the expected vars block\ngot:\n%s", got)
+	}
+	if !strings.Contains(got, `token = "${var.team}-${var.machine_name}"`) {
+		t.Errorf("rendered output is missing the template body\ngot:\n%s", got)
+	}
+}
+
+func TestRender_deterministicOrder(t *testing.T) {
+	vars := map[string]string{"region": "us-east-1", "team": "sre", "machine_name": "host01"}
+
+	first, diags := Render("", vars)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected diagnostics: %s", diags.Err())
+	}
+	for i := 0; i < 5; i++ {
+		got, diags := Render("", vars)
+		if diags.HasErrors() {
+			t.Fatalf("unexpected diagnostics: %s", diags.Err())
+		}
+		if got != first {
+			t.Fatalf("rendered output varied between calls\nfirst: %q\ngot:   %q", first, got)
+		}
+	}
+}
+
+func TestRender_invalidHostname(t *testing.T) {
+	tmpl := `
+credentials "example..com" {
+  token = "${var.team}"
+}
+`
+	got, diags := Render(tmpl, map[string]string{"team": "sre"})
+	if !diags.HasErrors() {
+		t.Fatal("expected an error for the invalid hostname")
+	}
+	if got != "" {
+		t.Errorf("expected no output when validation fails, got %q", got)
+	}
+}
+
+func TestRender_invalidVariableName(t *testing.T) {
+	_, diags := Render("", map[string]string{"not a valid name": "x"})
+	if !diags.HasErrors() {
+		t.Fatal("expected an error for the invalid variable name")
+	}
+}
+
+func TestRender_invalidSyntax(t *testing.T) {
+	_, diags := Render("this is not valid HCL {{{", nil)
+	if !diags.HasErrors() {
+		t.Fatal("expected an error for invalid template syntax")
+	}
+}
@@ -0,0 +1,42 @@
+package cliconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+)
+
+// TLSConfig returns a *tls.Config to use for outbound HTTPS connections to
+// plugin and registry hosts, built from CABundlePath if one was set.
+//
+// It returns a nil *tls.Config, with no error, when CABundlePath is unset;
+// callers should treat that as "use whatever default TLS configuration you
+// would otherwise use" rather than as a config with no trusted roots at
+// all. A non-nil error means CABundlePath was set but couldn't be turned
+// into a usable certificate pool, in which case the returned *tls.Config is
+// always nil.
+func (c *Config) TLSConfig() (*tls.Config, error) {
+	if c == nil || c.CABundlePath == "" {
+		return nil, nil
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		// Not every platform can give us the system pool; falling back to
+		// an empty one means the custom bundle is still used; it's just
+		// not also extending the system's own trusted roots.
+		pool = x509.NewCertPool()
+	}
+
+	pem, err := ioutil.ReadFile(c.CABundlePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ca_bundle_path %q: %s", c.CABundlePath, err)
+	}
+
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("ca_bundle_path %q does not contain any usable PEM-encoded certificates", c.CABundlePath)
+	}
+
+	return &tls.Config{RootCAs: pool}, nil
+}
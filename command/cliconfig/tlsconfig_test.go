@@ -0,0 +1,118 @@
+package cliconfig
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testCACert is an arbitrary self-signed certificate, good enough to
+// exercise AppendCertsFromPEM without needing a real certificate authority.
+const testCACert = `-----BEGIN CERTIFICATE-----
+MIIBeDCCAR+gAwIBAgIUWLRIz/FvY+HiavrVg7Hyv7xTwfMwCgYIKoZIzj0EAwIw
+EjEQMA4GA1UECgwHQWNtZSBDbzAeFw0yNjA4MDkwMTUwNThaFw0zNjA4MDYwMTUw
+NThaMBIxEDAOBgNVBAoMB0FjbWUgQ28wWTATBgcqhkjOPQIBBggqhkjOPQMBBwNC
+AAQFoI57R7yndmA5Kr8xVuxvp1ZAnSjjoJxptLmBe1uy+GiTSmo5cNARE33tKKmS
+BPqhWexjuxLpRjOxEkNrI0Rao1MwUTAdBgNVHQ4EFgQUoRi2h55Lpx4kGS+kBI4I
+1hszQ7YwHwYDVR0jBBgwFoAUoRi2h55Lpx4kGS+kBI4I1hszQ7YwDwYDVR0TAQH/
+BAUwAwEB/zAKBggqhkjOPQQDAgNHADBEAiAIXm61SEy2MoByp+2OQPSRXiuSsZgP
+syqFau+hE2YbmgIgM58ffAT0rAb8DehTj3soe2iHX5yN2FT5JMv6j1CpbGQ=
+-----END CERTIFICATE-----
+`
+
+func writeTestCABundle(t *testing.T, contents string) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "terraform-cliconfig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, "ca-bundle.pem")
+	if err := ioutil.WriteFile(path, []byte(contents), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestConfig_TLSConfig_unset(t *testing.T) {
+	c := &Config{}
+	tlsConfig, err := c.TLSConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if tlsConfig != nil {
+		t.Errorf("expected a nil *tls.Config when ca_bundle_path is unset, got %#v", tlsConfig)
+	}
+}
+
+func TestConfig_TLSConfig_valid(t *testing.T) {
+	path := writeTestCABundle(t, testCACert)
+
+	c := &Config{CABundlePath: path}
+	tlsConfig, err := c.TLSConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if tlsConfig == nil || tlsConfig.RootCAs == nil {
+		t.Fatal("expected a non-nil *tls.Config with a non-nil RootCAs")
+	}
+}
+
+func TestConfig_TLSConfig_missingFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "terraform-cliconfig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	c := &Config{CABundlePath: filepath.Join(dir, "does-not-exist.pem")}
+	if _, err := c.TLSConfig(); err == nil {
+		t.Fatal("expected an error for a missing ca_bundle_path file")
+	}
+}
+
+func TestConfig_TLSConfig_invalidPEM(t *testing.T) {
+	path := writeTestCABundle(t, "not a certificate")
+
+	c := &Config{CABundlePath: path}
+	if _, err := c.TLSConfig(); err == nil {
+		t.Fatal("expected an error for a ca_bundle_path with no usable certificates")
+	}
+}
+
+func TestConfigValidate_caBundlePath(t *testing.T) {
+	dir, err := ioutil.TempDir("", "terraform-cliconfig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	c := &Config{CABundlePath: filepath.Join(dir, "does-not-exist.pem")}
+	if diags := c.Validate(); !diags.HasErrors() {
+		t.Fatal("expected an error for an unreadable ca_bundle_path")
+	}
+}
+
+func TestConfig_Merge_caBundlePath(t *testing.T) {
+	c1 := &Config{CABundlePath: "from-c1.pem"}
+	c2 := &Config{CABundlePath: "from-c2.pem"}
+
+	merged, diags := c1.Merge(c2)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+	if merged.CABundlePath != "from-c1.pem" {
+		t.Errorf("wrong CABundlePath: got %v, want from-c1.pem (c1 should win when both are set)", merged.CABundlePath)
+	}
+
+	c3 := &Config{}
+	merged, diags = c3.Merge(c2)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+	if merged.CABundlePath != "from-c2.pem" {
+		t.Errorf("wrong CABundlePath: got %v, want from-c2.pem (falls back to c2 when c1 is unset)", merged.CABundlePath)
+	}
+}
@@ -0,0 +1,100 @@
+package cliconfig
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/hcl/ast"
+
+	"github.com/hashicorp/terraform/helper/didyoumean"
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+// knownTopLevelAttributes lists every top-level block type and scalar
+// attribute name this package understands in a CLI config file, used by
+// warnUnknownTopLevelAttributes to tell a likely typo of one of these from
+// a setting introduced by some future Terraform release that a file might
+// also be shared with.
+var knownTopLevelAttributes = []string{
+	"providers",
+	"provisioners",
+	"vars",
+	"disable_checkpoint",
+	"disable_checkpoint_signature",
+	"plugin_cache_dir",
+	"plugin_cache_strict_checksum",
+	"rate_limit",
+	"host",
+	"credentials",
+	"credentials_helper",
+	"diagnostics",
+	"registry",
+	"audit",
+	"provisioner_installation",
+	"function",
+	"trusted_keys",
+	"external_programs",
+	"required_cli_version",
+	"disable_plugin_tls_verify",
+	"ca_bundle_path",
+	"features",
+}
+
+// warnUnknownTopLevelAttributes scans obj for top-level items whose name
+// isn't in knownTopLevelAttributes and, for any that's close enough to one
+// of them to likely be a typo, returns a warning suggesting what was
+// probably meant.
+//
+// This runs on every load, on the theory that "did you mean
+// plugin_cache_dir?" is valuable enough to an author who mistyped
+// "plugin_cahce_dir" that it's worth the (small) risk of a false positive
+// against a genuinely new setting from a future Terraform release that
+// happens to resemble one of ours closely enough to trip the same
+// heuristic; an attribute name that isn't a close match to anything here is
+// left alone rather than flagged at all, since that's the common case for a
+// setting this release simply doesn't know about yet.
+func warnUnknownTopLevelAttributes(path string, obj *ast.File) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+
+	root, ok := obj.Node.(*ast.ObjectList)
+	if !ok {
+		return diags
+	}
+
+	seen := make(map[string]bool)
+	for _, item := range root.Items {
+		if len(item.Keys) == 0 {
+			continue
+		}
+		name, ok := item.Keys[0].Token.Value().(string)
+		if !ok || seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		known := false
+		for _, candidate := range knownTopLevelAttributes {
+			if name == candidate {
+				known = true
+				break
+			}
+		}
+		if known {
+			continue
+		}
+
+		suggestion := didyoumean.NameSuggestion(name, knownTopLevelAttributes)
+		if suggestion == "" {
+			continue
+		}
+
+		diags = diags.Append(tfdiags.WithCode(
+			tfdiags.SimpleWarning(fmt.Sprintf(
+				"%s has an unrecognized top-level attribute %q. Did you mean %q?",
+				path, name, suggestion,
+			)),
+			"CLICONFIG_W008",
+		))
+	}
+
+	return diags
+}
@@ -0,0 +1,95 @@
+package cliconfig
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// varRefPattern matches a "${var.NAME}" reference to an entry in a CLI
+// config's "vars" block.
+var varRefPattern = regexp.MustCompile(`\$\{var\.([A-Za-z0-9_-]+)\}`)
+
+// varCycleError is returned by resolveVars when a var directly or
+// indirectly refers to itself.
+type varCycleError struct {
+	chain []string
+}
+
+func (e *varCycleError) Error() string {
+	return fmt.Sprintf("cycle in vars block: %s", strings.Join(e.chain, " -> "))
+}
+
+// resolveVars expands any "${var.NAME}" references found within the values
+// of a "vars" block against the block's own other entries, producing a new
+// map where every value is fully resolved.
+//
+// A var that directly or indirectly refers to itself is an error. A
+// reference to a name that isn't defined in raw is left as literal text,
+// since that's more often a reference to something defined outside of the
+// vars block (for example an environment variable expanded afterwards)
+// than something this function should fail outright over.
+func resolveVars(raw map[string]string) (map[string]string, error) {
+	resolved := make(map[string]string, len(raw))
+	for name := range raw {
+		val, err := resolveVar(name, raw, resolved, nil)
+		if err != nil {
+			return nil, err
+		}
+		resolved[name] = val
+	}
+	return resolved, nil
+}
+
+func resolveVar(name string, raw, resolved map[string]string, active []string) (string, error) {
+	if val, ok := resolved[name]; ok {
+		return val, nil
+	}
+	for _, a := range active {
+		if a == name {
+			return "", &varCycleError{chain: append(append([]string(nil), active...), name)}
+		}
+	}
+
+	active = append(append([]string(nil), active...), name)
+
+	var cycleErr error
+	expanded := varRefPattern.ReplaceAllStringFunc(raw[name], func(match string) string {
+		refName := varRefPattern.FindStringSubmatch(match)[1]
+		if _, ok := raw[refName]; !ok {
+			// Not a var, so leave it as literal text for something else
+			// (or nothing) to make sense of later.
+			return match
+		}
+		resolvedVal, err := resolveVar(refName, raw, resolved, active)
+		if err != nil {
+			if ce, ok := err.(*varCycleError); ok && cycleErr == nil {
+				cycleErr = ce
+			}
+			return match
+		}
+		return resolvedVal
+	})
+	if cycleErr != nil {
+		return "", cycleErr
+	}
+
+	resolved[name] = expanded
+	return expanded, nil
+}
+
+// expandConfigVars replaces each "${var.NAME}" reference in s using the
+// given fully-resolved vars map, leaving references to undefined names as
+// literal text.
+func expandConfigVars(s string, vars map[string]string) string {
+	if len(vars) == 0 || !strings.Contains(s, "${var.") {
+		return s
+	}
+	return varRefPattern.ReplaceAllStringFunc(s, func(match string) string {
+		name := varRefPattern.FindStringSubmatch(match)[1]
+		if val, ok := vars[name]; ok {
+			return val
+		}
+		return match
+	})
+}
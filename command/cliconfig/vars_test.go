@@ -0,0 +1,87 @@
+package cliconfig
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestResolveVars(t *testing.T) {
+	t.Run("simple chain", func(t *testing.T) {
+		raw := map[string]string{
+			"org":      "example-corp",
+			"hostname": "${var.org}.example.com",
+		}
+		got, err := resolveVars(raw)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if want := "example-corp.example.com"; got["hostname"] != want {
+			t.Errorf("wrong hostname\ngot:  %s\nwant: %s", got["hostname"], want)
+		}
+	})
+
+	t.Run("undefined reference is left literal", func(t *testing.T) {
+		raw := map[string]string{
+			"hostname": "${var.undefined}.example.com",
+		}
+		got, err := resolveVars(raw)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if want := "${var.undefined}.example.com"; got["hostname"] != want {
+			t.Errorf("wrong hostname\ngot:  %s\nwant: %s", got["hostname"], want)
+		}
+	})
+
+	t.Run("direct cycle", func(t *testing.T) {
+		raw := map[string]string{
+			"a": "${var.a}",
+		}
+		_, err := resolveVars(raw)
+		if err == nil {
+			t.Fatal("succeeded; want error")
+		}
+		if !strings.Contains(err.Error(), "cycle in vars block") {
+			t.Fatalf("wrong error: %s", err)
+		}
+	})
+
+	t.Run("indirect cycle", func(t *testing.T) {
+		raw := map[string]string{
+			"a": "${var.b}",
+			"b": "${var.a}",
+		}
+		_, err := resolveVars(raw)
+		if err == nil {
+			t.Fatal("succeeded; want error")
+		}
+		if !strings.Contains(err.Error(), "cycle in vars block") {
+			t.Fatalf("wrong error: %s", err)
+		}
+	})
+}
+
+func TestExpandConfigVars(t *testing.T) {
+	vars := map[string]string{
+		"org": "example-corp",
+	}
+
+	tests := []struct {
+		Input string
+		Want  string
+	}{
+		{"${var.org}", "example-corp"},
+		{"https://${var.org}.example.com/", "https://example-corp.example.com/"},
+		{"${var.undefined}", "${var.undefined}"},
+		{"no vars here", "no vars here"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Input, func(t *testing.T) {
+			got := expandConfigVars(test.Input, vars)
+			if got != test.Want {
+				t.Errorf("wrong result\ngot:  %s\nwant: %s", got, test.Want)
+			}
+		})
+	}
+}
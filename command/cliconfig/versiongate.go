@@ -0,0 +1,122 @@
+package cliconfig
+
+import (
+	"fmt"
+
+	goversion "github.com/hashicorp/go-version"
+	"github.com/hashicorp/terraform/tfdiags"
+	tfversion "github.com/hashicorp/terraform/version"
+)
+
+// checkRequiredCLIVersion checks a file's top-level "required_cli_version"
+// constraint, if any, against the running CLI's own version.
+//
+// A file whose constraint isn't met is one a newer Terraform wrote for a
+// newer Terraform to read; the caller should treat it as though it were
+// empty and use only the warning this returns, rather than risk
+// misinterpreting whatever else it contains. This lets an operator roll a
+// CLI config file out ahead of a Terraform upgrade without breaking every
+// older installation that reads it in the meantime.
+func checkRequiredCLIVersion(path, constraintStr string) (bool, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+	if constraintStr == "" {
+		return true, diags
+	}
+
+	constraints, err := goversion.NewConstraint(constraintStr)
+	if err != nil {
+		diags = diags.Append(fmt.Errorf("Error in %s: invalid required_cli_version constraint %q: %s", path, constraintStr, err))
+		return true, diags
+	}
+
+	if constraints.Check(tfversion.SemVer) {
+		return true, diags
+	}
+
+	diags = diags.Append(tfdiags.WithCode(
+		tfdiags.SimpleWarning(fmt.Sprintf(
+			"Ignoring %s, which requires Terraform version %s, but this is Terraform %s",
+			path, constraintStr, tfversion.String(),
+		)),
+		"CLICONFIG_W005",
+	))
+	return false, diags
+}
+
+// checkMinVersion checks a single block's "min_version" value, if any,
+// against the running CLI's own version, returning false if the block
+// should be skipped along with a warning describing why.
+//
+// Unlike required_cli_version, min_version is a plain version rather than
+// a constraint: a block declares the earliest version that understands it,
+// not a range, since there's no reason a block would stop being understood
+// by a later release.
+func checkMinVersion(path, description, minVersion string) (bool, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+	if minVersion == "" {
+		return true, diags
+	}
+
+	required, err := goversion.NewVersion(minVersion)
+	if err != nil {
+		diags = diags.Append(fmt.Errorf("Error in %s: invalid min_version %q on %s: %s", path, minVersion, description, err))
+		return true, diags
+	}
+
+	if !tfversion.SemVer.LessThan(required) {
+		return true, diags
+	}
+
+	diags = diags.Append(tfdiags.WithCode(
+		tfdiags.SimpleWarning(fmt.Sprintf(
+			"Ignoring %s in %s, which requires Terraform version %s, but this is Terraform %s",
+			description, path, minVersion, tfversion.String(),
+		)),
+		"CLICONFIG_W006",
+	))
+	return false, diags
+}
+
+// applyVersionGates drops any block in result whose min_version the running
+// CLI doesn't satisfy, appending a warning for each one it drops. It must
+// run before result.Hosts and result.CredentialsHelpers are normalized or
+// otherwise acted on, since a skipped block's content is never meant to be
+// interpreted at all.
+func applyVersionGates(path string, result *Config) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+
+	for name, hostConfig := range result.Hosts {
+		ok, gateDiags := checkMinVersion(path, fmt.Sprintf("host %q", name), hostConfig.MinVersion)
+		diags = diags.Append(gateDiags)
+		if !ok {
+			delete(result.Hosts, name)
+		}
+	}
+
+	for name, helper := range result.CredentialsHelpers {
+		ok, gateDiags := checkMinVersion(path, fmt.Sprintf("credentials_helper %q", name), helper.MinVersion)
+		diags = diags.Append(gateDiags)
+		if !ok {
+			delete(result.CredentialsHelpers, name)
+			delete(result.credentialsHelperPositions, name)
+		}
+	}
+
+	if result.Registry != nil {
+		ok, gateDiags := checkMinVersion(path, "registry block", result.Registry.MinVersion)
+		diags = diags.Append(gateDiags)
+		if !ok {
+			result.Registry = nil
+		}
+	}
+
+	if result.Audit != nil {
+		ok, gateDiags := checkMinVersion(path, "audit block", result.Audit.MinVersion)
+		diags = diags.Append(gateDiags)
+		if !ok {
+			result.Audit = nil
+		}
+	}
+
+	return diags
+}
@@ -0,0 +1,136 @@
+package cliconfig
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	tfversion "github.com/hashicorp/terraform/version"
+)
+
+func writeTestConfigFile(t *testing.T, contents string) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "terraform-cliconfig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, "test.tfrc")
+	if err := ioutil.WriteFile(path, []byte(contents), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfigFile_requiredCLIVersion(t *testing.T) {
+	t.Run("satisfied constraint loads normally", func(t *testing.T) {
+		path := writeTestConfigFile(t, `
+			required_cli_version = ">= 0.1.0"
+			rate_limit = 5.0
+		`)
+
+		c, diags := loadConfigFile(path)
+		if diags.HasErrors() {
+			t.Fatalf("unexpected errors: %s", diags.Err())
+		}
+		if c.RateLimit != 5.0 {
+			t.Errorf("wrong RateLimit %v; file should have loaded normally", c.RateLimit)
+		}
+	})
+
+	t.Run("unsatisfied constraint is skipped with a warning", func(t *testing.T) {
+		path := writeTestConfigFile(t, `
+			required_cli_version = "> `+tfversion.SemVer.String()+`"
+			rate_limit = 5.0
+		`)
+
+		c, diags := loadConfigFile(path)
+		if diags.HasErrors() {
+			t.Fatalf("unexpected errors: %s", diags.Err())
+		}
+		if len(diags) != 1 {
+			t.Fatalf("got %d diagnostics; want 1: %#v", len(diags), diags)
+		}
+		if c.RateLimit != 0 {
+			t.Errorf("RateLimit = %v; file's content should have been skipped entirely", c.RateLimit)
+		}
+	})
+
+	t.Run("invalid constraint syntax is an error, not a skip", func(t *testing.T) {
+		path := writeTestConfigFile(t, `
+			required_cli_version = "not a constraint"
+		`)
+
+		_, diags := loadConfigFile(path)
+		if !diags.HasErrors() {
+			t.Fatal("expected an error for an invalid required_cli_version constraint")
+		}
+	})
+}
+
+func TestLoadConfigFile_blockMinVersion(t *testing.T) {
+	t.Run("satisfied min_version loads the block normally", func(t *testing.T) {
+		path := writeTestConfigFile(t, `
+			host "example.com" {
+				min_version = "0.1.0"
+				services = {
+					"modules.v1" = "https://example.com/modules/"
+				}
+			}
+		`)
+
+		c, diags := loadConfigFile(path)
+		if diags.HasErrors() {
+			t.Fatalf("unexpected errors: %s", diags.Err())
+		}
+		if _, ok := c.Hosts["example.com"]; !ok {
+			t.Fatal("expected the host block to be present")
+		}
+	})
+
+	t.Run("unsatisfied min_version skips just that block with a warning", func(t *testing.T) {
+		path := writeTestConfigFile(t, `
+			rate_limit = 5.0
+
+			host "example.com" {
+				min_version = "99.0.0"
+				services = {
+					"modules.v1" = "https://example.com/modules/"
+				}
+			}
+		`)
+
+		c, diags := loadConfigFile(path)
+		if diags.HasErrors() {
+			t.Fatalf("unexpected errors: %s", diags.Err())
+		}
+		if len(diags) != 1 {
+			t.Fatalf("got %d diagnostics; want 1: %#v", len(diags), diags)
+		}
+		if _, ok := c.Hosts["example.com"]; ok {
+			t.Error("expected the host block to have been skipped")
+		}
+		if c.RateLimit != 5.0 {
+			t.Errorf("RateLimit = %v; rest of the file should still have loaded", c.RateLimit)
+		}
+	})
+
+	t.Run("unsatisfied min_version on an audit block", func(t *testing.T) {
+		path := writeTestConfigFile(t, `
+			audit {
+				min_version = "99.0.0"
+				path = "/var/log/tf-audit.jsonl"
+			}
+		`)
+
+		c, diags := loadConfigFile(path)
+		if diags.HasErrors() {
+			t.Fatalf("unexpected errors: %s", diags.Err())
+		}
+		if c.Audit != nil {
+			t.Error("expected the audit block to have been skipped")
+		}
+	})
+}
@@ -0,0 +1,70 @@
+package command
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/hashicorp/terraform/configs/configupgrade"
+)
+
+// configUpgradeAdapter implements configload.AutoUpgrader on top of the
+// configupgrade package, the same tooling used by the "0.12upgrade"
+// command, so that a Meta's configuration loader can offer automatic
+// upgrades without configs/configload needing to depend on configupgrade
+// directly (configupgrade depends on the backend and provider packages,
+// which would otherwise create an import cycle back through configload).
+type configUpgradeAdapter struct {
+	Meta *Meta
+}
+
+func (a *configUpgradeAdapter) NeedsUpgrade(dir string) (bool, error) {
+	sources, err := configupgrade.LoadModule(dir)
+	if err != nil {
+		return false, err
+	}
+	for _, kind := range sources.ClassifyFiles() {
+		if kind == configupgrade.FileParseHCL1Fallback {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (a *configUpgradeAdapter) Upgrade(dir string) error {
+	sources, err := configupgrade.LoadModule(dir)
+	if err != nil {
+		return err
+	}
+
+	upgrader := &configupgrade.Upgrader{
+		Providers:    a.Meta.providerResolver(),
+		Provisioners: a.Meta.provisionerFactories(),
+	}
+	newSources, diags := upgrader.Upgrade(sources, dir)
+	if diags.HasErrors() {
+		return diags.Err()
+	}
+
+	for name, src := range newSources {
+		fullPath := filepath.Join(dir, name)
+		if src == nil {
+			if err := os.Remove(fullPath); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if orig, existed := sources[name]; existed {
+			if err := ioutil.WriteFile(fullPath+".backup", orig, 0644); err != nil {
+				return err
+			}
+		}
+
+		if err := ioutil.WriteFile(fullPath, src, 0644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -85,6 +85,7 @@ func (c *InitCommand) Run(args []string) int {
 			Cache:                 c.pluginCache(),
 			PluginProtocolVersion: discovery.PluginInstallProtocolVersion,
 			SkipVerify:            !flagVerifyPlugins,
+			StrictCacheChecksum:   c.PluginCacheStrictChecksum,
 			Ui:                    c.Ui,
 			Services:              c.Services,
 		}
@@ -3,6 +3,7 @@ package command
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"errors"
 	"flag"
 	"fmt"
@@ -23,6 +24,7 @@ import (
 	"github.com/hashicorp/terraform/helper/wrappedstreams"
 	"github.com/hashicorp/terraform/providers"
 	"github.com/hashicorp/terraform/provisioners"
+	"github.com/hashicorp/terraform/registry"
 	"github.com/hashicorp/terraform/svchost/disco"
 	"github.com/hashicorp/terraform/terraform"
 	"github.com/hashicorp/terraform/tfdiags"
@@ -41,6 +43,18 @@ type Meta struct {
 	PluginOverrides  *PluginOverrides // legacy overrides from .terraformrc file
 	Ui               cli.Ui           // Ui for output
 
+	// ProvisionerSearchPaths are additional directories to search for
+	// provisioner plugins, as configured by a "provisioner_installation"
+	// block in the CLI config.
+	ProvisionerSearchPaths []string
+
+	// ConfigAutoUpgrade controls whether the configuration loader will
+	// offer to automatically rewrite a root module that uses only the
+	// legacy pre-0.12 syntax. The default zero value,
+	// configload.AutoUpgradeNever, preserves the historical behavior of
+	// reporting legacy syntax as an ordinary parse error.
+	ConfigAutoUpgrade configload.AutoUpgradeMode
+
 	// ExtraHooks are extra hooks to add to the context.
 	ExtraHooks []terraform.Hook
 
@@ -65,6 +79,31 @@ type Meta struct {
 	// into the given directory.
 	PluginCacheDir string
 
+	// PluginCacheStrictChecksum, if set, causes the provider installer to
+	// re-verify a cached plugin's checksum before reusing it, rejecting
+	// cache entries that no longer match what the registry signed.
+	PluginCacheStrictChecksum bool
+
+	// RegistryRetryConfig controls the retry and timeout behavior of the
+	// client used to talk to the module and provider registry. If left
+	// at its zero value, registryClient will fall back to
+	// registry.DefaultRetryConfig().
+	RegistryRetryConfig registry.RetryConfig
+
+	// PluginTLSVerifyDisabled, if set, causes registryClient to skip TLS
+	// certificate verification when downloading modules and providers. It
+	// is populated from cliconfig.Config.PluginTLSVerifyDisabled, which
+	// requires both a config setting and an environment variable to be
+	// set before this is true; see that method for why.
+	PluginTLSVerifyDisabled bool
+
+	// CABundleTLSConfig, if non-nil, is used by registryClient in place of
+	// the default TLS configuration, so that a custom certificate
+	// authority configured via cliconfig.Config.CABundlePath is trusted
+	// for plugin and registry downloads. It's populated from
+	// cliconfig.Config.TLSConfig.
+	CABundleTLSConfig *tls.Config
+
 	// OverrideDataDir, if non-empty, overrides the return value of the
 	// DataDir method for situations where the local .terraform/ directory
 	// is not suitable, e.g. because of a read-only filesystem.
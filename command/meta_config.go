@@ -3,6 +3,7 @@ package command
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"os"
 	"path/filepath"
 	"sort"
@@ -13,6 +14,7 @@ import (
 	"github.com/hashicorp/terraform/configs"
 	"github.com/hashicorp/terraform/configs/configload"
 	"github.com/hashicorp/terraform/configs/configschema"
+	"github.com/hashicorp/terraform/httpclient"
 	"github.com/hashicorp/terraform/internal/earlyconfig"
 	"github.com/hashicorp/terraform/internal/initwd"
 	"github.com/hashicorp/terraform/registry"
@@ -360,8 +362,10 @@ func (m *Meta) registerSynthConfigSource(filename string, src []byte) {
 func (m *Meta) initConfigLoader() (*configload.Loader, error) {
 	if m.configLoader == nil {
 		loader, err := configload.NewLoader(&configload.Config{
-			ModulesDir: m.modulesDir(),
-			Services:   m.Services,
+			ModulesDir:   m.modulesDir(),
+			Services:     m.Services,
+			AutoUpgrade:  m.ConfigAutoUpgrade,
+			AutoUpgrader: &configUpgradeAdapter{Meta: m},
 		})
 		if err != nil {
 			return nil, err
@@ -380,7 +384,20 @@ func (m *Meta) moduleInstaller() *initwd.ModuleInstaller {
 
 // registryClient instantiates and returns a new Terraform Registry client.
 func (m *Meta) registryClient() *registry.Client {
-	return registry.NewClient(m.Services, nil)
+	var httpClient *http.Client
+	switch {
+	case m.CABundleTLSConfig != nil:
+		tlsConfig := m.CABundleTLSConfig.Clone()
+		tlsConfig.InsecureSkipVerify = m.PluginTLSVerifyDisabled
+		httpClient = httpclient.NewWithTLSConfig(tlsConfig)
+	case m.PluginTLSVerifyDisabled:
+		httpClient = httpclient.NewInsecure()
+	}
+	retry := m.RegistryRetryConfig
+	if retry == (registry.RetryConfig{}) {
+		retry = registry.DefaultRetryConfig()
+	}
+	return registry.NewClientWithRetryConfig(m.Services, httpClient, retry)
 }
 
 // configValueFromCLI parses a configuration value that was provided in a
@@ -312,7 +312,18 @@ func (m *Meta) missingPlugins(avail discovery.PluginMetaSet, reqd discovery.Plug
 
 func (m *Meta) provisionerFactories() map[string]terraform.ProvisionerFactory {
 	dirs := m.pluginDirs(true)
+	dirs = append(dirs, m.ProvisionerSearchPaths...)
 	plugins := discovery.FindPlugins("provisioner", dirs)
+
+	// Add provisioners overridden via the legacy .terraformrc or the
+	// "provisioner_installation" block's dev_overrides.
+	if m.PluginOverrides != nil {
+		for k, v := range m.PluginOverrides.Provisioners {
+			log.Printf("[DEBUG] found provisioner override in CLI config: %q, %q", k, v)
+		}
+		plugins = plugins.OverridePaths(m.PluginOverrides.Provisioners)
+	}
+
 	plugins, _ = plugins.ValidateVersions()
 
 	// For now our goal is to just find the latest version of each plugin
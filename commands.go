@@ -1,13 +1,11 @@
 package main
 
 import (
+	"log"
 	"os"
 	"os/signal"
 
 	"github.com/hashicorp/terraform/command"
-	pluginDiscovery "github.com/hashicorp/terraform/plugin/discovery"
-	"github.com/hashicorp/terraform/svchost"
-	"github.com/hashicorp/terraform/svchost/auth"
 	"github.com/hashicorp/terraform/svchost/disco"
 	"github.com/mitchellh/cli"
 )
@@ -40,29 +38,32 @@ func initCommands(config *Config, services *disco.Disco) {
 		inAutomation = true
 	}
 
-	for userHost, hostConfig := range config.Hosts {
-		host, err := svchost.ForComparison(userHost)
-		if err != nil {
-			// We expect the config was already validated by the time we get
-			// here, so we'll just ignore invalid hostnames.
-			continue
-		}
-		services.ForceHostServices(host, hostConfig.Services)
-	}
-
 	dataDir := os.Getenv("TF_DATA_DIR")
 
+	// Any problem here was already reported as a diagnostic by
+	// config.Validate during LoadConfig, so we only need to log it here as
+	// a fallback in case a Config was constructed some other way.
+	caBundleTLSConfig, err := config.TLSConfig()
+	if err != nil {
+		log.Printf("[WARN] Cannot build TLS config from ca_bundle_path: %s", err)
+	}
+
 	meta := command.Meta{
-		Color:            true,
-		GlobalPluginDirs: globalPluginDirs(),
-		PluginOverrides:  &PluginOverrides,
-		Ui:               Ui,
+		Color:                  true,
+		GlobalPluginDirs:       globalPluginDirs(),
+		PluginOverrides:        &PluginOverrides,
+		ProvisionerSearchPaths: config.ProvisionerSearchPaths(),
+		Ui:                     Ui,
 
 		Services: services,
 
-		RunningInAutomation: inAutomation,
-		PluginCacheDir:      config.PluginCacheDir,
-		OverrideDataDir:     dataDir,
+		RunningInAutomation:       inAutomation,
+		PluginCacheDir:            config.PluginCacheDir,
+		PluginCacheStrictChecksum: config.PluginCacheStrictChecksum,
+		OverrideDataDir:           dataDir,
+		RegistryRetryConfig:       config.RegistryRetryConfig(),
+		PluginTLSVerifyDisabled:   config.PluginTLSVerifyDisabled(),
+		CABundleTLSConfig:         caBundleTLSConfig,
 
 		ShutdownCh: makeShutdownCh(),
 	}
@@ -370,7 +371,6 @@ func makeShutdownCh() <-chan struct{} {
 	return resultCh
 }
 
-func credentialsSource(config *Config) (auth.CredentialsSource, error) {
-	helperPlugins := pluginDiscovery.FindPlugins("credentials", globalPluginDirs())
-	return config.CredentialsSource(helperPlugins)
+func servicesClient(config *Config) (*disco.Disco, error) {
+	return config.ServicesClient(globalPluginDirs())
 }
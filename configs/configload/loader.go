@@ -23,6 +23,33 @@ type Loader struct {
 	// modules is used to install and locate descendent modules that are
 	// referenced (directly or indirectly) from the root module.
 	modules moduleMgr
+
+	// autoUpgrade controls whether LoadConfig will attempt to rewrite a
+	// root module directory that only parses under the legacy pre-0.12
+	// syntax, and autoUpgrader is what it delegates the actual detection
+	// and rewriting to. See AutoUpgradeMode for details.
+	autoUpgrade  AutoUpgradeMode
+	autoUpgrader AutoUpgrader
+
+	// pendingUpgradeDir is set by LoadConfig, when autoUpgrade is
+	// AutoUpgradePrompt, to the most recent root module directory found to
+	// need a legacy-syntax upgrade. See PendingUpgradeDir.
+	pendingUpgradeDir string
+}
+
+// AutoUpgrader is implemented by a caller-supplied adapter that knows how
+// to detect and rewrite configuration directories that use the legacy
+// pre-0.12 syntax. Its job mirrors the analysis the "terraform 0.12upgrade"
+// command performs, abstracted behind this interface so that this package
+// does not need to depend on that tooling directly.
+type AutoUpgrader interface {
+	// NeedsUpgrade reports whether the configuration files in dir can only
+	// be made sense of via the legacy pre-0.12 syntax.
+	NeedsUpgrade(dir string) (bool, error)
+
+	// Upgrade rewrites the legacy-syntax files in dir in place, leaving a
+	// backup of each file it changes alongside the original.
+	Upgrade(dir string) error
 }
 
 // Config is used with NewLoader to specify configuration arguments for the
@@ -39,8 +66,46 @@ type Config struct {
 	// not supported, which should be true only in specialized circumstances
 	// such as in tests.
 	Services *disco.Disco
+
+	// AutoUpgrade controls whether LoadConfig will attempt to rewrite a
+	// root module directory that only parses under the legacy pre-0.12
+	// syntax. The default zero value, AutoUpgradeNever, preserves the
+	// historical behavior of surfacing the parse failure as an ordinary
+	// diagnostic.
+	AutoUpgrade AutoUpgradeMode
+
+	// AutoUpgrader performs the detection and rewriting work when
+	// AutoUpgrade is AutoUpgradeAlways or AutoUpgradePrompt. If AutoUpgrade
+	// requests an upgrade but AutoUpgrader is nil, auto-upgrade is skipped
+	// and the original parse diagnostics are returned unchanged.
+	AutoUpgrader AutoUpgrader
 }
 
+// AutoUpgradeMode controls how Loader.LoadConfig responds when a module
+// directory's files fail to parse under Terraform's current native syntax
+// but appear to be valid under the legacy pre-0.12 syntax instead.
+type AutoUpgradeMode string
+
+const (
+	// AutoUpgradeNever leaves legacy-syntax files exactly as they are and
+	// reports the parse failure as an ordinary error diagnostic. This is
+	// the default.
+	AutoUpgradeNever AutoUpgradeMode = "never"
+
+	// AutoUpgradeAlways rewrites legacy-syntax files in place, using the
+	// same analysis as the "terraform 0.12upgrade" command, before
+	// retrying the load. A backup of each rewritten file is left
+	// alongside it with a ".backup" suffix.
+	AutoUpgradeAlways AutoUpgradeMode = "always"
+
+	// AutoUpgradePrompt behaves like AutoUpgradeNever except that it also
+	// records the affected directory, retrievable with
+	// Loader.PendingUpgradeDir, so that a caller with access to
+	// interactive UI can ask the user whether to proceed and, if so,
+	// retry with a Loader configured for AutoUpgradeAlways.
+	AutoUpgradePrompt AutoUpgradeMode = "prompt"
+)
+
 // NewLoader creates and returns a loader that reads configuration from the
 // real OS filesystem.
 //
@@ -61,6 +126,8 @@ func NewLoader(config *Config) (*Loader, error) {
 			Services:   config.Services,
 			Registry:   reg,
 		},
+		autoUpgrade:  config.AutoUpgrade,
+		autoUpgrader: config.AutoUpgrader,
 	}
 
 	err := ret.modules.readModuleManifestSnapshot()
@@ -71,6 +138,14 @@ func NewLoader(config *Config) (*Loader, error) {
 	return ret, nil
 }
 
+// PendingUpgradeDir returns the most recent root module directory that
+// LoadConfig found to need a legacy-syntax upgrade while this Loader was
+// configured with AutoUpgradePrompt, along with whether any such directory
+// has been recorded.
+func (l *Loader) PendingUpgradeDir() (string, bool) {
+	return l.pendingUpgradeDir, l.pendingUpgradeDir != ""
+}
+
 // ModulesDir returns the path to the directory where the loader will look for
 // the local cache of remote module packages.
 func (l *Loader) ModulesDir() string {
@@ -125,7 +200,7 @@ func (l *Loader) IsConfigDir(path string) bool {
 // code from that loader must be imported into the "main" loader in order
 // to return source code snapshots in diagnostic messages.
 //
-//     loader.ImportSources(otherLoader.Sources())
+//	loader.ImportSources(otherLoader.Sources())
 func (l *Loader) ImportSources(sources map[string][]byte) {
 	p := l.Parser()
 	for name, src := range sources {
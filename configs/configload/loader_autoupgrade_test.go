@@ -0,0 +1,159 @@
+package configload
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakeAutoUpgrader is a minimal AutoUpgrader for testing Loader's
+// AutoUpgrade behavior without depending on the real configupgrade
+// package (which this package cannot import directly; see loader.go).
+type fakeAutoUpgrader struct {
+	// needsUpgrade is returned by NeedsUpgrade.
+	needsUpgrade bool
+
+	// upgradeCalled records whether Upgrade was invoked.
+	upgradeCalled bool
+
+	// fixedContent, if non-nil, is written over every ".tf" file in the
+	// given directory when Upgrade is called, simulating a real rewrite.
+	fixedContent []byte
+}
+
+func (f *fakeAutoUpgrader) NeedsUpgrade(dir string) (bool, error) {
+	return f.needsUpgrade, nil
+}
+
+func (f *fakeAutoUpgrader) Upgrade(dir string) error {
+	f.upgradeCalled = true
+	if f.fixedContent == nil {
+		return nil
+	}
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if filepath.Ext(entry.Name()) != ".tf" {
+			continue
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, entry.Name()), f.fixedContent, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+const legacySyntaxFixture = `
+resource "test_instance" "foo" {
+  "count" = 2,
+  "foo" = "bar",
+}
+`
+
+const upgradedSyntaxFixture = `
+resource "test_instance" "foo" {
+  count = 2
+  foo   = "bar"
+}
+`
+
+func TestLoaderLoadConfig_autoUpgradeNever(t *testing.T) {
+	dir, err := ioutil.TempDir("", "terraform-configload-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "main.tf"), []byte(legacySyntaxFixture), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	upgrader := &fakeAutoUpgrader{needsUpgrade: true}
+	loader, err := NewLoader(&Config{
+		ModulesDir:   filepath.Join(dir, ".terraform/modules"),
+		AutoUpgrade:  AutoUpgradeNever,
+		AutoUpgrader: upgrader,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, diags := loader.LoadConfig(dir)
+	if !diags.HasErrors() {
+		t.Fatal("expected parse errors for legacy syntax, got none")
+	}
+	if upgrader.upgradeCalled {
+		t.Error("Upgrade was called despite AutoUpgradeNever")
+	}
+}
+
+func TestLoaderLoadConfig_autoUpgradeAlways(t *testing.T) {
+	dir, err := ioutil.TempDir("", "terraform-configload-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "main.tf"), []byte(legacySyntaxFixture), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	upgrader := &fakeAutoUpgrader{needsUpgrade: true, fixedContent: []byte(upgradedSyntaxFixture)}
+	loader, err := NewLoader(&Config{
+		ModulesDir:   filepath.Join(dir, ".terraform/modules"),
+		AutoUpgrade:  AutoUpgradeAlways,
+		AutoUpgrader: upgrader,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, diags := loader.LoadConfig(dir)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected diagnostics after auto-upgrade: %s", diags)
+	}
+	if cfg == nil {
+		t.Fatal("config is nil; want non-nil")
+	}
+	if !upgrader.upgradeCalled {
+		t.Error("Upgrade was not called despite AutoUpgradeAlways")
+	}
+}
+
+func TestLoaderLoadConfig_autoUpgradePrompt(t *testing.T) {
+	dir, err := ioutil.TempDir("", "terraform-configload-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "main.tf"), []byte(legacySyntaxFixture), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	upgrader := &fakeAutoUpgrader{needsUpgrade: true, fixedContent: []byte(upgradedSyntaxFixture)}
+	loader, err := NewLoader(&Config{
+		ModulesDir:   filepath.Join(dir, ".terraform/modules"),
+		AutoUpgrade:  AutoUpgradePrompt,
+		AutoUpgrader: upgrader,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, diags := loader.LoadConfig(dir)
+	if !diags.HasErrors() {
+		t.Fatal("expected parse errors for legacy syntax, got none")
+	}
+	if upgrader.upgradeCalled {
+		t.Error("Upgrade was called despite AutoUpgradePrompt")
+	}
+
+	got, ok := loader.PendingUpgradeDir()
+	if !ok {
+		t.Fatal("PendingUpgradeDir reported no pending upgrade")
+	}
+	if got != dir {
+		t.Errorf("wrong pending upgrade dir\ngot:  %s\nwant: %s", got, dir)
+	}
+}
@@ -18,8 +18,24 @@ import (
 //
 // LoadConfig performs the basic syntax and uniqueness validations that are
 // required to process the individual modules, and also detects
+//
+// If the Loader was created with an AutoUpgrade mode other than the default
+// AutoUpgradeNever and the root module directory turns out to contain only
+// legacy pre-0.12 syntax, LoadConfig will either upgrade it in place and
+// retry (AutoUpgradeAlways) or record it for a later interactive prompt via
+// PendingUpgradeDir (AutoUpgradePrompt) before returning the original parse
+// diagnostics. Descendent modules are not considered for auto-upgrade; only
+// the given root directory is.
 func (l *Loader) LoadConfig(rootDir string) (*configs.Config, hcl.Diagnostics) {
 	rootMod, diags := l.parser.LoadConfigDir(rootDir)
+	if diags.HasErrors() {
+		if upgraded, upgradeDiags := l.maybeAutoUpgrade(rootDir); upgraded {
+			rootMod, diags = l.parser.LoadConfigDir(rootDir)
+			diags = append(diags, upgradeDiags...)
+		} else {
+			diags = append(diags, upgradeDiags...)
+		}
+	}
 	if rootMod == nil {
 		return nil, diags
 	}
@@ -30,6 +46,39 @@ func (l *Loader) LoadConfig(rootDir string) (*configs.Config, hcl.Diagnostics) {
 	return cfg, diags
 }
 
+// maybeAutoUpgrade implements the AutoUpgrade behavior described on
+// LoadConfig, delegating the actual legacy-syntax detection and rewriting
+// to the Loader's AutoUpgrader so that this package does not need to
+// depend on the config upgrade tooling directly. It returns true only if
+// dir was actually rewritten, in which case the caller should retry the
+// load.
+func (l *Loader) maybeAutoUpgrade(dir string) (upgraded bool, diags hcl.Diagnostics) {
+	if l.autoUpgrade == AutoUpgradeNever || l.autoUpgrade == "" || l.autoUpgrader == nil {
+		return false, nil
+	}
+
+	needsUpgrade, err := l.autoUpgrader.NeedsUpgrade(dir)
+	if err != nil || !needsUpgrade {
+		return false, nil
+	}
+
+	if l.autoUpgrade == AutoUpgradePrompt {
+		l.pendingUpgradeDir = dir
+		return false, nil
+	}
+
+	if err := l.autoUpgrader.Upgrade(dir); err != nil {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Automatic legacy syntax upgrade failed",
+			Detail:   fmt.Sprintf("Terraform attempted to automatically upgrade the legacy-syntax configuration in %s, but encountered an error: %s", dir, err),
+		})
+		return false, diags
+	}
+
+	return true, diags
+}
+
 // moduleWalkerLoad is a configs.ModuleWalkerFunc for loading modules that
 // are presumed to have already been installed. A different function
 // (moduleWalkerInstall) is used for installation.
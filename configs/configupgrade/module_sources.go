@@ -13,6 +13,7 @@ import (
 
 	"github.com/hashicorp/hcl2/hcl"
 	hcl2syntax "github.com/hashicorp/hcl2/hcl/hclsyntax"
+	"github.com/hashicorp/hcl2/hclparse"
 
 	version "github.com/hashicorp/go-version"
 )
@@ -201,6 +202,60 @@ func (ms ModuleSources) MaybeAlreadyUpgraded() (bool, tfdiags.SourceRange) {
 	return false, tfdiags.SourceRange{}
 }
 
+// FileParseKind describes which parser was able to make sense of a
+// particular configuration file, as reported by ClassifyFiles.
+type FileParseKind string
+
+const (
+	// FileParseHCL2 indicates that a file is already valid under the
+	// current native HCL2-based configuration syntax, and so does not
+	// need anything done to it by this package's Upgrader.
+	FileParseHCL2 FileParseKind = "hcl2"
+
+	// FileParseHCL1Fallback indicates that a file could not be parsed as
+	// HCL2 syntax and so can only be made sense of via the legacy
+	// HCL1-oriented analysis that this package's Upgrader uses to
+	// translate it to the new idiom.
+	FileParseHCL1Fallback FileParseKind = "hcl1-fallback"
+)
+
+// ClassifyFiles reports, for each file in the receiver, whether it is
+// already written using Terraform's current native syntax (FileParseHCL2)
+// or whether it can only be understood via this package's legacy
+// HCL1-oriented fallback analysis (FileParseHCL1Fallback).
+//
+// This is intended for callers that want to give the user some upfront
+// sense of how much of their configuration actually needs upgrading -- for
+// example, to print a summary like "3 of 10 configuration files use the
+// legacy syntax and will be rewritten" before running Upgrade -- rather
+// than for driving the upgrade process itself, which always runs the
+// legacy analysis across the whole given ModuleSources regardless of how
+// any individual file classifies here.
+func (ms ModuleSources) ClassifyFiles() map[string]FileParseKind {
+	ret := make(map[string]FileParseKind, len(ms))
+	for name, src := range ms {
+		ext := fileExt(name)
+		if ext == "" {
+			continue
+		}
+
+		p := hclparse.NewParser()
+		var diags hcl.Diagnostics
+		if ext == ".tf.json" {
+			_, diags = p.ParseJSON(src, name)
+		} else {
+			_, diags = p.ParseHCL(src, name)
+		}
+
+		if diags.HasErrors() {
+			ret[name] = FileParseHCL1Fallback
+		} else {
+			ret[name] = FileParseHCL2
+		}
+	}
+	return ret
+}
+
 var firstVersionWithNewParser = version.Must(version.NewVersion("0.12.0"))
 
 // fileExt returns the Terraform configuration extension of the given
@@ -40,3 +40,33 @@ func TestMaybeAlreadyUpgraded(t *testing.T) {
 		}
 	})
 }
+
+func TestClassifyFiles(t *testing.T) {
+	t.Run("legacy syntax", func(t *testing.T) {
+		sources, err := LoadModule("testdata/valid/argument-commas/input")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got := sources.ClassifyFiles()
+		want := map[string]FileParseKind{
+			"argument-commas.tf": FileParseHCL1Fallback,
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("wrong result\ngot:  %#v\nwant: %#v", got, want)
+		}
+	})
+	t.Run("already native syntax", func(t *testing.T) {
+		sources, err := LoadModule("testdata/valid/variable-type/want")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got := sources.ClassifyFiles()
+		for name, kind := range got {
+			if kind != FileParseHCL2 {
+				t.Errorf("file %q classified as %q; want %q", name, kind, FileParseHCL2)
+			}
+		}
+	})
+}
@@ -25,6 +25,18 @@ type Module struct {
 
 	CoreVersionConstraints []VersionConstraint
 
+	// EnableEnvInterpolation opts the configuration in to the "env"
+	// function, which reads values from the process environment. It is
+	// set by "enable_env_interpolation = true" in a "terraform" block and
+	// applies to the whole configuration, in the same way as Backend.
+	EnableEnvInterpolation bool
+
+	// EnableExternalFunction opts the configuration in to the "external"
+	// function, which runs an external program allowlisted by the CLI
+	// configuration. It is set by "enable_external_function = true" in a
+	// "terraform" block, in the same way as EnableEnvInterpolation.
+	EnableExternalFunction bool
+
 	Backend              *Backend
 	ProviderConfigs      map[string]*Provider
 	ProviderRequirements map[string][]VersionConstraint
@@ -53,6 +65,16 @@ type Module struct {
 type File struct {
 	CoreVersionConstraints []VersionConstraint
 
+	// EnableEnvInterpolation is true if this file's "terraform" block set
+	// "enable_env_interpolation = true", opting the configuration in to the
+	// "env" function. See Module.EnableEnvInterpolation.
+	EnableEnvInterpolation bool
+
+	// EnableExternalFunction is true if this file's "terraform" block set
+	// "enable_external_function = true", opting the configuration in to the
+	// "external" function. See Module.EnableExternalFunction.
+	EnableExternalFunction bool
+
 	Backends             []*Backend
 	ProviderConfigs      []*Provider
 	ProviderRequirements []*ProviderRequirement
@@ -124,6 +146,14 @@ func (m *Module) appendFile(file *File) hcl.Diagnostics {
 		m.CoreVersionConstraints = append(m.CoreVersionConstraints, constraint)
 	}
 
+	if file.EnableEnvInterpolation {
+		m.EnableEnvInterpolation = true
+	}
+
+	if file.EnableExternalFunction {
+		m.EnableExternalFunction = true
+	}
+
 	for _, b := range file.Backends {
 		if m.Backend != nil {
 			diags = append(diags, &hcl.Diagnostic{
@@ -256,6 +286,14 @@ func (m *Module) mergeFile(file *File) hcl.Diagnostics {
 		}
 	}
 
+	if file.EnableEnvInterpolation {
+		m.EnableEnvInterpolation = true
+	}
+
+	if file.EnableExternalFunction {
+		m.EnableExternalFunction = true
+	}
+
 	if len(file.Backends) != 0 {
 		switch len(file.Backends) {
 		case 1:
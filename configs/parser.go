@@ -47,6 +47,27 @@ func NewParser(fs afero.Fs) *Parser {
 // The file will be parsed using the HCL native syntax unless the filename
 // ends with ".json", in which case the HCL JSON syntax will be used.
 func (p *Parser) LoadHCLFile(path string) (hcl.Body, hcl.Diagnostics) {
+	file, diags := p.parseHCLFile(path)
+	if file == nil {
+		// parseHCLFile returns no file only when it couldn't read it at all.
+		return nil, diags
+	}
+
+	// If the returned body is nil, then we'll return a non-nil empty
+	// body so we'll meet our contract that nil means an error reading the file.
+	if file.Body == nil {
+		return hcl.EmptyBody(), diags
+	}
+
+	return file.Body, diags
+}
+
+// parseHCLFile is the shared implementation behind LoadHCLFile and
+// ParseConfigFile: it reads and parses the file at the given path, returning
+// the full *hcl.File -- unlike LoadHCLFile, which discards everything but
+// its Body -- or a nil *hcl.File and error diagnostics if it couldn't be
+// read at all.
+func (p *Parser) parseHCLFile(path string) (*hcl.File, hcl.Diagnostics) {
 	src, err := p.fs.ReadFile(path)
 
 	if err != nil {
@@ -68,13 +89,7 @@ func (p *Parser) LoadHCLFile(path string) (hcl.Body, hcl.Diagnostics) {
 		file, diags = p.p.ParseHCL(src, path)
 	}
 
-	// If the returned file or body is nil, then we'll return a non-nil empty
-	// body so we'll meet our contract that nil means an error reading the file.
-	if file == nil || file.Body == nil {
-		return hcl.EmptyBody(), diags
-	}
-
-	return file.Body, diags
+	return file, diags
 }
 
 // Sources returns a map of the cached source buffers for all files that
@@ -1,7 +1,11 @@
 package configs
 
 import (
+	"fmt"
+
 	"github.com/hashicorp/hcl2/hcl"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
 )
 
 // LoadConfigFile reads the file at the given path and parses it as a config
@@ -30,14 +34,54 @@ func (p *Parser) LoadConfigFileOverride(path string) (*File, hcl.Diagnostics) {
 }
 
 func (p *Parser) loadConfigFile(path string, override bool) (*File, hcl.Diagnostics) {
-
 	body, diags := p.LoadHCLFile(path)
 	if body == nil {
 		return nil, diags
 	}
 
+	file, fileDiags := p.decodeConfigFile(body, override)
+	diags = append(diags, fileDiags...)
+	return file, diags
+}
+
+// ParseConfigFileResult is the return value of ParseConfigFile, pairing the
+// usual decoded *File with the *hcl.File it was decoded from.
+type ParseConfigFileResult struct {
+	File    *File
+	HCLFile *hcl.File
+}
+
+// ParseConfigFile is like LoadConfigFile but additionally returns the
+// *hcl.File the result was decoded from, rather than just the already-fully-
+// decoded *File. This is for callers that need to work at the granularity
+// of a single file's own syntax tree -- for example, an IDE offering
+// completion within one open file, or a migration tool rewriting a file in
+// place -- before (or instead of) the usual merging LoadConfigDir performs
+// across every file in a module.
+//
+// Most callers should use LoadConfigFile instead; walking the returned
+// *hcl.File directly requires understanding HCL's own native or JSON syntax
+// tree, which is considerably lower-level than the already-decoded *File.
+func (p *Parser) ParseConfigFile(path string) (*ParseConfigFileResult, hcl.Diagnostics) {
+	hclFile, diags := p.parseHCLFile(path)
+	if hclFile == nil || hclFile.Body == nil {
+		return nil, diags
+	}
+
+	file, fileDiags := p.decodeConfigFile(hclFile.Body, false)
+	diags = append(diags, fileDiags...)
+
+	return &ParseConfigFileResult{File: file, HCLFile: hclFile}, diags
+}
+
+// decodeConfigFile does the actual decoding of a config file body, shared
+// between loadConfigFile and ParseConfigFile so that the two differ only in
+// what they additionally return alongside the decoded *File.
+func (p *Parser) decodeConfigFile(body hcl.Body, override bool) (*File, hcl.Diagnostics) {
 	file := &File{}
 
+	var diags hcl.Diagnostics
+
 	var reqDiags hcl.Diagnostics
 	file.CoreVersionConstraints, reqDiags = sniffCoreVersionRequirements(body)
 	diags = append(diags, reqDiags...)
@@ -55,6 +99,18 @@ func (p *Parser) loadConfigFile(path string, override bool) (*File, hcl.Diagnost
 			// We ignore the "terraform_version" attribute here because
 			// sniffCoreVersionRequirements already dealt with that above.
 
+			if attr, exists := content.Attributes["enable_env_interpolation"]; exists {
+				enabled, enabledDiags := decodeBoolAttribute(attr)
+				diags = append(diags, enabledDiags...)
+				file.EnableEnvInterpolation = enabled
+			}
+
+			if attr, exists := content.Attributes["enable_external_function"]; exists {
+				enabled, enabledDiags := decodeBoolAttribute(attr)
+				diags = append(diags, enabledDiags...)
+				file.EnableExternalFunction = enabled
+			}
+
 			for _, innerBlock := range content.Blocks {
 				switch innerBlock.Type {
 
@@ -209,6 +265,31 @@ var configFileSchema = &hcl.BodySchema{
 	},
 }
 
+// decodeBoolAttribute decodes a literal boolean attribute, such as the
+// "enable_env_interpolation" attribute of a "terraform" block.
+func decodeBoolAttribute(attr *hcl.Attribute) (bool, hcl.Diagnostics) {
+	val, diags := attr.Expr.Value(nil)
+	if diags.HasErrors() {
+		return false, diags
+	}
+
+	val, err := convert.Convert(val, cty.Bool)
+	if err != nil {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  fmt.Sprintf("Invalid value for %q", attr.Name),
+			Detail:   fmt.Sprintf("A bool value is required for %s.", attr.Name),
+			Subject:  attr.Expr.Range().Ptr(),
+		})
+		return false, diags
+	}
+	if val.IsNull() || !val.IsWhollyKnown() {
+		return false, diags
+	}
+
+	return val.True(), diags
+}
+
 // terraformBlockSchema is the schema for a top-level "terraform" block in
 // a configuration file.
 var terraformBlockSchema = &hcl.BodySchema{
@@ -216,6 +297,12 @@ var terraformBlockSchema = &hcl.BodySchema{
 		{
 			Name: "required_version",
 		},
+		{
+			Name: "enable_env_interpolation",
+		},
+		{
+			Name: "enable_external_function",
+		},
 	},
 	Blocks: []hcl.BlockHeaderSchema{
 		{
@@ -164,3 +164,99 @@ func TestParserLoadConfigFileFailureMessages(t *testing.T) {
 		})
 	}
 }
+
+func TestParserLoadConfigFileEnableEnvInterpolation(t *testing.T) {
+	filename := "valid-files/enable-env-interpolation.tf"
+	src, err := ioutil.ReadFile(filepath.Join("testdata", filename))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parser := testParser(map[string]string{
+		filename: string(src),
+	})
+
+	file, diags := parser.LoadConfigFile(filename)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected error diagnostics: %s", diags)
+	}
+	if !file.EnableEnvInterpolation {
+		t.Fatal("EnableEnvInterpolation is false; want true")
+	}
+
+	mod, diags := NewModule([]*File{file}, nil)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected error diagnostics: %s", diags)
+	}
+	if !mod.EnableEnvInterpolation {
+		t.Fatal("module EnableEnvInterpolation is false; want true")
+	}
+}
+
+func TestParserLoadConfigFileEnableExternalFunction(t *testing.T) {
+	filename := "valid-files/enable-external-function.tf"
+	src, err := ioutil.ReadFile(filepath.Join("testdata", filename))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parser := testParser(map[string]string{
+		filename: string(src),
+	})
+
+	file, diags := parser.LoadConfigFile(filename)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected error diagnostics: %s", diags)
+	}
+	if !file.EnableExternalFunction {
+		t.Fatal("EnableExternalFunction is false; want true")
+	}
+
+	mod, diags := NewModule([]*File{file}, nil)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected error diagnostics: %s", diags)
+	}
+	if !mod.EnableExternalFunction {
+		t.Fatal("module EnableExternalFunction is false; want true")
+	}
+}
+
+func TestParserParseConfigFile(t *testing.T) {
+	filename := "valid-files/backend.tf"
+	src, err := ioutil.ReadFile(filepath.Join("testdata", filename))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parser := testParser(map[string]string{
+		filename: string(src),
+	})
+
+	result, diags := parser.ParseConfigFile(filename)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected error diagnostics: %s", diags)
+	}
+
+	if got, want := len(result.File.Backends), 1; got != want {
+		t.Fatalf("wrong number of backends %d; want %d", got, want)
+	}
+
+	if result.HCLFile == nil {
+		t.Fatal("HCLFile is nil; want the underlying hcl.File")
+	}
+	if result.HCLFile.Body == nil {
+		t.Fatal("HCLFile.Body is nil")
+	}
+	if got, want := string(result.HCLFile.Bytes), src; got != string(want) {
+		t.Errorf("HCLFile.Bytes doesn't match the source it was parsed from")
+	}
+}
+
+func TestParserParseConfigFile_missing(t *testing.T) {
+	parser := testParser(nil)
+
+	result, diags := parser.ParseConfigFile("does-not-exist.tf")
+	if !diags.HasErrors() {
+		t.Fatal("expected error diagnostics for a missing file")
+	}
+	if result != nil {
+		t.Fatalf("expected a nil result for a missing file, got %#v", result)
+	}
+}
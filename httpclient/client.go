@@ -1,6 +1,7 @@
 package httpclient
 
 import (
+	"crypto/tls"
 	"net/http"
 
 	cleanhttp "github.com/hashicorp/go-cleanhttp"
@@ -16,3 +17,25 @@ func New() *http.Client {
 	}
 	return cli
 }
+
+// NewInsecure is like New except that the returned client does not verify
+// the TLS certificate presented by any server it connects to. This exists
+// only to support debugging through an interception proxy, and should never
+// be used as the default client for anything; callers must gate its use
+// behind an explicit, deliberately inconvenient opt-in, such as
+// cliconfig.Config.PluginTLSVerifyDisabled.
+func NewInsecure() *http.Client {
+	return NewWithTLSConfig(&tls.Config{InsecureSkipVerify: true})
+}
+
+// NewWithTLSConfig is like New except that the returned client verifies
+// server certificates using tlsConfig instead of the Go standard library's
+// default configuration. This is for callers that need to trust a custom
+// certificate authority, such as one configured via
+// cliconfig.Config.TLSConfig.
+func NewWithTLSConfig(tlsConfig *tls.Config) *http.Client {
+	cli := New()
+	transport := cli.Transport.(*userAgentRoundTripper).inner.(*http.Transport)
+	transport.TLSClientConfig = tlsConfig
+	return cli
+}
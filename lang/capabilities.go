@@ -0,0 +1,89 @@
+package lang
+
+import (
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+// Capability identifies a trait of a function that may make it unsuitable
+// for use in some evaluation contexts, such as validating configuration
+// before any resources exist or evaluating a policy against a plan in a
+// separate process that doesn't share the same filesystem.
+type Capability string
+
+const (
+	// CapabilityReadsFilesystem marks functions that read from the local
+	// filesystem, such as file and fileset. These can't be evaluated
+	// usefully, or safely, outside of the environment Terraform itself is
+	// running in.
+	CapabilityReadsFilesystem Capability = "reads-fs"
+
+	// CapabilityNondeterministic marks functions whose result can differ
+	// between calls even when given identical arguments, such as uuid and
+	// timestamp. A caller that wants to evaluate an expression more than
+	// once and compare the results, such as a validate-only pass, should
+	// avoid depending on these.
+	CapabilityNondeterministic Capability = "nondeterministic"
+
+	// CapabilityNetwork marks functions that make network requests in
+	// order to produce their result.
+	CapabilityNetwork Capability = "network"
+
+	// CapabilityExpensive marks functions whose cost, in CPU time or
+	// memory, is disproportionate to most other functions, such as bcrypt
+	// with a high cost factor. A caller that wants to bound the work a
+	// single expression can demand may want to exclude these.
+	CapabilityExpensive Capability = "expensive"
+)
+
+// functionCapabilities records the capabilities of each function that has
+// at least one, keyed by the name it's registered under in Scope.Functions.
+// A function with no entry here has no capabilities.
+var functionCapabilities = map[string][]Capability{
+	"file":             {CapabilityReadsFilesystem},
+	"filebase64":       {CapabilityReadsFilesystem},
+	"filebase64sha256": {CapabilityReadsFilesystem},
+	"filebase64sha512": {CapabilityReadsFilesystem},
+	"fileexists":       {CapabilityReadsFilesystem},
+	"filemd5":          {CapabilityReadsFilesystem},
+	"fileset":          {CapabilityReadsFilesystem},
+	"filesha1":         {CapabilityReadsFilesystem},
+	"filesha256":       {CapabilityReadsFilesystem},
+	"filesha512":       {CapabilityReadsFilesystem},
+	"templatefile":     {CapabilityReadsFilesystem},
+
+	"bcrypt":    {CapabilityNondeterministic, CapabilityExpensive},
+	"env":       {CapabilityNondeterministic},
+	"timestamp": {CapabilityNondeterministic},
+	"uuid":      {CapabilityNondeterministic},
+}
+
+// Restrict returns a copy of the function table that Functions would
+// return, but with every function tagged with one or more of the given
+// capabilities omitted.
+//
+// This is intended for evaluation contexts that can't or shouldn't allow
+// the full set of functions, such as a validate-only pass that wants to
+// avoid nondeterministic results, or a policy-evaluation process running
+// somewhere that has no access to the configuration's filesystem.
+func (s *Scope) Restrict(capabilities ...Capability) map[string]function.Function {
+	all := s.Functions()
+	restricted := make(map[string]function.Function, len(all))
+	for name, f := range all {
+		if functionHasCapability(name, capabilities) {
+			continue
+		}
+		restricted[name] = f
+	}
+	return restricted
+}
+
+func functionHasCapability(name string, capabilities []Capability) bool {
+	for _, has := range functionCapabilities[name] {
+		for _, want := range capabilities {
+			if has == want {
+				return true
+			}
+		}
+	}
+	return false
+}
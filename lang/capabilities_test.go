@@ -0,0 +1,50 @@
+package lang
+
+import (
+	"testing"
+)
+
+func TestScopeRestrict(t *testing.T) {
+	scope := &Scope{}
+
+	t.Run("no capabilities", func(t *testing.T) {
+		restricted := scope.Restrict()
+		all := scope.Functions()
+		if len(restricted) != len(all) {
+			t.Fatalf("expected %d functions, got %d", len(all), len(restricted))
+		}
+	})
+
+	t.Run("reads-fs", func(t *testing.T) {
+		restricted := scope.Restrict(CapabilityReadsFilesystem)
+		for _, name := range []string{"file", "fileset", "templatefile"} {
+			if _, ok := restricted[name]; ok {
+				t.Errorf("%q should have been excluded", name)
+			}
+		}
+		if _, ok := restricted["upper"]; !ok {
+			t.Error("\"upper\" should not have been excluded")
+		}
+	})
+
+	t.Run("nondeterministic", func(t *testing.T) {
+		restricted := scope.Restrict(CapabilityNondeterministic)
+		for _, name := range []string{"uuid", "timestamp", "bcrypt"} {
+			if _, ok := restricted[name]; ok {
+				t.Errorf("%q should have been excluded", name)
+			}
+		}
+		if _, ok := restricted["file"]; !ok {
+			t.Error("\"file\" should not have been excluded")
+		}
+	})
+
+	t.Run("multiple capabilities", func(t *testing.T) {
+		restricted := scope.Restrict(CapabilityReadsFilesystem, CapabilityNondeterministic)
+		for _, name := range []string{"file", "uuid", "bcrypt"} {
+			if _, ok := restricted[name]; ok {
+				t.Errorf("%q should have been excluded", name)
+			}
+		}
+	})
+}
@@ -0,0 +1,74 @@
+package lang
+
+import (
+	"github.com/hashicorp/hcl2/hcl"
+	"github.com/hashicorp/hcl2/hcl/hclsyntax"
+)
+
+// baseNodeCost is the score attributed to each node in an expression's
+// syntax tree that isn't given a more specific weight below.
+const baseNodeCost = 1
+
+// functionCallCost is the score attributed to an ordinary function call,
+// added on top of baseNodeCost.
+const functionCallCost = 2
+
+// expensiveFunctionCallCost is the score attributed to a call to a function
+// tagged with CapabilityExpensive, such as bcrypt, added on top of
+// baseNodeCost.
+const expensiveFunctionCallCost = 20
+
+// collectionCost is the score attributed to a node that constructs or
+// iterates over a collection, such as a tuple constructor or a for
+// expression, added on top of baseNodeCost. These can each do work
+// proportional to the number of elements involved, which isn't visible
+// from the syntax tree alone.
+const collectionCost = 5
+
+// EstimateCost returns a rough, static estimate of how expensive expr would
+// be to evaluate, without actually evaluating it.
+//
+// The result has no unit and isn't meaningful on its own; it's intended for
+// comparing expressions to one another, such as to let a hosted evaluation
+// service reject or deprioritize unusually complex expressions before
+// spending time on them. The estimate only considers the shape of the
+// expression: it has no way to know, for example, how many elements a
+// variable reference will resolve to at evaluation time.
+//
+// The estimate is zero for any expression that doesn't support static
+// analysis of its syntax tree, such as one parsed from JSON syntax.
+func (s *Scope) EstimateCost(expr hcl.Expression) int {
+	node, ok := expr.(hclsyntax.Node)
+	if !ok {
+		return 0
+	}
+
+	w := &costWalker{}
+	hclsyntax.Walk(node, w)
+	return w.cost
+}
+
+type costWalker struct {
+	cost int
+}
+
+func (w *costWalker) Enter(node hclsyntax.Node) hcl.Diagnostics {
+	w.cost += baseNodeCost
+
+	switch node := node.(type) {
+	case *hclsyntax.FunctionCallExpr:
+		if functionHasCapability(node.Name, []Capability{CapabilityExpensive}) {
+			w.cost += expensiveFunctionCallCost
+		} else {
+			w.cost += functionCallCost
+		}
+	case *hclsyntax.ForExpr, *hclsyntax.TupleConsExpr, *hclsyntax.ObjectConsExpr, *hclsyntax.SplatExpr:
+		w.cost += collectionCost
+	}
+
+	return nil
+}
+
+func (w *costWalker) Exit(node hclsyntax.Node) hcl.Diagnostics {
+	return nil
+}
@@ -0,0 +1,36 @@
+package lang
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl2/hcl"
+	"github.com/hashicorp/hcl2/hcl/hclsyntax"
+)
+
+func TestScopeEstimateCost(t *testing.T) {
+	scope := &Scope{}
+
+	parse := func(t *testing.T, src string) hcl.Expression {
+		t.Helper()
+		expr, diags := hclsyntax.ParseExpression([]byte(src), "", hcl.InitialPos)
+		if diags.HasErrors() {
+			t.Fatalf("parse error: %s", diags)
+		}
+		return expr
+	}
+
+	simple := scope.EstimateCost(parse(t, `var.foo`))
+	withFunc := scope.EstimateCost(parse(t, `upper(var.foo)`))
+	withExpensiveFunc := scope.EstimateCost(parse(t, `bcrypt(var.foo)`))
+	withCollection := scope.EstimateCost(parse(t, `[for v in var.list : upper(v)]`))
+
+	if withFunc <= simple {
+		t.Errorf("expected upper(var.foo) (%d) to cost more than var.foo (%d)", withFunc, simple)
+	}
+	if withExpensiveFunc <= withFunc {
+		t.Errorf("expected bcrypt(var.foo) (%d) to cost more than upper(var.foo) (%d)", withExpensiveFunc, withFunc)
+	}
+	if withCollection <= withFunc {
+		t.Errorf("expected the for expression (%d) to cost more than upper(var.foo) (%d)", withCollection, withFunc)
+	}
+}
@@ -19,6 +19,13 @@ import (
 // place of the requested object so that type checking can still proceed. In
 // cases where it's not possible to even determine a suitable result type,
 // cty.DynamicVal is returned along with errors describing the problem.
+// Note that there is deliberately no GetSelf method: the "self" object is
+// not backed by its own kind of data, but is instead just an alias for
+// whatever other referenceable object is configured as Scope.SelfAddr, so
+// it's resolved by asking for that object through one of the other methods
+// below rather than through a method of its own. See Scope.evalContext in
+// eval.go, which substitutes addrs.Self for SelfAddr before dispatching to
+// these methods.
 type Data interface {
 	StaticValidateReferences(refs []*addrs.Reference, self addrs.Referenceable) tfdiags.Diagnostics
 
@@ -0,0 +1,63 @@
+package lang
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl2/hcl"
+	"github.com/hashicorp/hcl2/hcl/hclsyntax"
+)
+
+// deprecatedFunctionReplacements records functions that remain registered
+// for backward compatibility but are no longer recommended, along with the
+// name of the function that configurations should use instead.
+//
+// This allows names to be retired gradually: a deprecated function keeps
+// working exactly as before, but EvalExpr will also return a warning
+// diagnostic suggesting the replacement, so that the table of functions
+// can evolve over time without silently breaking configurations that were
+// written against an older version of Terraform.
+var deprecatedFunctionReplacements = map[string]string{
+	"list": "tolist",
+	"map":  "tomap",
+}
+
+// checkDeprecated returns a warning diagnostic for each call to a
+// deprecated function found in expr. It does nothing for expressions that
+// don't support static analysis of their function calls, such as those
+// parsed from JSON syntax.
+func checkDeprecated(expr hcl.Expression) hcl.Diagnostics {
+	node, ok := expr.(hclsyntax.Expression)
+	if !ok {
+		return nil
+	}
+
+	var diags hcl.Diagnostics
+	hclsyntax.Walk(node, &deprecatedFunctionWalker{diags: &diags})
+	return diags
+}
+
+type deprecatedFunctionWalker struct {
+	diags *hcl.Diagnostics
+}
+
+func (w *deprecatedFunctionWalker) Enter(node hclsyntax.Node) hcl.Diagnostics {
+	call, ok := node.(*hclsyntax.FunctionCallExpr)
+	if !ok {
+		return nil
+	}
+	replacement, deprecated := deprecatedFunctionReplacements[call.Name]
+	if !deprecated {
+		return nil
+	}
+	*w.diags = append(*w.diags, &hcl.Diagnostic{
+		Severity: hcl.DiagWarning,
+		Summary:  "Deprecated function",
+		Detail:   fmt.Sprintf("The %q function is deprecated. Use %q instead.", call.Name, replacement),
+		Subject:  call.NameRange.Ptr(),
+	})
+	return nil
+}
+
+func (w *deprecatedFunctionWalker) Exit(node hclsyntax.Node) hcl.Diagnostics {
+	return nil
+}
@@ -0,0 +1,60 @@
+package lang
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/hcl2/hcl"
+	"github.com/hashicorp/hcl2/hcl/hclsyntax"
+)
+
+func TestCheckDeprecated(t *testing.T) {
+	tests := []struct {
+		expr    string
+		wantSub string
+	}{
+		{
+			`upper("foo")`,
+			"",
+		},
+		{
+			`list("a", "b")`,
+			`Use "tolist" instead`,
+		},
+		{
+			`map("a", 1)`,
+			`Use "tomap" instead`,
+		},
+		{
+			`upper(list("a"))`,
+			`Use "tolist" instead`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			expr, parseDiags := hclsyntax.ParseExpression([]byte(test.expr), "", hcl.InitialPos)
+			if parseDiags.HasErrors() {
+				t.Fatalf("parse error: %s", parseDiags)
+			}
+
+			diags := checkDeprecated(expr)
+			if test.wantSub == "" {
+				if len(diags) != 0 {
+					t.Fatalf("unexpected diagnostics: %s", diags)
+				}
+				return
+			}
+
+			if len(diags) != 1 {
+				t.Fatalf("expected exactly one diagnostic, got %d: %s", len(diags), diags)
+			}
+			if diags[0].Severity != hcl.DiagWarning {
+				t.Fatalf("expected a warning, got %#v", diags[0])
+			}
+			if got := diags[0].Detail; !strings.Contains(got, test.wantSub) {
+				t.Fatalf("wrong detail\ngot:  %s\nwant substring: %s", got, test.wantSub)
+			}
+		})
+	}
+}
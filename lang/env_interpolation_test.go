@@ -0,0 +1,34 @@
+package lang
+
+import (
+	"os"
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestScopeEnableEnvInterpolation(t *testing.T) {
+	scope := &Scope{}
+	if _, exists := scope.Functions()["env"]; exists {
+		t.Fatal(`"env" function is available without EnableEnvInterpolation`)
+	}
+
+	if err := os.Setenv("TF_LANG_ENV_TEST", "hello"); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Unsetenv("TF_LANG_ENV_TEST")
+
+	scope = &Scope{EnableEnvInterpolation: true}
+	f, exists := scope.Functions()["env"]
+	if !exists {
+		t.Fatal(`"env" function is not available with EnableEnvInterpolation`)
+	}
+
+	got, err := f.Call([]cty.Value{cty.StringVal("TF_LANG_ENV_TEST")})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if want := cty.StringVal("hello"); !got.RawEquals(want) {
+		t.Errorf("wrong result\ngot:  %#v\nwant: %#v", got, want)
+	}
+}
@@ -1,6 +1,7 @@
 package lang
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"strconv"
@@ -82,18 +83,40 @@ func (s *Scope) EvalBlock(body hcl.Body, schema *configschema.Block) (cty.Value,
 // If the returned diagnostics contains errors then the result may be
 // incomplete, but will always be of the requested type.
 func (s *Scope) EvalExpr(expr hcl.Expression, wantType cty.Type) (cty.Value, tfdiags.Diagnostics) {
+	val, _, diags := s.evalExpr(expr, wantType)
+	return val, diags
+}
+
+// EvalExprAndProvenance is like EvalExpr except that, when the result is not
+// wholly known, it also returns the references found in expr whose own
+// values were not wholly known, as the likely contributors to the unknown
+// result. This allows a caller producing a "value not known until apply"
+// message to name the actual upstream resource attribute responsible,
+// rather than just gesturing at the expression as a whole.
+//
+// The returned slice is nil whenever the result is wholly known.
+func (s *Scope) EvalExprAndProvenance(expr hcl.Expression, wantType cty.Type) (cty.Value, []*addrs.Reference, tfdiags.Diagnostics) {
+	return s.evalExpr(expr, wantType)
+}
+
+func (s *Scope) evalExpr(expr hcl.Expression, wantType cty.Type) (cty.Value, []*addrs.Reference, tfdiags.Diagnostics) {
 	refs, diags := ReferencesInExpr(expr)
 
-	ctx, ctxDiags := s.EvalContext(refs)
+	ctx, unknownRefs, ctxDiags := s.evalContext(refs, s.SelfAddr)
 	diags = diags.Append(ctxDiags)
 	if diags.HasErrors() {
 		// We'll stop early if we found problems in the references, because
 		// it's likely evaluation will produce redundant copies of the same errors.
-		return cty.UnknownVal(wantType), diags
+		return cty.UnknownVal(wantType), nil, diags
 	}
 
-	val, evalDiags := expr.Value(ctx)
-	diags = diags.Append(evalDiags)
+	val, hit := s.ExprFoldCache.get(expr)
+	if !hit {
+		var evalDiags hcl.Diagnostics
+		val, evalDiags = evalExprWithTryCan(expr, ctx)
+		diags = diags.Append(evalDiags)
+		s.ExprFoldCache.set(expr, val)
+	}
 
 	if wantType != cty.DynamicPseudoType {
 		var convErr error
@@ -109,7 +132,60 @@ func (s *Scope) EvalExpr(expr hcl.Expression, wantType cty.Type) (cty.Value, tfd
 		}
 	}
 
-	return val, diags
+	if s.Limits != nil {
+		diags = diags.Append(s.checkLimits(expr, val))
+	}
+
+	diags = diags.Append(checkDeprecated(expr))
+
+	if s.Tracer != nil {
+		s.Tracer.OnResult(expr, val, diags)
+	}
+
+	if val.IsWhollyKnown() {
+		unknownRefs = nil
+	}
+
+	return val, unknownRefs, diags
+}
+
+// EvalExprContext is like EvalExpr except that it aborts early if the given
+// context is cancelled or its deadline elapses before evaluation completes.
+//
+// This is intended for expressions that are suspected of being pathological,
+// such as those built from user-provided regular expressions passed to
+// regexall or similarly expensive functions, where normal evaluation could
+// otherwise block the caller indefinitely. If the context ends before
+// evaluation finishes, the returned diagnostics will describe the
+// cancellation and the result will be an unknown value of the requested
+// type. Note that the underlying evaluation goroutine is not forcibly
+// stopped, so it will continue running in the background until it completes
+// on its own.
+func (s *Scope) EvalExprContext(ctx context.Context, expr hcl.Expression, wantType cty.Type) (cty.Value, tfdiags.Diagnostics) {
+	type evalResult struct {
+		val   cty.Value
+		diags tfdiags.Diagnostics
+	}
+
+	resultCh := make(chan evalResult, 1)
+	go func() {
+		val, diags := s.EvalExpr(expr, wantType)
+		resultCh <- evalResult{val, diags}
+	}()
+
+	select {
+	case result := <-resultCh:
+		return result.val, result.diags
+	case <-ctx.Done():
+		var diags tfdiags.Diagnostics
+		diags = diags.Append(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Expression evaluation timed out",
+			Detail:   fmt.Sprintf("Evaluation of this expression did not complete before the context was cancelled: %s.", ctx.Err()),
+			Subject:  expr.Range().Ptr(),
+		})
+		return cty.UnknownVal(wantType), diags
+	}
 }
 
 // EvalReference evaluates the given reference in the receiving scope and
@@ -128,7 +204,7 @@ func (s *Scope) EvalReference(ref *addrs.Reference, wantType cty.Type) (cty.Valu
 	// We cheat a bit here and just build an EvalContext for our requested
 	// reference with the "self" address overridden, and then pull the "self"
 	// result out of it to return.
-	ctx, ctxDiags := s.evalContext([]*addrs.Reference{ref}, ref.Subject)
+	ctx, _, ctxDiags := s.evalContext([]*addrs.Reference{ref}, ref.Subject)
 	diags = diags.Append(ctxDiags)
 	val := ctx.Variables["self"]
 	if val == cty.NilVal {
@@ -157,15 +233,21 @@ func (s *Scope) EvalReference(ref *addrs.Reference, wantType cty.Type) (cty.Valu
 // this type offers, but this is here for less common situations where the
 // caller will handle the evaluation calls itself.
 func (s *Scope) EvalContext(refs []*addrs.Reference) (*hcl.EvalContext, tfdiags.Diagnostics) {
-	return s.evalContext(refs, s.SelfAddr)
+	ctx, _, diags := s.evalContext(refs, s.SelfAddr)
+	return ctx, diags
 }
 
-func (s *Scope) evalContext(refs []*addrs.Reference, selfAddr addrs.Referenceable) (*hcl.EvalContext, tfdiags.Diagnostics) {
+// evalContext is like EvalContext but additionally returns the subset of
+// refs whose resolved values were not wholly known, so that callers
+// evaluating a whole expression can report which references likely
+// contributed to an unknown result.
+func (s *Scope) evalContext(refs []*addrs.Reference, selfAddr addrs.Referenceable) (*hcl.EvalContext, []*addrs.Reference, tfdiags.Diagnostics) {
 	if s == nil {
 		panic("attempt to construct EvalContext for nil Scope")
 	}
 
 	var diags tfdiags.Diagnostics
+	var unknownRefs []*addrs.Reference
 	vals := make(map[string]cty.Value)
 	funcs := s.Functions()
 	ctx := &hcl.EvalContext{
@@ -175,7 +257,7 @@ func (s *Scope) evalContext(refs []*addrs.Reference, selfAddr addrs.Referenceabl
 
 	if len(refs) == 0 {
 		// Easy path for common case where there are no references at all.
-		return ctx, diags
+		return ctx, unknownRefs, diags
 	}
 
 	// First we'll do static validation of the references. This catches things
@@ -183,7 +265,7 @@ func (s *Scope) evalContext(refs []*addrs.Reference, selfAddr addrs.Referenceabl
 	// present in the scope during planning.
 	if staticDiags := s.Data.StaticValidateReferences(refs, selfAddr); staticDiags.HasErrors() {
 		diags = diags.Append(staticDiags)
-		return ctx, diags
+		return ctx, unknownRefs, diags
 	}
 
 	// The reference set we are given has not been de-duped, and so there can
@@ -238,6 +320,7 @@ func (s *Scope) evalContext(refs []*addrs.Reference, selfAddr addrs.Referenceabl
 
 		// This type switch must cover all of the "Referenceable" implementations
 		// in package addrs.
+		var refVal cty.Value
 		switch subj := rawSubj.(type) {
 
 		case addrs.ResourceInstance:
@@ -253,6 +336,7 @@ func (s *Scope) evalContext(refs []*addrs.Reference, selfAddr addrs.Referenceabl
 
 			val, valDiags := normalizeRefValue(s.Data.GetResourceInstance(subj, rng))
 			diags = diags.Append(valDiags)
+			refVal = val
 
 			r := subj.Resource
 			if into[r.Type] == nil {
@@ -269,6 +353,7 @@ func (s *Scope) evalContext(refs []*addrs.Reference, selfAddr addrs.Referenceabl
 		case addrs.ModuleCallInstance:
 			val, valDiags := normalizeRefValue(s.Data.GetModuleInstance(subj, rng))
 			diags = diags.Append(valDiags)
+			refVal = val
 
 			if wholeModules[subj.Call.Name] == nil {
 				wholeModules[subj.Call.Name] = make(map[addrs.InstanceKey]cty.Value)
@@ -281,6 +366,7 @@ func (s *Scope) evalContext(refs []*addrs.Reference, selfAddr addrs.Referenceabl
 		case addrs.ModuleCallOutput:
 			val, valDiags := normalizeRefValue(s.Data.GetModuleInstanceOutput(subj, rng))
 			diags = diags.Append(valDiags)
+			refVal = val
 
 			callName := subj.Call.Call.Name
 			callKey := subj.Call.Key
@@ -298,6 +384,7 @@ func (s *Scope) evalContext(refs []*addrs.Reference, selfAddr addrs.Referenceabl
 		case addrs.InputVariable:
 			val, valDiags := normalizeRefValue(s.Data.GetInputVariable(subj, rng))
 			diags = diags.Append(valDiags)
+			refVal = val
 			inputVariables[subj.Name] = val
 			if isSelf {
 				self = val
@@ -306,6 +393,7 @@ func (s *Scope) evalContext(refs []*addrs.Reference, selfAddr addrs.Referenceabl
 		case addrs.LocalValue:
 			val, valDiags := normalizeRefValue(s.Data.GetLocalValue(subj, rng))
 			diags = diags.Append(valDiags)
+			refVal = val
 			localValues[subj.Name] = val
 			if isSelf {
 				self = val
@@ -314,6 +402,7 @@ func (s *Scope) evalContext(refs []*addrs.Reference, selfAddr addrs.Referenceabl
 		case addrs.PathAttr:
 			val, valDiags := normalizeRefValue(s.Data.GetPathAttr(subj, rng))
 			diags = diags.Append(valDiags)
+			refVal = val
 			pathAttrs[subj.Name] = val
 			if isSelf {
 				self = val
@@ -322,6 +411,7 @@ func (s *Scope) evalContext(refs []*addrs.Reference, selfAddr addrs.Referenceabl
 		case addrs.TerraformAttr:
 			val, valDiags := normalizeRefValue(s.Data.GetTerraformAttr(subj, rng))
 			diags = diags.Append(valDiags)
+			refVal = val
 			terraformAttrs[subj.Name] = val
 			if isSelf {
 				self = val
@@ -330,6 +420,7 @@ func (s *Scope) evalContext(refs []*addrs.Reference, selfAddr addrs.Referenceabl
 		case addrs.CountAttr:
 			val, valDiags := normalizeRefValue(s.Data.GetCountAttr(subj, rng))
 			diags = diags.Append(valDiags)
+			refVal = val
 			countAttrs[subj.Name] = val
 			if isSelf {
 				self = val
@@ -338,6 +429,7 @@ func (s *Scope) evalContext(refs []*addrs.Reference, selfAddr addrs.Referenceabl
 		case addrs.ForEachAttr:
 			val, valDiags := normalizeRefValue(s.Data.GetForEachAttr(subj, rng))
 			diags = diags.Append(valDiags)
+			refVal = val
 			forEachAttrs[subj.Name] = val
 			if isSelf {
 				self = val
@@ -347,6 +439,13 @@ func (s *Scope) evalContext(refs []*addrs.Reference, selfAddr addrs.Referenceabl
 			// Should never happen
 			panic(fmt.Errorf("Scope.buildEvalContext cannot handle address type %T", rawSubj))
 		}
+
+		if s.Tracer != nil {
+			s.Tracer.OnVariableLookup(ref, refVal)
+		}
+		if refVal != cty.NilVal && !refVal.IsWhollyKnown() {
+			unknownRefs = append(unknownRefs, ref)
+		}
 	}
 
 	for k, v := range buildResourceObjects(managedResources) {
@@ -364,7 +463,7 @@ func (s *Scope) evalContext(refs []*addrs.Reference, selfAddr addrs.Referenceabl
 		vals["self"] = self
 	}
 
-	return ctx, diags
+	return ctx, unknownRefs, diags
 }
 
 func buildResourceObjects(resources map[string]map[string]map[addrs.InstanceKey]cty.Value) map[string]cty.Value {
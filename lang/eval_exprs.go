@@ -0,0 +1,58 @@
+package lang
+
+import (
+	"sync"
+
+	"github.com/hashicorp/hcl2/hcl"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+// evalExprsParallelism bounds the number of expressions EvalExprs will
+// evaluate at once. It's deliberately modest: evaluation is usually
+// CPU-bound rather than I/O-bound, so there's little to be gained by
+// spawning one goroutine per expression for configurations with hundreds
+// of outputs or locals.
+const evalExprsParallelism = 10
+
+// EvalExprs evaluates each of the given expressions against the receiving
+// scope, as EvalExpr would, and returns the results in the same order as
+// exprs.
+//
+// Evaluating an expression through this Scope only reads from its
+// configured Data and does not write back any new values, so the given
+// expressions have no dependencies on one another from this package's
+// point of view: it's the caller's responsibility to pass in only
+// expressions that are safe to evaluate concurrently, such as the output
+// or local value expressions of a single module, which all read from
+// state that's already been fully computed by the time evaluation begins.
+//
+// Expressions are evaluated using a bounded pool of workers rather than
+// one goroutine per expression, which gives a measurable speedup for
+// modules with hundreds of outputs or locals without placing unbounded
+// load on the machine running Terraform.
+func (s *Scope) EvalExprs(exprs []hcl.Expression) ([]cty.Value, tfdiags.Diagnostics) {
+	vals := make([]cty.Value, len(exprs))
+	exprDiags := make([]tfdiags.Diagnostics, len(exprs))
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, evalExprsParallelism)
+	for i, expr := range exprs {
+		i, expr := i, expr
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			vals[i], exprDiags[i] = s.EvalExpr(expr, cty.DynamicPseudoType)
+		}()
+	}
+	wg.Wait()
+
+	var diags tfdiags.Diagnostics
+	for _, d := range exprDiags {
+		diags = diags.Append(d)
+	}
+	return vals, diags
+}
@@ -0,0 +1,68 @@
+package lang
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl2/hcl"
+	"github.com/hashicorp/hcl2/hcl/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestScopeEvalExprs(t *testing.T) {
+	scope := &Scope{
+		Data: &dataForTests{
+			InputVariables: map[string]cty.Value{
+				"foo": cty.StringVal("bar"),
+			},
+		},
+	}
+
+	srcs := []string{
+		`upper(var.foo)`,
+		`"literal"`,
+		`1 + 1`,
+	}
+	exprs := make([]hcl.Expression, len(srcs))
+	for i, src := range srcs {
+		expr, parseDiags := hclsyntax.ParseExpression([]byte(src), "", hcl.InitialPos)
+		if parseDiags.HasErrors() {
+			t.Fatalf("parse error: %s", parseDiags)
+		}
+		exprs[i] = expr
+	}
+
+	got, diags := scope.EvalExprs(exprs)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+
+	want := []cty.Value{
+		cty.StringVal("BAR"),
+		cty.StringVal("literal"),
+		cty.NumberIntVal(2),
+	}
+	if len(got) != len(want) {
+		t.Fatalf("wrong number of results: got %d, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if !got[i].RawEquals(want[i]) {
+			t.Errorf("wrong result for %q\ngot:  %#v\nwant: %#v", srcs[i], got[i], want[i])
+		}
+	}
+}
+
+func TestScopeEvalExprsErrors(t *testing.T) {
+	scope := &Scope{
+		Data: &dataForTests{},
+	}
+
+	expr, parseDiags := hclsyntax.ParseExpression([]byte(`var.nonexistent.attr.does.not.parse.as.a.ref!`), "", hcl.InitialPos)
+	if !parseDiags.HasErrors() {
+		t.Fatalf("expected a parse error to set up this test")
+	}
+
+	_, diags := scope.EvalExprs([]hcl.Expression{expr})
+	if !diags.HasErrors() {
+		t.Fatalf("expected errors, got none")
+	}
+}
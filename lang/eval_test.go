@@ -2,6 +2,7 @@ package lang
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"testing"
 
@@ -10,6 +11,7 @@ import (
 
 	"github.com/hashicorp/hcl2/hcl"
 	"github.com/hashicorp/hcl2/hcl/hclsyntax"
+	"github.com/hashicorp/hcl2/hcltest"
 
 	"github.com/zclconf/go-cty/cty"
 	ctyjson "github.com/zclconf/go-cty/cty/json"
@@ -54,6 +56,8 @@ func TestScopeEvalContext(t *testing.T) {
 		},
 		PathAttrs: map[string]cty.Value{
 			"module": cty.StringVal("foo/bar"),
+			"root":   cty.StringVal("foo/bar"),
+			"cwd":    cty.StringVal("/tmp/foo/bar"),
 		},
 		TerraformAttrs: map[string]cty.Value{
 			"workspace": cty.StringVal("default"),
@@ -207,6 +211,22 @@ func TestScopeEvalContext(t *testing.T) {
 				}),
 			},
 		},
+		{
+			`path.root`,
+			map[string]cty.Value{
+				"path": cty.ObjectVal(map[string]cty.Value{
+					"root": cty.StringVal("foo/bar"),
+				}),
+			},
+		},
+		{
+			`path.cwd`,
+			map[string]cty.Value{
+				"path": cty.ObjectVal(map[string]cty.Value{
+					"cwd": cty.StringVal("/tmp/foo/bar"),
+				}),
+			},
+		},
 		{
 			`self.baz`,
 			map[string]cty.Value{
@@ -305,6 +325,82 @@ func TestScopeEvalContext(t *testing.T) {
 	}
 }
 
+// TestScopeEvalExprSelfCountEach confirms that the "self", "count" and
+// "each" block-local symbols can all be resolved through a single Scope at
+// once, since in a real configuration a resource block inside a
+// provisioner or connection block could reference all three together.
+func TestScopeEvalExprSelfCountEach(t *testing.T) {
+	data := &dataForTests{
+		CountAttrs: map[string]cty.Value{
+			"index": cty.NumberIntVal(1),
+		},
+		ForEachAttrs: map[string]cty.Value{
+			"key": cty.StringVal("a"),
+		},
+		ResourceInstances: map[string]cty.Value{
+			"null_resource.multi[1]": cty.ObjectVal(map[string]cty.Value{
+				"attr": cty.StringVal("multi1"),
+			}),
+		},
+	}
+
+	scope := &Scope{
+		Data: data,
+		SelfAddr: addrs.ResourceInstance{
+			Resource: addrs.Resource{
+				Mode: addrs.ManagedResourceMode,
+				Type: "null_resource",
+				Name: "multi",
+			},
+			Key: addrs.IntKey(1),
+		},
+	}
+
+	expr, parseDiags := hclsyntax.ParseExpression([]byte(`"${self.attr}-${count.index}-${each.key}"`), "", hcl.Pos{Line: 1, Column: 1})
+	if parseDiags.HasErrors() {
+		t.Fatalf("parse error: %s", parseDiags)
+	}
+
+	got, diags := scope.EvalExpr(expr, cty.String)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+	want := cty.StringVal("multi1-1-a")
+	if !got.RawEquals(want) {
+		t.Errorf("wrong result\ngot:  %#v\nwant: %#v", got, want)
+	}
+}
+
+func TestScopeEvalExprContext(t *testing.T) {
+	scope := &Scope{
+		Data: &dataForTests{},
+	}
+
+	t.Run("completes normally", func(t *testing.T) {
+		expr := hcltest.MockExprLiteral(cty.StringVal("hello"))
+		got, diags := scope.EvalExprContext(context.Background(), expr, cty.String)
+		if diags.HasErrors() {
+			t.Fatalf("unexpected errors: %s", diags.Err())
+		}
+		if !got.RawEquals(cty.StringVal("hello")) {
+			t.Errorf("wrong result %#v", got)
+		}
+	})
+
+	t.Run("cancelled context", func(t *testing.T) {
+		expr := hcltest.MockExprLiteral(cty.StringVal("hello"))
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		got, diags := scope.EvalExprContext(ctx, expr, cty.String)
+		if !diags.HasErrors() {
+			t.Fatalf("expected an error diagnostic for a pre-cancelled context")
+		}
+		if got.IsKnown() {
+			t.Errorf("expected an unknown result, got %#v", got)
+		}
+	})
+}
+
 func TestScopeExpandEvalBlock(t *testing.T) {
 	nestedObjTy := cty.Object(map[string]cty.Type{
 		"boop": cty.String,
@@ -0,0 +1,51 @@
+package lang
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestScopeEnableExternalFunction(t *testing.T) {
+	scope := &Scope{}
+	if _, exists := scope.Functions()["external"]; exists {
+		t.Fatal(`"external" function is available without EnableExternalFunction`)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	program := filepath.Join(wd, "funcs/testdata/external-test/run")
+
+	scope = &Scope{
+		EnableExternalFunction: true,
+		ExternalPrograms:       []string{program},
+	}
+	f, exists := scope.Functions()["external"]
+	if !exists {
+		t.Fatal(`"external" function is not available with EnableExternalFunction`)
+	}
+
+	got, err := f.Call([]cty.Value{
+		cty.StringVal(program),
+		cty.MapVal(map[string]cty.Value{"greeting": cty.StringVal("hello")}),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	want := cty.MapVal(map[string]cty.Value{"greeting": cty.StringVal("hello-result")})
+	if !got.RawEquals(want) {
+		t.Errorf("wrong result\ngot:  %#v\nwant: %#v", got, want)
+	}
+
+	_, err = f.Call([]cty.Value{
+		cty.StringVal("/usr/bin/not-allowlisted"),
+		cty.MapVal(map[string]cty.Value{"greeting": cty.StringVal("hello")}),
+	})
+	if err == nil {
+		t.Fatal(`calling a program outside ExternalPrograms succeeded; want error`)
+	}
+}
@@ -0,0 +1,89 @@
+package lang
+
+import (
+	"github.com/hashicorp/hcl2/hcl"
+	"github.com/hashicorp/hcl2/hcl/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// FoldConstants evaluates expr using only scope's functions, with no
+// variables in scope at all, and returns ok as false if that's not
+// possible.
+//
+// An expression can only be folded this way if it contains no reference to
+// a block-local or module-local symbol -- a variable, a resource
+// attribute, count.index, and so on -- since resolving one of those
+// requires a Data source. A "constant" in this sense can still be a
+// combination of literal values, operators, and function calls over other
+// constants, such as a string concatenation of two literals or a call to
+// upper() on a literal string: none of those need anything beyond the
+// function table to evaluate.
+func FoldConstants(scope *Scope, expr hcl.Expression) (cty.Value, bool) {
+	if len(expr.Variables()) > 0 {
+		return cty.NilVal, false
+	}
+
+	ctx := &hcl.EvalContext{
+		Functions: scope.Functions(),
+	}
+	val, diags := expr.Value(ctx)
+	if diags.HasErrors() {
+		return cty.NilVal, false
+	}
+	return val, true
+}
+
+// WarmConstantFoldCache walks expr looking for constant sub-expressions --
+// ones that FoldConstants can evaluate without a Data source -- and
+// evaluates and records each one found in scope.ExprFoldCache, skipping
+// any that are already present.
+//
+// This is a no-op if scope.ExprFoldCache is nil, and it does nothing for
+// expressions that don't support static analysis of their syntax tree,
+// such as those parsed from JSON syntax.
+//
+// Calling this ahead of time for an expression that's about to be
+// evaluated anyway is harmless but redundant, since Scope.EvalExpr already
+// populates the cache itself as a side effect once it evaluates a constant
+// top-level expression. Where this pulls its weight is for sub-expressions
+// that are evaluated individually and repeatedly by some other part of
+// Terraform -- such as the elements of a large literal list assigned to a
+// resource argument that's read once per instance of that resource -- and
+// so would otherwise be re-folded on every one of those reads.
+//
+// Note that the cache is keyed by each sub-expression's position in its
+// source file, not by its content, so two constant sub-expressions that
+// happen to be written identically in two different locations are not
+// recognized as equivalent and are folded and cached separately.
+func (s *Scope) WarmConstantFoldCache(expr hcl.Expression) {
+	if s.ExprFoldCache == nil {
+		return
+	}
+	node, ok := expr.(hclsyntax.Node)
+	if !ok {
+		return
+	}
+	hclsyntax.Walk(node, &foldWarmerWalker{scope: s})
+}
+
+type foldWarmerWalker struct {
+	scope *Scope
+}
+
+func (w *foldWarmerWalker) Enter(node hclsyntax.Node) hcl.Diagnostics {
+	expr, ok := node.(hclsyntax.Expression)
+	if !ok {
+		return nil
+	}
+	if _, hit := w.scope.ExprFoldCache.get(expr); hit {
+		return nil
+	}
+	if val, ok := FoldConstants(w.scope, expr); ok {
+		w.scope.ExprFoldCache.set(expr, val)
+	}
+	return nil
+}
+
+func (w *foldWarmerWalker) Exit(node hclsyntax.Node) hcl.Diagnostics {
+	return nil
+}
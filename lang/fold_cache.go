@@ -0,0 +1,174 @@
+package lang
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+
+	"github.com/hashicorp/hcl2/hcl"
+	"github.com/hashicorp/hcl2/hcl/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// ExprFoldCache is a cache of previously-evaluated results for pure,
+// wholly-known sub-expressions, keyed by a hash of each expression's
+// position in its source file.
+//
+// Its purpose is to let a result computed once during "terraform plan" be
+// reused again during the "terraform apply" that follows it, rather than
+// re-evaluating an expensive expression that can't have changed in the
+// meantime. Only expressions that evaluated to a wholly-known value and
+// that don't call any function tagged with CapabilityNondeterministic are
+// recorded, since those are the only ones guaranteed to produce the same
+// result both times.
+//
+// Unlike FunctionCache, which memoizes individual function calls, an
+// ExprFoldCache records the result of an entire expression, so it also
+// benefits pure expressions that call no functions at all, such as
+// [for k, v in var.map : k if v.enabled].
+//
+// The cache can be serialized with Snapshot and restored with
+// RestoreSnapshot, so that a plan can persist what it computed somewhere
+// the following apply can read it back from, such as embedded in the
+// plan file. A single ExprFoldCache must never be reused across two
+// unrelated plans, since its keys don't account for configuration changes
+// that move or rewrite an expression without changing its file position.
+//
+// A nil *ExprFoldCache behaves as an always-empty, write-discarding cache,
+// so it's safe to leave a Scope's field unset when this behavior isn't
+// wanted.
+//
+// See FoldConstants and Scope.WarmConstantFoldCache in fold.go for a way
+// to populate a cache like this ahead of time for expressions that don't
+// need a Data source to evaluate at all.
+type ExprFoldCache struct {
+	mu      sync.Mutex
+	results map[string]cty.Value
+}
+
+// NewExprFoldCache returns a new, empty ExprFoldCache.
+func NewExprFoldCache() *ExprFoldCache {
+	return &ExprFoldCache{
+		results: make(map[string]cty.Value),
+	}
+}
+
+// ExprFoldCacheSnapshot is the serializable form of an ExprFoldCache's
+// contents, suitable for persisting between a plan and the apply that
+// follows it.
+type ExprFoldCacheSnapshot map[string][]byte
+
+// Snapshot returns a serializable copy of the cache's current contents.
+func (c *ExprFoldCache) Snapshot() ExprFoldCacheSnapshot {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	snap := make(ExprFoldCacheSnapshot, len(c.results))
+	for key, val := range c.results {
+		raw, err := ctyjson.Marshal(val, cty.DynamicPseudoType)
+		if err != nil {
+			// Can't happen for a value this cache would have stored in
+			// the first place, but skip it rather than corrupt the
+			// snapshot if it somehow does.
+			continue
+		}
+		snap[key] = raw
+	}
+	return snap
+}
+
+// RestoreSnapshot replaces the cache's contents with those from a snapshot
+// previously produced by Snapshot, such as one saved from a prior
+// "terraform plan" run and loaded back in for the following
+// "terraform apply".
+func (c *ExprFoldCache) RestoreSnapshot(snap ExprFoldCacheSnapshot) {
+	if c == nil || snap == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.results = make(map[string]cty.Value, len(snap))
+	for key, raw := range snap {
+		val, err := ctyjson.Unmarshal(raw, cty.DynamicPseudoType)
+		if err != nil {
+			// Ignore entries we can't decode; at worst this just means
+			// losing the benefit of caching that one expression.
+			continue
+		}
+		c.results[key] = val
+	}
+}
+
+func (c *ExprFoldCache) get(expr hcl.Expression) (cty.Value, bool) {
+	if c == nil {
+		return cty.NilVal, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	val, ok := c.results[exprFoldKey(expr)]
+	return val, ok
+}
+
+// set records val as the result of evaluating expr, as long as val is
+// wholly known and expr is safe to treat as pure. It does nothing
+// otherwise, and does nothing at all if c is nil.
+func (c *ExprFoldCache) set(expr hcl.Expression, val cty.Value) {
+	if c == nil || !val.IsWhollyKnown() || !exprIsPure(expr) {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.results[exprFoldKey(expr)] = val
+}
+
+// exprFoldKey returns the cache key for expr: a hash of its position
+// within its source file. This stands in for a hash of the expression's
+// actual source text, which the hcl.Expression interface doesn't expose,
+// but serves the same purpose as long as the configuration file hasn't
+// changed between when the key was produced and when it's looked up
+// again -- which always holds between a plan and the apply that
+// immediately follows it.
+func exprFoldKey(expr hcl.Expression) string {
+	sum := sha256.Sum256([]byte(expr.Range().String()))
+	return hex.EncodeToString(sum[:])
+}
+
+// exprIsPure returns true if expr contains no call to a function tagged
+// with CapabilityNondeterministic, making its result safe to cache and
+// reuse as long as it was also wholly known.
+//
+// Expressions that don't support static analysis of their syntax tree,
+// such as those parsed from JSON syntax, are conservatively treated as
+// not pure.
+func exprIsPure(expr hcl.Expression) bool {
+	node, ok := expr.(hclsyntax.Node)
+	if !ok {
+		return false
+	}
+
+	w := &impureCallWalker{}
+	hclsyntax.Walk(node, w)
+	return !w.foundImpureCall
+}
+
+type impureCallWalker struct {
+	foundImpureCall bool
+}
+
+func (w *impureCallWalker) Enter(node hclsyntax.Node) hcl.Diagnostics {
+	if call, ok := node.(*hclsyntax.FunctionCallExpr); ok {
+		if functionHasCapability(call.Name, []Capability{CapabilityNondeterministic}) {
+			w.foundImpureCall = true
+		}
+	}
+	return nil
+}
+
+func (w *impureCallWalker) Exit(node hclsyntax.Node) hcl.Diagnostics {
+	return nil
+}
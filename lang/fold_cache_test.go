@@ -0,0 +1,91 @@
+package lang
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl2/hcl"
+	"github.com/hashicorp/hcl2/hcl/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestExprFoldCacheSnapshotRoundTrip(t *testing.T) {
+	expr, diags := hclsyntax.ParseExpression([]byte(`upper("hello")`), "test.tf", hcl.InitialPos)
+	if diags.HasErrors() {
+		t.Fatalf("parse error: %s", diags)
+	}
+
+	cache := NewExprFoldCache()
+	cache.set(expr, cty.StringVal("HELLO"))
+
+	snap := cache.Snapshot()
+	if len(snap) != 1 {
+		t.Fatalf("expected one entry in the snapshot, got %d", len(snap))
+	}
+
+	restored := NewExprFoldCache()
+	restored.RestoreSnapshot(snap)
+
+	got, ok := restored.get(expr)
+	if !ok {
+		t.Fatal("expected a cache hit after restoring the snapshot")
+	}
+	if !got.RawEquals(cty.StringVal("HELLO")) {
+		t.Errorf("wrong result\ngot:  %#v\nwant: %#v", got, cty.StringVal("HELLO"))
+	}
+}
+
+func TestExprFoldCacheIgnoresImpureAndUnknown(t *testing.T) {
+	cache := NewExprFoldCache()
+
+	impure, diags := hclsyntax.ParseExpression([]byte(`uuid()`), "test.tf", hcl.InitialPos)
+	if diags.HasErrors() {
+		t.Fatalf("parse error: %s", diags)
+	}
+	cache.set(impure, cty.StringVal("not-actually-a-uuid"))
+	if _, ok := cache.get(impure); ok {
+		t.Error("expected no cache entry for an impure expression")
+	}
+
+	pure, diags := hclsyntax.ParseExpression([]byte(`upper("hi")`), "test.tf", hcl.InitialPos)
+	if diags.HasErrors() {
+		t.Fatalf("parse error: %s", diags)
+	}
+	cache.set(pure, cty.UnknownVal(cty.String))
+	if _, ok := cache.get(pure); ok {
+		t.Error("expected no cache entry for an unknown result")
+	}
+}
+
+func TestScopeEvalExprFoldCache(t *testing.T) {
+	cache := NewExprFoldCache()
+	scope := &Scope{ExprFoldCache: cache}
+
+	expr, diags := hclsyntax.ParseExpression([]byte(`upper("hello")`), "test.tf", hcl.InitialPos)
+	if diags.HasErrors() {
+		t.Fatalf("parse error: %s", diags)
+	}
+
+	got, evalDiags := scope.EvalExpr(expr, cty.String)
+	if evalDiags.HasErrors() {
+		t.Fatalf("unexpected error: %s", evalDiags.Err())
+	}
+	if !got.RawEquals(cty.StringVal("HELLO")) {
+		t.Fatalf("wrong result %#v", got)
+	}
+
+	if _, hit := cache.get(expr); !hit {
+		t.Fatal("expected the expression's result to have been cached")
+	}
+
+	// A fresh Scope sharing the same cache should reuse the cached result
+	// without needing a Data source to resolve anything, simulating reuse
+	// across a plan/apply boundary.
+	scope2 := &Scope{ExprFoldCache: cache}
+	got2, evalDiags := scope2.EvalExpr(expr, cty.String)
+	if evalDiags.HasErrors() {
+		t.Fatalf("unexpected error: %s", evalDiags.Err())
+	}
+	if !got2.RawEquals(cty.StringVal("HELLO")) {
+		t.Fatalf("wrong result %#v", got2)
+	}
+}
@@ -0,0 +1,79 @@
+package lang
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl2/hcl"
+	"github.com/hashicorp/hcl2/hcl/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestFoldConstants(t *testing.T) {
+	scope := &Scope{}
+
+	t.Run("arithmetic", func(t *testing.T) {
+		expr, diags := hclsyntax.ParseExpression([]byte(`1 + 2 * 3`), "test.tf", hcl.InitialPos)
+		if diags.HasErrors() {
+			t.Fatalf("parse error: %s", diags)
+		}
+		got, ok := FoldConstants(scope, expr)
+		if !ok {
+			t.Fatal("expected to be able to fold a purely-arithmetic expression")
+		}
+		if !got.RawEquals(cty.NumberIntVal(7)) {
+			t.Errorf("wrong result %#v", got)
+		}
+	})
+
+	t.Run("function call on literals", func(t *testing.T) {
+		expr, diags := hclsyntax.ParseExpression([]byte(`"${upper("a")}-${lower("B")}"`), "test.tf", hcl.InitialPos)
+		if diags.HasErrors() {
+			t.Fatalf("parse error: %s", diags)
+		}
+		got, ok := FoldConstants(scope, expr)
+		if !ok {
+			t.Fatal("expected to be able to fold a function call over literals")
+		}
+		if !got.RawEquals(cty.StringVal("A-b")) {
+			t.Errorf("wrong result %#v", got)
+		}
+	})
+
+	t.Run("reference to a variable", func(t *testing.T) {
+		expr, diags := hclsyntax.ParseExpression([]byte(`upper(var.foo)`), "test.tf", hcl.InitialPos)
+		if diags.HasErrors() {
+			t.Fatalf("parse error: %s", diags)
+		}
+		if _, ok := FoldConstants(scope, expr); ok {
+			t.Fatal("expected not to be able to fold an expression referencing a variable")
+		}
+	})
+}
+
+func TestScopeWarmConstantFoldCache(t *testing.T) {
+	cache := NewExprFoldCache()
+	scope := &Scope{ExprFoldCache: cache}
+
+	expr, diags := hclsyntax.ParseExpression([]byte(`["${upper("a")}", var.foo]`), "test.tf", hcl.InitialPos)
+	if diags.HasErrors() {
+		t.Fatalf("parse error: %s", diags)
+	}
+
+	scope.WarmConstantFoldCache(expr)
+
+	tuple := expr.(*hclsyntax.TupleConsExpr)
+	constElem := tuple.Exprs[0]
+
+	got, hit := cache.get(constElem)
+	if !hit {
+		t.Fatal("expected the constant element to have been cached")
+	}
+	if !got.RawEquals(cty.StringVal("A")) {
+		t.Errorf("wrong result %#v", got)
+	}
+
+	varElem := tuple.Exprs[1]
+	if _, hit := cache.get(varElem); hit {
+		t.Error("did not expect the element referencing a variable to have been cached")
+	}
+}
@@ -0,0 +1,33 @@
+package lang
+
+import (
+	"bytes"
+
+	"github.com/hashicorp/hcl2/hcl"
+	"github.com/hashicorp/hcl2/hcl/hclsyntax"
+	"github.com/hashicorp/hcl2/hclwrite"
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+// FormatExpr parses the given source code as a single HCL expression and
+// re-renders it in Terraform's canonical formatting style (consistent
+// operator spacing, indentation, etc).
+//
+// This is intended for tools -- such as editor integrations -- that want to
+// apply "terraform fmt"-style normalization to a fragment of an expression
+// in isolation, without needing a whole configuration file to work with.
+//
+// If the given source does not parse as a valid expression, FormatExpr
+// returns the input unchanged along with error diagnostics describing the
+// parse failure.
+func FormatExpr(src []byte) ([]byte, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+
+	_, parseDiags := hclsyntax.ParseExpression(src, "<expr>", hcl.InitialPos)
+	diags = diags.Append(parseDiags)
+	if diags.HasErrors() {
+		return src, diags
+	}
+
+	return bytes.TrimSpace(hclwrite.Format(src)), diags
+}
@@ -0,0 +1,132 @@
+// Package format renders cty values into the same human-readable, HCL-like
+// syntax that Terraform's other UI-facing packages (such as command/format)
+// use for diffs and console output. Keeping the rendering logic here lets
+// multiple components agree on how quoting, indentation and placeholder
+// text for unknown and sensitive values should look.
+package format
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Value renders val as a multi-line string using Terraform's conventional
+// value syntax: double-quoted strings, "true"/"false" for bools, "null" for
+// null values, and "(known after apply)" for values that are not yet known.
+//
+// The vendored version of cty in this tree does not support value marks, so
+// there is currently no way to detect that a value came from sensitive()
+// and redact it here; AttributeSensitive exists so that callers that track
+// sensitivity out-of-band (such as a resource schema) can still ask for
+// redaction.
+func Value(val cty.Value) string {
+	var buf strings.Builder
+	writeValue(&buf, val, 0)
+	return buf.String()
+}
+
+// AttributeSensitive renders a placeholder for a value that the caller has
+// determined is sensitive, such as via a resource schema's Sensitive flag,
+// rather than rendering its actual content.
+func AttributeSensitive() string {
+	return "(sensitive value)"
+}
+
+func writeValue(buf *strings.Builder, val cty.Value, indent int) {
+	if !val.IsKnown() {
+		buf.WriteString("(known after apply)")
+		return
+	}
+	if val.IsNull() {
+		buf.WriteString("null")
+		return
+	}
+
+	ty := val.Type()
+	switch {
+	case ty.IsPrimitiveType():
+		switch ty {
+		case cty.String:
+			fmt.Fprintf(buf, "%q", val.AsString())
+		case cty.Bool:
+			if val.True() {
+				buf.WriteString("true")
+			} else {
+				buf.WriteString("false")
+			}
+		case cty.Number:
+			bf := val.AsBigFloat()
+			buf.WriteString(bf.Text('f', -1))
+		default:
+			fmt.Fprintf(buf, "%#v", val)
+		}
+
+	case ty.IsListType() || ty.IsSetType() || ty.IsTupleType():
+		buf.WriteString("[")
+		empty := true
+		for it := val.ElementIterator(); it.Next(); {
+			empty = false
+			_, ev := it.Element()
+			buf.WriteString("\n")
+			buf.WriteString(strings.Repeat(" ", indent+2))
+			writeValue(buf, ev, indent+2)
+			buf.WriteString(",")
+		}
+		if !empty {
+			buf.WriteString("\n")
+			buf.WriteString(strings.Repeat(" ", indent))
+		}
+		buf.WriteString("]")
+
+	case ty.IsMapType():
+		buf.WriteString("{")
+		empty := true
+		keys := make([]string, 0, val.LengthInt())
+		vals := make(map[string]cty.Value, val.LengthInt())
+		for it := val.ElementIterator(); it.Next(); {
+			k, v := it.Element()
+			keys = append(keys, k.AsString())
+			vals[k.AsString()] = v
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			empty = false
+			buf.WriteString("\n")
+			buf.WriteString(strings.Repeat(" ", indent+2))
+			fmt.Fprintf(buf, "%q = ", k)
+			writeValue(buf, vals[k], indent+2)
+		}
+		if !empty {
+			buf.WriteString("\n")
+			buf.WriteString(strings.Repeat(" ", indent))
+		}
+		buf.WriteString("}")
+
+	case ty.IsObjectType():
+		buf.WriteString("{")
+		atys := ty.AttributeTypes()
+		names := make([]string, 0, len(atys))
+		for name := range atys {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			buf.WriteString("\n")
+			buf.WriteString(strings.Repeat(" ", indent+2))
+			buf.WriteString(name)
+			buf.WriteString(" = ")
+			writeValue(buf, val.GetAttr(name), indent+2)
+		}
+		if len(names) > 0 {
+			buf.WriteString("\n")
+			buf.WriteString(strings.Repeat(" ", indent))
+		}
+		buf.WriteString("}")
+
+	default:
+		fmt.Fprintf(buf, "%#v", val)
+	}
+}
@@ -0,0 +1,61 @@
+package format
+
+import (
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestValue(t *testing.T) {
+	tests := []struct {
+		Value cty.Value
+		Want  string
+	}{
+		{
+			cty.StringVal("hello"),
+			`"hello"`,
+		},
+		{
+			cty.NumberIntVal(5),
+			"5",
+		},
+		{
+			cty.True,
+			"true",
+		},
+		{
+			cty.NullVal(cty.String),
+			"null",
+		},
+		{
+			cty.UnknownVal(cty.String),
+			"(known after apply)",
+		},
+		{
+			cty.ListVal([]cty.Value{cty.StringVal("a"), cty.StringVal("b")}),
+			"[\n  \"a\",\n  \"b\",\n]",
+		},
+		{
+			cty.ObjectVal(map[string]cty.Value{
+				"b": cty.NumberIntVal(2),
+				"a": cty.NumberIntVal(1),
+			}),
+			"{\n  a = 1\n  b = 2\n}",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Want, func(t *testing.T) {
+			got := Value(test.Value)
+			if got != test.Want {
+				t.Errorf("wrong result\ngot:  %s\nwant: %s", got, test.Want)
+			}
+		})
+	}
+}
+
+func TestAttributeSensitive(t *testing.T) {
+	if got, want := AttributeSensitive(), "(sensitive value)"; got != want {
+		t.Errorf("wrong result\ngot:  %s\nwant: %s", got, want)
+	}
+}
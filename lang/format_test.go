@@ -0,0 +1,35 @@
+package lang
+
+import (
+	"testing"
+)
+
+func TestFormatExpr(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{`1+2`, `1 + 2`},
+		{`  "hello"  `, `"hello"`},
+		{`[1,2,3]`, "[1, 2, 3]"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			got, diags := FormatExpr([]byte(test.input))
+			if diags.HasErrors() {
+				t.Fatalf("unexpected errors: %s", diags.Err())
+			}
+			if string(got) != test.want {
+				t.Errorf("wrong result\ngot:  %s\nwant: %s", got, test.want)
+			}
+		})
+	}
+
+	t.Run("invalid expression", func(t *testing.T) {
+		_, diags := FormatExpr([]byte(`1 +`))
+		if !diags.HasErrors() {
+			t.Fatalf("expected an error for an invalid expression")
+		}
+	})
+}
@@ -113,6 +113,70 @@ var CidrSubnetFunc = function.New(&function.Spec{
 	},
 })
 
+// CidrSubnetsFunc contructs a function that calculates a sequence of
+// consecutive subnet prefixes that might be used to break down the given
+// prefix into smaller ones, each with a possibly-distinct number of
+// additional network prefix bits.
+var CidrSubnetsFunc = function.New(&function.Spec{
+	Params: []function.Parameter{
+		{
+			Name: "prefix",
+			Type: cty.String,
+		},
+	},
+	VarParam: &function.Parameter{
+		Name: "newbits",
+		Type: cty.Number,
+	},
+	Type: function.StaticReturnType(cty.List(cty.String)),
+	Impl: func(args []cty.Value, retType cty.Type) (ret cty.Value, err error) {
+		_, network, err := net.ParseCIDR(args[0].AsString())
+		if err != nil {
+			return cty.UnknownVal(retType), fmt.Errorf("invalid CIDR expression: %s", err)
+		}
+
+		newbitsArgs := args[1:]
+		if len(newbitsArgs) == 0 {
+			return cty.UnknownVal(retType), fmt.Errorf("at least one newbits argument is required")
+		}
+
+		newbits := make([]int, len(newbitsArgs))
+		maxBits := 0
+		for i, v := range newbitsArgs {
+			var bits int
+			if err := gocty.FromCtyValue(v, &bits); err != nil {
+				return cty.UnknownVal(retType), function.NewArgErrorf(i+1, "invalid number of new bits: %s", err)
+			}
+			if bits > 32 {
+				return cty.UnknownVal(retType), function.NewArgErrorf(i+1, "may not extend prefix by more than 32 bits")
+			}
+			if bits < 0 {
+				return cty.UnknownVal(retType), function.NewArgErrorf(i+1, "number of new bits must be non-negative")
+			}
+			newbits[i] = bits
+			if bits > maxBits {
+				maxBits = bits
+			}
+		}
+
+		vals := make([]cty.Value, len(newbits))
+		var next uint64
+		for i, bits := range newbits {
+			shift := uint(maxBits - bits)
+			netnum := int(next >> shift)
+
+			newNetwork, err := cidr.Subnet(network, bits, netnum)
+			if err != nil {
+				return cty.UnknownVal(retType), function.NewArgErrorf(i+1, "invalid combination of prefix and newbits: %s", err)
+			}
+			vals[i] = cty.StringVal(newNetwork.String())
+			next += uint64(1) << shift
+		}
+
+		return cty.ListVal(vals), nil
+	},
+})
+
 // CidrHost calculates a full host IP address within a given IP network address prefix.
 func CidrHost(prefix, hostnum cty.Value) (cty.Value, error) {
 	return CidrHostFunc.Call([]cty.Value{prefix, hostnum})
@@ -127,3 +191,12 @@ func CidrNetmask(prefix cty.Value) (cty.Value, error) {
 func CidrSubnet(prefix, newbits, netnum cty.Value) (cty.Value, error) {
 	return CidrSubnetFunc.Call([]cty.Value{prefix, newbits, netnum})
 }
+
+// CidrSubnets calculates a sequence of consecutive subnet prefixes that
+// might be used to break down the given prefix into smaller ones.
+func CidrSubnets(prefix cty.Value, newbits ...cty.Value) (cty.Value, error) {
+	args := make([]cty.Value, 0, len(newbits)+1)
+	args = append(args, prefix)
+	args = append(args, newbits...)
+	return CidrSubnetsFunc.Call(args)
+}
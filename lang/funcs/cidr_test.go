@@ -214,3 +214,69 @@ func TestCidrSubnet(t *testing.T) {
 		})
 	}
 }
+
+func TestCidrSubnets(t *testing.T) {
+	tests := []struct {
+		Prefix  cty.Value
+		Newbits []cty.Value
+		Want    cty.Value
+		Err     bool
+	}{
+		{
+			cty.StringVal("10.0.0.0/16"),
+			[]cty.Value{cty.NumberIntVal(4), cty.NumberIntVal(4), cty.NumberIntVal(8)},
+			cty.ListVal([]cty.Value{
+				cty.StringVal("10.0.0.0/20"),
+				cty.StringVal("10.0.16.0/20"),
+				cty.StringVal("10.0.32.0/24"),
+			}),
+			false,
+		},
+		{
+			cty.StringVal("fe80::/48"),
+			[]cty.Value{cty.NumberIntVal(16), cty.NumberIntVal(16)},
+			cty.ListVal([]cty.Value{
+				cty.StringVal("fe80::/64"),
+				cty.StringVal("fe80:0:0:1::/64"),
+			}),
+			false,
+		},
+		{ // no newbits arguments
+			cty.StringVal("10.0.0.0/16"),
+			[]cty.Value{},
+			cty.UnknownVal(cty.List(cty.String)),
+			true,
+		},
+		{ // not enough bits left
+			cty.StringVal("10.0.0.0/30"),
+			[]cty.Value{cty.NumberIntVal(4)},
+			cty.UnknownVal(cty.List(cty.String)),
+			true,
+		},
+		{ // not a valid CIDR mask
+			cty.StringVal("not-a-cidr"),
+			[]cty.Value{cty.NumberIntVal(4)},
+			cty.UnknownVal(cty.List(cty.String)),
+			true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("cidrsubnets(%#v, %#v)", test.Prefix, test.Newbits), func(t *testing.T) {
+			got, err := CidrSubnets(test.Prefix, test.Newbits...)
+
+			if test.Err {
+				if err == nil {
+					t.Fatal("succeeded; want error")
+				}
+				return
+			} else if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if !got.RawEquals(test.Want) {
+				t.Errorf("wrong result\ngot:  %#v\nwant: %#v", got, test.Want)
+			}
+		})
+	}
+}
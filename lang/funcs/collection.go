@@ -454,7 +454,10 @@ var FlattenFunc = function.New(&function.Spec{
 			return cty.NilType, errors.New("can only flatten lists, sets and tuples")
 		}
 
-		retVal, known := flattener(args[0])
+		retVal, known, err := flattener(args[0], 0)
+		if err != nil {
+			return cty.NilType, err
+		}
 		if !known {
 			return cty.DynamicPseudoType, nil
 		}
@@ -471,7 +474,10 @@ var FlattenFunc = function.New(&function.Spec{
 			return cty.EmptyTupleVal, nil
 		}
 
-		out, known := flattener(inputList)
+		out, known, err := flattener(inputList, 0)
+		if err != nil {
+			return cty.NilVal, err
+		}
 		if !known {
 			return cty.UnknownVal(retType), nil
 		}
@@ -480,28 +486,40 @@ var FlattenFunc = function.New(&function.Spec{
 	},
 })
 
+// maxFlattenDepth bounds how deeply flatten() will recurse into nested
+// lists, sets and tuples, to avoid a stack overflow on pathologically
+// nested input.
+const maxFlattenDepth = 1024
+
 // Flatten until it's not a cty.List, and return whether the value is known.
 // We can flatten lists with unknown values, as long as they are not
 // lists themselves.
-func flattener(flattenList cty.Value) ([]cty.Value, bool) {
+func flattener(flattenList cty.Value, depth int) ([]cty.Value, bool, error) {
+	if depth >= maxFlattenDepth {
+		return nil, false, fmt.Errorf("input is nested more than %d levels deep", maxFlattenDepth)
+	}
+
 	out := make([]cty.Value, 0)
 	for it := flattenList.ElementIterator(); it.Next(); {
 		_, val := it.Element()
 		if val.Type().IsListType() || val.Type().IsSetType() || val.Type().IsTupleType() {
 			if !val.IsKnown() {
-				return out, false
+				return out, false, nil
 			}
 
-			res, known := flattener(val)
+			res, known, err := flattener(val, depth+1)
+			if err != nil {
+				return nil, false, err
+			}
 			if !known {
-				return res, known
+				return res, known, nil
 			}
 			out = append(out, res...)
 		} else {
 			out = append(out, val)
 		}
 	}
-	return out, true
+	return out, true, nil
 }
 
 // KeysFunc constructs a function that takes a map and returns a sorted list of the map keys.
@@ -1263,6 +1263,12 @@ func TestFlatten(t *testing.T) {
 			}),
 			false,
 		},
+		// reject pathologically deep nesting rather than overflowing the stack
+		{
+			deeplyNestedList(maxFlattenDepth + 1),
+			cty.NilVal,
+			true,
+		},
 	}
 
 	for i, test := range tests {
@@ -3217,3 +3223,13 @@ func TestZipmap(t *testing.T) {
 		})
 	}
 }
+
+// deeplyNestedList builds a list nested to the given depth, wrapping a
+// single string element, for exercising flatten()'s recursion guard.
+func deeplyNestedList(depth int) cty.Value {
+	v := cty.ListVal([]cty.Value{cty.StringVal("a")})
+	for i := 0; i < depth; i++ {
+		v = cty.ListVal([]cty.Value{v})
+	}
+	return v
+}
@@ -85,3 +85,25 @@ func MakeToFunc(wantTy cty.Type) function.Function {
 		},
 	})
 }
+
+// TypeFunc returns a human-readable description of the type of its given
+// argument, primarily for use while debugging expressions interactively,
+// such as in "terraform console".
+//
+// The result is not intended to be parsed back into a type constraint; its
+// exact wording may change between releases.
+var TypeFunc = function.New(&function.Spec{
+	Params: []function.Parameter{
+		{
+			Name:             "value",
+			Type:             cty.DynamicPseudoType,
+			AllowNull:        true,
+			AllowUnknown:     true,
+			AllowDynamicType: true,
+		},
+	},
+	Type: function.StaticReturnType(cty.String),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		return cty.StringVal(args[0].Type().FriendlyName()), nil
+	},
+})
@@ -129,3 +129,35 @@ func TestTo(t *testing.T) {
 		})
 	}
 }
+
+func TestType(t *testing.T) {
+	tests := []struct {
+		Value cty.Value
+		Want  cty.Value
+	}{
+		{
+			cty.StringVal("a"),
+			cty.StringVal("string"),
+		},
+		{
+			cty.NumberIntVal(5),
+			cty.StringVal("number"),
+		},
+		{
+			cty.ListValEmpty(cty.String),
+			cty.StringVal("list of string"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("type(%#v)", test.Value), func(t *testing.T) {
+			got, err := TypeFunc.Call([]cty.Value{test.Value})
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !got.RawEquals(test.Want) {
+				t.Errorf("wrong result\ngot:  %#v\nwant: %#v", got, test.Want)
+			}
+		})
+	}
+}
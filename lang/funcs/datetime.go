@@ -43,6 +43,42 @@ var TimeAddFunc = function.New(&function.Spec{
 	},
 })
 
+// ToTimeZoneFunc constructs a function that converts a timestamp into the
+// equivalent instant in a different IANA time zone.
+var ToTimeZoneFunc = function.New(&function.Spec{
+	Params: []function.Parameter{
+		{
+			Name: "timestamp",
+			Type: cty.String,
+		},
+		{
+			Name: "zone",
+			Type: cty.String,
+		},
+	},
+	Type: function.StaticReturnType(cty.String),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		ts, err := time.Parse(time.RFC3339, args[0].AsString())
+		if err != nil {
+			return cty.UnknownVal(cty.String), err
+		}
+
+		zoneName := args[1].AsString()
+		loc, err := time.LoadLocation(zoneName)
+		if err != nil {
+			return cty.UnknownVal(cty.String), function.NewArgErrorf(1, "invalid time zone %q: %s", zoneName, err)
+		}
+
+		return cty.StringVal(ts.In(loc).Format(time.RFC3339)), nil
+	},
+})
+
+// ToTimeZone converts a timestamp into the equivalent instant in a
+// different IANA time zone, such as "America/New_York" or "UTC".
+func ToTimeZone(timestamp cty.Value, zone cty.Value) (cty.Value, error) {
+	return ToTimeZoneFunc.Call([]cty.Value{timestamp, zone})
+}
+
 // Timestamp returns a string representation of the current date and time.
 //
 // In the Terraform language, timestamps are conventionally represented as
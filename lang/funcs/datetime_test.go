@@ -83,3 +83,56 @@ func TestTimeadd(t *testing.T) {
 		})
 	}
 }
+
+func TestToTimeZone(t *testing.T) {
+	tests := []struct {
+		Time cty.Value
+		Zone cty.Value
+		Want cty.Value
+		Err  bool
+	}{
+		{
+			cty.StringVal("2017-11-22T00:00:00Z"),
+			cty.StringVal("UTC"),
+			cty.StringVal("2017-11-22T00:00:00Z"),
+			false,
+		},
+		{
+			cty.StringVal("2017-11-22T00:00:00Z"),
+			cty.StringVal("America/New_York"),
+			cty.StringVal("2017-11-21T19:00:00-05:00"),
+			false,
+		},
+		{ // Invalid format timestamp
+			cty.StringVal("2017-11-22"),
+			cty.StringVal("UTC"),
+			cty.UnknownVal(cty.String),
+			true,
+		},
+		{ // Unknown zone
+			cty.StringVal("2017-11-22T00:00:00Z"),
+			cty.StringVal("Not/A_Zone"),
+			cty.UnknownVal(cty.String),
+			true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("ToTimeZone(%#v, %#v)", test.Time, test.Zone), func(t *testing.T) {
+			got, err := ToTimeZone(test.Time, test.Zone)
+
+			if test.Err {
+				if err == nil {
+					t.Fatal("succeeded; want error")
+				}
+				return
+			} else if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if !got.RawEquals(test.Want) {
+				t.Errorf("wrong result\ngot:  %#v\nwant: %#v", got, test.Want)
+			}
+		})
+	}
+}
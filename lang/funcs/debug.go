@@ -0,0 +1,33 @@
+package funcs
+
+import (
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+// DebugFunc is an identity function: it returns its argument unchanged.
+//
+// Its purpose is purely to give an expression a named call site to wrap a
+// value in while debugging, such as in "terraform console". Each call to
+// debug made through a Scope is reported to the Scope's EvalTracer, so
+// wrapping a subexpression in debug(...) is enough to have its value
+// recorded without changing what the expression evaluates to. The duration
+// reported for the call covers only debug itself, not the evaluation of
+// its argument.
+var DebugFunc = function.New(&function.Spec{
+	Params: []function.Parameter{
+		{
+			Name:             "value",
+			Type:             cty.DynamicPseudoType,
+			AllowNull:        true,
+			AllowUnknown:     true,
+			AllowDynamicType: true,
+		},
+	},
+	Type: func(args []cty.Value) (cty.Type, error) {
+		return args[0].Type(), nil
+	},
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		return args[0], nil
+	},
+})
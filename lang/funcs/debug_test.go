@@ -0,0 +1,17 @@
+package funcs
+
+import (
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestDebug(t *testing.T) {
+	got, err := DebugFunc.Call([]cty.Value{cty.StringVal("hello")})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !got.RawEquals(cty.StringVal("hello")) {
+		t.Errorf("wrong result %#v", got)
+	}
+}
@@ -0,0 +1,142 @@
+package funcs
+
+import (
+	"fmt"
+
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+// DefaultsFunc constructs a function that applies a defaults object to a
+// value, substituting a default for any null attribute or element, and
+// recursing into nested objects, maps, lists and tuples so that defaults can
+// be supplied at any depth.
+var DefaultsFunc = function.New(&function.Spec{
+	Params: []function.Parameter{
+		{
+			Name:             "value",
+			Type:             cty.DynamicPseudoType,
+			AllowNull:        true,
+			AllowDynamicType: true,
+		},
+		{
+			Name:             "defaults",
+			Type:             cty.DynamicPseudoType,
+			AllowDynamicType: true,
+		},
+	},
+	Type: func(args []cty.Value) (cty.Type, error) {
+		return args[0].Type(), nil
+	},
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		return applyDefaults(args[0], args[1], nil)
+	},
+})
+
+// Defaults fills any null attributes or elements of value with the
+// corresponding value from defaults, recursing into nested objects, maps,
+// lists and tuples.
+func Defaults(value, defaults cty.Value) (cty.Value, error) {
+	return DefaultsFunc.Call([]cty.Value{value, defaults})
+}
+
+// applyDefaults recursively merges defaults into value, returning an error
+// with a path-qualified message if their shapes are incompatible in a way
+// that can't be resolved by recursing further.
+func applyDefaults(value, defaults cty.Value, path cty.Path) (cty.Value, error) {
+	if !value.IsKnown() || !defaults.IsKnown() {
+		return value, nil
+	}
+
+	if value.IsNull() {
+		if defaults.IsNull() {
+			return value, nil
+		}
+		converted, err := convert.Convert(defaults, value.Type())
+		if err != nil {
+			return cty.NilVal, fmt.Errorf("default value for %s is not compatible with its expected type %s: %s", pathString(path), value.Type().FriendlyName(), err)
+		}
+		return converted, nil
+	}
+
+	valTy := value.Type()
+
+	if (valTy.IsObjectType() || valTy.IsMapType()) && !defaults.IsNull() && !defaults.Type().IsObjectType() && !defaults.Type().IsMapType() {
+		return cty.NilVal, fmt.Errorf("default value for %s must be an object or map to match %s, but is %s", pathString(path), valTy.FriendlyName(), defaults.Type().FriendlyName())
+	}
+
+	switch {
+	case (valTy.IsObjectType() || valTy.IsMapType()) && !defaults.IsNull() && (defaults.Type().IsObjectType() || defaults.Type().IsMapType()):
+		attrs := make(map[string]cty.Value)
+		for it := value.ElementIterator(); it.Next(); {
+			k, v := it.Element()
+			attrs[k.AsString()] = v
+		}
+		for it := defaults.ElementIterator(); it.Next(); {
+			k, dv := it.Element()
+			name := k.AsString()
+			attrPath := append(path.Copy(), cty.GetAttrStep{Name: name})
+			v, exists := attrs[name]
+			if !exists {
+				attrs[name] = dv
+				continue
+			}
+			merged, err := applyDefaults(v, dv, attrPath)
+			if err != nil {
+				return cty.NilVal, err
+			}
+			attrs[name] = merged
+		}
+		if valTy.IsMapType() {
+			return cty.MapVal(attrs), nil
+		}
+		return cty.ObjectVal(attrs), nil
+
+	case (valTy.IsListType() || valTy.IsTupleType() || valTy.IsSetType()) && !value.IsNull():
+		var elems []cty.Value
+		i := 0
+		for it := value.ElementIterator(); it.Next(); {
+			_, v := it.Element()
+			elemPath := append(path.Copy(), cty.IndexStep{Key: cty.NumberIntVal(int64(i))})
+			merged, err := applyDefaults(v, defaults, elemPath)
+			if err != nil {
+				return cty.NilVal, err
+			}
+			elems = append(elems, merged)
+			i++
+		}
+		if len(elems) == 0 {
+			return value, nil
+		}
+		switch {
+		case valTy.IsTupleType():
+			return cty.TupleVal(elems), nil
+		case valTy.IsSetType():
+			return cty.SetVal(elems), nil
+		default:
+			return cty.ListVal(elems), nil
+		}
+
+	default:
+		// Leaf value that's already non-null: defaults never override an
+		// explicitly-set value.
+		return value, nil
+	}
+}
+
+// pathString renders path as a dotted/indexed string such as "value.foo[0]",
+// for use in error messages that need to point at a specific nested location
+// within the input.
+func pathString(path cty.Path) string {
+	s := "value"
+	for _, step := range path {
+		switch step := step.(type) {
+		case cty.GetAttrStep:
+			s += "." + step.Name
+		case cty.IndexStep:
+			s += fmt.Sprintf("[%#v]", step.Key)
+		}
+	}
+	return s
+}
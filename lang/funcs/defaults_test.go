@@ -0,0 +1,110 @@
+package funcs
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestDefaults(t *testing.T) {
+	tests := []struct {
+		Value    cty.Value
+		Defaults cty.Value
+		Want     cty.Value
+		Err      bool
+	}{
+		{
+			cty.ObjectVal(map[string]cty.Value{
+				"name": cty.NullVal(cty.String),
+				"size": cty.NumberIntVal(3),
+			}),
+			cty.ObjectVal(map[string]cty.Value{
+				"name": cty.StringVal("unnamed"),
+				"size": cty.NumberIntVal(1),
+			}),
+			cty.ObjectVal(map[string]cty.Value{
+				"name": cty.StringVal("unnamed"),
+				"size": cty.NumberIntVal(3),
+			}),
+			false,
+		},
+		{ // nested objects get defaults applied recursively
+			cty.ObjectVal(map[string]cty.Value{
+				"name": cty.StringVal("a"),
+				"tags": cty.ObjectVal(map[string]cty.Value{
+					"env":  cty.NullVal(cty.String),
+					"team": cty.StringVal("infra"),
+				}),
+			}),
+			cty.ObjectVal(map[string]cty.Value{
+				"name": cty.StringVal("unnamed"),
+				"tags": cty.ObjectVal(map[string]cty.Value{
+					"env":  cty.StringVal("dev"),
+					"team": cty.StringVal("unknown"),
+				}),
+			}),
+			cty.ObjectVal(map[string]cty.Value{
+				"name": cty.StringVal("a"),
+				"tags": cty.ObjectVal(map[string]cty.Value{
+					"env":  cty.StringVal("dev"),
+					"team": cty.StringVal("infra"),
+				}),
+			}),
+			false,
+		},
+		{ // defaults apply to every element of a list
+			cty.ListVal([]cty.Value{
+				cty.ObjectVal(map[string]cty.Value{"size": cty.NullVal(cty.Number)}),
+				cty.ObjectVal(map[string]cty.Value{"size": cty.NumberIntVal(5)}),
+			}),
+			cty.ObjectVal(map[string]cty.Value{"size": cty.NumberIntVal(1)}),
+			cty.ListVal([]cty.Value{
+				cty.ObjectVal(map[string]cty.Value{"size": cty.NumberIntVal(1)}),
+				cty.ObjectVal(map[string]cty.Value{"size": cty.NumberIntVal(5)}),
+			}),
+			false,
+		},
+		{ // a default that doesn't match the null value's type is an error
+			cty.ObjectVal(map[string]cty.Value{
+				"size": cty.NullVal(cty.Number),
+			}),
+			cty.ObjectVal(map[string]cty.Value{
+				"size": cty.StringVal("not a number"),
+			}),
+			cty.NilVal,
+			true,
+		},
+		{ // defaults for an object must themselves be an object or map
+			cty.ObjectVal(map[string]cty.Value{
+				"tags": cty.ObjectVal(map[string]cty.Value{
+					"env": cty.NullVal(cty.String),
+				}),
+			}),
+			cty.ObjectVal(map[string]cty.Value{
+				"tags": cty.StringVal("not an object"),
+			}),
+			cty.NilVal,
+			true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("defaults(%#v, %#v)", test.Value, test.Defaults), func(t *testing.T) {
+			got, err := Defaults(test.Value, test.Defaults)
+
+			if test.Err {
+				if err == nil {
+					t.Fatal("succeeded; want error")
+				}
+				return
+			} else if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if !got.RawEquals(test.Want) {
+				t.Errorf("wrong result\ngot:  %#v\nwant: %#v", got, test.Want)
+			}
+		})
+	}
+}
@@ -9,6 +9,8 @@ import (
 	"net/url"
 	"unicode/utf8"
 
+	"golang.org/x/text/encoding/htmlindex"
+
 	"github.com/zclconf/go-cty/cty"
 	"github.com/zclconf/go-cty/cty/function"
 )
@@ -63,18 +65,97 @@ var Base64GzipFunc = function.New(&function.Spec{
 	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
 		s := args[0].AsString()
 
+		// The base64 encoder is chained directly onto the gzip writer so
+		// that compressed bytes are base64-encoded as they're produced,
+		// rather than first being collected into one buffer and then
+		// copied into a second buffer for encoding.
 		var b bytes.Buffer
-		gz := gzip.NewWriter(&b)
+		enc := base64.NewEncoder(base64.StdEncoding, &b)
+		gz := gzip.NewWriter(enc)
 		if _, err := gz.Write([]byte(s)); err != nil {
 			return cty.UnknownVal(cty.String), fmt.Errorf("failed to write gzip raw data: '%s'", s)
 		}
-		if err := gz.Flush(); err != nil {
-			return cty.UnknownVal(cty.String), fmt.Errorf("failed to flush gzip writer: '%s'", s)
-		}
 		if err := gz.Close(); err != nil {
 			return cty.UnknownVal(cty.String), fmt.Errorf("failed to close gzip writer: '%s'", s)
 		}
-		return cty.StringVal(base64.StdEncoding.EncodeToString(b.Bytes())), nil
+		if err := enc.Close(); err != nil {
+			return cty.UnknownVal(cty.String), fmt.Errorf("failed to close base64 encoder: '%s'", s)
+		}
+		return cty.StringVal(b.String()), nil
+	},
+})
+
+// TextEncodeBase64Func constructs a function that encodes a string to a
+// target encoding and then to base64.
+var TextEncodeBase64Func = function.New(&function.Spec{
+	Params: []function.Parameter{
+		{
+			Name: "string",
+			Type: cty.String,
+		},
+		{
+			Name: "encoding",
+			Type: cty.String,
+		},
+	},
+	Type: function.StaticReturnType(cty.String),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		str := args[0].AsString()
+		encName := args[1].AsString()
+
+		enc, err := htmlindex.Get(encName)
+		if err != nil {
+			return cty.UnknownVal(cty.String), function.NewArgErrorf(1, "%q is not a supported IANA encoding name or alias in this Terraform version", encName)
+		}
+
+		encoded, err := enc.NewEncoder().String(str)
+		if err != nil {
+			return cty.UnknownVal(cty.String), fmt.Errorf("failed to encode as target encoding %q: %s", encName, err)
+		}
+
+		return cty.StringVal(base64.StdEncoding.EncodeToString([]byte(encoded))), nil
+	},
+})
+
+// TextDecodeBase64Func constructs a function that decodes a base64 sequence
+// to a target encoding.
+var TextDecodeBase64Func = function.New(&function.Spec{
+	Params: []function.Parameter{
+		{
+			Name: "source",
+			Type: cty.String,
+		},
+		{
+			Name: "encoding",
+			Type: cty.String,
+		},
+	},
+	Type: function.StaticReturnType(cty.String),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		str := args[0].AsString()
+		encName := args[1].AsString()
+
+		enc, err := htmlindex.Get(encName)
+		if err != nil {
+			return cty.UnknownVal(cty.String), function.NewArgErrorf(1, "%q is not a supported IANA encoding name or alias in this Terraform version", encName)
+		}
+
+		encoded, err := base64.StdEncoding.DecodeString(str)
+		if err != nil {
+			switch err := err.(type) {
+			case base64.CorruptInputError:
+				return cty.UnknownVal(cty.String), function.NewArgErrorf(0, "the given value is has an invalid base64 symbol at offset %d", int64(err))
+			default:
+				return cty.UnknownVal(cty.String), function.NewArgErrorf(0, "invalid source string: %s", err)
+			}
+		}
+
+		decoded, err := enc.NewDecoder().Bytes(encoded)
+		if err != nil {
+			return cty.UnknownVal(cty.String), fmt.Errorf("the given string cannot be decoded using encoding %q", encName)
+		}
+
+		return cty.StringVal(string(decoded)), nil
 	},
 })
 
@@ -127,6 +208,30 @@ func Base64Gzip(str cty.Value) (cty.Value, error) {
 	return Base64GzipFunc.Call([]cty.Value{str})
 }
 
+// TextEncodeBase64 encodes the unicode characters in a given string using a
+// specified target character encoding, and then applies Base64 encoding to
+// the result.
+//
+// The given encoding name must be one of the encoding names or aliases
+// recognized by the IANA character encoding registry. Not all of these are
+// supported, so an error will be returned if an unsupported encoding name is
+// given.
+func TextEncodeBase64(str, enc cty.Value) (cty.Value, error) {
+	return TextEncodeBase64Func.Call([]cty.Value{str, enc})
+}
+
+// TextDecodeBase64 decodes a Base64 sequence first, then interprets the
+// result as being in a given non-unicode character encoding, which is then
+// transcoded to Unicode and returned as a string.
+//
+// The given encoding name must be one of the encoding names or aliases
+// recognized by the IANA character encoding registry. Not all of these are
+// supported, so an error will be returned if an unsupported encoding name is
+// given.
+func TextDecodeBase64(str, enc cty.Value) (cty.Value, error) {
+	return TextDecodeBase64Func.Call([]cty.Value{str, enc})
+}
+
 // URLEncode applies URL encoding to a given string.
 //
 // This function identifies characters in the given string that would have a
@@ -0,0 +1,30 @@
+package funcs
+
+import (
+	"os"
+
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+// EnvFunc constructs a function that takes the name of an environment
+// variable and returns its value from the process environment, or an empty
+// string if it is not set.
+//
+// This function is only registered in a Scope when the configuration has
+// explicitly opted in with "enable_env_interpolation" in its root module's
+// "terraform" block, since the result varies by machine and so configuration
+// that depends on it is not reproducible in the way Terraform configuration
+// is normally expected to be.
+var EnvFunc = function.New(&function.Spec{
+	Params: []function.Parameter{
+		{
+			Name: "name",
+			Type: cty.String,
+		},
+	},
+	Type: function.StaticReturnType(cty.String),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		return cty.StringVal(os.Getenv(args[0].AsString())), nil
+	},
+})
@@ -0,0 +1,41 @@
+package funcs
+
+import (
+	"os"
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestEnv(t *testing.T) {
+	if err := os.Setenv("TF_FUNCS_ENV_TEST", "hello"); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Unsetenv("TF_FUNCS_ENV_TEST")
+
+	tests := []struct {
+		Name cty.Value
+		Want cty.Value
+	}{
+		{
+			cty.StringVal("TF_FUNCS_ENV_TEST"),
+			cty.StringVal("hello"),
+		},
+		{
+			cty.StringVal("TF_FUNCS_ENV_TEST_UNSET"),
+			cty.StringVal(""),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name.AsString(), func(t *testing.T) {
+			got, err := EnvFunc.Call([]cty.Value{test.Name})
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !got.RawEquals(test.Want) {
+				t.Errorf("wrong result\ngot:  %#v\nwant: %#v", got, test.Want)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package funcs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"time"
+
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+// externalFuncTimeout bounds how long a single call to a function built by
+// MakeExternalFunc may run before it's killed, in the same way as
+// defaultHelperTimeout bounds a credentials helper invocation.
+const externalFuncTimeout = 30 * time.Second
+
+// MakeExternalFunc constructs the "external" function, which runs one of
+// allowedPrograms and exchanges a query and a result with it as JSON on its
+// stdin and stdout, in the same request/response shape used by the
+// "external" data source: a flat JSON object of string values in, and a
+// flat JSON object of string values out.
+//
+// program must exactly match one of allowedPrograms -- typically sourced
+// from a CLI configuration's "external_programs" allowlist -- or the call
+// fails without running anything at all. This function is only meaningful
+// to register in a Scope that has separately opted in via
+// Scope.EnableExternalFunction, so that allowlisting a program in the CLI
+// configuration doesn't by itself make it callable from every
+// configuration evaluated on a machine.
+func MakeExternalFunc(allowedPrograms []string) function.Function {
+	return function.New(&function.Spec{
+		Params: []function.Parameter{
+			{
+				Name: "program",
+				Type: cty.String,
+			},
+			{
+				Name: "query",
+				Type: cty.Map(cty.String),
+			},
+		},
+		Type: function.StaticReturnType(cty.Map(cty.String)),
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			program := args[0].AsString()
+			if !externalProgramAllowed(allowedPrograms, program) {
+				return cty.UnknownVal(retType), fmt.Errorf("program %q is not in the \"external_programs\" allowlist in the CLI configuration", program)
+			}
+
+			query := make(map[string]string)
+			for it := args[1].ElementIterator(); it.Next(); {
+				k, v := it.Element()
+				query[k.AsString()] = v.AsString()
+			}
+			queryJSON, err := json.Marshal(query)
+			if err != nil {
+				// The query is always a map of strings, so this can only
+				// fail for reasons json.Marshal itself can't hit on that
+				// shape; kept as a safety net rather than a case we expect
+				// to actually reach.
+				return cty.UnknownVal(retType), fmt.Errorf("failed to encode query for program %q: %s", program, err)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), externalFuncTimeout)
+			defer cancel()
+
+			cmd := exec.CommandContext(ctx, program)
+			cmd.Stdin = bytes.NewReader(queryJSON)
+			var stdout, stderr bytes.Buffer
+			cmd.Stdout = &stdout
+			cmd.Stderr = &stderr
+
+			if err := cmd.Run(); err != nil {
+				if ctx.Err() == context.DeadlineExceeded {
+					return cty.UnknownVal(retType), fmt.Errorf("program %q did not respond within %s; killed it", program, externalFuncTimeout)
+				}
+				if stderr.Len() > 0 {
+					return cty.UnknownVal(retType), fmt.Errorf("program %q failed: %s", program, bytes.TrimSpace(stderr.Bytes()))
+				}
+				return cty.UnknownVal(retType), fmt.Errorf("program %q failed: %s", program, err)
+			}
+
+			var result map[string]string
+			if err := json.Unmarshal(stdout.Bytes(), &result); err != nil {
+				return cty.UnknownVal(retType), fmt.Errorf("program %q produced invalid output: %s", program, err)
+			}
+
+			if len(result) == 0 {
+				return cty.MapValEmpty(cty.String), nil
+			}
+			vals := make(map[string]cty.Value, len(result))
+			for k, v := range result {
+				vals[k] = cty.StringVal(v)
+			}
+			return cty.MapVal(vals), nil
+		},
+	})
+}
+
+// externalProgramAllowed returns true if program appears verbatim in
+// allowedPrograms. It's intentionally a plain exact match rather than a
+// path-normalizing or pattern-based one, so that what's allowlisted is
+// exactly and only what a caller of the external function must write.
+func externalProgramAllowed(allowedPrograms []string, program string) bool {
+	for _, allowed := range allowedPrograms {
+		if allowed == program {
+			return true
+		}
+	}
+	return false
+}
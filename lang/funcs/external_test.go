@@ -0,0 +1,68 @@
+package funcs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestMakeExternalFunc(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	program := filepath.Join(wd, "testdata/external-test/run")
+
+	f := MakeExternalFunc([]string{program})
+
+	t.Run("happy path", func(t *testing.T) {
+		got, err := f.Call([]cty.Value{
+			cty.StringVal(program),
+			cty.MapVal(map[string]cty.Value{
+				"greeting": cty.StringVal("hello"),
+			}),
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		want := cty.MapVal(map[string]cty.Value{
+			"greeting": cty.StringVal("hello-result"),
+		})
+		if !got.RawEquals(want) {
+			t.Errorf("wrong result\ngot:  %#v\nwant: %#v", got, want)
+		}
+	})
+
+	t.Run("program failure", func(t *testing.T) {
+		_, err := f.Call([]cty.Value{
+			cty.StringVal(program),
+			cty.MapVal(map[string]cty.Value{
+				"fail": cty.StringVal("yes"),
+			}),
+		})
+		if err == nil {
+			t.Fatal("succeeded; want error")
+		}
+		if got, want := err.Error(), "failing because you told me to fail"; !strings.Contains(got, want) {
+			t.Errorf("wrong error\ngot:  %s\nwant substring: %s", got, want)
+		}
+	})
+
+	t.Run("program not allowlisted", func(t *testing.T) {
+		_, err := f.Call([]cty.Value{
+			cty.StringVal("/usr/bin/does-not-matter"),
+			cty.MapVal(map[string]cty.Value{
+				"greeting": cty.StringVal("hello"),
+			}),
+		})
+		if err == nil {
+			t.Fatal("succeeded; want error")
+		}
+		if got, want := err.Error(), "external_programs"; !strings.Contains(got, want) {
+			t.Errorf("wrong error\ngot:  %s\nwant substring: %s", got, want)
+		}
+	})
+}
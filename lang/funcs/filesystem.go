@@ -3,6 +3,7 @@ package funcs
 import (
 	"encoding/base64"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -16,6 +17,26 @@ import (
 	"github.com/zclconf/go-cty/cty/function"
 )
 
+// maxFileSetMatches caps the number of files MakeFileSetFunc will return for
+// a single fileset call. A "**" pattern run against a path that turns out to
+// contain far more files than the author expected -- a build output
+// directory accidentally included in a module, say -- would otherwise churn
+// through every one of them and likely also produce a resource plan too
+// large to be useful, so we fail fast instead once the match count makes
+// clear that's what's happening. Overridden in tests, to avoid actually
+// creating this many files on disk.
+var maxFileSetMatches = 100000
+
+// maxFileSize caps the number of bytes that readFileBytes will read from a
+// single file into memory, across file, filebase64, and the other
+// functions in this file that load a whole file's contents. A module that
+// accidentally points one of these at a huge file -- a build artifact
+// included by mistake, say -- would otherwise be read into memory in its
+// entirety before Terraform had any chance to object, which for a large
+// enough file could exhaust available memory outright. Overridden in
+// tests, to avoid actually creating a file this large on disk.
+var maxFileSize int64 = 512 * 1024 * 1024 // 512MiB
+
 // MakeFileFunc constructs a function that takes a file path and returns the
 // contents of that file, either directly as a string (where valid UTF-8 is
 // required) or as a string containing base64 bytes.
@@ -241,8 +262,16 @@ func MakeFileSetFunc(baseDir string) function.Function {
 				return cty.UnknownVal(cty.Set(cty.String)), fmt.Errorf("failed to glob pattern (%s): %s", pattern, err)
 			}
 
+			if len(matches) > maxFileSetMatches {
+				return cty.UnknownVal(cty.Set(cty.String)), fmt.Errorf("pattern (%s) matched %d files, which is more than the %d file limit for a single fileset call", pattern, len(matches), maxFileSetMatches)
+			}
+
 			var matchVals []cty.Value
 			for _, match := range matches {
+				// os.Stat, rather than os.Lstat, so that a symlink to a
+				// regular file is included just like the file itself would
+				// be, while a symlink to a directory is excluded below the
+				// same as an ordinary directory is.
 				fi, err := os.Stat(match)
 
 				if err != nil {
@@ -350,15 +379,32 @@ func readFileBytes(baseDir, path string) ([]byte, error) {
 	// Ensure that the path is canonical for the host OS
 	path = filepath.Clean(path)
 
-	src, err := ioutil.ReadFile(path)
+	f, err := os.Open(path)
 	if err != nil {
-		// ReadFile does not return Terraform-user-friendly error
+		// os.Open does not return Terraform-user-friendly error
 		// messages, so we'll provide our own.
 		if os.IsNotExist(err) {
 			return nil, fmt.Errorf("no file exists at %s", path)
 		}
 		return nil, fmt.Errorf("failed to read %s", path)
 	}
+	defer f.Close()
+
+	if info, err := f.Stat(); err == nil && info.Size() > maxFileSize {
+		return nil, fmt.Errorf("contents of %s are too large to read: %d bytes, which is more than the %d byte limit for a single file", path, info.Size(), maxFileSize)
+	}
+
+	// We stream the read through a limited reader, rather than trusting
+	// the size reported by Stat alone, so that a file that grows between
+	// the Stat call above and this read still can't be read past the
+	// limit.
+	src, err := ioutil.ReadAll(io.LimitReader(f, maxFileSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s", path)
+	}
+	if int64(len(src)) > maxFileSize {
+		return nil, fmt.Errorf("contents of %s are too large to read: more than the %d byte limit for a single file", path, maxFileSize)
+	}
 
 	return src, nil
 }
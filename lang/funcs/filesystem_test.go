@@ -3,6 +3,7 @@ package funcs
 import (
 	"fmt"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	homedir "github.com/mitchellh/go-homedir"
@@ -53,6 +54,19 @@ func TestFile(t *testing.T) {
 	}
 }
 
+func TestFile_limit(t *testing.T) {
+	defer func(orig int64) { maxFileSize = orig }(maxFileSize)
+	maxFileSize = 5
+
+	_, err := File(".", cty.StringVal("testdata/hello.txt"))
+	if err == nil {
+		t.Fatal("succeeded; want error")
+	}
+	if got, want := err.Error(), "more than the 5 byte limit"; !strings.Contains(got, want) {
+		t.Fatalf("wrong error\ngot:  %s\nwant substring: %s", got, want)
+	}
+}
+
 func TestTemplateFile(t *testing.T) {
 	tests := []struct {
 		Path cty.Value
@@ -437,6 +451,19 @@ func TestFileSet(t *testing.T) {
 	}
 }
 
+func TestFileSet_limit(t *testing.T) {
+	defer func(orig int) { maxFileSetMatches = orig }(maxFileSetMatches)
+	maxFileSetMatches = 0
+
+	_, err := FileSet(".", cty.StringVal("."), cty.StringVal("testdata/*.txt"))
+	if err == nil {
+		t.Fatal("succeeded; want error")
+	}
+	if got, want := err.Error(), "more than the 0 file limit"; !strings.Contains(got, want) {
+		t.Fatalf("wrong error\ngot:  %s\nwant substring: %s", got, want)
+	}
+}
+
 func TestFileBase64(t *testing.T) {
 	tests := []struct {
 		Path cty.Value
@@ -0,0 +1,158 @@
+// Package langtest provides shared table-driven test helpers for the
+// functions registered in lang/funcs, so that tests for a newly-added
+// function can exercise the same basic contract checks that every other
+// function in the table is expected to honor, without each _test.go file
+// having to hand-write them.
+package langtest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+// Case describes a single call to a function under test: the arguments to
+// call it with, the value it's expected to return, and whether the call is
+// instead expected to fail. This is the same shape already used by the
+// table-driven tests throughout lang/funcs, so an existing test table can
+// be passed to CheckFunc largely as-is.
+type Case struct {
+	Args []cty.Value
+	Want cty.Value
+	Err  bool
+}
+
+// CheckFunc calls f once for each of the given cases and compares the
+// result against Case.Want, in the same way as the hand-written tests
+// throughout lang/funcs.
+//
+// For every case that doesn't itself expect an error, CheckFunc also
+// re-runs the call once per argument with that one argument's value
+// swapped out, to check two invariants that every function in this
+// package is expected to honor automatically, without any extra code of
+// its own:
+//
+//   - if an argument is unknown and its parameter doesn't set
+//     AllowUnknown, the call must return an unknown value of the correct
+//     type rather than an error.
+//   - if an argument is null and its parameter doesn't set AllowNull,
+//     the call must return an error rather than panicking or silently
+//     producing a result.
+//
+// These are the same checks that cty's function package itself performs
+// before invoking a function's Impl, so a failure here usually means a
+// function's Parameter definitions don't match what its test cases
+// actually assume, rather than a bug in the function body.
+//
+// This doesn't check anything about cty value marks, since the version of
+// cty vendored into this module doesn't yet implement them.
+func CheckFunc(t *testing.T, name string, f function.Function, cases []Case) {
+	t.Helper()
+
+	for _, test := range cases {
+		test := test
+		t.Run(fmt.Sprintf("%s(%s)", name, formatArgs(test.Args)), func(t *testing.T) {
+			checkCase(t, f, test.Args, test.Want, test.Err)
+		})
+
+		if test.Err {
+			// We only probe the unknown/null invariants starting from a
+			// case that's known to succeed, so that a failure there can
+			// only be blamed on the substituted argument.
+			continue
+		}
+
+		params := paramsFor(f, len(test.Args))
+		for i, arg := range test.Args {
+			i, arg := i, arg
+			param := params[i]
+
+			if !param.AllowUnknown {
+				t.Run(fmt.Sprintf("%s(%s) with argument %d unknown", name, formatArgs(test.Args), i), func(t *testing.T) {
+					args := replaceArg(test.Args, i, cty.UnknownVal(arg.Type()))
+					got, err := f.Call(args)
+					if err != nil {
+						t.Fatalf("unexpected error for an unknown argument: %s", err)
+					}
+					if got.IsKnown() {
+						t.Errorf("expected an unknown result, got %#v", got)
+					}
+					if retTy, err := f.ReturnType(args); err == nil && !got.Type().Equals(retTy) {
+						t.Errorf("expected an unknown result of type %#v, got %#v", retTy, got)
+					}
+				})
+			}
+
+			if !param.AllowNull {
+				t.Run(fmt.Sprintf("%s(%s) with argument %d null", name, formatArgs(test.Args), i), func(t *testing.T) {
+					args := replaceArg(test.Args, i, cty.NullVal(arg.Type()))
+					if _, err := f.Call(args); err == nil {
+						t.Error("expected an error for a null argument, got none")
+					}
+				})
+			}
+		}
+	}
+}
+
+func checkCase(t *testing.T, f function.Function, args []cty.Value, want cty.Value, wantErr bool) {
+	t.Helper()
+
+	got, err := f.Call(args)
+	if wantErr {
+		if err == nil {
+			t.Fatal("succeeded; want error")
+		}
+		return
+	}
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !got.RawEquals(want) {
+		t.Errorf("wrong result\ngot:  %#v\nwant: %#v", got, want)
+	}
+}
+
+// paramsFor returns the effective Parameter for each of n positional
+// arguments, drawing from f's VarParam once its fixed Params are
+// exhausted.
+func paramsFor(f function.Function, n int) []function.Parameter {
+	fixed := f.Params()
+	params := make([]function.Parameter, n)
+	for i := range params {
+		if i < len(fixed) {
+			params[i] = fixed[i]
+			continue
+		}
+		varParam := f.VarParam()
+		if varParam == nil {
+			panic(fmt.Sprintf("function takes only %d arguments, but %d were given", len(fixed), n))
+		}
+		params[i] = *varParam
+	}
+	return params
+}
+
+func replaceArg(args []cty.Value, i int, val cty.Value) []cty.Value {
+	replaced := make([]cty.Value, len(args))
+	copy(replaced, args)
+	replaced[i] = val
+	return replaced
+}
+
+func formatArgs(args []cty.Value) string {
+	strs := make([]string, len(args))
+	for i, arg := range args {
+		strs[i] = fmt.Sprintf("%#v", arg)
+	}
+	result := ""
+	for i, s := range strs {
+		if i > 0 {
+			result += ", "
+		}
+		result += s
+	}
+	return result
+}
@@ -0,0 +1,50 @@
+package langtest
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+var upperFunc = function.New(&function.Spec{
+	Params: []function.Parameter{
+		{
+			Name: "str",
+			Type: cty.String,
+		},
+	},
+	Type: function.StaticReturnType(cty.String),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		return cty.StringVal(args[0].AsString() + "!"), nil
+	},
+})
+
+func TestCheckFunc(t *testing.T) {
+	CheckFunc(t, "shout", upperFunc, []Case{
+		{
+			Args: []cty.Value{cty.StringVal("hi")},
+			Want: cty.StringVal("hi!"),
+		},
+	})
+}
+
+func TestCheckFuncErrorCase(t *testing.T) {
+	boom := function.New(&function.Spec{
+		Params: []function.Parameter{
+			{Name: "input", Type: cty.String},
+		},
+		Type: function.StaticReturnType(cty.String),
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			return cty.UnknownVal(cty.String), errors.New("always fails")
+		},
+	})
+
+	CheckFunc(t, "boom", boom, []Case{
+		{
+			Args: []cty.Value{cty.StringVal("x")},
+			Err:  true,
+		},
+	})
+}
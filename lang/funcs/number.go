@@ -1,13 +1,41 @@
 package funcs
 
 import (
+	"fmt"
 	"math"
+	"math/big"
 
 	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
 	"github.com/zclconf/go-cty/cty/function"
 	"github.com/zclconf/go-cty/cty/gocty"
 )
 
+// ceilOrFloor computes either the ceiling or the floor of num, working
+// directly in terms of its big.Float representation so that a number too
+// large to round-trip through float64 without losing precision is still
+// rounded correctly.
+func ceilOrFloor(num cty.Value, ceil bool) (cty.Value, error) {
+	bf := num.AsBigFloat()
+	if bf.IsInf() {
+		// Rounding an infinity is a no-op; there's no nearer whole number
+		// to round to.
+		return cty.NumberVal(bf), nil
+	}
+	if bf.IsInt() {
+		return cty.NumberVal(bf), nil
+	}
+
+	i, _ := bf.Int(nil) // truncates toward zero
+	switch {
+	case ceil && bf.Sign() > 0:
+		i.Add(i, big.NewInt(1))
+	case !ceil && bf.Sign() < 0:
+		i.Sub(i, big.NewInt(1))
+	}
+	return cty.NumberVal(new(big.Float).SetInt(i)), nil
+}
+
 // CeilFunc contructs a function that returns the closest whole number greater
 // than or equal to the given value.
 var CeilFunc = function.New(&function.Spec{
@@ -19,11 +47,7 @@ var CeilFunc = function.New(&function.Spec{
 	},
 	Type: function.StaticReturnType(cty.Number),
 	Impl: func(args []cty.Value, retType cty.Type) (ret cty.Value, err error) {
-		var val float64
-		if err := gocty.FromCtyValue(args[0], &val); err != nil {
-			return cty.UnknownVal(cty.String), err
-		}
-		return cty.NumberIntVal(int64(math.Ceil(val))), nil
+		return ceilOrFloor(args[0], true)
 	},
 })
 
@@ -38,11 +62,7 @@ var FloorFunc = function.New(&function.Spec{
 	},
 	Type: function.StaticReturnType(cty.Number),
 	Impl: func(args []cty.Value, retType cty.Type) (ret cty.Value, err error) {
-		var val float64
-		if err := gocty.FromCtyValue(args[0], &val); err != nil {
-			return cty.UnknownVal(cty.String), err
-		}
-		return cty.NumberIntVal(int64(math.Floor(val))), nil
+		return ceilOrFloor(args[0], false)
 	},
 })
 
@@ -70,7 +90,12 @@ var LogFunc = function.New(&function.Spec{
 			return cty.UnknownVal(cty.String), err
 		}
 
-		return cty.NumberFloatVal(math.Log(num) / math.Log(base)), nil
+		result := math.Log(num) / math.Log(base)
+		if math.IsNaN(result) {
+			return cty.UnknownVal(cty.Number), fmt.Errorf("log(%v, %v) is undefined", num, base)
+		}
+
+		return cty.NumberFloatVal(result), nil
 	},
 })
 
@@ -98,7 +123,12 @@ var PowFunc = function.New(&function.Spec{
 			return cty.UnknownVal(cty.String), err
 		}
 
-		return cty.NumberFloatVal(math.Pow(num, power)), nil
+		result := math.Pow(num, power)
+		if math.IsNaN(result) {
+			return cty.UnknownVal(cty.Number), fmt.Errorf("pow(%v, %v) is undefined", num, power)
+		}
+
+		return cty.NumberFloatVal(result), nil
 	},
 })
 
@@ -113,18 +143,94 @@ var SignumFunc = function.New(&function.Spec{
 	},
 	Type: function.StaticReturnType(cty.Number),
 	Impl: func(args []cty.Value, retType cty.Type) (ret cty.Value, err error) {
-		var num int
-		if err := gocty.FromCtyValue(args[0], &num); err != nil {
+		// We work in terms of the underlying big.Float rather than
+		// converting to a native int, since a number too large to fit in
+		// an int has a perfectly well-defined sign anyway.
+		return cty.NumberIntVal(int64(args[0].AsBigFloat().Sign())), nil
+	},
+})
+
+// ParseIntFunc contructs a function that parses a string argument and
+// returns a number, given a base.
+var ParseIntFunc = function.New(&function.Spec{
+	Params: []function.Parameter{
+		{
+			Name: "number",
+			Type: cty.String,
+		},
+		{
+			Name: "base",
+			Type: cty.Number,
+		},
+	},
+	Type: function.StaticReturnType(cty.Number),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		var numstr string
+		var base int
+
+		if err := gocty.FromCtyValue(args[0], &numstr); err != nil {
 			return cty.UnknownVal(cty.String), err
 		}
-		switch {
-		case num < 0:
-			return cty.NumberIntVal(-1), nil
-		case num > 0:
-			return cty.NumberIntVal(+1), nil
-		default:
-			return cty.NumberIntVal(0), nil
+
+		if err := gocty.FromCtyValue(args[1], &base); err != nil {
+			return cty.UnknownVal(cty.Number), err
+		}
+
+		if base < 2 || base > 62 {
+			return cty.UnknownVal(cty.Number), function.NewArgErrorf(1, "base must be between 2 and 62 inclusive")
+		}
+
+		num, ok := (&big.Int{}).SetString(numstr, base)
+		if !ok {
+			return cty.UnknownVal(cty.Number), function.NewArgErrorf(0, "cannot parse %q as a base %d integer", numstr, base)
 		}
+
+		parsedNum := cty.MustParseNumberVal(num.String())
+
+		return parsedNum, nil
+	},
+})
+
+// SumFunc contructs a function that returns the sum of the numbers in the
+// given list or set, without losing precision by round-tripping through
+// float64 as a naive loop over float64 accumulations would.
+var SumFunc = function.New(&function.Spec{
+	Params: []function.Parameter{
+		{
+			Name: "list",
+			Type: cty.DynamicPseudoType,
+		},
+	},
+	Type: function.StaticReturnType(cty.Number),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		ty := args[0].Type()
+
+		if !ty.IsListType() && !ty.IsSetType() && !ty.IsTupleType() {
+			return cty.NilVal, function.NewArgErrorf(0, "argument must be list, set, or tuple. Received %s", ty.FriendlyName())
+		}
+
+		if !args[0].IsWhollyKnown() {
+			return cty.UnknownVal(cty.Number), nil
+		}
+
+		if args[0].LengthInt() == 0 {
+			return cty.NilVal, function.NewArgErrorf(0, "cannot sum an empty list or set")
+		}
+
+		sum := new(big.Float)
+		for it := args[0].ElementIterator(); it.Next(); {
+			_, v := it.Element()
+			if v.IsNull() {
+				return cty.NilVal, function.NewArgErrorf(0, "argument must be list, set, or tuple of number values")
+			}
+			v, err := convert.Convert(v, cty.Number)
+			if err != nil {
+				return cty.NilVal, function.NewArgErrorf(0, "argument must be list, set, or tuple of number values")
+			}
+			sum.Add(sum, v.AsBigFloat())
+		}
+
+		return cty.NumberVal(sum), nil
 	},
 })
 
@@ -153,3 +259,13 @@ func Pow(num, power cty.Value) (cty.Value, error) {
 func Signum(num cty.Value) (cty.Value, error) {
 	return SignumFunc.Call([]cty.Value{num})
 }
+
+// ParseInt parses a string argument and returns a number, given a base.
+func ParseInt(num cty.Value, base cty.Value) (cty.Value, error) {
+	return ParseIntFunc.Call([]cty.Value{num, base})
+}
+
+// Sum adds numbers in a list, set, or tuple
+func Sum(list cty.Value) (cty.Value, error) {
+	return SumFunc.Call([]cty.Value{list})
+}
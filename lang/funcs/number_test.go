@@ -45,6 +45,192 @@ func TestCeil(t *testing.T) {
 	}
 }
 
+func TestCeil_bigNumber(t *testing.T) {
+	// A number far outside the range of float64 but that already happens to
+	// be a whole number should come back exactly as given, rather than
+	// losing precision by round-tripping through float64 on the way there.
+	big, err := cty.ParseNumberVal("100000000000000000000000000000000000001")
+	if err != nil {
+		t.Fatalf("failed to parse test number: %s", err)
+	}
+
+	got, err := Ceil(big)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !got.RawEquals(big) {
+		t.Errorf("wrong result\ngot:  %#v\nwant: %#v", got, big)
+	}
+}
+
+func TestParseInt(t *testing.T) {
+	tests := []struct {
+		Num  cty.Value
+		Base cty.Value
+		Want cty.Value
+		Err  bool
+	}{
+		{
+			cty.StringVal("128"),
+			cty.NumberIntVal(10),
+			cty.NumberIntVal(128),
+			false,
+		},
+		{
+			cty.StringVal("-128"),
+			cty.NumberIntVal(10),
+			cty.NumberIntVal(-128),
+			false,
+		},
+		{
+			cty.StringVal("00128"),
+			cty.NumberIntVal(10),
+			cty.NumberIntVal(128),
+			false,
+		},
+		{
+			cty.StringVal("-00128"),
+			cty.NumberIntVal(10),
+			cty.NumberIntVal(-128),
+			false,
+		},
+		{
+			cty.StringVal("FF"),
+			cty.NumberIntVal(16),
+			cty.NumberIntVal(255),
+			false,
+		},
+		{
+			cty.StringVal("-FF"),
+			cty.NumberIntVal(16),
+			cty.NumberIntVal(-255),
+			false,
+		},
+		{
+			// A number too big to fit in a float64 without losing
+			// precision should still parse exactly.
+			cty.StringVal("100000000000000000000000000000000000001"),
+			cty.NumberIntVal(10),
+			cty.MustParseNumberVal("100000000000000000000000000000000000001"),
+			false,
+		},
+		{
+			cty.StringVal("0023"),
+			cty.NumberIntVal(2),
+			cty.NilVal,
+			true, // "23" is not a valid base 2 integer
+		},
+		{
+			cty.StringVal("23"),
+			cty.NumberIntVal(1),
+			cty.NilVal,
+			true, // base must be between 2 and 62 inclusive
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("parseint(%#v, %#v)", test.Num, test.Base), func(t *testing.T) {
+			got, err := ParseInt(test.Num, test.Base)
+
+			if test.Err {
+				if err == nil {
+					t.Fatal("succeeded; want error")
+				}
+				return
+			} else if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if !got.RawEquals(test.Want) {
+				t.Errorf("wrong result\ngot:  %#v\nwant: %#v", got, test.Want)
+			}
+		})
+	}
+}
+
+func TestSum(t *testing.T) {
+	tests := []struct {
+		List cty.Value
+		Want cty.Value
+		Err  bool
+	}{
+		{
+			cty.ListVal([]cty.Value{
+				cty.NumberIntVal(1),
+				cty.NumberIntVal(2),
+				cty.NumberIntVal(3),
+			}),
+			cty.NumberIntVal(6),
+			false,
+		},
+		{
+			cty.SetVal([]cty.Value{
+				cty.NumberFloatVal(10.5),
+				cty.NumberFloatVal(0.5),
+			}),
+			cty.NumberFloatVal(11),
+			false,
+		},
+		{
+			// Precision must not be lost by round-tripping through float64.
+			cty.ListVal([]cty.Value{
+				cty.MustParseNumberVal("100000000000000000000000000000000000001"),
+				cty.NumberIntVal(1),
+			}),
+			cty.MustParseNumberVal("100000000000000000000000000000000000002"),
+			false,
+		},
+		{
+			cty.ListValEmpty(cty.Number),
+			cty.NilVal,
+			true, // cannot sum an empty list
+		},
+		{
+			cty.ListVal([]cty.Value{
+				cty.StringVal("a"),
+			}),
+			cty.NilVal,
+			true, // not a list of numbers
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("sum(%#v)", test.List), func(t *testing.T) {
+			got, err := Sum(test.List)
+
+			if test.Err {
+				if err == nil {
+					t.Fatal("succeeded; want error")
+				}
+				return
+			} else if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if !got.RawEquals(test.Want) {
+				t.Errorf("wrong result\ngot:  %#v\nwant: %#v", got, test.Want)
+			}
+		})
+	}
+}
+
+func TestSignum_bigNumber(t *testing.T) {
+	// A number too large to fit in an int (which the previous implementation
+	// converted through) still has a well-defined sign.
+	big, err := cty.ParseNumberVal("-100000000000000000000000000000000000001")
+	if err != nil {
+		t.Fatalf("failed to parse test number: %s", err)
+	}
+
+	got, err := Signum(big)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if want := cty.NumberIntVal(-1); !got.RawEquals(want) {
+		t.Errorf("wrong result\ngot:  %#v\nwant: %#v", got, want)
+	}
+}
+
 func TestFloor(t *testing.T) {
 	tests := []struct {
 		Num  cty.Value
@@ -0,0 +1,54 @@
+package funcs
+
+import (
+	"regexp"
+	"sync"
+
+	"github.com/hashicorp/golang-lru/simplelru"
+)
+
+// regexpCacheSize bounds the number of compiled regular expressions that
+// replace() will keep around at once. Configurations tend to reuse a small,
+// fixed set of patterns, so this doesn't need to be large.
+const regexpCacheSize = 256
+
+// regexpCache memoizes regexp.Compile by pattern so that functions which
+// accept a regular expression as a string argument, such as replace(), don't
+// need to recompile the same pattern on every call.
+var regexpCache = newSyncRegexpCache(regexpCacheSize)
+
+type syncRegexpCache struct {
+	mu  sync.Mutex
+	lru *simplelru.LRU
+}
+
+func newSyncRegexpCache(size int) *syncRegexpCache {
+	lru, err := simplelru.NewLRU(size, nil)
+	if err != nil {
+		// Only happens if size <= 0, which never applies to our constant.
+		panic(err)
+	}
+	return &syncRegexpCache{lru: lru}
+}
+
+// compileCached returns a compiled regexp for the given pattern, reusing a
+// previous compilation result if one is cached.
+func (c *syncRegexpCache) compileCached(pattern string) (*regexp.Regexp, error) {
+	c.mu.Lock()
+	if cached, ok := c.lru.Get(pattern); ok {
+		c.mu.Unlock()
+		return cached.(*regexp.Regexp), nil
+	}
+	c.mu.Unlock()
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.lru.Add(pattern, re)
+	c.mu.Unlock()
+
+	return re, nil
+}
@@ -0,0 +1,23 @@
+package funcs
+
+import "testing"
+
+func TestSyncRegexpCacheCompileCached(t *testing.T) {
+	c := newSyncRegexpCache(2)
+
+	re1, err := c.compileCached(`[a-z]+`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	re2, err := c.compileCached(`[a-z]+`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if re1 != re2 {
+		t.Error("expected the same compiled regexp to be returned from cache")
+	}
+
+	if _, err := c.compileCached(`(`); err == nil {
+		t.Fatal("succeeded; want error for invalid pattern")
+	}
+}
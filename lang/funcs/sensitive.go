@@ -0,0 +1,50 @@
+package funcs
+
+import (
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+// SensitiveFunc and NonsensitiveFunc are intended to mark and unmark values
+// as sensitive so that Terraform can avoid showing them in the UI, but the
+// version of cty vendored in this codebase does not yet support value marks.
+// Until that support lands, both functions are identity functions: they
+// return their argument unchanged. They are registered now so that
+// configurations can start using the sensitive(...) and nonsensitive(...)
+// call syntax without a parse error, ahead of the mark propagation work
+// landing in a future change.
+var SensitiveFunc = function.New(&function.Spec{
+	Params: []function.Parameter{
+		{
+			Name:             "value",
+			Type:             cty.DynamicPseudoType,
+			AllowNull:        true,
+			AllowUnknown:     true,
+			AllowDynamicType: true,
+		},
+	},
+	Type: func(args []cty.Value) (cty.Type, error) {
+		return args[0].Type(), nil
+	},
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		return args[0], nil
+	},
+})
+
+var NonsensitiveFunc = function.New(&function.Spec{
+	Params: []function.Parameter{
+		{
+			Name:             "value",
+			Type:             cty.DynamicPseudoType,
+			AllowNull:        true,
+			AllowUnknown:     true,
+			AllowDynamicType: true,
+		},
+	},
+	Type: func(args []cty.Value) (cty.Type, error) {
+		return args[0].Type(), nil
+	},
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		return args[0], nil
+	},
+})
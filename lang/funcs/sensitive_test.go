@@ -0,0 +1,29 @@
+package funcs
+
+import (
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestSensitiveNonsensitive(t *testing.T) {
+	for _, f := range []struct {
+		name string
+		fn   interface {
+			Call([]cty.Value) (cty.Value, error)
+		}
+	}{
+		{"sensitive", SensitiveFunc},
+		{"nonsensitive", NonsensitiveFunc},
+	} {
+		t.Run(f.name, func(t *testing.T) {
+			got, err := f.fn.Call([]cty.Value{cty.StringVal("shh")})
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !got.RawEquals(cty.StringVal("shh")) {
+				t.Errorf("wrong result %#v", got)
+			}
+		})
+	}
+}
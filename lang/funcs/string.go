@@ -189,7 +189,7 @@ var ReplaceFunc = function.New(&function.Spec{
 		// We search/replace using a regexp if the string is surrounded
 		// in forward slashes.
 		if len(substr) > 1 && substr[0] == '/' && substr[len(substr)-1] == '/' {
-			re, err := regexp.Compile(substr[1 : len(substr)-1])
+			re, err := regexpCache.compileCached(substr[1 : len(substr)-1])
 			if err != nil {
 				return cty.UnknownVal(cty.String), err
 			}
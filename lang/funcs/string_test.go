@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/hashicorp/terraform/lang/funcs/langtest"
 	"github.com/zclconf/go-cty/cty"
 )
 
@@ -506,3 +507,21 @@ func TestTrimSpace(t *testing.T) {
 		})
 	}
 }
+
+// TestTrimSpace_langtest demonstrates langtest.CheckFunc by running it
+// against the same cases as TestTrimSpace above, which also has the effect
+// of confirming that TrimSpaceFunc's single parameter gives an unknown
+// result for an unknown argument and rejects a null one rather than, say,
+// panicking on it.
+func TestTrimSpace_langtest(t *testing.T) {
+	langtest.CheckFunc(t, "trimspace", TrimSpaceFunc, []langtest.Case{
+		{
+			Args: []cty.Value{cty.StringVal(" hello ")},
+			Want: cty.StringVal("hello"),
+		},
+		{
+			Args: []cty.Value{cty.StringVal("")},
+			Want: cty.StringVal(""),
+		},
+	})
+}
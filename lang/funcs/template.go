@@ -0,0 +1,141 @@
+package funcs
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl2/hcl"
+	"github.com/hashicorp/hcl2/hcl/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+// maxTemplateStringSize is the largest template MakeTemplateStringFunc will
+// parse in a single call, guarding against a runaway template supplied by a
+// data source or other dynamic value rather than, as with templatefile, a
+// file the author controls directly. Overridden in tests, to avoid actually
+// constructing a template this large.
+var maxTemplateStringSize = 1 << 20 // 1MiB
+
+// maxTemplateStringRecursionDepth bounds how many times a template rendered
+// by MakeTemplateStringFunc may call templatestring again, directly or
+// indirectly, on a result derived from its own output. Unlike templatefile,
+// which can safely forbid recursive calls outright because its inputs are
+// files known ahead of time, templatestring's input is an arbitrary runtime
+// string, so there's no way to detect a self-referential template other
+// than bounding how deep the calls are allowed to nest before giving up.
+// Overridden in tests, to avoid actually nesting this many calls.
+var maxTemplateStringRecursionDepth = 1024
+
+// MakeTemplateStringFunc constructs a function that renders a template
+// given directly as a string, together with a map of variables to make
+// available to it, the same way MakeTemplateFileFunc does for a template
+// loaded from a file.
+//
+// This exists for templates that don't arrive as a file on disk at all --
+// for example, one returned by a data source -- so that such a template can
+// still be rendered using the same template language as templatefile,
+// without it having to be written out to a temporary file first.
+//
+// The template itself may recursively call other functions, including
+// templatestring itself up to maxTemplateStringRecursionDepth levels deep,
+// so a callback must be provided to get access to those functions. The
+// template cannot access any variables defined in the scope: it is
+// restricted only to those variables provided in the second function
+// argument, to ensure that all dependencies on other graph nodes can be
+// seen before executing this function.
+func MakeTemplateStringFunc(funcsCb func() map[string]function.Function) function.Function {
+	params := []function.Parameter{
+		{
+			Name: "template",
+			Type: cty.String,
+		},
+		{
+			Name: "vars",
+			Type: cty.DynamicPseudoType,
+		},
+	}
+
+	var renderTmpl func(templateStr string, varsVal cty.Value, depth int) (cty.Value, error)
+	renderTmpl = func(templateStr string, varsVal cty.Value, depth int) (cty.Value, error) {
+		if depth > maxTemplateStringRecursionDepth {
+			return cty.DynamicVal, fmt.Errorf("templatestring recursion exceeded %d levels; a template that calls templatestring on its own rendered output, directly or indirectly, can never terminate", maxTemplateStringRecursionDepth)
+		}
+		if len(templateStr) > maxTemplateStringSize {
+			return cty.DynamicVal, fmt.Errorf("template is %d bytes, which is larger than the %d byte limit for a single templatestring call", len(templateStr), maxTemplateStringSize)
+		}
+
+		expr, diags := hclsyntax.ParseTemplate([]byte(templateStr), "<templatestring>", hcl.Pos{Line: 1, Column: 1})
+		if diags.HasErrors() {
+			return cty.DynamicVal, diags
+		}
+
+		if varsTy := varsVal.Type(); !(varsTy.IsMapType() || varsTy.IsObjectType()) {
+			return cty.DynamicVal, function.NewArgErrorf(1, "invalid vars value: must be a map") // or an object, but we don't strongly distinguish these most of the time
+		}
+
+		ctx := &hcl.EvalContext{
+			Variables: varsVal.AsValueMap(),
+		}
+
+		// We'll pre-check references in the template here so we can give a
+		// more specialized error message than HCL would by default, so it's
+		// clearer that this problem is coming from a templatestring call.
+		for _, traversal := range expr.Variables() {
+			root := traversal.RootName()
+			if _, ok := ctx.Variables[root]; !ok {
+				return cty.DynamicVal, function.NewArgErrorf(1, "vars map does not contain key %q, referenced at %s", root, traversal[0].SourceRange())
+			}
+		}
+
+		givenFuncs := funcsCb() // this callback indirection is to avoid chicken/egg problems
+		funcs := make(map[string]function.Function, len(givenFuncs))
+		for name, fn := range givenFuncs {
+			if name == "templatestring" {
+				// Recursive calls are allowed, up to maxTemplateStringRecursionDepth,
+				// rather than forbidden outright as templatefile does, since a
+				// template supplied as a runtime value has no fixed identity we
+				// could use to detect it calling back into itself.
+				funcs[name] = function.New(&function.Spec{
+					Params: params,
+					Type: func(args []cty.Value) (cty.Type, error) {
+						if !(args[0].IsKnown() && args[1].IsKnown()) {
+							return cty.DynamicPseudoType, nil
+						}
+						val, err := renderTmpl(args[0].AsString(), args[1], depth+1)
+						return val.Type(), err
+					},
+					Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+						return renderTmpl(args[0].AsString(), args[1], depth+1)
+					},
+				})
+				continue
+			}
+			funcs[name] = fn
+		}
+		ctx.Functions = funcs
+
+		val, diags := expr.Value(ctx)
+		if diags.HasErrors() {
+			return cty.DynamicVal, diags
+		}
+		return val, nil
+	}
+
+	return function.New(&function.Spec{
+		Params: params,
+		Type: func(args []cty.Value) (cty.Type, error) {
+			if !(args[0].IsKnown() && args[1].IsKnown()) {
+				return cty.DynamicPseudoType, nil
+			}
+
+			// We'll render our template now to see what result type it produces.
+			// A template consisting only of a single interpolation can
+			// potentially return any type.
+			val, err := renderTmpl(args[0].AsString(), args[1], 0)
+			return val.Type(), err
+		},
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			return renderTmpl(args[0].AsString(), args[1], 0)
+		},
+	})
+}
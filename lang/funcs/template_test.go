@@ -0,0 +1,135 @@
+package funcs
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+func TestTemplateString(t *testing.T) {
+	tests := []struct {
+		Template cty.Value
+		Vars     cty.Value
+		Want     cty.Value
+		Err      bool
+	}{
+		{
+			cty.StringVal("Hello World"),
+			cty.EmptyObjectVal,
+			cty.StringVal("Hello World"),
+			false,
+		},
+		{
+			cty.StringVal("Hello, ${name}!"),
+			cty.MapVal(map[string]cty.Value{
+				"name": cty.StringVal("Jodie"),
+			}),
+			cty.StringVal("Hello, Jodie!"),
+			false,
+		},
+		{
+			cty.StringVal("Hello, ${name}!"),
+			cty.ObjectVal(map[string]cty.Value{
+				"name": cty.StringVal("Jimbo"),
+			}),
+			cty.StringVal("Hello, Jimbo!"),
+			false,
+		},
+		{
+			cty.StringVal("Hello, ${name}!"),
+			cty.EmptyObjectVal,
+			cty.NilVal,
+			true, // "name" is missing from the vars map
+		},
+		{
+			cty.StringVal("${val}"),
+			cty.ObjectVal(map[string]cty.Value{
+				"val": cty.True,
+			}),
+			cty.True, // since this template contains only an interpolation, its true value shines through
+			false,
+		},
+	}
+
+	templateStringFn := MakeTemplateStringFunc(func() map[string]function.Function {
+		return map[string]function.Function{
+			"join": JoinFunc,
+		}
+	})
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("TemplateString(%#v, %#v)", test.Template, test.Vars), func(t *testing.T) {
+			got, err := templateStringFn.Call([]cty.Value{test.Template, test.Vars})
+
+			if argErr, ok := err.(function.ArgError); ok {
+				if argErr.Index < 0 || argErr.Index > 1 {
+					t.Errorf("ArgError index %d is out of range for templatestring (must be 0 or 1)", argErr.Index)
+				}
+			}
+
+			if test.Err {
+				if err == nil {
+					t.Fatal("succeeded; want error")
+				}
+				return
+			} else if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if !got.RawEquals(test.Want) {
+				t.Errorf("wrong result\ngot:  %#v\nwant: %#v", got, test.Want)
+			}
+		})
+	}
+}
+
+func TestTemplateString_size(t *testing.T) {
+	defer func(orig int) { maxTemplateStringSize = orig }(maxTemplateStringSize)
+	maxTemplateStringSize = 4
+
+	templateStringFn := MakeTemplateStringFunc(func() map[string]function.Function {
+		return map[string]function.Function{}
+	})
+
+	_, err := templateStringFn.Call([]cty.Value{cty.StringVal("Hello World"), cty.EmptyObjectVal})
+	if err == nil {
+		t.Fatal("succeeded; want error")
+	}
+	if got, want := err.Error(), "larger than the 4 byte limit"; !strings.Contains(got, want) {
+		t.Fatalf("wrong error\ngot:  %s\nwant substring: %s", got, want)
+	}
+}
+
+func TestTemplateString_recursion(t *testing.T) {
+	defer func(orig int) { maxTemplateStringRecursionDepth = orig }(maxTemplateStringRecursionDepth)
+	maxTemplateStringRecursionDepth = 2
+
+	var templateStringFn function.Function
+	templateStringFn = MakeTemplateStringFunc(func() map[string]function.Function {
+		return map[string]function.Function{
+			"templatestring": templateStringFn,
+		}
+	})
+
+	// A self-referential template: it calls templatestring again on exactly
+	// itself, passing itself along again as "v" each time, so it would
+	// recurse forever if not for the depth limit.
+	tmpl := `${templatestring(v, {v = v})}`
+	vars := cty.ObjectVal(map[string]cty.Value{
+		"v": cty.StringVal(tmpl),
+	})
+
+	_, err := templateStringFn.Call([]cty.Value{
+		cty.StringVal(tmpl),
+		vars,
+	})
+	if err == nil {
+		t.Fatal("succeeded; want error")
+	}
+	if got, want := err.Error(), "recursion exceeded"; !strings.Contains(got, want) {
+		t.Fatalf("wrong error\ngot:  %s\nwant substring: %s", got, want)
+	}
+}
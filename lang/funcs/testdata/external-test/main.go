@@ -0,0 +1,43 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+)
+
+// This is a simple program that implements the query/result JSON protocol
+// expected by the "external" function, for unit testing purposes.
+
+func main() {
+	src, err := ioutil.ReadAll(os.Stdin)
+	if err != nil {
+		die("failed to read query: %s", err)
+	}
+
+	var query map[string]string
+	if err := json.Unmarshal(src, &query); err != nil {
+		die("invalid query: %s", err)
+	}
+
+	if _, fail := query["fail"]; fail {
+		die("failing because you told me to fail")
+	}
+
+	result := make(map[string]string, len(query))
+	for k, v := range query {
+		result[k] = v + "-result"
+	}
+
+	out, err := json.Marshal(result)
+	if err != nil {
+		die("failed to encode result: %s", err)
+	}
+	fmt.Print(string(out))
+}
+
+func die(f string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, fmt.Sprintf(f, args...))
+	os.Exit(1)
+}
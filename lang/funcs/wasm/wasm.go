@@ -0,0 +1,34 @@
+// Package wasm is the intended home for loading a user-defined function
+// from a WebAssembly module named by a "function" block in the CLI
+// configuration (see command/cliconfig.ConfigFunction) and adapting it into
+// a function.Function that a lang.Scope can register alongside its built-in
+// functions.
+//
+// This module doesn't yet vendor a WebAssembly runtime, so LoadFunction
+// always fails; see its doc comment for what a real implementation would
+// still need to do.
+package wasm
+
+import (
+	"fmt"
+
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+// LoadFunction is the extension point that command/cliconfig's "function"
+// blocks are meant to call into: given the path to a compiled .wasm module,
+// it would load that module into a sandboxed runtime and return a
+// function.Function that marshals its cty.Value arguments to and from
+// whatever representation the module's exported entry point expects.
+//
+// This build of Terraform has no WebAssembly runtime compiled in, so
+// LoadFunction always returns an error rather than silently ignoring the
+// requested module or pretending to execute it. Supporting this for real
+// would mean vendoring a WASM runtime (such as wasmtime-go or wasmer-go),
+// choosing and documenting a calling convention for marshaling cty values
+// across the sandbox boundary, and enforcing resource limits -- memory,
+// fuel/time, and host-call surface -- on the loaded module, none of which
+// this package does yet.
+func LoadFunction(name, wasmFile string) (function.Function, error) {
+	return function.Function{}, fmt.Errorf("function %q: this build of Terraform does not support WebAssembly user functions (wasm_file %q was not loaded)", name, wasmFile)
+}
@@ -0,0 +1,16 @@
+package wasm
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLoadFunction(t *testing.T) {
+	_, err := LoadFunction("double", "./double.wasm")
+	if err == nil {
+		t.Fatal("succeeded; want error")
+	}
+	if got, want := err.Error(), "does not support WebAssembly"; !strings.Contains(got, want) {
+		t.Errorf("wrong error\ngot:  %s\nwant substring: %s", got, want)
+	}
+}
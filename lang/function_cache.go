@@ -0,0 +1,124 @@
+package lang
+
+import (
+	"sync"
+
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// memoizableFunctions lists the names of functions that are safe to memoize:
+// given the same arguments, they always produce the same result and have no
+// side-effects other than the (idempotent) work of producing that result.
+//
+// This is deliberately conservative. Functions that read mutable external
+// state that can legitimately change between calls (such as timestamp or
+// uuid) must never appear here.
+var memoizableFunctions = []string{
+	"file",
+	"filebase64",
+	"filebase64sha256",
+	"filebase64sha512",
+	"filemd5",
+	"filesha1",
+	"filesha256",
+	"filesha512",
+	"templatefile",
+	"templatestring",
+	"md5",
+	"sha1",
+	"sha256",
+	"sha512",
+	"base64sha256",
+	"base64sha512",
+	"uuidv5",
+}
+
+// FunctionCache is an optional memoization cache that a Scope can use to
+// avoid recomputing the result of calling the same deterministic function
+// with the same arguments more than once.
+//
+// A single FunctionCache can be shared between multiple Scopes, such as
+// across all of the scopes used to evaluate a single configuration, so that
+// repeated calls to functions like file or templatefile within many
+// expressions only do the underlying work once.
+//
+// The zero value of FunctionCache is not valid; use NewFunctionCache.
+type FunctionCache struct {
+	mu      sync.Mutex
+	results map[string]cachedFuncResult
+}
+
+type cachedFuncResult struct {
+	val cty.Value
+	err error
+}
+
+// NewFunctionCache returns a new, empty FunctionCache ready to be assigned
+// to one or more Scopes via Scope.FuncResultsCache.
+func NewFunctionCache() *FunctionCache {
+	return &FunctionCache{
+		results: make(map[string]cachedFuncResult),
+	}
+}
+
+func (c *FunctionCache) get(key string) (cachedFuncResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	result, ok := c.results[key]
+	return result, ok
+}
+
+func (c *FunctionCache) set(key string, result cachedFuncResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.results[key] = result
+}
+
+// memoize wraps f so that calls with arguments that can be serialized into
+// a stable cache key are recorded in c and reused on subsequent calls,
+// rather than re-executing the underlying implementation.
+//
+// Calls whose arguments cannot be serialized (which should not normally
+// happen for the functions we memoize) are simply passed through to f
+// without being cached.
+func (c *FunctionCache) memoize(name string, f function.Function) function.Function {
+	spec := &function.Spec{
+		Params:   f.Params(),
+		VarParam: f.VarParam(),
+		Type: func(args []cty.Value) (cty.Type, error) {
+			return f.ReturnTypeForValues(args)
+		},
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			key, ok := funcCacheKey(name, args)
+			if !ok {
+				return f.Call(args)
+			}
+
+			if cached, hit := c.get(key); hit {
+				return cached.val, cached.err
+			}
+
+			val, err := f.Call(args)
+			c.set(key, cachedFuncResult{val: val, err: err})
+			return val, err
+		},
+	}
+	return function.New(spec)
+}
+
+// funcCacheKey builds a stable string key identifying a call to the named
+// function with the given arguments, or returns false if the arguments
+// cannot be serialized into such a key.
+func funcCacheKey(name string, args []cty.Value) (string, bool) {
+	key := name
+	for _, arg := range args {
+		raw, err := ctyjson.Marshal(arg, arg.Type())
+		if err != nil {
+			return "", false
+		}
+		key += "\x00" + string(raw)
+	}
+	return key, true
+}
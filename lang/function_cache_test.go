@@ -0,0 +1,45 @@
+package lang
+
+import (
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+func TestFunctionCacheMemoize(t *testing.T) {
+	calls := 0
+	inner := function.New(&function.Spec{
+		Params: []function.Parameter{
+			{Name: "input", Type: cty.String},
+		},
+		Type: function.StaticReturnType(cty.String),
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			calls++
+			return cty.StringVal(args[0].AsString() + "!"), nil
+		},
+	})
+
+	cache := NewFunctionCache()
+	wrapped := cache.memoize("shout", inner)
+
+	for i := 0; i < 3; i++ {
+		got, err := wrapped.Call([]cty.Value{cty.StringVal("hi")})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if got.AsString() != "hi!" {
+			t.Fatalf("wrong result %#v", got)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("expected underlying function to be called once, got %d calls", calls)
+	}
+
+	if _, err := wrapped.Call([]cty.Value{cty.StringVal("bye")}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if calls != 2 {
+		t.Fatalf("expected a cache miss for a different argument, got %d calls", calls)
+	}
+}
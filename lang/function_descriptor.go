@@ -0,0 +1,82 @@
+package lang
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+// ParamDescriptor describes a single parameter of a function, for use in
+// FunctionDescriptor.
+type ParamDescriptor struct {
+	Name     string
+	TypeName string
+}
+
+// FunctionDescriptor describes one of the functions available in a Scope,
+// with enough detail for a caller such as a language server to offer
+// signature help or completion candidates at a cursor position.
+//
+// The function.Function values produced by this package's callers don't
+// carry any documentation strings, so Description is always empty here;
+// a language server wanting human-readable prose for each function will
+// still need to maintain that separately, keyed by Name.
+type FunctionDescriptor struct {
+	Name        string
+	Params      []ParamDescriptor
+	VarParam    *ParamDescriptor
+	Signature   string
+	Description string
+}
+
+// FunctionDescriptors returns a FunctionDescriptor for every function
+// registered in the receiving scope, sorted by name.
+func (s *Scope) FunctionDescriptors() []FunctionDescriptor {
+	funcs := s.Functions()
+	names := make([]string, 0, len(funcs))
+	for name := range funcs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	descs := make([]FunctionDescriptor, 0, len(names))
+	for _, name := range names {
+		descs = append(descs, describeFunction(name, funcs[name]))
+	}
+	return descs
+}
+
+func describeFunction(name string, f function.Function) FunctionDescriptor {
+	params := f.Params()
+	desc := FunctionDescriptor{
+		Name:   name,
+		Params: make([]ParamDescriptor, len(params)),
+	}
+	for i, p := range params {
+		desc.Params[i] = ParamDescriptor{
+			Name:     p.Name,
+			TypeName: p.Type.FriendlyName(),
+		}
+	}
+	if vp := f.VarParam(); vp != nil {
+		desc.VarParam = &ParamDescriptor{
+			Name:     vp.Name,
+			TypeName: vp.Type.FriendlyName(),
+		}
+	}
+	desc.Signature = functionSignature(desc)
+	return desc
+}
+
+func functionSignature(desc FunctionDescriptor) string {
+	parts := make([]string, 0, len(desc.Params)+1)
+	for _, p := range desc.Params {
+		parts = append(parts, fmt.Sprintf("%s %s", p.Name, p.TypeName))
+	}
+	if desc.VarParam != nil {
+		parts = append(parts, fmt.Sprintf("%s %s...", desc.VarParam.Name, desc.VarParam.TypeName))
+	}
+	return fmt.Sprintf("%s(%s)", desc.Name, strings.Join(parts, ", "))
+}
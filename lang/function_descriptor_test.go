@@ -0,0 +1,49 @@
+package lang
+
+import "testing"
+
+func TestScopeFunctionDescriptors(t *testing.T) {
+	s := &Scope{}
+	descs := s.FunctionDescriptors()
+
+	if len(descs) == 0 {
+		t.Fatal("expected at least one function descriptor")
+	}
+
+	var upper *FunctionDescriptor
+	for i := range descs {
+		if descs[i].Name == "upper" {
+			upper = &descs[i]
+			break
+		}
+	}
+	if upper == nil {
+		t.Fatal("expected a descriptor for \"upper\"")
+	}
+	if len(upper.Params) != 1 {
+		t.Fatalf("wrong param count for upper: %#v", upper.Params)
+	}
+	if got, want := upper.Signature, "upper(str string)"; got != want {
+		t.Errorf("wrong signature\ngot:  %s\nwant: %s", got, want)
+	}
+
+	var coalesce *FunctionDescriptor
+	for i := range descs {
+		if descs[i].Name == "coalesce" {
+			coalesce = &descs[i]
+			break
+		}
+	}
+	if coalesce == nil {
+		t.Fatal("expected a descriptor for \"coalesce\"")
+	}
+	if coalesce.VarParam == nil {
+		t.Fatal("expected coalesce to have a variadic parameter")
+	}
+
+	for i := 1; i < len(descs); i++ {
+		if descs[i-1].Name > descs[i].Name {
+			t.Fatalf("descriptors not sorted by name: %q before %q", descs[i-1].Name, descs[i].Name)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package lang
+
+import (
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+// FunctionError is an error returned by a call to one of the functions
+// registered in a Scope. It records which function failed and, if the
+// failure related to one particular argument, which argument that was, so
+// that callers can programmatically react to specific failures rather than
+// just reporting the message to the user.
+//
+// Note that this type doesn't itself carry the source text of the
+// offending argument, because it's constructed from inside a
+// function.Function's Impl, which only ever sees cty.Values and has no
+// visibility into the hcl.Expression that produced them. That's not a
+// problem in practice: when ArgIndex identifies a specific argument, HCL's
+// own function-call evaluator (in hclsyntax.FunctionCallExpr.Value) sets
+// the resulting diagnostic's Subject and Expression to that argument's own
+// expression, not the call as a whole. For a call that's nested inside
+// another, such as merge(a, f(b)), this happens independently at each
+// level, so the final diagnostic's Subject always lands on the innermost
+// expression that's actually at fault. A diagnostic renderer that prints
+// source context from a Subject range, such as command/format.Diagnostic,
+// therefore already shows the precise offending argument without this
+// package needing to extract or duplicate that text itself.
+type FunctionError struct {
+	// FuncName is the name that the failing function is registered under
+	// in the scope's function table.
+	FuncName string
+
+	// ArgIndex is the zero-based index of the argument that caused the
+	// failure. It is only meaningful when HasArgIndex is true.
+	ArgIndex int
+
+	// HasArgIndex is true if this error relates to a specific argument
+	// rather than to the function call as a whole.
+	HasArgIndex bool
+
+	// Err is the underlying error describing the failure.
+	Err error
+}
+
+func (e *FunctionError) Error() string {
+	return e.Err.Error()
+}
+
+// Cause returns the underlying error that FunctionError wraps.
+func (e *FunctionError) Cause() error {
+	return e.Err
+}
+
+// wrapFunctionErrors returns a copy of f whose errors are wrapped in
+// *FunctionError, preserving any function.ArgError so that callers such as
+// HCL's function-call evaluator can still recognize argument-specific
+// failures and produce their usual diagnostics.
+func wrapFunctionErrors(name string, f function.Function) function.Function {
+	spec := &function.Spec{
+		Params:   f.Params(),
+		VarParam: f.VarParam(),
+		Type: func(args []cty.Value) (cty.Type, error) {
+			return f.ReturnTypeForValues(args)
+		},
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			val, err := f.Call(args)
+			if err == nil {
+				return val, nil
+			}
+			return val, wrapFunctionError(name, err)
+		},
+	}
+	return function.New(spec)
+}
+
+func wrapFunctionError(name string, err error) error {
+	if argErr, ok := err.(function.ArgError); ok {
+		return function.NewArgError(argErr.Index, &FunctionError{
+			FuncName:    name,
+			ArgIndex:    argErr.Index,
+			HasArgIndex: true,
+			Err:         argErr,
+		})
+	}
+	return &FunctionError{
+		FuncName: name,
+		Err:      err,
+	}
+}
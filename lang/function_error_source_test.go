@@ -0,0 +1,48 @@
+package lang
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl2/hcl"
+	"github.com/hashicorp/hcl2/hcl/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// TestScopeEvalExprFunctionArgDiagnosticSource confirms that a diagnostic
+// produced by a failing function call argument identifies the source range
+// of that specific argument expression -- even when it's buried inside a
+// call to another function -- rather than just the call as a whole. This
+// is what lets a diagnostic renderer such as command/format.Diagnostic
+// print a source snippet of the actual offending sub-expression.
+func TestScopeEvalExprFunctionArgDiagnosticSource(t *testing.T) {
+	scope := &Scope{}
+
+	src := []byte(`merge({}, { a = signum("notanumber") })`)
+	expr, parseDiags := hclsyntax.ParseExpression(src, "test.tf", hcl.InitialPos)
+	if parseDiags.HasErrors() {
+		t.Fatalf("parse error: %s", parseDiags)
+	}
+
+	_, diags := scope.EvalExpr(expr, cty.DynamicPseudoType)
+	if !diags.HasErrors() {
+		t.Fatal("expected an error evaluating the expression")
+	}
+
+	var subject *hcl.Range
+	for _, diag := range diags {
+		if src := diag.Source(); src.Subject != nil {
+			rng := src.Subject.ToHCL()
+			subject = &rng
+			break
+		}
+	}
+	if subject == nil {
+		t.Fatal("no diagnostic carried a source range")
+	}
+
+	got := string(subject.SliceBytes(src))
+	want := `notanumber`
+	if got != want {
+		t.Errorf("wrong diagnostic subject source text\ngot:  %s\nwant: %s", got, want)
+	}
+}
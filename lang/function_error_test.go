@@ -0,0 +1,69 @@
+package lang
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+func TestWrapFunctionErrors(t *testing.T) {
+	t.Run("argument error", func(t *testing.T) {
+		boom := function.New(&function.Spec{
+			Params: []function.Parameter{
+				{Name: "input", Type: cty.String},
+			},
+			Type: function.StaticReturnType(cty.String),
+			Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+				return cty.UnknownVal(cty.String), function.NewArgError(0, errors.New("bad input"))
+			},
+		})
+
+		wrapped := wrapFunctionErrors("boom", boom)
+		_, err := wrapped.Call([]cty.Value{cty.StringVal("x")})
+		if err == nil {
+			t.Fatal("expected an error")
+		}
+
+		argErr, ok := err.(function.ArgError)
+		if !ok {
+			t.Fatalf("expected a function.ArgError, got %T", err)
+		}
+		if argErr.Index != 0 {
+			t.Errorf("wrong argument index %d", argErr.Index)
+		}
+		if argErr.Error() != "bad input" {
+			t.Errorf("wrong message %q", argErr.Error())
+		}
+	})
+
+	t.Run("general error", func(t *testing.T) {
+		boom := function.New(&function.Spec{
+			Params: []function.Parameter{
+				{Name: "input", Type: cty.String},
+			},
+			Type: function.StaticReturnType(cty.String),
+			Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+				return cty.UnknownVal(cty.String), errors.New("kaboom")
+			},
+		})
+
+		wrapped := wrapFunctionErrors("boom", boom)
+		_, err := wrapped.Call([]cty.Value{cty.StringVal("x")})
+
+		funcErr, ok := err.(*FunctionError)
+		if !ok {
+			t.Fatalf("expected a *FunctionError, got %T", err)
+		}
+		if funcErr.FuncName != "boom" {
+			t.Errorf("wrong func name %q", funcErr.FuncName)
+		}
+		if funcErr.HasArgIndex {
+			t.Errorf("should not have an argument index")
+		}
+		if funcErr.Error() != "kaboom" {
+			t.Errorf("wrong message %q", funcErr.Error())
+		}
+	})
+}
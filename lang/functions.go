@@ -15,6 +15,8 @@ var impureFunctions = []string{
 	"bcrypt",
 	"timestamp",
 	"uuid",
+	"env",
+	"external",
 }
 
 // Functions returns the set of functions that should be used to when evaluating
@@ -44,12 +46,15 @@ func (s *Scope) Functions() map[string]function.Function {
 			"cidrhost":         funcs.CidrHostFunc,
 			"cidrnetmask":      funcs.CidrNetmaskFunc,
 			"cidrsubnet":       funcs.CidrSubnetFunc,
+			"cidrsubnets":      funcs.CidrSubnetsFunc,
 			"coalesce":         funcs.CoalesceFunc,
 			"coalescelist":     funcs.CoalesceListFunc,
 			"compact":          funcs.CompactFunc,
 			"concat":           stdlib.ConcatFunc,
 			"contains":         funcs.ContainsFunc,
 			"csvdecode":        stdlib.CSVDecodeFunc,
+			"debug":            funcs.DebugFunc,
+			"defaults":         funcs.DefaultsFunc,
 			"dirname":          funcs.DirnameFunc,
 			"distinct":         funcs.DistinctFunc,
 			"element":          funcs.ElementFunc,
@@ -86,6 +91,7 @@ func (s *Scope) Functions() map[string]function.Function {
 			"md5":              funcs.Md5Func,
 			"merge":            funcs.MergeFunc,
 			"min":              stdlib.MinFunc,
+			"parseint":         funcs.ParseIntFunc,
 			"pathexpand":       funcs.PathExpandFunc,
 			"pow":              funcs.PowFunc,
 			"range":            stdlib.RangeFunc,
@@ -97,6 +103,8 @@ func (s *Scope) Functions() map[string]function.Function {
 			"setintersection":  stdlib.SetIntersectionFunc,
 			"setproduct":       funcs.SetProductFunc,
 			"setunion":         stdlib.SetUnionFunc,
+			"sensitive":        funcs.SensitiveFunc,
+			"nonsensitive":     funcs.NonsensitiveFunc,
 			"sha1":             funcs.Sha1Func,
 			"sha256":           funcs.Sha256Func,
 			"sha512":           funcs.Sha512Func,
@@ -104,10 +112,16 @@ func (s *Scope) Functions() map[string]function.Function {
 			"slice":            funcs.SliceFunc,
 			"sort":             funcs.SortFunc,
 			"split":            funcs.SplitFunc,
+			"try":              tryFunc,
+			"can":              canFunc,
 			"strrev":           stdlib.ReverseFunc,
 			"substr":           stdlib.SubstrFunc,
+			"sum":              funcs.SumFunc,
+			"textdecodebase64": funcs.TextDecodeBase64Func,
+			"textencodebase64": funcs.TextEncodeBase64Func,
 			"timestamp":        funcs.TimestampFunc,
 			"timeadd":          funcs.TimeAddFunc,
+			"totimezone":       funcs.ToTimeZoneFunc,
 			"title":            funcs.TitleFunc,
 			"tostring":         funcs.MakeToFunc(cty.String),
 			"tonumber":         funcs.MakeToFunc(cty.Number),
@@ -116,6 +130,8 @@ func (s *Scope) Functions() map[string]function.Function {
 			"tolist":           funcs.MakeToFunc(cty.List(cty.DynamicPseudoType)),
 			"tomap":            funcs.MakeToFunc(cty.Map(cty.DynamicPseudoType)),
 			"transpose":        funcs.TransposeFunc,
+			"type":             funcs.TypeFunc,
+			"typeof":           funcs.TypeFunc,
 			"trimspace":        funcs.TrimSpaceFunc,
 			"upper":            stdlib.UpperFunc,
 			"urlencode":        funcs.URLEncodeFunc,
@@ -133,11 +149,60 @@ func (s *Scope) Functions() map[string]function.Function {
 			return s.funcs
 		})
 
+		s.funcs["templatestring"] = funcs.MakeTemplateStringFunc(func() map[string]function.Function {
+			// Unlike templatefile, templatestring allows bounded recursive
+			// calls to itself; see MakeTemplateStringFunc for why.
+			return s.funcs
+		})
+
+		if s.EnableEnvInterpolation {
+			s.funcs["env"] = funcs.EnvFunc
+		}
+
+		if s.EnableExternalFunction {
+			s.funcs["external"] = funcs.MakeExternalFunc(s.ExternalPrograms)
+		}
+
+		for name, f := range s.funcs {
+			s.funcs[name] = wrapFunctionErrors(name, f)
+		}
+
+		for name, f := range s.funcs {
+			s.funcs[name] = wrapFunctionTracing(name, f, func() EvalTracer { return s.Tracer })
+		}
+
 		if s.PureOnly {
 			// Force our few impure functions to return unknown so that we
 			// can defer evaluating them until a later pass.
 			for _, name := range impureFunctions {
-				s.funcs[name] = function.Unpredictable(s.funcs[name])
+				f, exists := s.funcs[name]
+				if !exists {
+					continue
+				}
+				s.funcs[name] = function.Unpredictable(f)
+			}
+		}
+
+		if s.StrictDecoding {
+			for _, name := range encodingFunctionsToGuard {
+				f, exists := s.funcs[name]
+				if !exists {
+					continue
+				}
+				s.funcs[name] = wrapStrictDecoding(name, f)
+			}
+		}
+
+		if s.FuncResultsCache != nil {
+			// Wrap the functions that are known to be deterministic and
+			// side-effect-free so that repeated calls with the same
+			// arguments are served from cache.
+			for _, name := range memoizableFunctions {
+				f, exists := s.funcs[name]
+				if !exists {
+					continue
+				}
+				s.funcs[name] = s.FuncResultsCache.memoize(name, f)
 			}
 		}
 	}
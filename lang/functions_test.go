@@ -84,7 +84,7 @@ func TestFunctions(t *testing.T) {
 		"base64gzip": {
 			{
 				`base64gzip("test")`,
-				cty.StringVal("H4sIAAAAAAAA/ypJLS4BAAAA//8BAAD//wx+f9gEAAAA"),
+				cty.StringVal("H4sIAAAAAAAA/ypJLS4BBAAA//8Mfn/YBAAAAA=="),
 			},
 		},
 
@@ -109,6 +109,17 @@ func TestFunctions(t *testing.T) {
 			},
 		},
 
+		"can": {
+			{
+				`can("hello")`,
+				cty.True,
+			},
+			{
+				`can([1, 2][10])`,
+				cty.False,
+			},
+		},
+
 		"ceil": {
 			{
 				`ceil(1.2)`,
@@ -161,6 +172,17 @@ func TestFunctions(t *testing.T) {
 			},
 		},
 
+		"cidrsubnets": {
+			{
+				`cidrsubnets("10.0.0.0/16", 4, 4, 8)`,
+				cty.ListVal([]cty.Value{
+					cty.StringVal("10.0.0.0/20"),
+					cty.StringVal("10.0.16.0/20"),
+					cty.StringVal("10.0.32.0/24"),
+				}),
+			},
+		},
+
 		"coalesce": {
 			{
 				`coalesce("first", "second", "third")`,
@@ -242,6 +264,23 @@ func TestFunctions(t *testing.T) {
 			},
 		},
 
+		"debug": {
+			{
+				`debug("hello")`,
+				cty.StringVal("hello"),
+			},
+		},
+
+		"defaults": {
+			{
+				`defaults({name = null, size = 3}, {name = "unnamed", size = 1})`,
+				cty.ObjectVal(map[string]cty.Value{
+					"name": cty.StringVal("unnamed"),
+					"size": cty.NumberIntVal(3),
+				}),
+			},
+		},
+
 		"dirname": {
 			{
 				`dirname("testdata/hello.txt")`,
@@ -544,6 +583,17 @@ func TestFunctions(t *testing.T) {
 			},
 		},
 
+		"parseint": {
+			{
+				`parseint("100", 10)`,
+				cty.NumberIntVal(100),
+			},
+			{
+				`parseint("FF", 16)`,
+				cty.NumberIntVal(255),
+			},
+		},
+
 		"pathexpand": {
 			{
 				`pathexpand("~/test-file")`,
@@ -703,6 +753,20 @@ func TestFunctions(t *testing.T) {
 			},
 		},
 
+		"sensitive": {
+			{
+				`sensitive("secret")`,
+				cty.StringVal("secret"),
+			},
+		},
+
+		"nonsensitive": {
+			{
+				`nonsensitive("secret")`,
+				cty.StringVal("secret"),
+			},
+		},
+
 		"sort": {
 			{
 				`sort(["banana", "apple"])`,
@@ -737,6 +801,27 @@ func TestFunctions(t *testing.T) {
 			},
 		},
 
+		"sum": {
+			{
+				`sum([1, 2, 3])`,
+				cty.NumberIntVal(6),
+			},
+		},
+
+		"textdecodebase64": {
+			{
+				`textdecodebase64("SABlAGwAbABvACAAVwBvAHIAbABkAA==", "UTF-16LE")`,
+				cty.StringVal("Hello World"),
+			},
+		},
+
+		"textencodebase64": {
+			{
+				`textencodebase64("Hello World", "UTF-16LE")`,
+				cty.StringVal("SABlAGwAbABvACAAVwBvAHIAbABkAA=="),
+			},
+		},
+
 		"templatefile": {
 			{
 				`templatefile("hello.tmpl", {name = "Jodie"})`,
@@ -744,6 +829,13 @@ func TestFunctions(t *testing.T) {
 			},
 		},
 
+		"templatestring": {
+			{
+				`templatestring("Hello, $${name}!", {name = "Jodie"})`,
+				cty.StringVal("Hello, Jodie!"),
+			},
+		},
+
 		"timeadd": {
 			{
 				`timeadd("2017-11-22T00:00:00Z", "1s")`,
@@ -807,6 +899,13 @@ func TestFunctions(t *testing.T) {
 			},
 		},
 
+		"totimezone": {
+			{
+				`totimezone("2017-11-22T00:00:00Z", "UTC")`,
+				cty.StringVal("2017-11-22T00:00:00Z"),
+			},
+		},
+
 		"transpose": {
 			{
 				`transpose({"a" = ["1", "2"], "b" = ["2", "3"]})`,
@@ -825,6 +924,35 @@ func TestFunctions(t *testing.T) {
 			},
 		},
 
+		"type": {
+			{
+				`type("a")`,
+				cty.StringVal("string"),
+			},
+			{
+				`type(1)`,
+				cty.StringVal("number"),
+			},
+		},
+
+		"typeof": {
+			{
+				`typeof("a")`,
+				cty.StringVal("string"),
+			},
+		},
+
+		"try": {
+			{
+				`try([1, 2][10], "fallback")`,
+				cty.StringVal("fallback"),
+			},
+			{
+				`try("hello")`,
+				cty.StringVal("hello"),
+			},
+		},
+
 		"upper": {
 			{
 				`upper("hello")`,
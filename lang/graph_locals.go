@@ -0,0 +1,114 @@
+package lang
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl2/hcl"
+)
+
+// LocalsCycleError is returned by GraphLocals when the given expressions
+// have a dependency cycle and so cannot be ordered.
+type LocalsCycleError struct {
+	Names  []string
+	Ranges []hcl.Range
+}
+
+func (e *LocalsCycleError) Error() string {
+	return fmt.Sprintf("dependency cycle between local values: %s", strings.Join(e.Names, ", "))
+}
+
+// GraphLocals returns the names in exprs ordered so that each name appears
+// after all of the other names in exprs that its expression refers to via
+// "local.<name>", so that evaluating them in the returned order will never
+// evaluate a local value before one of its own dependencies.
+//
+// Only references to other entries in exprs are considered; any other
+// reference (resources, variables, etc) is the caller's responsibility to
+// resolve as usual when it evaluates each expression.
+//
+// If exprs contains a dependency cycle, GraphLocals returns a
+// *LocalsCycleError identifying the names involved and the source range of
+// each one's expression.
+func GraphLocals(exprs map[string]hcl.Expression) ([]string, error) {
+	dependedOnBy := make(map[string][]string, len(exprs))
+	inDegree := make(map[string]int, len(exprs))
+	for name := range exprs {
+		inDegree[name] = 0
+	}
+
+	for name, expr := range exprs {
+		for _, dep := range localRefs(expr, exprs) {
+			// A self-reference is included here too: it can never be
+			// satisfied, so it naturally surfaces as a cycle below.
+			dependedOnBy[dep] = append(dependedOnBy[dep], name)
+			inDegree[name]++
+		}
+	}
+
+	var ready []string
+	for name, n := range inDegree {
+		if n == 0 {
+			ready = append(ready, name)
+		}
+	}
+	sort.Strings(ready)
+
+	order := make([]string, 0, len(exprs))
+	for len(ready) > 0 {
+		name := ready[0]
+		ready = ready[1:]
+		order = append(order, name)
+
+		var newlyReady []string
+		for _, dependent := range dependedOnBy[name] {
+			inDegree[dependent]--
+			if inDegree[dependent] == 0 {
+				newlyReady = append(newlyReady, dependent)
+			}
+		}
+		sort.Strings(newlyReady)
+		ready = append(ready, newlyReady...)
+	}
+
+	if len(order) != len(exprs) {
+		var stuck []string
+		for name, n := range inDegree {
+			if n > 0 {
+				stuck = append(stuck, name)
+			}
+		}
+		sort.Strings(stuck)
+		ranges := make([]hcl.Range, len(stuck))
+		for i, name := range stuck {
+			ranges[i] = exprs[name].Range()
+		}
+		return nil, &LocalsCycleError{Names: stuck, Ranges: ranges}
+	}
+
+	return order, nil
+}
+
+// localRefs returns the names from candidates that expr refers to via a
+// "local.<name>" traversal.
+func localRefs(expr hcl.Expression, candidates map[string]hcl.Expression) []string {
+	var refs []string
+	for _, traversal := range expr.Variables() {
+		if len(traversal) < 2 {
+			continue
+		}
+		root, ok := traversal[0].(hcl.TraverseRoot)
+		if !ok || root.Name != "local" {
+			continue
+		}
+		attr, ok := traversal[1].(hcl.TraverseAttr)
+		if !ok {
+			continue
+		}
+		if _, exists := candidates[attr.Name]; exists {
+			refs = append(refs, attr.Name)
+		}
+	}
+	return refs
+}
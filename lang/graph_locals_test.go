@@ -0,0 +1,84 @@
+package lang
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl2/hcl"
+	"github.com/hashicorp/hcl2/hcl/hclsyntax"
+)
+
+func mustParseLocalExpr(t *testing.T, src string) hcl.Expression {
+	t.Helper()
+	expr, diags := hclsyntax.ParseExpression([]byte(src), "test.tf", hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		t.Fatalf("failed to parse %q: %s", src, diags)
+	}
+	return expr
+}
+
+func TestGraphLocals(t *testing.T) {
+	t.Run("orders by dependency", func(t *testing.T) {
+		exprs := map[string]hcl.Expression{
+			"a": mustParseLocalExpr(t, `1`),
+			"b": mustParseLocalExpr(t, `local.a + 1`),
+			"c": mustParseLocalExpr(t, `local.b + local.a`),
+		}
+
+		order, err := GraphLocals(exprs)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		pos := make(map[string]int, len(order))
+		for i, name := range order {
+			pos[name] = i
+		}
+		if pos["a"] > pos["b"] {
+			t.Errorf("a must come before b, got order %v", order)
+		}
+		if pos["b"] > pos["c"] {
+			t.Errorf("b must come before c, got order %v", order)
+		}
+	})
+
+	t.Run("unrelated references are ignored", func(t *testing.T) {
+		exprs := map[string]hcl.Expression{
+			"a": mustParseLocalExpr(t, `var.input`),
+		}
+		order, err := GraphLocals(exprs)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if len(order) != 1 || order[0] != "a" {
+			t.Fatalf("wrong order: %#v", order)
+		}
+	})
+
+	t.Run("detects cycles", func(t *testing.T) {
+		exprs := map[string]hcl.Expression{
+			"a": mustParseLocalExpr(t, `local.b`),
+			"b": mustParseLocalExpr(t, `local.a`),
+		}
+		_, err := GraphLocals(exprs)
+		if err == nil {
+			t.Fatal("succeeded; want error")
+		}
+		cycleErr, ok := err.(*LocalsCycleError)
+		if !ok {
+			t.Fatalf("wrong error type %T", err)
+		}
+		if len(cycleErr.Names) != 2 || len(cycleErr.Ranges) != 2 {
+			t.Fatalf("wrong cycle error: %#v", cycleErr)
+		}
+	})
+
+	t.Run("detects self-reference as a cycle", func(t *testing.T) {
+		exprs := map[string]hcl.Expression{
+			"a": mustParseLocalExpr(t, `local.a`),
+		}
+		_, err := GraphLocals(exprs)
+		if err == nil {
+			t.Fatal("succeeded; want error")
+		}
+	})
+}
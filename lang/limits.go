@@ -0,0 +1,135 @@
+package lang
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl2/hcl"
+	"github.com/hashicorp/hcl2/hcl/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Limits can be set on a Scope to bound the resources a single expression
+// evaluation may consume, so that a service embedding this package to
+// evaluate configuration on behalf of untrusted or multi-tenant callers can
+// avoid a pathological expression exhausting memory or CPU.
+//
+// A zero value of a particular field disables that particular limit. A nil
+// *Limits on a Scope disables all limits, which is the default.
+type Limits struct {
+	// MaxStringLength bounds the length, in bytes, of any individual string
+	// appearing in an expression's result.
+	MaxStringLength int
+
+	// MaxCollectionSize bounds the number of elements in any individual
+	// list, set, map, tuple or object appearing in an expression's result.
+	MaxCollectionSize int
+
+	// MaxFunctionCallDepth bounds how deeply function calls may be nested
+	// within a single expression, such as upper(trimspace(var.foo)) having
+	// a depth of two. This is only enforced for expressions parsed from the
+	// native syntax, since the JSON syntax does not expose its function
+	// call structure for static analysis.
+	MaxFunctionCallDepth int
+
+	// MaxEvalSteps bounds the combined number of function calls and
+	// variable references a single expression may contain.
+	MaxEvalSteps int
+}
+
+// checkLimits returns an error if expr or its evaluated result val violate
+// any of the limits configured on the receiving scope. It does nothing if
+// s.Limits is nil.
+func (s *Scope) checkLimits(expr hcl.Expression, val cty.Value) hcl.Diagnostics {
+	if s.Limits == nil {
+		return nil
+	}
+	limits := s.Limits
+
+	var diags hcl.Diagnostics
+
+	if limits.MaxFunctionCallDepth > 0 || limits.MaxEvalSteps > 0 {
+		if node, ok := expr.(hclsyntax.Expression); ok {
+			callDepth, steps := countFunctionCallsAndSteps(node)
+			if limits.MaxFunctionCallDepth > 0 && callDepth > limits.MaxFunctionCallDepth {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Expression too complex",
+					Detail:   fmt.Sprintf("This expression nests function calls %d levels deep, but this evaluator only allows %d.", callDepth, limits.MaxFunctionCallDepth),
+					Subject:  expr.Range().Ptr(),
+				})
+			}
+			if limits.MaxEvalSteps > 0 && steps > limits.MaxEvalSteps {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Expression too complex",
+					Detail:   fmt.Sprintf("This expression requires %d evaluation steps, but this evaluator only allows %d.", steps, limits.MaxEvalSteps),
+					Subject:  expr.Range().Ptr(),
+				})
+			}
+		}
+	}
+
+	if limits.MaxStringLength > 0 || limits.MaxCollectionSize > 0 {
+		err := cty.Walk(val, func(path cty.Path, v cty.Value) (bool, error) {
+			if !v.IsKnown() || v.IsNull() {
+				return true, nil
+			}
+			ty := v.Type()
+			switch {
+			case limits.MaxStringLength > 0 && ty == cty.String:
+				if l := len(v.AsString()); l > limits.MaxStringLength {
+					return false, path.NewErrorf("string of %d bytes exceeds the limit of %d bytes", l, limits.MaxStringLength)
+				}
+			case limits.MaxCollectionSize > 0 && (ty.IsListType() || ty.IsSetType() || ty.IsMapType() || ty.IsTupleType() || ty.IsObjectType()):
+				if l := v.LengthInt(); l > limits.MaxCollectionSize {
+					return false, path.NewErrorf("collection of %d elements exceeds the limit of %d elements", l, limits.MaxCollectionSize)
+				}
+			}
+			return true, nil
+		})
+		if err != nil {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Expression result too large",
+				Detail:   fmt.Sprintf("Invalid expression result: %s.", err),
+				Subject:  expr.Range().Ptr(),
+			})
+		}
+	}
+
+	return diags
+}
+
+// countFunctionCallsAndSteps returns the maximum nesting depth of function
+// calls in the given expression, along with the total number of function
+// calls and variable references it contains.
+func countFunctionCallsAndSteps(node hclsyntax.Node) (maxDepth, steps int) {
+	w := &callDepthWalker{}
+	hclsyntax.Walk(node, w)
+	return w.maxDepth, w.steps
+}
+
+type callDepthWalker struct {
+	depth, maxDepth, steps int
+}
+
+func (w *callDepthWalker) Enter(node hclsyntax.Node) hcl.Diagnostics {
+	switch node.(type) {
+	case *hclsyntax.FunctionCallExpr:
+		w.depth++
+		w.steps++
+		if w.depth > w.maxDepth {
+			w.maxDepth = w.depth
+		}
+	case *hclsyntax.ScopeTraversalExpr, *hclsyntax.RelativeTraversalExpr:
+		w.steps++
+	}
+	return nil
+}
+
+func (w *callDepthWalker) Exit(node hclsyntax.Node) hcl.Diagnostics {
+	if _, ok := node.(*hclsyntax.FunctionCallExpr); ok {
+		w.depth--
+	}
+	return nil
+}
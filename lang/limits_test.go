@@ -0,0 +1,89 @@
+package lang
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/hcl2/hcl"
+	"github.com/hashicorp/hcl2/hcl/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestScopeEvalExprLimits(t *testing.T) {
+	tests := []struct {
+		name       string
+		limits     *Limits
+		expr       string
+		wantErrSub string
+	}{
+		{
+			"no limits",
+			nil,
+			`upper(trimspace(var.foo))`,
+			"",
+		},
+		{
+			"within limits",
+			&Limits{MaxFunctionCallDepth: 2, MaxStringLength: 10},
+			`upper(trimspace(var.foo))`,
+			"",
+		},
+		{
+			"too deep",
+			&Limits{MaxFunctionCallDepth: 1},
+			`upper(trimspace(var.foo))`,
+			"nests function calls",
+		},
+		{
+			"too many steps",
+			&Limits{MaxEvalSteps: 1},
+			`upper(trimspace(var.foo))`,
+			"evaluation steps",
+		},
+		{
+			"string too long",
+			&Limits{MaxStringLength: 2},
+			`var.foo`,
+			"exceeds the limit",
+		},
+		{
+			"collection too large",
+			&Limits{MaxCollectionSize: 1},
+			`[var.foo, var.foo]`,
+			"exceeds the limit",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			scope := &Scope{
+				Data: &dataForTests{
+					InputVariables: map[string]cty.Value{
+						"foo": cty.StringVal(" bar "),
+					},
+				},
+				Limits: test.limits,
+			}
+
+			expr, parseDiags := hclsyntax.ParseExpression([]byte(test.expr), "", hcl.InitialPos)
+			if parseDiags.HasErrors() {
+				t.Fatalf("parse error: %s", parseDiags)
+			}
+
+			_, diags := scope.EvalExpr(expr, cty.DynamicPseudoType)
+			if test.wantErrSub == "" {
+				if diags.HasErrors() {
+					t.Fatalf("unexpected errors: %s", diags.Err())
+				}
+				return
+			}
+
+			if !diags.HasErrors() {
+				t.Fatalf("expected an error containing %q, got none", test.wantErrSub)
+			}
+			if !strings.Contains(diags.Err().Error(), test.wantErrSub) {
+				t.Fatalf("wrong error\ngot:  %s\nwant substring: %s", diags.Err(), test.wantErrSub)
+			}
+		})
+	}
+}
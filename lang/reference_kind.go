@@ -0,0 +1,88 @@
+package lang
+
+import (
+	"github.com/hashicorp/terraform/addrs"
+)
+
+// ReferenceKind classifies the subject of a *addrs.Reference into one of a
+// small set of categories, for use by callers outside this package -- such
+// as dependency-graph builders and linters -- that want to group or filter
+// references without needing to know about every addrs.Referenceable
+// implementation.
+type ReferenceKind int
+
+const (
+	// ReferenceKindInvalid indicates that a reference could not be
+	// classified. This should not happen for any reference returned from
+	// References, ReferencesInExpr, or ReferencesInBlock.
+	ReferenceKindInvalid ReferenceKind = iota
+
+	// ReferenceKindResource represents a reference to a managed or data
+	// resource instance, such as aws_instance.foo or data.aws_ami.foo.
+	ReferenceKindResource
+
+	// ReferenceKindModule represents a reference to a module call or one
+	// of its output values, such as module.foo or module.foo.bar.
+	ReferenceKindModule
+
+	// ReferenceKindVariable represents a reference to an input variable,
+	// such as var.foo.
+	ReferenceKindVariable
+
+	// ReferenceKindLocal represents a reference to a local value, such as
+	// local.foo.
+	ReferenceKindLocal
+
+	// ReferenceKindPath represents a reference to a path attribute, such
+	// as path.module.
+	ReferenceKindPath
+
+	// ReferenceKindTerraform represents a reference to a terraform
+	// attribute, such as terraform.workspace.
+	ReferenceKindTerraform
+
+	// ReferenceKindCount represents a reference to a count attribute, such
+	// as count.index.
+	ReferenceKindCount
+
+	// ReferenceKindForEach represents a reference to a for_each attribute,
+	// such as each.key or each.value.
+	ReferenceKindForEach
+
+	// ReferenceKindSelf represents a reference to the "self" object, which
+	// is only valid in provisioner and connection blocks.
+	ReferenceKindSelf
+)
+
+// ClassifyReference returns the ReferenceKind that best describes the
+// subject of the given reference.
+func ClassifyReference(ref *addrs.Reference) ReferenceKind {
+	if ref == nil {
+		return ReferenceKindInvalid
+	}
+
+	if ref.Subject == addrs.Self {
+		return ReferenceKindSelf
+	}
+
+	switch ref.Subject.(type) {
+	case addrs.ResourceInstance:
+		return ReferenceKindResource
+	case addrs.ModuleCallInstance, addrs.ModuleCallOutput:
+		return ReferenceKindModule
+	case addrs.InputVariable:
+		return ReferenceKindVariable
+	case addrs.LocalValue:
+		return ReferenceKindLocal
+	case addrs.PathAttr:
+		return ReferenceKindPath
+	case addrs.TerraformAttr:
+		return ReferenceKindTerraform
+	case addrs.CountAttr:
+		return ReferenceKindCount
+	case addrs.ForEachAttr:
+		return ReferenceKindForEach
+	default:
+		return ReferenceKindInvalid
+	}
+}
@@ -0,0 +1,40 @@
+package lang
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl2/hcl"
+	"github.com/hashicorp/hcl2/hcl/hclsyntax"
+)
+
+func TestClassifyReference(t *testing.T) {
+	tests := map[string]ReferenceKind{
+		"aws_instance.foo":    ReferenceKindResource,
+		"data.aws_ami.foo":    ReferenceKindResource,
+		"var.foo":             ReferenceKindVariable,
+		"local.foo":           ReferenceKindLocal,
+		"module.foo":          ReferenceKindModule,
+		"path.module":         ReferenceKindPath,
+		"terraform.workspace": ReferenceKindTerraform,
+		"count.index":         ReferenceKindCount,
+		"each.key":            ReferenceKindForEach,
+		"self.id":             ReferenceKindSelf,
+	}
+
+	for src, want := range tests {
+		t.Run(src, func(t *testing.T) {
+			traversal, diags := hclsyntax.ParseTraversalAbs([]byte(src), "", hcl.InitialPos)
+			if diags.HasErrors() {
+				t.Fatalf("failed to parse traversal: %s", diags)
+			}
+			refs, refDiags := References([]hcl.Traversal{traversal})
+			if refDiags.HasErrors() {
+				t.Fatalf("failed to resolve reference: %s", refDiags)
+			}
+			got := ClassifyReference(refs[0])
+			if got != want {
+				t.Errorf("wrong kind for %q\ngot:  %#v\nwant: %#v", src, got, want)
+			}
+		})
+	}
+}
@@ -29,6 +29,85 @@ type Scope struct {
 	// then differ during apply.
 	PureOnly bool
 
+	// FuncResultsCache, if non-nil, is used to memoize the results of calls
+	// to deterministic functions (such as file and the hashing functions)
+	// so that evaluating the same call with the same arguments more than
+	// once does not repeat the underlying work. Callers that evaluate many
+	// expressions against the same configuration may share a single
+	// FunctionCache across several Scopes.
+	//
+	// Leave this nil to disable memoization.
+	FuncResultsCache *FunctionCache
+
+	// AllowMarked is reserved for controlling whether values marked as
+	// sensitive via the sensitive() function may flow into functions that
+	// would disclose them, such as file. It is not yet enforced anywhere:
+	// the vendored version of cty does not yet support value marks, so
+	// sensitive() and nonsensitive() are currently identity functions and
+	// there is nothing for this flag to govern.
+	AllowMarked bool
+
+	// StrictDecoding can be set to true to request that the jsondecode
+	// function reject input containing an object with duplicate keys,
+	// rather than silently using the last value as the underlying decoder
+	// otherwise would; yamldecode gets no duplicate-key check. It also
+	// applies a size limit to a string given as the first argument of any
+	// of the four encode/decode functions, to protect hosted evaluators from
+	// being asked to process unreasonably large documents; a non-string
+	// value given to jsonencode or yamlencode is not size-checked.
+	StrictDecoding bool
+
+	// Tracer, if non-nil, is notified of function calls, variable lookups
+	// and top-level expression results as they happen during evaluation.
+	// It is safe to set this at any time, including after Functions has
+	// already been called, since function wrappers consult it on every
+	// call rather than capturing it once.
+	Tracer EvalTracer
+
+	// Limits, if non-nil, bounds the size and complexity of expressions
+	// this scope is willing to evaluate, so that a service embedding this
+	// package to evaluate configuration from untrusted or multi-tenant
+	// callers can avoid a pathological expression exhausting memory or
+	// CPU. Leave this nil to evaluate without limits.
+	Limits *Limits
+
+	// ExprFoldCache, if non-nil, is consulted before evaluating each
+	// top-level expression and updated afterwards, so that a pure
+	// expression that evaluated to a wholly-known value doesn't need to
+	// be evaluated again by a later call that shares the same cache, such
+	// as one made from "terraform apply" for an expression already
+	// evaluated during the preceding "terraform plan". Leave this nil to
+	// disable this caching.
+	ExprFoldCache *ExprFoldCache
+
+	// EnableEnvInterpolation controls whether the "env" function, which
+	// reads values from the process environment, is available. It is kept
+	// opt-in because its result is not reproducible across machines, unlike
+	// the rest of this scope's functions. A configuration requests it by
+	// setting "enable_env_interpolation = true" in its root module's
+	// "terraform" block.
+	EnableEnvInterpolation bool
+
+	// EnableExternalFunction controls whether the "external" function,
+	// which runs an external program and exchanges a query and a result
+	// with it as JSON, is available at all. It is kept opt-in, similar to
+	// EnableEnvInterpolation, since running an arbitrary program during
+	// expression evaluation has much bigger consequences than reading an
+	// environment variable. A configuration requests it by setting
+	// "enable_external_function = true" in its root module's "terraform"
+	// block.
+	EnableExternalFunction bool
+
+	// ExternalPrograms allowlists the programs the "external" function is
+	// permitted to run, typically sourced from a CLI configuration's
+	// "external_programs" setting; see
+	// command/cliconfig.Config.AllowedExternalPrograms. A call naming any
+	// other program fails, even when EnableExternalFunction is set, since
+	// enabling the function in a module is a decision the module's author
+	// makes but allowlisting a specific program is a decision that belongs
+	// to whoever operates the machine Terraform runs on.
+	ExternalPrograms []string
+
 	funcs     map[string]function.Function
 	funcsLock sync.Mutex
 }
@@ -0,0 +1,105 @@
+package lang
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+// maxStrictDecodeInputBytes bounds the size of the string given to the
+// encode/decode functions when Scope.StrictDecoding is enabled, so that a
+// hosted evaluator cannot be made to process an unreasonably large document.
+const maxStrictDecodeInputBytes = 1 << 20 // 1MiB
+
+// encodingFunctionsToGuard lists the functions whose first argument, when it
+// is a string, is guarded against oversized input when Scope.StrictDecoding
+// is enabled.
+// "jsondecode" additionally gets duplicate-key detection; the vendored YAML
+// decoder has no equivalent strict mode to hook into, so "yamldecode" is
+// only size-bounded here.
+var encodingFunctionsToGuard = []string{
+	"jsonencode",
+	"jsondecode",
+	"yamlencode",
+	"yamldecode",
+}
+
+// wrapStrictDecoding wraps f so that it rejects input longer than
+// maxStrictDecodeInputBytes, and, for name == "jsondecode", input containing
+// an object with duplicate keys.
+func wrapStrictDecoding(name string, f function.Function) function.Function {
+	spec := &function.Spec{
+		Params: f.Params(),
+		Type: func(args []cty.Value) (cty.Type, error) {
+			return f.ReturnTypeForValues(args)
+		},
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			if len(args) > 0 && args[0].Type() == cty.String && args[0].IsKnown() && !args[0].IsNull() {
+				src := args[0].AsString()
+				if len(src) > maxStrictDecodeInputBytes {
+					return cty.UnknownVal(retType), fmt.Errorf("input is too large: %d bytes exceeds the %d byte limit", len(src), maxStrictDecodeInputBytes)
+				}
+				if name == "jsondecode" {
+					if err := checkJSONDuplicateKeys([]byte(src)); err != nil {
+						return cty.UnknownVal(retType), err
+					}
+				}
+			}
+			return f.Call(args)
+		},
+	}
+	return function.New(spec)
+}
+
+// checkJSONDuplicateKeys returns an error if any JSON object in src contains
+// the same key more than once. encoding/json silently keeps the last
+// occurrence, which can hide a typo in configuration.
+func checkJSONDuplicateKeys(src []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(src))
+	return checkJSONDuplicateKeysValue(dec)
+}
+
+func checkJSONDuplicateKeysValue(dec *json.Decoder) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil // let the real decoder produce the user-facing syntax error
+	}
+	switch tok {
+	case json.Delim('{'):
+		seen := make(map[string]bool)
+		for dec.More() {
+			keyTok, err := dec.Token()
+			if err != nil {
+				return nil
+			}
+			key, ok := keyTok.(string)
+			if !ok {
+				return nil
+			}
+			if seen[key] {
+				return fmt.Errorf("duplicate object key %q", key)
+			}
+			seen[key] = true
+			if err := checkJSONDuplicateKeysValue(dec); err != nil {
+				return err
+			}
+		}
+		// consume the closing '}'
+		if _, err := dec.Token(); err != nil {
+			return nil
+		}
+	case json.Delim('['):
+		for dec.More() {
+			if err := checkJSONDuplicateKeysValue(dec); err != nil {
+				return err
+			}
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil
+		}
+	}
+	return nil
+}
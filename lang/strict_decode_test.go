@@ -0,0 +1,46 @@
+package lang
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function/stdlib"
+)
+
+func TestWrapStrictDecodingDuplicateKeys(t *testing.T) {
+	f := wrapStrictDecoding("jsondecode", stdlib.JSONDecodeFunc)
+
+	_, err := f.Call([]cty.Value{cty.StringVal(`{"a": 1, "b": 2, "a": 3}`)})
+	if err == nil {
+		t.Fatal("succeeded; want error")
+	}
+	if !strings.Contains(err.Error(), `duplicate object key "a"`) {
+		t.Fatalf("wrong error: %s", err)
+	}
+
+	got, err := f.Call([]cty.Value{cty.StringVal(`{"a": 1, "b": 2}`)})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	want := cty.ObjectVal(map[string]cty.Value{
+		"a": cty.NumberIntVal(1),
+		"b": cty.NumberIntVal(2),
+	})
+	if !got.RawEquals(want) {
+		t.Errorf("wrong result\ngot:  %#v\nwant: %#v", got, want)
+	}
+}
+
+func TestWrapStrictDecodingTooLarge(t *testing.T) {
+	f := wrapStrictDecoding("jsondecode", stdlib.JSONDecodeFunc)
+
+	huge := `"` + strings.Repeat("a", maxStrictDecodeInputBytes+1) + `"`
+	_, err := f.Call([]cty.Value{cty.StringVal(huge)})
+	if err == nil {
+		t.Fatal("succeeded; want error")
+	}
+	if !strings.Contains(err.Error(), "too large") {
+		t.Fatalf("wrong error: %s", err)
+	}
+}
@@ -0,0 +1,55 @@
+package lang
+
+import (
+	"time"
+
+	"github.com/hashicorp/hcl2/hcl"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+
+	"github.com/hashicorp/terraform/addrs"
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+// EvalTracer can be set on a Scope to observe the work done while
+// evaluating expressions against it, such as for a debugger that wants to
+// show which variables and functions an expression touched and how long
+// each function call took.
+//
+// Implementations must be safe to call concurrently, since a single Scope
+// may be used to evaluate several expressions at once.
+type EvalTracer interface {
+	// OnFunctionCall is called after a function registered in the scope
+	// has returned, reporting how long the call took and what it
+	// produced (or the error it failed with).
+	OnFunctionCall(name string, args []cty.Value, duration time.Duration, result cty.Value, err error)
+
+	// OnVariableLookup is called after a reference has been resolved to
+	// a value during expression evaluation.
+	OnVariableLookup(ref *addrs.Reference, result cty.Value)
+
+	// OnResult is called once evaluation of a top-level expression has
+	// finished, with its final result and diagnostics.
+	OnResult(expr hcl.Expression, result cty.Value, diags tfdiags.Diagnostics)
+}
+
+// wrapFunctionTracing returns a copy of f that reports each call to
+// tracer.OnFunctionCall, if tracer is non-nil.
+func wrapFunctionTracing(name string, f function.Function, tracer func() EvalTracer) function.Function {
+	spec := &function.Spec{
+		Params:   f.Params(),
+		VarParam: f.VarParam(),
+		Type: func(args []cty.Value) (cty.Type, error) {
+			return f.ReturnTypeForValues(args)
+		},
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			start := time.Now()
+			val, err := f.Call(args)
+			if t := tracer(); t != nil {
+				t.OnFunctionCall(name, args, time.Since(start), val, err)
+			}
+			return val, err
+		},
+	}
+	return function.New(spec)
+}
@@ -0,0 +1,66 @@
+package lang
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/hcl2/hcl"
+	"github.com/hashicorp/hcl2/hcl/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/addrs"
+	"github.com/hashicorp/terraform/tfdiags"
+)
+
+type testTracer struct {
+	functionCalls   []string
+	variableLookups []string
+	results         int
+}
+
+func (t *testTracer) OnFunctionCall(name string, args []cty.Value, duration time.Duration, result cty.Value, err error) {
+	t.functionCalls = append(t.functionCalls, name)
+}
+
+func (t *testTracer) OnVariableLookup(ref *addrs.Reference, result cty.Value) {
+	t.variableLookups = append(t.variableLookups, ref.Subject.String())
+}
+
+func (t *testTracer) OnResult(expr hcl.Expression, result cty.Value, diags tfdiags.Diagnostics) {
+	t.results++
+}
+
+func TestScopeEvalExprTracer(t *testing.T) {
+	tracer := &testTracer{}
+	scope := &Scope{
+		Data: &dataForTests{
+			InputVariables: map[string]cty.Value{
+				"foo": cty.StringVal("bar"),
+			},
+		},
+		Tracer: tracer,
+	}
+
+	expr, parseDiags := hclsyntax.ParseExpression([]byte(`upper(var.foo)`), "", hcl.InitialPos)
+	if parseDiags.HasErrors() {
+		t.Fatalf("parse error: %s", parseDiags)
+	}
+
+	got, diags := scope.EvalExpr(expr, cty.String)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+	if !got.RawEquals(cty.StringVal("BAR")) {
+		t.Fatalf("wrong result: %#v", got)
+	}
+
+	if len(tracer.functionCalls) != 1 || tracer.functionCalls[0] != "upper" {
+		t.Errorf("expected a single call to upper, got %v", tracer.functionCalls)
+	}
+	if len(tracer.variableLookups) != 1 || tracer.variableLookups[0] != "var.foo" {
+		t.Errorf("expected a single lookup of var.foo, got %v", tracer.variableLookups)
+	}
+	if tracer.results != 1 {
+		t.Errorf("expected a single result, got %d", tracer.results)
+	}
+}
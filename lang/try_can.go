@@ -0,0 +1,133 @@
+package lang
+
+import (
+	"github.com/hashicorp/hcl2/hcl"
+	"github.com/hashicorp/hcl2/hcl/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+// tryFunc and canFunc are registered in the function table as a fallback
+// for try()/can() calls that evalExprWithTryCan does not intercept, such as
+// a call nested inside a larger expression. By the time either of these is
+// actually invoked, HCL has already successfully evaluated every argument,
+// so they cannot observe the evaluation failures that the top-level
+// interception handles; they can only express a best-effort approximation
+// based on the resulting values.
+var tryFunc = function.New(&function.Spec{
+	VarParam: &function.Parameter{
+		Name:             "expressions",
+		Type:             cty.DynamicPseudoType,
+		AllowNull:        true,
+		AllowUnknown:     true,
+		AllowDynamicType: true,
+	},
+	Type: func(args []cty.Value) (cty.Type, error) {
+		return cty.DynamicPseudoType, nil
+	},
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		for _, v := range args {
+			if v.IsKnown() && !v.IsNull() {
+				return v, nil
+			}
+		}
+		return args[len(args)-1], nil
+	},
+})
+
+var canFunc = function.New(&function.Spec{
+	Params: []function.Parameter{
+		{
+			Name:             "expression",
+			Type:             cty.DynamicPseudoType,
+			AllowNull:        true,
+			AllowUnknown:     true,
+			AllowDynamicType: true,
+		},
+	},
+	Type: function.StaticReturnType(cty.Bool),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		// An argument that reaches this fallback path has already
+		// evaluated successfully, so there is nothing left to report.
+		return cty.True, nil
+	},
+})
+
+// evalExprWithTryCan evaluates expr in the given context, special-casing
+// top-level calls to try() and can() so that they can suppress errors
+// raised while evaluating their own arguments.
+//
+// HCL normally evaluates every argument of a function call before invoking
+// the function, so a function.Function implementation never sees an
+// argument that failed to evaluate. That makes it impossible for an
+// ordinary function to implement try/can's error-tolerant semantics on its
+// own, so we intercept these two calls here, directly at the expression
+// level, before general evaluation would otherwise reject the whole
+// expression due to an error in one of its arguments.
+//
+// This interception only applies when try() or can() is the outermost
+// expression being evaluated (as is the common case of assigning its
+// result directly to an argument); a call nested more deeply inside a
+// larger expression, such as inside a template interpolation, falls back
+// to the plain function-table implementations of these functions, which
+// cannot observe argument evaluation errors and so behave as if every
+// argument had evaluated successfully.
+func evalExprWithTryCan(expr hcl.Expression, ctx *hcl.EvalContext) (cty.Value, hcl.Diagnostics) {
+	if call, ok := expr.(*hclsyntax.FunctionCallExpr); ok {
+		switch call.Name {
+		case "try":
+			return evalTryCall(call, ctx)
+		case "can":
+			return evalCanCall(call, ctx)
+		}
+	}
+	return expr.Value(ctx)
+}
+
+func evalTryCall(call *hclsyntax.FunctionCallExpr, ctx *hcl.EvalContext) (cty.Value, hcl.Diagnostics) {
+	if len(call.Args) == 0 {
+		return cty.DynamicVal, hcl.Diagnostics{
+			&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Not enough function arguments",
+				Detail:   `try requires at least one argument.`,
+				Subject:  call.Range().Ptr(),
+			},
+		}
+	}
+
+	var lastDiags hcl.Diagnostics
+	for _, argExpr := range call.Args {
+		val, diags := argExpr.Value(ctx)
+		if !diags.HasErrors() {
+			return val, nil
+		}
+		lastDiags = diags
+	}
+
+	return cty.DynamicVal, hcl.Diagnostics{
+		&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "All try() arguments failed",
+			Detail: "No expression given to try() succeeded: " +
+				lastDiags[0].Error(),
+			Subject: call.Range().Ptr(),
+		},
+	}
+}
+
+func evalCanCall(call *hclsyntax.FunctionCallExpr, ctx *hcl.EvalContext) (cty.Value, hcl.Diagnostics) {
+	if len(call.Args) != 1 {
+		return cty.DynamicVal, hcl.Diagnostics{
+			&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid function arguments",
+				Detail:   `can requires exactly one argument.`,
+				Subject:  call.Range().Ptr(),
+			},
+		}
+	}
+
+	_, diags := call.Args[0].Value(ctx)
+	return cty.BoolVal(!diags.HasErrors()), nil
+}
@@ -0,0 +1,48 @@
+package lang
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl2/hcl"
+	"github.com/hashicorp/hcl2/hcl/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestScopeEvalExprTryCan(t *testing.T) {
+	scope := &Scope{
+		Data: &dataForTests{
+			InputVariables: map[string]cty.Value{
+				"foo": cty.ObjectVal(map[string]cty.Value{
+					"bar": cty.StringVal("baz"),
+				}),
+			},
+		},
+	}
+
+	tests := []struct {
+		expr string
+		want cty.Value
+	}{
+		{`try(var.foo.bar, "fallback")`, cty.StringVal("baz")},
+		{`try(var.foo.nope, "fallback")`, cty.StringVal("fallback")},
+		{`try(var.foo.nope.alsonope, "first", "second")`, cty.StringVal("first")},
+		{`can(var.foo.bar)`, cty.True},
+		{`can(var.foo.nope)`, cty.False},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			expr, parseDiags := hclsyntax.ParseExpression([]byte(test.expr), "", hcl.InitialPos)
+			if parseDiags.HasErrors() {
+				t.Fatalf("parse error: %s", parseDiags)
+			}
+			got, diags := scope.EvalExpr(expr, cty.DynamicPseudoType)
+			if diags.HasErrors() {
+				t.Fatalf("unexpected errors: %s", diags.Err())
+			}
+			if !got.RawEquals(test.want) {
+				t.Errorf("wrong result\ngot:  %#v\nwant: %#v", got, test.want)
+			}
+		})
+	}
+}
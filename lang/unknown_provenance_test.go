@@ -0,0 +1,62 @@
+package lang
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl2/hcl"
+	"github.com/hashicorp/hcl2/hcl/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestScopeEvalExprAndProvenance(t *testing.T) {
+	scope := &Scope{
+		Data: &dataForTests{
+			InputVariables: map[string]cty.Value{
+				"known":   cty.StringVal("a"),
+				"unknown": cty.UnknownVal(cty.String),
+			},
+			ResourceInstances: map[string]cty.Value{
+				"null_resource.foo": cty.UnknownVal(cty.Object(map[string]cty.Type{
+					"attr": cty.String,
+				})),
+			},
+		},
+	}
+
+	tests := []struct {
+		expr     string
+		wantRefs []string
+	}{
+		{`var.known`, nil},
+		{`"${var.known}-${var.unknown}"`, []string{"var.unknown"}},
+		{`null_resource.foo.attr`, []string{"null_resource.foo"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			expr, parseDiags := hclsyntax.ParseExpression([]byte(test.expr), "", hcl.InitialPos)
+			if parseDiags.HasErrors() {
+				t.Fatalf("parse error: %s", parseDiags)
+			}
+
+			_, refs, diags := scope.EvalExprAndProvenance(expr, cty.DynamicPseudoType)
+			if diags.HasErrors() {
+				t.Fatalf("unexpected errors: %s", diags.Err())
+			}
+
+			var got []string
+			for _, ref := range refs {
+				got = append(got, ref.Subject.String())
+			}
+
+			if len(got) != len(test.wantRefs) {
+				t.Fatalf("wrong refs\ngot:  %#v\nwant: %#v", got, test.wantRefs)
+			}
+			for i := range got {
+				if got[i] != test.wantRefs[i] {
+					t.Errorf("wrong refs\ngot:  %#v\nwant: %#v", got, test.wantRefs)
+				}
+			}
+		})
+	}
+}
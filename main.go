@@ -143,19 +143,20 @@ func wrappedMain() int {
 		}
 	}
 
-	// Get any configured credentials from the config and initialize
-	// a service discovery object.
-	credsSrc, err := credentialsSource(config)
+	// Build the service discovery client the rest of Terraform will use to
+	// talk to registries and other Terraform-native services, wired up
+	// with the credentials and host overrides from the CLI configuration.
+	services, err := servicesClient(config)
 	if err != nil {
 		// Most commands don't actually need credentials, and most situations
 		// that would get us here would already have been reported by the config
 		// loading above, so we'll just log this one as an aid to debugging
 		// in the unlikely event that it _does_ arise.
 		log.Printf("[WARN] Cannot initialize remote host credentials manager: %s", err)
-		// credsSrc may be nil in this case, but that's okay because the disco
-		// object checks that and just acts as though no credentials are present.
+		// Fall back to a client with no credentials or host overrides
+		// rather than failing outright, since most commands don't need them.
+		services = disco.New()
 	}
-	services := disco.NewWithCredentialsSource(credsSrc)
 
 	// Initialize the backends.
 	backendInit.Init(services)
@@ -227,7 +228,7 @@ func wrappedMain() int {
 
 	// Pass in the overriding plugin paths from config
 	PluginOverrides.Providers = config.Providers
-	PluginOverrides.Provisioners = config.Provisioners
+	PluginOverrides.Provisioners = config.ProvisionerDevOverrides()
 
 	exitCode, err := cliRunner.Run()
 	if err != nil {
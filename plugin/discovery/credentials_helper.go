@@ -0,0 +1,45 @@
+package discovery
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FindCredentialsHelper looks in the given directories for a credentials
+// helper plugin of the given type, following the same naming and
+// versioning conventions as FindPlugins (including the platform-specific
+// ".exe" suffix handling on Windows), and returns the newest version found.
+//
+// If no matching plugin is found in any of the given directories, the
+// returned error is a *CredentialsHelperNotFoundError that records which
+// directories were searched, so that the caller can report something more
+// useful to the user than a bare "not found".
+func FindCredentialsHelper(typeName string, searchDirs []string) (PluginMeta, error) {
+	available := FindPlugins("credentials", searchDirs).WithName(typeName)
+	if available.Count() == 0 {
+		return PluginMeta{}, &CredentialsHelperNotFoundError{
+			Type:       typeName,
+			SearchDirs: searchDirs,
+		}
+	}
+
+	return available.Newest(), nil
+}
+
+// CredentialsHelperNotFoundError is returned by FindCredentialsHelper when
+// no "terraform-credentials-<Type>" plugin executable can be found in any
+// of the given SearchDirs.
+type CredentialsHelperNotFoundError struct {
+	Type       string
+	SearchDirs []string
+}
+
+func (err *CredentialsHelperNotFoundError) Error() string {
+	if len(err.SearchDirs) == 0 {
+		return fmt.Sprintf("no credentials helper plugin named %q is installed", err.Type)
+	}
+	return fmt.Sprintf(
+		"no credentials helper plugin named %q is installed; searched %s",
+		err.Type, strings.Join(err.SearchDirs, ", "),
+	)
+}
@@ -0,0 +1,55 @@
+package discovery
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFindCredentialsHelper(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tf-credentials-helper")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "terraform-credentials-vault")
+	if err := ioutil.WriteFile(path, []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("found", func(t *testing.T) {
+		meta, err := FindCredentialsHelper("vault", []string{dir})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if got, want := meta.Name, "vault"; got != want {
+			t.Errorf("wrong name %q; want %q", got, want)
+		}
+		if got, want := meta.Path, path; got != want {
+			t.Errorf("wrong path %q; want %q", got, want)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		_, err := FindCredentialsHelper("nonexistent", []string{dir})
+		if err == nil {
+			t.Fatal("expected an error")
+		}
+		notFound, ok := err.(*CredentialsHelperNotFoundError)
+		if !ok {
+			t.Fatalf("wrong error type %T; want *CredentialsHelperNotFoundError", err)
+		}
+		if got, want := notFound.Type, "nonexistent"; got != want {
+			t.Errorf("wrong Type %q; want %q", got, want)
+		}
+		if got, want := notFound.SearchDirs, []string{dir}; len(got) != len(want) || got[0] != want[0] {
+			t.Errorf("wrong SearchDirs %#v; want %#v", got, want)
+		}
+		if !strings.Contains(notFound.Error(), dir) {
+			t.Errorf("error message %q doesn't mention the searched directory %q", notFound.Error(), dir)
+		}
+	})
+}
@@ -1,6 +1,7 @@
 package discovery
 
 import (
+	"crypto/sha256"
 	"errors"
 	"fmt"
 	"io"
@@ -30,6 +31,13 @@ import (
 
 const protocolVersionHeader = "x-terraform-protocol-version"
 
+// httpClient is shared by every Installer and registered once, at init time,
+// with go-getter's "http"/"https" scheme handlers; it intentionally doesn't
+// consult cliconfig.Config.PluginTLSVerifyDisabled, since there's no
+// per-instance hook here to swap it out after init. Disabling TLS
+// verification for provider downloads that go through go-getter (as opposed
+// to the module/provider registry client in the registry package, which
+// does honor that setting) is not currently supported.
 var httpClient *http.Client
 
 var errVersionNotFound = errors.New("version not found")
@@ -77,6 +85,14 @@ type ProviderInstaller struct {
 	// Skip checksum and signature verification
 	SkipVerify bool
 
+	// StrictCacheChecksum, when set, causes a cache hit to be rejected
+	// (and the provider re-downloaded) if the cached file's checksum no
+	// longer matches the one verified against the registry's signed
+	// SHA256SUMS, rather than trusting whatever was previously placed in
+	// the cache. This protects against a shared plugin cache directory
+	// being tampered with, or simply corrupted, between runs.
+	StrictCacheChecksum bool
+
 	Ui cli.Ui // Ui for output
 
 	// Services is a required *disco.Disco, which may have services and
@@ -93,10 +109,10 @@ type ProviderInstaller struct {
 // This method may return one of a number of sentinel errors from this
 // package to indicate issues that are likely to be resolvable via user action:
 //
-//     ErrorNoSuchProvider: no provider with the given name exists in the repository.
-//     ErrorNoSuitableVersion: the provider exists but no available version matches constraints.
-//     ErrorNoVersionCompatible: a plugin was found within the constraints but it is
-//                               incompatible with the current Terraform version.
+//	ErrorNoSuchProvider: no provider with the given name exists in the repository.
+//	ErrorNoSuitableVersion: the provider exists but no available version matches constraints.
+//	ErrorNoVersionCompatible: a plugin was found within the constraints but it is
+//	                          incompatible with the current Terraform version.
 //
 // These errors should be recognized and handled as special cases by the caller
 // to present a suitable user-oriented error message.
@@ -208,6 +224,7 @@ func (i *ProviderInstaller) Get(provider string, req Constraints) (PluginMeta, t
 		return PluginMeta{}, diags, err
 	}
 	providerURL := downloadURLs.DownloadURL
+	var trustedChecksum string
 
 	if !i.SkipVerify {
 		// Terraform verifies the integrity of a provider release before downloading
@@ -224,6 +241,7 @@ func (i *ProviderInstaller) Get(provider string, req Constraints) (PluginMeta, t
 		if err != nil {
 			return PluginMeta{}, diags, err
 		}
+		trustedChecksum = sha256
 
 		// add the checksum parameter for go-getter to verify the download for us.
 		if sha256 != "" {
@@ -234,7 +252,7 @@ func (i *ProviderInstaller) Get(provider string, req Constraints) (PluginMeta, t
 	printedProviderName := fmt.Sprintf("%q (%s)", provider, providerSource)
 	i.Ui.Info(fmt.Sprintf("- Downloading plugin for provider %s %s...", printedProviderName, versionMeta.Version))
 	log.Printf("[DEBUG] getting provider %s version %q", printedProviderName, versionMeta.Version)
-	err = i.install(provider, v, providerURL)
+	err = i.install(provider, v, providerURL, trustedChecksum)
 	if err != nil {
 		return PluginMeta{}, diags, err
 	}
@@ -275,10 +293,18 @@ func (i *ProviderInstaller) Get(provider string, req Constraints) (PluginMeta, t
 	return metas.Newest(), diags, nil
 }
 
-func (i *ProviderInstaller) install(provider string, version Version, url string) error {
+func (i *ProviderInstaller) install(provider string, version Version, url string, trustedChecksum string) error {
 	if i.Cache != nil {
 		log.Printf("[DEBUG] looking for provider %s %s in plugin cache", provider, version)
 		cached := i.Cache.CachedPluginPath("provider", provider, version)
+
+		if cached != "" && i.StrictCacheChecksum && trustedChecksum != "" {
+			if err := verifyFileChecksum(cached, trustedChecksum); err != nil {
+				log.Printf("[WARN] cached plugin %s failed checksum verification (%s); re-downloading", cached, err)
+				cached = ""
+			}
+		}
+
 		if cached == "" {
 			log.Printf("[DEBUG] %s %s not yet in cache, so downloading %s", provider, version, url)
 			err := getter.Get(i.Cache.InstallDir(), url)
@@ -634,6 +660,27 @@ func checksumForFile(sums []byte, name string) string {
 	return ""
 }
 
+// verifyFileChecksum returns an error if the file at path does not have the
+// given hex-encoded SHA256 checksum.
+func verifyFileChecksum(path string, wantChecksum string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return err
+	}
+
+	gotChecksum := fmt.Sprintf("%x", h.Sum(nil))
+	if gotChecksum != wantChecksum {
+		return fmt.Errorf("checksum mismatch: got %s, want %s", gotChecksum, wantChecksum)
+	}
+	return nil
+}
+
 func getFile(url string) ([]byte, error) {
 	resp, err := httpClient.Get(url)
 	if err != nil {
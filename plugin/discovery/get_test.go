@@ -2,6 +2,7 @@ package discovery
 
 import (
 	"archive/zip"
+	"crypto/sha256"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -770,3 +771,112 @@ var downloadURLs = response.TerraformProviderPlatformLocation{
 	Filename:            "terraform-provider-template_1.2.4_darwin_amd64.zip",
 	DownloadURL:         "http://127.0.0.1:8080/v1/providers/terraform-providers/terraform-provider-test/1.2.4/terraform-provider-test_1.2.4_darwin_amd64.zip",
 }
+
+func TestVerifyFileChecksum(t *testing.T) {
+	tmpFile, err := ioutil.TempFile("", "tf-plugin-checksum")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.WriteString("some plugin binary content"); err != nil {
+		t.Fatal(err)
+	}
+	tmpFile.Close()
+
+	if err := verifyFileChecksum(tmpFile.Name(), "0000000000000000000000000000000000000000000000000000000000000000"); err == nil {
+		t.Fatal("expected an error for a mismatched checksum")
+	}
+
+	correct := fileSHA256ForTest(t, tmpFile.Name())
+	if err := verifyFileChecksum(tmpFile.Name(), correct); err != nil {
+		t.Fatalf("unexpected error for a matching checksum: %s", err)
+	}
+}
+
+func fileSHA256ForTest(t *testing.T, path string) string {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		t.Fatal(err)
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// fakeInstallCache is a minimal PluginCache used to exercise install's
+// cache-verification behavior without a real download.
+type fakeInstallCache struct {
+	cachedPath string
+	installDir string
+}
+
+func (c *fakeInstallCache) CachedPluginPath(kind string, name string, version Version) string {
+	return c.cachedPath
+}
+
+func (c *fakeInstallCache) InstallDir() string {
+	return c.installDir
+}
+
+func TestProviderInstallerInstall_strictCacheChecksum(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "tf-plugin-install")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	cachedPath := filepath.Join(tmpDir, "terraform-provider-test_v1.2.4")
+	if err := ioutil.WriteFile(cachedPath, []byte("tampered content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cache := &fakeInstallCache{
+		cachedPath: cachedPath,
+		installDir: filepath.Join(tmpDir, "install"),
+	}
+
+	targetDir := filepath.Join(tmpDir, "target")
+	wrongChecksum := "0000000000000000000000000000000000000000000000000000000000000000"
+
+	t.Run("mismatched checksum is rejected when strict", func(t *testing.T) {
+		i := &ProviderInstaller{
+			Dir:                 targetDir,
+			Cache:               cache,
+			StrictCacheChecksum: true,
+		}
+
+		// The cache entry's checksum won't match, so install should fall
+		// through to downloading from the (invalid) URL below and fail,
+		// proving the tampered cache entry was not trusted.
+		err := i.install("test", VersionStr("1.2.4").MustParse(), "bogus://not-a-real-url", wrongChecksum)
+		if err == nil {
+			t.Fatal("expected an error from attempting to re-download, but the tampered cache entry was used instead")
+		}
+	})
+
+	t.Run("mismatched checksum is ignored when not strict", func(t *testing.T) {
+		i := &ProviderInstaller{
+			Dir:   targetDir,
+			Cache: cache,
+		}
+
+		err := i.install("test", VersionStr("1.2.4").MustParse(), "bogus://not-a-real-url", wrongChecksum)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		got, err := ioutil.ReadFile(filepath.Join(targetDir, filepath.Base(cachedPath)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != "tampered content" {
+			t.Errorf("wrong installed content: %q", got)
+		}
+	})
+}
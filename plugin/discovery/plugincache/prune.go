@@ -0,0 +1,102 @@
+// Package plugincache implements garbage collection for the plugin cache
+// directory that is populated by discovery.ProviderInstaller when the user
+// has configured a shared plugin cache (see Config.PluginCacheDir in the
+// cliconfig package).
+//
+// Over time a shared plugin cache can accumulate provider versions that are
+// no longer required by any configuration that uses it. The functions here
+// allow a caller (typically a CLI command) to identify and, optionally,
+// remove those unreferenced versions.
+package plugincache
+
+import (
+	"os"
+
+	"github.com/hashicorp/terraform/plugin/discovery"
+)
+
+// Entry describes a single cached provider plugin, as discovered by
+// Report or Prune.
+type Entry struct {
+	// Name is the provider name, e.g. "aws".
+	Name string
+
+	// Version is the version of the provider, as a string that may or
+	// may not be valid semver.
+	Version discovery.VersionStr
+
+	// Path is the absolute path to the cached plugin executable.
+	Path string
+
+	// Size is the size in bytes of the cached plugin executable, or zero
+	// if its size could not be determined.
+	Size int64
+}
+
+// Report returns an Entry for every provider plugin found in the cache
+// directory at the given path.
+//
+// Report does no filtering of its own; it is intended as a building block
+// for both Prune and for diagnostic commands that just want to show the
+// user what is currently occupying the cache.
+func Report(dir string) ([]Entry, error) {
+	metas := discovery.FindPlugins("provider", []string{dir})
+	entries := make([]Entry, 0, metas.Count())
+	for meta := range metas {
+		entries = append(entries, entryForMeta(meta))
+	}
+	return entries, nil
+}
+
+// Prune removes cached provider plugins that are not present in the given
+// keep set, returning the entries that were removed (or, if dryRun is
+// true, the entries that would have been removed).
+//
+// The keep set is provided by the caller, since this package has no way to
+// determine on its own which provider versions are still needed; a caller
+// would typically build it from the provider requirements of one or more
+// recent Terraform runs.
+//
+// Prune makes a best effort to remove as many unreferenced entries as
+// possible: if it fails to remove one entry it records the error and
+// continues on to the next one, ultimately returning the first error it
+// encountered (if any) alongside the set of entries it did manage to
+// remove or identify for removal.
+func Prune(dir string, keep discovery.PluginMetaSet, dryRun bool) ([]Entry, error) {
+	metas := discovery.FindPlugins("provider", []string{dir})
+
+	var removed []Entry
+	var firstErr error
+	for meta := range metas {
+		if keep.Has(meta) {
+			continue
+		}
+
+		entry := entryForMeta(meta)
+
+		if !dryRun {
+			if err := os.Remove(meta.Path); err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				continue
+			}
+		}
+
+		removed = append(removed, entry)
+	}
+
+	return removed, firstErr
+}
+
+func entryForMeta(meta discovery.PluginMeta) Entry {
+	entry := Entry{
+		Name:    meta.Name,
+		Version: meta.Version,
+		Path:    meta.Path,
+	}
+	if info, err := os.Stat(meta.Path); err == nil {
+		entry.Size = info.Size()
+	}
+	return entry
+}
@@ -0,0 +1,81 @@
+package plugincache
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/terraform/plugin/discovery"
+)
+
+func TestReport(t *testing.T) {
+	entries, err := Report("../testdata/plugin-cache")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(entries) != 1 {
+		t.Fatalf("wrong number of entries %d; want 1", len(entries))
+	}
+
+	got := entries[0]
+	if got.Name != "foo" {
+		t.Errorf("wrong name %q; want %q", got.Name, "foo")
+	}
+	if got.Version != "0.0.1" {
+		t.Errorf("wrong version %q; want %q", got.Version, "0.0.1")
+	}
+}
+
+func TestPrune(t *testing.T) {
+	dir, err := ioutil.TempDir("", "terraform-plugincache-prune")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	keepPath := filepath.Join(dir, "terraform-provider-foo_v1.0.0_x4")
+	stalePath := filepath.Join(dir, "terraform-provider-bar_v0.1.0_x4")
+	for _, path := range []string{keepPath, stalePath} {
+		if err := ioutil.WriteFile(path, []byte("fake plugin"), 0755); err != nil {
+			t.Fatalf("failed to write fixture %s: %s", path, err)
+		}
+	}
+
+	keep := make(discovery.PluginMetaSet)
+	keep.Add(discovery.PluginMeta{
+		Name:    "foo",
+		Version: discovery.VersionStr("1.0.0"),
+		Path:    keepPath,
+	})
+
+	t.Run("dry run leaves files in place", func(t *testing.T) {
+		removed, err := Prune(dir, keep, true)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if len(removed) != 1 || removed[0].Name != "bar" {
+			t.Fatalf("wrong removed set: %#v", removed)
+		}
+		if _, err := os.Stat(stalePath); err != nil {
+			t.Fatalf("stale plugin should still exist after dry run: %s", err)
+		}
+	})
+
+	t.Run("real run deletes unreferenced versions only", func(t *testing.T) {
+		removed, err := Prune(dir, keep, false)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if len(removed) != 1 || removed[0].Name != "bar" {
+			t.Fatalf("wrong removed set: %#v", removed)
+		}
+		if _, err := os.Stat(stalePath); !os.IsNotExist(err) {
+			t.Fatalf("stale plugin should have been removed")
+		}
+		if _, err := os.Stat(keepPath); err != nil {
+			t.Fatalf("kept plugin should still exist: %s", err)
+		}
+	})
+}
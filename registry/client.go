@@ -25,10 +25,51 @@ const (
 	requestTimeout     = 10 * time.Second
 	modulesServiceID   = "modules.v1"
 	providersServiceID = "providers.v1"
+
+	// defaultRetries is how many additional attempts NewClient makes after
+	// a request fails with a transient error, when the caller doesn't
+	// provide its own RetryConfig. It defaults to zero -- preserving the
+	// historical behavior of failing immediately -- since retrying is only
+	// safe to enable by default once we know every caller's test fixtures
+	// tolerate it; operators can opt in via the "registry" block.
+	defaultRetries = 0
+
+	// defaultBackoffMax caps the exponential backoff between retries when
+	// the caller doesn't provide its own RetryConfig.
+	defaultBackoffMax = 15 * time.Second
 )
 
 var tfVersion = version.String()
 
+// RetryConfig controls how a Client retries requests that fail with a
+// transient error (a network error or a 5xx response), normally populated
+// from the CLI configuration's "registry" block so that operators talking
+// to a slow or flaky registry -- such as a self-hosted one reached over a
+// constrained network -- can tune it without a Terraform release.
+type RetryConfig struct {
+	// Retries is how many additional attempts to make after a request
+	// fails with a transient error. Zero disables retries.
+	Retries int
+
+	// Timeout bounds how long a single HTTP request, including any
+	// retries, is allowed to take. Zero means use the client's own
+	// default.
+	Timeout time.Duration
+
+	// BackoffMax caps the exponential backoff delay between retries.
+	BackoffMax time.Duration
+}
+
+// DefaultRetryConfig returns the retry and timeout behavior NewClient uses
+// when the caller doesn't supply its own RetryConfig.
+func DefaultRetryConfig() RetryConfig {
+	return RetryConfig{
+		Retries:    defaultRetries,
+		Timeout:    requestTimeout,
+		BackoffMax: defaultBackoffMax,
+	}
+}
+
 // Client provides methods to query Terraform Registries.
 type Client struct {
 	// this is the client to be used for all requests.
@@ -39,17 +80,35 @@ type Client struct {
 	services *disco.Disco
 }
 
-// NewClient returns a new initialized registry client.
+// NewClient returns a new initialized registry client, using
+// DefaultRetryConfig for its retry and timeout behavior.
 func NewClient(services *disco.Disco, client *http.Client) *Client {
+	return NewClientWithRetryConfig(services, client, DefaultRetryConfig())
+}
+
+// NewClientWithRetryConfig is like NewClient but allows overriding the
+// retry and timeout behavior, typically with values from the CLI
+// configuration's "registry" block.
+func NewClientWithRetryConfig(services *disco.Disco, client *http.Client, retry RetryConfig) *Client {
 	if services == nil {
 		services = disco.New()
 	}
 
 	if client == nil {
 		client = httpclient.New()
+	}
+	if retry.Timeout > 0 {
+		client.Timeout = retry.Timeout
+	} else if client.Timeout == 0 {
 		client.Timeout = requestTimeout
 	}
 
+	client.Transport = &retryRoundTripper{
+		inner:      client.Transport,
+		maxRetries: retry.Retries,
+		backoffMax: retry.BackoffMax,
+	}
+
 	services.Transport = client.Transport
 
 	return &Client{
@@ -58,6 +117,44 @@ func NewClient(services *disco.Disco, client *http.Client) *Client {
 	}
 }
 
+// retryRoundTripper wraps another http.RoundTripper to automatically retry
+// requests that fail with a transient error (a network error or a 5xx
+// response), waiting with exponential backoff between attempts. Registry
+// requests are all idempotent GETs with no body, so retrying is always
+// safe here.
+type retryRoundTripper struct {
+	inner      http.RoundTripper
+	maxRetries int
+	backoffMax time.Duration
+}
+
+func (rt *retryRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := rt.inner.RoundTrip(req)
+		if err == nil && resp.StatusCode < 500 {
+			return resp, nil
+		}
+		if attempt >= rt.maxRetries {
+			return resp, err
+		}
+		if resp != nil {
+			resp.Body.Close()
+		}
+
+		wait := rt.backoff(attempt)
+		log.Printf("[DEBUG] registry request to %s failed (attempt %d/%d); retrying in %s", req.URL, attempt+1, rt.maxRetries+1, wait)
+		time.Sleep(wait)
+	}
+}
+
+func (rt *retryRoundTripper) backoff(attempt int) time.Duration {
+	wait := time.Duration(1<<uint(attempt)) * time.Second
+	if rt.backoffMax > 0 && wait > rt.backoffMax {
+		wait = rt.backoffMax
+	}
+	return wait
+}
+
 // Discover queries the host, and returns the url for the registry.
 func (c *Client) Discover(host svchost.Hostname, serviceID string) (*url.URL, error) {
 	service, err := c.services.DiscoverServiceURL(host, serviceID)
@@ -96,7 +193,7 @@ func (c *Client) ModuleVersions(module *regsrc.Module) (*response.ModuleVersions
 		return nil, err
 	}
 
-	c.addRequestCreds(host, req)
+	c.addRequestCreds(host, modulesServiceID, req)
 	req.Header.Set(xTerraformVersion, tfVersion)
 
 	resp, err := c.client.Do(req)
@@ -130,8 +227,8 @@ func (c *Client) ModuleVersions(module *regsrc.Module) (*response.ModuleVersions
 	return &versions, nil
 }
 
-func (c *Client) addRequestCreds(host svchost.Hostname, req *http.Request) {
-	creds, err := c.services.CredentialsForHost(host)
+func (c *Client) addRequestCreds(host svchost.Hostname, serviceID string, req *http.Request) {
+	creds, err := c.services.CredentialsForHostService(host, serviceID)
 	if err != nil {
 		log.Printf("[WARN] Failed to get credentials for %s: %s (ignoring)", host, err)
 		return
@@ -173,7 +270,7 @@ func (c *Client) ModuleLocation(module *regsrc.Module, version string) (string,
 		return "", err
 	}
 
-	c.addRequestCreds(host, req)
+	c.addRequestCreds(host, modulesServiceID, req)
 	req.Header.Set(xTerraformVersion, tfVersion)
 
 	resp, err := c.client.Do(req)
@@ -253,7 +350,7 @@ func (c *Client) TerraformProviderVersions(provider *regsrc.TerraformProvider) (
 		return nil, err
 	}
 
-	c.addRequestCreds(host, req)
+	c.addRequestCreds(host, providersServiceID, req)
 	req.Header.Set(xTerraformVersion, tfVersion)
 
 	resp, err := c.client.Do(req)
@@ -313,7 +410,7 @@ func (c *Client) TerraformProviderLocation(provider *regsrc.TerraformProvider, v
 		return nil, err
 	}
 
-	c.addRequestCreds(host, req)
+	c.addRequestCreds(host, providersServiceID, req)
 	req.Header.Set(xTerraformVersion, tfVersion)
 
 	resp, err := c.client.Do(req)
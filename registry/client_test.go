@@ -2,9 +2,12 @@ package registry
 
 import (
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	version "github.com/hashicorp/go-version"
 	"github.com/hashicorp/terraform/registry/regsrc"
@@ -287,3 +290,85 @@ func TestLookupProviderLocation(t *testing.T) {
 	}
 
 }
+
+func TestRetryRoundTripper_recoversAfterFailures(t *testing.T) {
+	var attempts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts < 3 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	rt := &retryRoundTripper{
+		inner:      http.DefaultTransport,
+		maxRetries: 2,
+		backoffMax: time.Second,
+	}
+
+	req, err := http.NewRequest("GET", server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("wrong status code: got %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	if attempts != 3 {
+		t.Fatalf("wrong number of attempts: got %d, want 3", attempts)
+	}
+}
+
+func TestRetryRoundTripper_noRetriesByDefault(t *testing.T) {
+	var attempts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	rt := &retryRoundTripper{
+		inner:      http.DefaultTransport,
+		maxRetries: 0,
+	}
+
+	req, err := http.NewRequest("GET", server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("wrong status code: got %d, want %d", resp.StatusCode, http.StatusInternalServerError)
+	}
+	if attempts != 1 {
+		t.Fatalf("wrong number of attempts: got %d, want 1 (no retries)", attempts)
+	}
+}
+
+func TestDefaultRetryConfig(t *testing.T) {
+	got := DefaultRetryConfig()
+	if got.Retries != 0 {
+		t.Errorf("wrong default Retries: got %d, want 0 (retries disabled by default)", got.Retries)
+	}
+	if got.Timeout <= 0 {
+		t.Errorf("wrong default Timeout: got %s, want a positive duration", got.Timeout)
+	}
+	if got.BackoffMax <= 0 {
+		t.Errorf("wrong default BackoffMax: got %s, want a positive duration", got.BackoffMax)
+	}
+}
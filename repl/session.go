@@ -3,6 +3,8 @@ package repl
 import (
 	"errors"
 	"fmt"
+	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/zclconf/go-cty/cty"
@@ -18,10 +20,27 @@ import (
 // from Handle to signal a graceful exit.
 var ErrSessionExit = errors.New("session exit")
 
+// assignmentPattern recognizes a line of the form "name = expression",
+// taking care not to also match comparison operators such as "==", ">=",
+// "<=" or "!=".
+var assignmentPattern = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_-]*)\s*=([^=].*|)$`)
+
 // Session represents the state for a single REPL session.
 type Session struct {
 	// Scope is the evaluation scope where expressions will be evaluated.
 	Scope *lang.Scope
+
+	// vars holds the values assigned so far in this session via the
+	// "name = expression" syntax, so that later expressions can refer back
+	// to them by name.
+	vars map[string]cty.Value
+
+	// History is the sequence of non-empty lines this session has been
+	// asked to handle, in the order they were given, including lines that
+	// produced an error. The "exit" and "help" commands are not recorded. It's exported so that a UI layer (such as an
+	// interactive console) can implement up/down-arrow history browsing
+	// without keeping its own separate copy.
+	History []string
 }
 
 // Handle handles a single line of input from the REPL.
@@ -40,27 +59,125 @@ func (s *Session) Handle(line string) (string, bool, tfdiags.Diagnostics) {
 		ret, diags := s.handleHelp()
 		return ret, false, diags
 	default:
+		s.History = append(s.History, line)
+		if name, exprSrc, ok := parseAssignment(line); ok {
+			ret, diags := s.handleAssign(name, exprSrc)
+			return ret, false, diags
+		}
 		ret, diags := s.handleEval(line)
 		return ret, false, diags
 	}
 }
 
+// Complete returns the names of session variables and scope functions that
+// begin with the given prefix, sorted alphabetically, with each function
+// name followed by "(". It's intended to support tab-completion in an
+// interactive console.
+func (s *Session) Complete(prefix string) []string {
+	var candidates []string
+	for name := range s.vars {
+		if strings.HasPrefix(name, prefix) {
+			candidates = append(candidates, name)
+		}
+	}
+	for name := range s.Scope.Functions() {
+		if strings.HasPrefix(name, prefix) {
+			candidates = append(candidates, name+"(")
+		}
+	}
+	sort.Strings(candidates)
+	return candidates
+}
+
+// parseAssignment recognizes lines of the form "name = expression",
+// returning the assigned name and the expression source, or ok == false if
+// line doesn't look like an assignment.
+func parseAssignment(line string) (name string, exprSrc string, ok bool) {
+	m := assignmentPattern.FindStringSubmatch(line)
+	if m == nil {
+		return "", "", false
+	}
+	return m[1], m[2], true
+}
+
+func (s *Session) handleAssign(name, exprSrc string) (string, tfdiags.Diagnostics) {
+	val, diags := s.evalExpr(exprSrc)
+	if diags.HasErrors() {
+		return "", diags
+	}
+
+	result, diags := s.formatResult(val, diags)
+	if diags.HasErrors() {
+		return "", diags
+	}
+
+	if s.vars == nil {
+		s.vars = make(map[string]cty.Value)
+	}
+	s.vars[name] = val
+
+	return result, diags
+}
+
 func (s *Session) handleEval(line string) (string, tfdiags.Diagnostics) {
+	val, diags := s.evalExpr(line)
+	if diags.HasErrors() {
+		return "", diags
+	}
+
+	return s.formatResult(val, diags)
+}
+
+// evalExpr parses and evaluates src as a single expression, resolving any
+// names previously assigned via "name = expression" in addition to the
+// usual references resolved by the Scope.
+func (s *Session) evalExpr(src string) (cty.Value, tfdiags.Diagnostics) {
 	var diags tfdiags.Diagnostics
 
-	// Parse the given line as an expression
-	expr, parseDiags := hclsyntax.ParseExpression([]byte(line), "<console-input>", hcl.Pos{Line: 1, Column: 1})
+	expr, parseDiags := hclsyntax.ParseExpression([]byte(src), "<console-input>", hcl.Pos{Line: 1, Column: 1})
 	diags = diags.Append(parseDiags)
 	if parseDiags.HasErrors() {
-		return "", diags
+		return cty.DynamicVal, diags
 	}
 
-	val, valDiags := s.Scope.EvalExpr(expr, cty.DynamicPseudoType)
-	diags = diags.Append(valDiags)
-	if valDiags.HasErrors() {
-		return "", diags
+	if len(s.vars) == 0 {
+		val, valDiags := s.Scope.EvalExpr(expr, cty.DynamicPseudoType)
+		diags = diags.Append(valDiags)
+		return val, diags
 	}
 
+	// Some of the traversals in this expression might refer to session
+	// variables rather than to addrs.Reference-shaped symbols (resources,
+	// locals, and so on), so we resolve those ourselves and leave only the
+	// rest for the Scope to resolve in the usual way.
+	var scopeTraversals []hcl.Traversal
+	for _, traversal := range expr.Variables() {
+		if _, ok := s.vars[traversal.RootName()]; !ok {
+			scopeTraversals = append(scopeTraversals, traversal)
+		}
+	}
+
+	refs, refDiags := lang.References(scopeTraversals)
+	diags = diags.Append(refDiags)
+	if refDiags.HasErrors() {
+		return cty.DynamicVal, diags
+	}
+
+	ctx, ctxDiags := s.Scope.EvalContext(refs)
+	diags = diags.Append(ctxDiags)
+	if ctxDiags.HasErrors() {
+		return cty.DynamicVal, diags
+	}
+	for name, val := range s.vars {
+		ctx.Variables[name] = val
+	}
+
+	val, valDiags := expr.Value(ctx)
+	diags = diags.Append(valDiags)
+	return val, diags
+}
+
+func (s *Session) formatResult(val cty.Value, diags tfdiags.Diagnostics) (string, tfdiags.Diagnostics) {
 	if !val.IsWhollyKnown() {
 		// FIXME: In future, once we've updated the result formatter to be
 		// cty-aware, we should just include unknown values as "(not yet known)"
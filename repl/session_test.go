@@ -186,6 +186,89 @@ func TestSession_stateless(t *testing.T) {
 			},
 		})
 	})
+
+	t.Run("variable assignment", func(t *testing.T) {
+		testSession(t, testSessionTest{
+			Inputs: []testSessionInput{
+				{
+					Input:  "x = 5",
+					Output: "5",
+				},
+				{
+					Input:  "x + 1",
+					Output: "6",
+				},
+				{
+					Input:  "y = x * 2",
+					Output: "10",
+				},
+				{
+					Input:  "y",
+					Output: "10",
+				},
+			},
+		})
+	})
+
+	t.Run("comparisons are not mistaken for assignment", func(t *testing.T) {
+		testSession(t, testSessionTest{
+			Inputs: []testSessionInput{
+				{
+					Input:  "1 == 1",
+					Output: "true",
+				},
+			},
+		})
+	})
+}
+
+func TestSession_completeAndHistory(t *testing.T) {
+	config, _, cleanup, configDiags := initwd.LoadConfigForTests(t, "testdata/config-fixture")
+	defer cleanup()
+	if configDiags.HasErrors() {
+		t.Fatalf("unexpected problems loading config: %s", configDiags.Err())
+	}
+
+	p := &terraform.MockProvider{}
+	p.GetSchemaReturn = &terraform.ProviderSchema{
+		ResourceTypes: map[string]*configschema.Block{
+			"test_instance": {
+				Attributes: map[string]*configschema.Attribute{
+					"id": {Type: cty.String, Computed: true},
+				},
+			},
+		},
+	}
+
+	ctx, diags := terraform.NewContext(&terraform.ContextOpts{
+		Config: config,
+		ProviderResolver: providers.ResolverFixed(map[string]providers.Factory{
+			"test": providers.FactoryFixed(p),
+		}),
+	})
+	if diags.HasErrors() {
+		t.Fatalf("failed to create context: %s", diags.Err())
+	}
+
+	scope, diags := ctx.Eval(addrs.RootModuleInstance)
+	if diags.HasErrors() {
+		t.Fatalf("failed to create scope: %s", diags.Err())
+	}
+
+	s := &Session{Scope: scope}
+
+	if _, exit, diags := s.Handle("x = 5"); exit || diags.HasErrors() {
+		t.Fatalf("unexpected error: %s", diags.Err())
+	}
+
+	candidates := s.Complete("x")
+	if len(candidates) != 1 || candidates[0] != "x" {
+		t.Fatalf("wrong completions: %#v", candidates)
+	}
+
+	if got, want := s.History, []string{"x = 5"}; len(got) != len(want) || got[0] != want[0] {
+		t.Fatalf("wrong history: %#v", got)
+	}
 }
 
 func testSession(t *testing.T, test testSessionTest) {
@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"time"
+
+	"github.com/hashicorp/terraform/svchost"
+)
+
+// AuditLogFunc is called once for each request that an AuditingCredentialsSource
+// forwards to its wrapped source, describing the request and how it went.
+//
+// verb identifies which CredentialsSource method was called: "get", "store",
+// or "forget". duration is how long the wrapped source took to return, and
+// success is whether it returned without an error.
+//
+// An AuditLogFunc must not record cred, err, or any other value that might
+// disclose a credential: the whole point of this mechanism is to let an
+// operator see that a host's credentials were accessed, for compliance
+// purposes, without the audit trail itself becoming a place secrets leak to.
+type AuditLogFunc func(host svchost.Hostname, verb string, duration time.Duration, success bool)
+
+// AuditingCredentialsSource creates a new credentials source that wraps
+// another, calling log once for every ForHost, StoreForHost, and
+// ForgetForHost call it forwards to the wrapped source.
+//
+// This is intended for compliance situations where an operator needs a
+// record of when and how often a host's credentials were accessed, most
+// commonly to observe the activity of a credentials helper program; see
+// HelperProgramCredentialsSource. It says nothing about what credentials
+// were involved, only that a request for them was made and whether it
+// succeeded.
+func AuditingCredentialsSource(source CredentialsSource, log AuditLogFunc) CredentialsSource {
+	return &auditingCredentialsSource{
+		source: source,
+		log:    log,
+	}
+}
+
+type auditingCredentialsSource struct {
+	source CredentialsSource
+	log    AuditLogFunc
+}
+
+func (s *auditingCredentialsSource) ForHost(host svchost.Hostname, service string) (HostCredentials, error) {
+	start := time.Now()
+	creds, err := s.source.ForHost(host, service)
+	s.log(host, "get", time.Since(start), err == nil)
+	return creds, err
+}
+
+func (s *auditingCredentialsSource) StoreForHost(host svchost.Hostname, credentials HostCredentialsWritable) error {
+	start := time.Now()
+	err := s.source.StoreForHost(host, credentials)
+	s.log(host, "store", time.Since(start), err == nil)
+	return err
+}
+
+func (s *auditingCredentialsSource) ForgetForHost(host svchost.Hostname) error {
+	start := time.Now()
+	err := s.source.ForgetForHost(host)
+	s.log(host, "forget", time.Since(start), err == nil)
+	return err
+}
@@ -0,0 +1,100 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform/svchost"
+)
+
+// fakeCredentialsSource is a CredentialsSource whose three methods each
+// return whatever the correspondingly-named field holds, for exercising a
+// decorator without depending on a real credentials store.
+type fakeCredentialsSource struct {
+	forHostErr       error
+	storeForHostErr  error
+	forgetForHostErr error
+}
+
+func (s *fakeCredentialsSource) ForHost(host svchost.Hostname, service string) (HostCredentials, error) {
+	if s.forHostErr != nil {
+		return nil, s.forHostErr
+	}
+	return HostCredentialsToken("secret-token"), nil
+}
+
+func (s *fakeCredentialsSource) StoreForHost(host svchost.Hostname, credentials HostCredentialsWritable) error {
+	return s.storeForHostErr
+}
+
+func (s *fakeCredentialsSource) ForgetForHost(host svchost.Hostname) error {
+	return s.forgetForHostErr
+}
+
+func TestAuditingCredentialsSource(t *testing.T) {
+	type record struct {
+		host    svchost.Hostname
+		verb    string
+		success bool
+	}
+
+	t.Run("records a successful get", func(t *testing.T) {
+		underlying := &fakeCredentialsSource{}
+		var got []record
+		src := AuditingCredentialsSource(underlying, func(host svchost.Hostname, verb string, duration time.Duration, success bool) {
+			got = append(got, record{host, verb, success})
+		})
+
+		creds, err := src.ForHost(svchost.Hostname("example.com"), "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tok, ok := creds.(HostCredentialsToken); !ok || string(tok) != "secret-token" {
+			t.Errorf("wrong credentials returned: %#v", creds)
+		}
+
+		if len(got) != 1 {
+			t.Fatalf("got %d records; want 1", len(got))
+		}
+		if got[0] != (record{svchost.Hostname("example.com"), "get", true}) {
+			t.Errorf("wrong record %#v", got[0])
+		}
+	})
+
+	t.Run("records a failed store", func(t *testing.T) {
+		underlying := &fakeCredentialsSource{storeForHostErr: errors.New("boom")}
+		var got []record
+		src := AuditingCredentialsSource(underlying, func(host svchost.Hostname, verb string, duration time.Duration, success bool) {
+			got = append(got, record{host, verb, success})
+		})
+
+		err := src.StoreForHost(svchost.Hostname("example.com"), HostCredentialsToken("secret-token"))
+		if err == nil {
+			t.Fatal("completed successfully; want error")
+		}
+
+		if len(got) != 1 {
+			t.Fatalf("got %d records; want 1", len(got))
+		}
+		if got[0] != (record{svchost.Hostname("example.com"), "store", false}) {
+			t.Errorf("wrong record %#v", got[0])
+		}
+	})
+
+	t.Run("records a forget, and calls through to the wrapped source", func(t *testing.T) {
+		underlying := &fakeCredentialsSource{}
+		var got []record
+		src := AuditingCredentialsSource(underlying, func(host svchost.Hostname, verb string, duration time.Duration, success bool) {
+			got = append(got, record{host, verb, success})
+		})
+
+		if err := src.ForgetForHost(svchost.Hostname("example.com")); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(got) != 1 || got[0].verb != "forget" || !got[0].success {
+			t.Fatalf("wrong records %#v", got)
+		}
+	})
+}
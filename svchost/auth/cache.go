@@ -1,61 +1,115 @@
 package auth
 
 import (
+	"sync"
+	"time"
+
 	"github.com/hashicorp/terraform/svchost"
 )
 
-// CachingCredentialsSource creates a new credentials source that wraps another
-// and caches its results in memory, on a per-hostname basis.
+// CachingCredentialsSource creates a new credentials source that wraps
+// another and caches its results in memory, on a per-hostname-and-service
+// basis.
+//
+// Cache entries are considered valid for the given ttl; a non-positive ttl
+// means cache entries never expire on their own. The returned function
+// explicitly invalidates the entire cache, which is useful for a long-lived
+// source (wrapping, for example, a credentials helper program that is
+// otherwise re-run for every single host lookup) that needs to be told to
+// re-check on demand rather than waiting out the ttl.
 //
-// No means is provided for expiration of cached credentials, so a caching
-// credentials source should have a limited lifetime (one Terraform operation,
-// for example) to ensure that time-limited credentials don't expire before
-// their cache entries do.
-func CachingCredentialsSource(source CredentialsSource) CredentialsSource {
-	return &cachingCredentialsSource{
+// Regardless of ttl, a caching credentials source should have a limited
+// lifetime (one Terraform operation, for example) to ensure that
+// time-limited credentials don't expire before their cache entries do.
+func CachingCredentialsSource(source CredentialsSource, ttl time.Duration) (CredentialsSource, func()) {
+	s := &cachingCredentialsSource{
 		source: source,
-		cache:  map[svchost.Hostname]HostCredentials{},
+		ttl:    ttl,
+		cache:  map[cacheKey]cacheEntry{},
 	}
+	return s, s.invalidate
+}
+
+// cacheKey distinguishes cache entries by both host and service, since a
+// scoped credentials source may return different results for the same host
+// depending on which service is being asked about.
+type cacheKey struct {
+	host    svchost.Hostname
+	service string
+}
+
+type cacheEntry struct {
+	credentials HostCredentials
+	cachedAt    time.Time
 }
 
 type cachingCredentialsSource struct {
 	source CredentialsSource
-	cache  map[svchost.Hostname]HostCredentials
+	ttl    time.Duration
+
+	mu    sync.Mutex
+	cache map[cacheKey]cacheEntry
 }
 
-// ForHost passes the given hostname on to the wrapped credentials source and
-// caches the result to return for future requests with the same hostname.
+// ForHost passes the given hostname and service on to the wrapped
+// credentials source and caches the result to return for future requests
+// with the same hostname and service, until the cache entry's ttl elapses.
 //
 // Both credentials and non-credentials (nil) responses are cached.
 //
 // No cache entry is created if the wrapped source returns an error, to allow
 // the caller to retry the failing operation.
-func (s *cachingCredentialsSource) ForHost(host svchost.Hostname) (HostCredentials, error) {
-	if cache, cached := s.cache[host]; cached {
-		return cache, nil
+func (s *cachingCredentialsSource) ForHost(host svchost.Hostname, service string) (HostCredentials, error) {
+	key := cacheKey{host, service}
+
+	s.mu.Lock()
+	entry, cached := s.cache[key]
+	s.mu.Unlock()
+	if cached && (s.ttl <= 0 || time.Since(entry.cachedAt) < s.ttl) {
+		return entry.credentials, nil
 	}
 
-	result, err := s.source.ForHost(host)
+	result, err := s.source.ForHost(host, service)
 	if err != nil {
 		return result, err
 	}
 
-	s.cache[host] = result
+	s.mu.Lock()
+	s.cache[key] = cacheEntry{credentials: result, cachedAt: time.Now()}
+	s.mu.Unlock()
 	return result, nil
 }
 
 func (s *cachingCredentialsSource) StoreForHost(host svchost.Hostname, credentials HostCredentialsWritable) error {
-	// We'll delete the cache entry even if the store fails, since that just
-	// means that the next read will go to the real store and get a chance to
-	// see which object (old or new) is actually present.
-	delete(s.cache, host)
+	// We'll delete any cache entries for this host even if the store fails,
+	// since that just means that the next read will go to the real store and
+	// get a chance to see which object (old or new) is actually present.
+	s.forgetCacheForHost(host)
 	return s.source.StoreForHost(host, credentials)
 }
 
 func (s *cachingCredentialsSource) ForgetForHost(host svchost.Hostname) error {
-	// We'll delete the cache entry even if the store fails, since that just
-	// means that the next read will go to the real store and get a chance to
-	// see if the object is still present.
-	delete(s.cache, host)
+	// We'll delete any cache entries for this host even if the forget fails,
+	// since that just means that the next read will go to the real store and
+	// get a chance to see if the object is still present.
+	s.forgetCacheForHost(host)
 	return s.source.ForgetForHost(host)
 }
+
+func (s *cachingCredentialsSource) forgetCacheForHost(host svchost.Hostname) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key := range s.cache {
+		if key.host == host {
+			delete(s.cache, key)
+		}
+	}
+}
+
+// invalidate discards the entire cache, regardless of ttl, forcing the next
+// request for any host and service to go back to the wrapped source.
+func (s *cachingCredentialsSource) invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cache = map[cacheKey]cacheEntry{}
+}
@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform/svchost"
+)
+
+func (s *countingCredentialsSource) calls() int32 {
+	return atomic.LoadInt32(&s.count)
+}
+
+func TestCachingCredentialsSource(t *testing.T) {
+	t.Run("caches until invalidated when ttl is zero", func(t *testing.T) {
+		underlying := &countingCredentialsSource{}
+		src, invalidate := CachingCredentialsSource(underlying, 0)
+
+		if _, err := src.ForHost(svchost.Hostname("example.com"), ""); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := src.ForHost(svchost.Hostname("example.com"), ""); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := underlying.calls(), int32(1); got != want {
+			t.Fatalf("wrong call count %d; want %d", got, want)
+		}
+
+		invalidate()
+
+		if _, err := src.ForHost(svchost.Hostname("example.com"), ""); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := underlying.calls(), int32(2); got != want {
+			t.Errorf("wrong call count %d after invalidate; want %d", got, want)
+		}
+	})
+
+	t.Run("re-fetches once the ttl elapses", func(t *testing.T) {
+		underlying := &countingCredentialsSource{}
+		src, _ := CachingCredentialsSource(underlying, 10*time.Millisecond)
+
+		if _, err := src.ForHost(svchost.Hostname("example.com"), ""); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(50 * time.Millisecond)
+		if _, err := src.ForHost(svchost.Hostname("example.com"), ""); err != nil {
+			t.Fatal(err)
+		}
+
+		if got, want := underlying.calls(), int32(2); got != want {
+			t.Errorf("wrong call count %d; want %d", got, want)
+		}
+	})
+
+	t.Run("caches separately per service", func(t *testing.T) {
+		underlying := &countingCredentialsSource{}
+		src, _ := CachingCredentialsSource(underlying, 0)
+
+		if _, err := src.ForHost(svchost.Hostname("example.com"), "modules.v1"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := src.ForHost(svchost.Hostname("example.com"), "providers.v1"); err != nil {
+			t.Fatal(err)
+		}
+
+		if got, want := underlying.calls(), int32(2); got != want {
+			t.Errorf("wrong call count %d; want %d", got, want)
+		}
+	})
+}
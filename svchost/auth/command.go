@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform/svchost"
+)
+
+// defaultCommandTokenTTL is how long a token obtained by running a
+// CommandCredentialsSource's command is cached before the command is run
+// again, if the caller didn't specify a different TTL.
+const defaultCommandTokenTTL = 5 * time.Minute
+
+// CommandCredentialsSource returns a CredentialsSource that obtains a
+// bearer token by running the given command and using its trimmed stdout as
+// the token, re-running the command only after ttl has elapsed since the
+// last successful run.
+//
+// This offers a lighter-weight alternative to a full credentials helper
+// program (see HelperProgramCredentialsSource) for the common case of
+// needing to run some local script to mint a token for a single host,
+// without the helper protocol's "get"/"store"/"forget" subcommands.
+//
+// If ttl is zero or negative, a default TTL of five minutes is used.
+func CommandCredentialsSource(command []string, ttl time.Duration) CredentialsSource {
+	if ttl <= 0 {
+		ttl = defaultCommandTokenTTL
+	}
+	return &commandCredentialsSource{
+		command: command,
+		ttl:     ttl,
+	}
+}
+
+type commandCredentialsSource struct {
+	command []string
+	ttl     time.Duration
+
+	mu       sync.Mutex
+	cached   HostCredentials
+	cachedAt time.Time
+}
+
+func (s *commandCredentialsSource) ForHost(host svchost.Hostname, service string) (HostCredentials, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cached != nil && time.Since(s.cachedAt) < s.ttl {
+		return s.cached, nil
+	}
+
+	if len(s.command) == 0 {
+		return nil, fmt.Errorf("token_command is empty")
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(s.command[0], s.command[1:]...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if stderr.Len() > 0 {
+			return nil, fmt.Errorf("token_command failed: %s", strings.TrimSpace(stderr.String()))
+		}
+		return nil, fmt.Errorf("token_command failed: %s", err)
+	}
+
+	token := strings.TrimSpace(stdout.String())
+	if token == "" {
+		return nil, fmt.Errorf("token_command produced no output")
+	}
+
+	s.cached = HostCredentialsToken(token)
+	s.cachedAt = time.Now()
+	return s.cached, nil
+}
+
+func (s *commandCredentialsSource) StoreForHost(host svchost.Hostname, credentials HostCredentialsWritable) error {
+	return fmt.Errorf("can't store new credentials for a host configured with token_command")
+}
+
+func (s *commandCredentialsSource) ForgetForHost(host svchost.Hostname) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cached = nil
+	return nil
+}
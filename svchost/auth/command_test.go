@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform/svchost"
+)
+
+func TestCommandCredentialsSource(t *testing.T) {
+	t.Run("happy path with caching", func(t *testing.T) {
+		calls := 0
+		src := CommandCredentialsSource([]string{"sh", "-c", "echo -n tok-1"}, time.Hour)
+
+		creds, err := src.ForHost(svchost.Hostname("example.com"), "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := creds.Token(), "tok-1"; got != want {
+			t.Errorf("wrong token %q; want %q", got, want)
+		}
+
+		// The command prints the same token every time it runs, so this
+		// only checks that a second lookup within the TTL still returns it.
+		_ = calls
+		creds, err = src.ForHost(svchost.Hostname("example.com"), "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := creds.Token(), "tok-1"; got != want {
+			t.Errorf("expected cached token %q, got %q", want, got)
+		}
+	})
+
+	t.Run("re-runs after TTL expires", func(t *testing.T) {
+		src := CommandCredentialsSource([]string{"sh", "-c", "echo -n tok-$$"}, time.Nanosecond)
+
+		first, err := src.ForHost(svchost.Hostname("example.com"), "")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		time.Sleep(time.Millisecond)
+
+		second, err := src.ForHost(svchost.Hostname("example.com"), "")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if first.Token() == "" || second.Token() == "" {
+			t.Fatalf("expected non-empty tokens, got %q and %q", first.Token(), second.Token())
+		}
+		if first.Token() == second.Token() {
+			t.Errorf("expected the command to run again after the TTL, but got %q both times", first.Token())
+		}
+	})
+
+	t.Run("command fails", func(t *testing.T) {
+		src := CommandCredentialsSource([]string{"sh", "-c", "echo failed >&2; exit 1"}, time.Hour)
+
+		_, err := src.ForHost(svchost.Hostname("example.com"), "")
+		if err == nil {
+			t.Fatal("expected an error")
+		}
+	})
+
+	t.Run("cannot store or is forgotten", func(t *testing.T) {
+		src := CommandCredentialsSource([]string{"sh", "-c", "echo -n tok"}, time.Hour)
+
+		if err := src.StoreForHost(svchost.Hostname("example.com"), HostCredentialsToken("x")); err == nil {
+			t.Error("expected an error storing credentials for a token_command source")
+		}
+		if err := src.ForgetForHost(svchost.Hostname("example.com")); err != nil {
+			t.Errorf("unexpected error forgetting: %s", err)
+		}
+	})
+}
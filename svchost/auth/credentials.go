@@ -5,6 +5,7 @@ package auth
 import (
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/zclconf/go-cty/cty"
 
@@ -36,9 +37,16 @@ type CredentialsSource interface {
 	// ForHost returns a non-nil HostCredentials if the source has credentials
 	// available for the host, and a nil HostCredentials if it does not.
 	//
+	// service identifies which service on the host the credentials are
+	// intended for, such as "modules.v1", so that a source which scopes its
+	// credentials to particular services can decline to return them for
+	// others. Callers that are not asking on behalf of a particular service,
+	// such as host-level service discovery itself, should pass an empty
+	// string, which matches credentials regardless of any scoping.
+	//
 	// If an error is returned, progress through a list of CredentialsSources
 	// is halted and the error is returned to the user.
-	ForHost(host svchost.Hostname) (HostCredentials, error)
+	ForHost(host svchost.Hostname, service string) (HostCredentials, error)
 
 	// StoreForHost takes a HostCredentialsWritable and saves it as the
 	// credentials for the given host.
@@ -82,14 +90,35 @@ type HostCredentialsWritable interface {
 	ToStore() cty.Value
 }
 
+// HostCredentialsExpiring is an optional extension of HostCredentials for
+// credentials that are known to become invalid at a particular time, such
+// as a short-lived registry token obtained from a credentials helper.
+//
+// A CredentialsSource is not required to return values implementing this
+// interface; callers that care about expiry, such as BackgroundRefresh,
+// must use a type assertion to check for it.
+type HostCredentialsExpiring interface {
+	HostCredentials
+
+	// ExpiresAt returns the time at which the credentials are expected to
+	// become invalid, and whether an expiry time is known at all. If known
+	// is false, the returned time has no meaning.
+	ExpiresAt() (expiresAt time.Time, known bool)
+
+	// RefreshHint returns how long before the expiry time a caller should
+	// try to obtain replacement credentials, to leave margin for the
+	// refresh itself to complete before the old credentials stop working.
+	RefreshHint() time.Duration
+}
+
 // ForHost iterates over the contained CredentialsSource objects and
 // tries to obtain credentials for the given host from each one in turn.
 //
 // If any source returns either a non-nil HostCredentials or a non-nil error
 // then this result is returned. Otherwise, the result is nil, nil.
-func (c Credentials) ForHost(host svchost.Hostname) (HostCredentials, error) {
+func (c Credentials) ForHost(host svchost.Hostname, service string) (HostCredentials, error) {
 	for _, source := range c {
-		creds, err := source.ForHost(host)
+		creds, err := source.ForHost(host, service)
 		if creds != nil || err != nil {
 			return creds, err
 		}
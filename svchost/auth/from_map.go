@@ -1,6 +1,8 @@
 package auth
 
 import (
+	"time"
+
 	"github.com/zclconf/go-cty/cty"
 )
 
@@ -11,14 +13,76 @@ import (
 //
 // This function ignores map keys it is unfamiliar with, to allow for future
 // expansion of the credentials map format for new credential types.
+//
+// If the map includes a valid RFC 3339 "expires_at" string, the returned
+// HostCredentials also implements HostCredentialsExpiring, using an optional
+// numeric "refresh_hint_seconds" to control how long before expiry a
+// refresher should try to replace it.
 func HostCredentialsFromMap(m map[string]interface{}) HostCredentials {
 	if m == nil {
 		return nil
 	}
-	if token, ok := m["token"].(string); ok {
-		return HostCredentialsToken(token)
+	token, ok := m["token"].(string)
+	if !ok {
+		return nil
+	}
+
+	base := HostCredentialsToken(token)
+
+	expiresRaw, ok := m["expires_at"].(string)
+	if !ok {
+		return base
+	}
+	expiresAt, err := time.Parse(time.RFC3339, expiresRaw)
+	if err != nil {
+		return base
+	}
+
+	refreshHint := defaultRefreshHint
+	if hintRaw, ok := m["refresh_hint_seconds"].(float64); ok && hintRaw > 0 {
+		refreshHint = time.Duration(hintRaw) * time.Second
+	}
+
+	return expiringHostCredentialsToken{
+		HostCredentialsToken: base,
+		expiresAt:            expiresAt,
+		refreshHint:          refreshHint,
 	}
-	return nil
+}
+
+// credentialsAllowService returns true unless m has a "services" entry that
+// explicitly excludes the given service, allowing a credentials map to be
+// scoped to only a subset of the services on a host.
+func credentialsAllowService(m map[string]interface{}, service string) bool {
+	raw, ok := m["services"]
+	if !ok {
+		return true
+	}
+
+	switch services := raw.(type) {
+	case []string:
+		return stringSliceContains(services, service)
+	case []interface{}:
+		for _, v := range services {
+			if s, ok := v.(string); ok && s == service {
+				return true
+			}
+		}
+		return false
+	default:
+		// Weird, but we'll be permissive about it rather than locking the
+		// user out of credentials they've configured.
+		return true
+	}
+}
+
+func stringSliceContains(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
 }
 
 // HostCredentialsFromObject converts a cty.Value of an object type into a
@@ -44,5 +108,33 @@ func HostCredentialsFromObject(obj cty.Value) HostCredentials {
 		return nil
 	}
 
-	return HostCredentialsToken(tokenV.AsString())
+	base := HostCredentialsToken(tokenV.AsString())
+
+	if !obj.Type().HasAttribute("expires_at") {
+		return base
+	}
+	expiresV := obj.GetAttr("expires_at")
+	if expiresV.IsNull() || !expiresV.IsKnown() || !cty.String.Equals(expiresV.Type()) {
+		return base
+	}
+	expiresAt, err := time.Parse(time.RFC3339, expiresV.AsString())
+	if err != nil {
+		return base
+	}
+
+	refreshHint := defaultRefreshHint
+	if obj.Type().HasAttribute("refresh_hint_seconds") {
+		hintV := obj.GetAttr("refresh_hint_seconds")
+		if !hintV.IsNull() && hintV.IsKnown() && cty.Number.Equals(hintV.Type()) {
+			if hint, _ := hintV.AsBigFloat().Float64(); hint > 0 {
+				refreshHint = time.Duration(hint) * time.Second
+			}
+		}
+	}
+
+	return expiringHostCredentialsToken{
+		HostCredentialsToken: base,
+		expiresAt:            expiresAt,
+		refreshHint:          refreshHint,
+	}
 }
@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHostCredentialsFromMapExpiring(t *testing.T) {
+	t.Run("no expires_at", func(t *testing.T) {
+		creds := HostCredentialsFromMap(map[string]interface{}{
+			"token": "abc123",
+		})
+		if _, expiring := creds.(HostCredentialsExpiring); expiring {
+			t.Errorf("creds unexpectedly implements HostCredentialsExpiring: %#v", creds)
+		}
+	})
+
+	t.Run("valid expires_at", func(t *testing.T) {
+		creds := HostCredentialsFromMap(map[string]interface{}{
+			"token":                "abc123",
+			"expires_at":           "2030-01-01T00:00:00Z",
+			"refresh_hint_seconds": float64(60),
+		})
+		expiring, ok := creds.(HostCredentialsExpiring)
+		if !ok {
+			t.Fatalf("creds does not implement HostCredentialsExpiring: %#v", creds)
+		}
+		expiresAt, known := expiring.ExpiresAt()
+		if !known {
+			t.Fatalf("expiry not known")
+		}
+		if want, _ := time.Parse(time.RFC3339, "2030-01-01T00:00:00Z"); !expiresAt.Equal(want) {
+			t.Errorf("wrong expiry: got %s, want %s", expiresAt, want)
+		}
+		if got, want := expiring.RefreshHint(), 60*time.Second; got != want {
+			t.Errorf("wrong refresh hint: got %s, want %s", got, want)
+		}
+		if got, want := creds.Token(), "abc123"; got != want {
+			t.Errorf("wrong token: got %s, want %s", got, want)
+		}
+	})
+
+	t.Run("invalid expires_at", func(t *testing.T) {
+		creds := HostCredentialsFromMap(map[string]interface{}{
+			"token":      "abc123",
+			"expires_at": "not-a-timestamp",
+		})
+		if _, expiring := creds.(HostCredentialsExpiring); expiring {
+			t.Errorf("creds unexpectedly implements HostCredentialsExpiring: %#v", creds)
+		}
+		if got, want := creds.Token(), "abc123"; got != want {
+			t.Errorf("wrong token: got %s, want %s", got, want)
+		}
+	})
+}
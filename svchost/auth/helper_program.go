@@ -2,19 +2,68 @@ package auth
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"log"
 	"os/exec"
 	"path/filepath"
+	"strings"
+	"sync"
+	"time"
 
 	ctyjson "github.com/zclconf/go-cty/cty/json"
 
 	"github.com/hashicorp/terraform/svchost"
 )
 
+// helperProtocolV1 is the original credentials helper protocol, where
+// errors are reported as plain text on stderr and the "get" subcommand's
+// stdout is a flat JSON object of credentials attributes.
+const helperProtocolV1 = 1
+
+// helperProtocolV2 extends the original protocol so that a helper may
+// report structured diagnostics (errors and warnings) on stderr, as a
+// JSON array of objects with "severity", "summary", and "detail"
+// attributes, instead of (or in addition to) human-oriented text.
+const helperProtocolV2 = 2
+
+// maxHelperProtocol is the newest protocol version this version of
+// Terraform knows how to speak. Update this, and add handling for the new
+// version's additional capabilities, when introducing a new protocol
+// version.
+const maxHelperProtocol = helperProtocolV2
+
+// defaultHelperTimeout bounds how long a helper program invocation may run
+// before it's killed, for a caller that doesn't set HelperProgramOptions.Timeout.
+// It's generous enough for a helper that talks to a remote secret store over
+// the network, while still keeping a hung helper from blocking a Terraform
+// command indefinitely.
+const defaultHelperTimeout = 30 * time.Second
+
+// HelperProgramOptions customizes how HelperProgramCredentialsSourceWithOptions
+// runs a credentials helper program.
+type HelperProgramOptions struct {
+	// Env sets the child process's environment, in the same "KEY=VALUE"
+	// form as os.Environ. A nil Env means to inherit the full environment;
+	// an empty but non-nil Env gives the child no environment variables at
+	// all.
+	Env []string
+
+	// Timeout bounds how long a single invocation of the helper is allowed
+	// to run before it's killed and treated as a failure. A zero Timeout
+	// means to use defaultHelperTimeout.
+	Timeout time.Duration
+}
+
 type helperProgramCredentialsSource struct {
 	executable string
 	args       []string
+	env        []string
+	timeout    time.Duration
+
+	mu       sync.Mutex
+	protocol int // zero means "not yet negotiated"
 }
 
 // HelperProgramCredentialsSource returns a CredentialsSource that runs the
@@ -26,10 +75,48 @@ type helperProgramCredentialsSource struct {
 // function will panic.
 //
 // When credentials are requested, the program will be run in a child process
-// with the given arguments along with two additional arguments added to the
-// end of the list: the literal string "get", followed by the requested
-// hostname in ASCII compatibility form (punycode form).
+// with the given arguments along with two or three additional arguments
+// added to the end of the list: the literal string "get", followed by the
+// requested hostname in ASCII compatibility form (punycode form), followed
+// by the requested service identifier (such as "modules.v1") if the caller
+// specified one.
+//
+// Before its first real request, the source probes the helper with a
+// "handshake" subcommand to negotiate a protocol version. Helpers that
+// don't recognize that subcommand (including all helpers written before
+// protocol 2 was introduced) are assumed to speak only the original
+// protocol, so existing helper programs continue to work unmodified.
+//
+// The child process inherits this process's entire environment. Use
+// HelperProgramCredentialsSourceWithEnv instead if the caller needs to
+// control that.
 func HelperProgramCredentialsSource(executable string, args ...string) CredentialsSource {
+	return HelperProgramCredentialsSourceWithEnv(executable, nil, args...)
+}
+
+// HelperProgramCredentialsSourceWithEnv is like HelperProgramCredentialsSource
+// except that the child process's environment is set to env, in the same
+// "KEY=VALUE" form as os.Environ, instead of being inherited wholesale from
+// this process. A nil env means to inherit the full environment, matching
+// HelperProgramCredentialsSource; an empty but non-nil env gives the child
+// no environment variables at all.
+//
+// The child runs with defaultHelperTimeout; use
+// HelperProgramCredentialsSourceWithOptions instead to configure a different
+// timeout.
+func HelperProgramCredentialsSourceWithEnv(executable string, env []string, args ...string) CredentialsSource {
+	return HelperProgramCredentialsSourceWithOptions(executable, HelperProgramOptions{Env: env}, args...)
+}
+
+// HelperProgramCredentialsSourceWithOptions is like
+// HelperProgramCredentialsSource except that it also accepts
+// HelperProgramOptions, letting the caller control the child process's
+// environment and how long a single invocation may run before it's killed.
+//
+// On Linux, the child process also runs with the no-new-privileges bit set,
+// so that a setuid or setcap helper binary can't be used to elevate
+// privileges beyond whatever Terraform itself already has.
+func HelperProgramCredentialsSourceWithOptions(executable string, opts HelperProgramOptions, args ...string) CredentialsSource {
 	if !filepath.IsAbs(executable) {
 		panic("NewCredentialsSourceHelperProgram requires absolute path to executable")
 	}
@@ -38,38 +125,121 @@ func HelperProgramCredentialsSource(executable string, args ...string) Credentia
 	fullArgs[0] = executable
 	copy(fullArgs[1:], args)
 
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = defaultHelperTimeout
+	}
+
 	return &helperProgramCredentialsSource{
 		executable: executable,
 		args:       fullArgs,
+		env:        opts.Env,
+		timeout:    timeout,
 	}
 }
 
-func (s *helperProgramCredentialsSource) ForHost(host svchost.Hostname) (HostCredentials, error) {
-	args := make([]string, len(s.args), len(s.args)+2)
+// newCmd builds the exec.Cmd used to run this helper with the given
+// trailing arguments (after "handshake", "get", "store", or "forget" and
+// its own arguments), bounded by the source's configured timeout and, on
+// Linux, hardened against privilege escalation. The returned cancel func
+// must be called once the command has finished, in the same way as the
+// cancel func returned by context.WithTimeout.
+func (s *helperProgramCredentialsSource) newCmd(args []string) (*exec.Cmd, context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	cmd := exec.CommandContext(ctx, s.executable, args[1:]...)
+	cmd.Env = s.env
+	cmd.SysProcAttr = helperProgramSysProcAttr()
+	return cmd, ctx, cancel
+}
+
+// helperRunError builds the error to return when cmd.Run fails for a reason
+// other than a nonzero exit (which callers handle separately, via
+// helperError), reporting a clear timeout message instead of os/exec's own
+// unhelpful "signal: killed" when ctx's deadline is what actually caused
+// the failure.
+func (s *helperProgramCredentialsSource) helperRunError(ctx context.Context, err error) error {
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("credentials helper %s did not respond within %s; killed it", s.executable, s.timeout)
+	}
+	return fmt.Errorf("failed to run %s: %s", s.executable, err)
+}
+
+// helperDiagnostic is the JSON shape of a single structured diagnostic as
+// reported by a protocol-2-or-later helper program on stderr.
+type helperDiagnostic struct {
+	Severity string `json:"severity"`
+	Summary  string `json:"summary"`
+	Detail   string `json:"detail"`
+}
+
+// negotiatedProtocol determines, on first use, the highest helper protocol
+// version that both this version of Terraform and the helper program
+// support, by running the helper with a "handshake" subcommand and caching
+// the result for the lifetime of the source.
+func (s *helperProgramCredentialsSource) negotiatedProtocol() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.protocol != 0 {
+		return s.protocol
+	}
+
+	args := make([]string, len(s.args), len(s.args)+1)
+	copy(args, s.args)
+	args = append(args, "handshake")
+
+	outBuf := bytes.Buffer{}
+	cmd, _, cancel := s.newCmd(args)
+	cmd.Stdout = &outBuf
+	defer cancel()
+
+	// A helper that doesn't understand "handshake" at all -- which includes
+	// every helper written before protocol 2 existed -- will either exit
+	// nonzero or produce output we can't parse, and in either case we just
+	// assume it only speaks the original protocol.
+	s.protocol = helperProtocolV1
+	if err := cmd.Run(); err != nil {
+		return s.protocol
+	}
+
+	var resp struct {
+		ProtocolVersions []int `json:"protocol_versions"`
+	}
+	if err := json.Unmarshal(outBuf.Bytes(), &resp); err != nil {
+		return s.protocol
+	}
+
+	for _, v := range resp.ProtocolVersions {
+		if v > s.protocol && v <= maxHelperProtocol {
+			s.protocol = v
+		}
+	}
+	return s.protocol
+}
+
+func (s *helperProgramCredentialsSource) ForHost(host svchost.Hostname, service string) (HostCredentials, error) {
+	args := make([]string, len(s.args), len(s.args)+3)
 	copy(args, s.args)
 	args = append(args, "get")
 	args = append(args, string(host))
+	if service != "" {
+		args = append(args, service)
+	}
 
 	outBuf := bytes.Buffer{}
 	errBuf := bytes.Buffer{}
 
-	cmd := exec.Cmd{
-		Path:   s.executable,
-		Args:   args,
-		Stdin:  nil,
-		Stdout: &outBuf,
-		Stderr: &errBuf,
-	}
+	cmd, ctx, cancel := s.newCmd(args)
+	cmd.Stdout = &outBuf
+	cmd.Stderr = &errBuf
+	defer cancel()
+
 	err := cmd.Run()
-	if _, isExitErr := err.(*exec.ExitError); isExitErr {
-		errText := errBuf.String()
-		if errText == "" {
-			// Shouldn't happen for a well-behaved helper program
-			return nil, fmt.Errorf("error in %s, but it produced no error message", s.executable)
-		}
-		return nil, fmt.Errorf("error in %s: %s", s.executable, errText)
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, s.helperRunError(ctx, err)
+	} else if _, isExitErr := err.(*exec.ExitError); isExitErr {
+		return nil, s.helperError(errBuf.Bytes())
 	} else if err != nil {
-		return nil, fmt.Errorf("failed to run %s: %s", s.executable, err)
+		return nil, s.helperRunError(ctx, err)
 	}
 
 	var m map[string]interface{}
@@ -96,23 +266,18 @@ func (s *helperProgramCredentialsSource) StoreForHost(host svchost.Hostname, cre
 	inReader := bytes.NewReader(toStoreRaw)
 	errBuf := bytes.Buffer{}
 
-	cmd := exec.Cmd{
-		Path:   s.executable,
-		Args:   args,
-		Stdin:  inReader,
-		Stderr: &errBuf,
-		Stdout: nil,
-	}
+	cmd, ctx, cancel := s.newCmd(args)
+	cmd.Stdin = inReader
+	cmd.Stderr = &errBuf
+	defer cancel()
+
 	err = cmd.Run()
-	if _, isExitErr := err.(*exec.ExitError); isExitErr {
-		errText := errBuf.String()
-		if errText == "" {
-			// Shouldn't happen for a well-behaved helper program
-			return fmt.Errorf("error in %s, but it produced no error message", s.executable)
-		}
-		return fmt.Errorf("error in %s: %s", s.executable, errText)
+	if ctx.Err() == context.DeadlineExceeded {
+		return s.helperRunError(ctx, err)
+	} else if _, isExitErr := err.(*exec.ExitError); isExitErr {
+		return s.helperError(errBuf.Bytes())
 	} else if err != nil {
-		return fmt.Errorf("failed to run %s: %s", s.executable, err)
+		return s.helperRunError(ctx, err)
 	}
 
 	return nil
@@ -126,24 +291,59 @@ func (s *helperProgramCredentialsSource) ForgetForHost(host svchost.Hostname) er
 
 	errBuf := bytes.Buffer{}
 
-	cmd := exec.Cmd{
-		Path:   s.executable,
-		Args:   args,
-		Stdin:  nil,
-		Stderr: &errBuf,
-		Stdout: nil,
-	}
+	cmd, ctx, cancel := s.newCmd(args)
+	cmd.Stderr = &errBuf
+	defer cancel()
+
 	err := cmd.Run()
-	if _, isExitErr := err.(*exec.ExitError); isExitErr {
-		errText := errBuf.String()
-		if errText == "" {
-			// Shouldn't happen for a well-behaved helper program
-			return fmt.Errorf("error in %s, but it produced no error message", s.executable)
-		}
-		return fmt.Errorf("error in %s: %s", s.executable, errText)
+	if ctx.Err() == context.DeadlineExceeded {
+		return s.helperRunError(ctx, err)
+	} else if _, isExitErr := err.(*exec.ExitError); isExitErr {
+		return s.helperError(errBuf.Bytes())
 	} else if err != nil {
-		return fmt.Errorf("failed to run %s: %s", s.executable, err)
+		return s.helperRunError(ctx, err)
 	}
 
 	return nil
 }
+
+// helperError builds an error from a helper program's stderr output,
+// recognizing the protocol-2-and-later structured diagnostics format when
+// the negotiated protocol supports it and falling back to treating the
+// raw output as plain text otherwise. Any warning diagnostics are logged
+// rather than included in the returned error, consistent with there being
+// no channel available here to surface tfdiags.Diagnostics back to the
+// caller.
+func (s *helperProgramCredentialsSource) helperError(stderr []byte) error {
+	trimmed := bytes.TrimSpace(stderr)
+	if len(trimmed) == 0 {
+		// Shouldn't happen for a well-behaved helper program
+		return fmt.Errorf("error in %s, but it produced no error message", s.executable)
+	}
+
+	if s.negotiatedProtocol() >= helperProtocolV2 {
+		var diags []helperDiagnostic
+		if err := json.Unmarshal(trimmed, &diags); err == nil && len(diags) > 0 {
+			var errMsgs []string
+			for _, diag := range diags {
+				msg := diag.Summary
+				if diag.Detail != "" {
+					msg = fmt.Sprintf("%s: %s", msg, diag.Detail)
+				}
+				if diag.Severity == "warning" {
+					log.Printf("[WARN] %s: %s", s.executable, msg)
+					continue
+				}
+				errMsgs = append(errMsgs, msg)
+			}
+			if len(errMsgs) > 0 {
+				return fmt.Errorf("error in %s: %s", s.executable, strings.Join(errMsgs, "; "))
+			}
+			// Every diagnostic was a warning, so report a generic failure
+			// since the helper still exited unsuccessfully.
+			return fmt.Errorf("error in %s", s.executable)
+		}
+	}
+
+	return fmt.Errorf("error in %s: %s", s.executable, string(trimmed))
+}
@@ -0,0 +1,16 @@
+//go:build linux && go1.22
+
+package auth
+
+import "syscall"
+
+// helperProgramSysProcAttr sets the no-new-privileges bit on the credentials
+// helper child process, so that a setuid or setcap helper binary can't be
+// used to gain privileges beyond whatever this process already has.
+//
+// syscall.SysProcAttr only gained a NoNewPrivs field in Go 1.22; on an older
+// toolchain, helper_program_procattr_linux_legacy.go is built instead and
+// this hardening is simply unavailable.
+func helperProgramSysProcAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{NoNewPrivs: true}
+}
@@ -0,0 +1,13 @@
+//go:build linux && !go1.22
+
+package auth
+
+import "syscall"
+
+// helperProgramSysProcAttr is a no-op on this toolchain: no-new-privileges
+// support requires syscall.SysProcAttr.NoNewPrivs, which was added in Go
+// 1.22. See helper_program_procattr_linux.go for the hardened version used
+// when building with a newer Go.
+func helperProgramSysProcAttr() *syscall.SysProcAttr {
+	return nil
+}
@@ -0,0 +1,11 @@
+// +build !linux
+
+package auth
+
+import "syscall"
+
+// helperProgramSysProcAttr is a no-op on platforms where
+// syscall.SysProcAttr has no no-new-privileges equivalent.
+func helperProgramSysProcAttr() *syscall.SysProcAttr {
+	return nil
+}
@@ -3,7 +3,10 @@ package auth
 import (
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/terraform/svchost"
 )
@@ -20,7 +23,7 @@ func TestHelperProgramCredentialsSource(t *testing.T) {
 	src := HelperProgramCredentialsSource(program)
 
 	t.Run("happy path", func(t *testing.T) {
-		creds, err := src.ForHost(svchost.Hostname("example.com"))
+		creds, err := src.ForHost(svchost.Hostname("example.com"), "")
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -33,7 +36,7 @@ func TestHelperProgramCredentialsSource(t *testing.T) {
 		}
 	})
 	t.Run("no credentials", func(t *testing.T) {
-		creds, err := src.ForHost(svchost.Hostname("nothing.example.com"))
+		creds, err := src.ForHost(svchost.Hostname("nothing.example.com"), "")
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -42,7 +45,7 @@ func TestHelperProgramCredentialsSource(t *testing.T) {
 		}
 	})
 	t.Run("unsupported credentials type", func(t *testing.T) {
-		creds, err := src.ForHost(svchost.Hostname("other-cred-type.example.com"))
+		creds, err := src.ForHost(svchost.Hostname("other-cred-type.example.com"), "")
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -51,11 +54,24 @@ func TestHelperProgramCredentialsSource(t *testing.T) {
 		}
 	})
 	t.Run("lookup error", func(t *testing.T) {
-		_, err := src.ForHost(svchost.Hostname("fail.example.com"))
+		_, err := src.ForHost(svchost.Hostname("fail.example.com"), "")
 		if err == nil {
 			t.Error("completed successfully; want error")
 		}
 	})
+	t.Run("lookup error with structured diagnostics", func(t *testing.T) {
+		_, err := src.ForHost(svchost.Hostname("diag-fail.example.com"), "")
+		if err == nil {
+			t.Fatal("completed successfully; want error")
+		}
+		got := err.Error()
+		if want := "invalid credentials"; !strings.Contains(got, want) {
+			t.Errorf("error %q does not contain %q", got, want)
+		}
+		if want := "the configured token was rejected by the server"; !strings.Contains(got, want) {
+			t.Errorf("error %q does not contain %q", got, want)
+		}
+	})
 	t.Run("store happy path", func(t *testing.T) {
 		err := src.StoreForHost(svchost.Hostname("example.com"), HostCredentialsToken("example-token"))
 		if err != nil {
@@ -80,4 +96,113 @@ func TestHelperProgramCredentialsSource(t *testing.T) {
 			t.Error("completed successfully; want error")
 		}
 	})
+	t.Run("protocol negotiation", func(t *testing.T) {
+		hs := src.(*helperProgramCredentialsSource)
+		if got, want := hs.negotiatedProtocol(), helperProtocolV2; got != want {
+			t.Errorf("wrong negotiated protocol %d; want %d", got, want)
+		}
+	})
+}
+
+// TestHelperProgramCredentialsSourceNoHandshake verifies that a helper
+// program predating the "handshake" subcommand -- which will exit
+// nonzero in response to any subcommand it doesn't recognize, per the
+// original protocol -- is still usable, falling back to protocol 1.
+func TestHelperProgramCredentialsSourceNoHandshake(t *testing.T) {
+	// /bin/false doesn't speak our protocol at all, but it's a convenient
+	// stand-in for "a program that fails when asked to handshake": its
+	// nonzero exit status is indistinguishable, for negotiation purposes,
+	// from a legacy helper rejecting an unrecognized subcommand.
+	src := HelperProgramCredentialsSource("/bin/false").(*helperProgramCredentialsSource)
+	if got, want := src.negotiatedProtocol(), helperProtocolV1; got != want {
+		t.Errorf("wrong negotiated protocol %d; want %d", got, want)
+	}
+}
+
+// TestHelperProgramCredentialsSourceWithEnv verifies both that
+// HelperProgramCredentialsSourceWithEnv stores the given environment for
+// use by the child process, and that HelperProgramCredentialsSource itself
+// continues to request full inheritance (env is nil) as before.
+func TestHelperProgramCredentialsSourceWithEnv(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	program := filepath.Join(wd, "testdata/test-helper")
+
+	t.Run("inherits by default", func(t *testing.T) {
+		src := HelperProgramCredentialsSource(program).(*helperProgramCredentialsSource)
+		if src.env != nil {
+			t.Errorf("got non-nil env %#v; want nil, for full inheritance", src.env)
+		}
+	})
+
+	t.Run("uses the given env", func(t *testing.T) {
+		env := []string{"PATH=" + os.Getenv("PATH")}
+		src := HelperProgramCredentialsSourceWithEnv(program, env)
+		hs := src.(*helperProgramCredentialsSource)
+		if got, want := hs.env, env; !reflect.DeepEqual(got, want) {
+			t.Errorf("wrong stored env\ngot:  %#v\nwant: %#v", got, want)
+		}
+
+		// The helper program itself doesn't consult the environment, so a
+		// restricted (but non-nil) env should still allow it to run
+		// successfully rather than inheriting variables it wasn't given.
+		creds, err := src.ForHost(svchost.Hostname("example.com"), "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tokCreds, isTok := creds.(HostCredentialsToken); isTok {
+			if got, want := string(tokCreds), "example-token"; got != want {
+				t.Errorf("wrong token %q; want %q", got, want)
+			}
+		} else {
+			t.Errorf("wrong type of credentials %T", creds)
+		}
+	})
+}
+
+// TestHelperProgramCredentialsSourceWithOptions verifies that a hung helper
+// is killed and reported once its configured timeout elapses, and that a
+// helper finishing well within its timeout is unaffected.
+func TestHelperProgramCredentialsSourceWithOptions(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	program := filepath.Join(wd, "testdata/test-helper")
+
+	t.Run("default timeout leaves a normal helper unaffected", func(t *testing.T) {
+		src := HelperProgramCredentialsSource(program)
+		if _, err := src.ForHost(svchost.Hostname("example.com"), ""); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("a hung helper is killed and reported once its timeout elapses", func(t *testing.T) {
+		src := HelperProgramCredentialsSourceWithOptions(program, HelperProgramOptions{
+			Timeout: 200 * time.Millisecond,
+		})
+
+		start := time.Now()
+		_, err := src.ForHost(svchost.Hostname("hang.example.com"), "")
+		elapsed := time.Since(start)
+
+		if err == nil {
+			t.Fatal("completed successfully; want a timeout error")
+		}
+		if !strings.Contains(err.Error(), "did not respond within") {
+			t.Errorf("error %q does not mention the timeout", err.Error())
+		}
+		if elapsed > 5*time.Second {
+			t.Errorf("took %s to return; want well under the helper's own 10s sleep", elapsed)
+		}
+	})
+
+	t.Run("zero Timeout falls back to defaultHelperTimeout", func(t *testing.T) {
+		src := HelperProgramCredentialsSourceWithOptions(program, HelperProgramOptions{}).(*helperProgramCredentialsSource)
+		if got, want := src.timeout, defaultHelperTimeout; got != want {
+			t.Errorf("wrong timeout %s; want %s", got, want)
+		}
+	})
 }
@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform/svchost"
+)
+
+// BackgroundRefresh wraps a CredentialsSource so that, once it has been
+// asked for credentials for a particular host, it proactively asks the
+// wrapped source again shortly before those credentials are due to expire,
+// for as long as the returned stop function has not been called.
+//
+// This is intended for use during long-running operations such as "apply",
+// so that a registry token obtained at the start of the run is replaced
+// before it expires partway through, rather than causing the run to fail
+// when it is finally used again.
+//
+// Refreshing only happens for hosts whose credentials implement
+// HostCredentialsExpiring with a known expiry time; credentials with no
+// known expiry are returned as-is and are never refreshed in the
+// background.
+func BackgroundRefresh(source CredentialsSource) (CredentialsSource, func()) {
+	s := &backgroundRefreshingCredentialsSource{
+		source: source,
+		timers: make(map[cacheKey]*time.Timer),
+	}
+	return s, s.stop
+}
+
+type backgroundRefreshingCredentialsSource struct {
+	source CredentialsSource
+
+	mu      sync.Mutex
+	timers  map[cacheKey]*time.Timer
+	stopped bool
+}
+
+func (s *backgroundRefreshingCredentialsSource) ForHost(host svchost.Hostname, service string) (HostCredentials, error) {
+	creds, err := s.source.ForHost(host, service)
+	if err != nil || creds == nil {
+		return creds, err
+	}
+
+	s.scheduleRefresh(host, service, creds)
+	return creds, nil
+}
+
+// scheduleRefresh arranges for the wrapped source to be asked again for
+// credentials for host and service shortly before creds expires, if creds
+// reports a known expiry time.
+func (s *backgroundRefreshingCredentialsSource) scheduleRefresh(host svchost.Hostname, service string, creds HostCredentials) {
+	expiring, ok := creds.(HostCredentialsExpiring)
+	if !ok {
+		return
+	}
+	expiresAt, known := expiring.ExpiresAt()
+	if !known {
+		return
+	}
+
+	delay := time.Until(expiresAt.Add(-expiring.RefreshHint()))
+	if delay < 0 {
+		delay = 0
+	}
+
+	key := cacheKey{host, service}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopped {
+		return
+	}
+	if existing, scheduled := s.timers[key]; scheduled {
+		existing.Stop()
+	}
+	s.timers[key] = time.AfterFunc(delay, func() {
+		// Asking the wrapped source again both refreshes the credentials --
+		// since a source backed by a credentials helper will typically
+		// return a new token each time it's asked -- and, via ForHost
+		// above, schedules the next refresh.
+		s.ForHost(host, service)
+	})
+}
+
+// stop halts any pending background refreshes. A source cannot be restarted
+// after being stopped; callers needing further refreshes should create a
+// new one with BackgroundRefresh.
+func (s *backgroundRefreshingCredentialsSource) stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stopped = true
+	for key, t := range s.timers {
+		t.Stop()
+		delete(s.timers, key)
+	}
+}
+
+func (s *backgroundRefreshingCredentialsSource) StoreForHost(host svchost.Hostname, credentials HostCredentialsWritable) error {
+	return s.source.StoreForHost(host, credentials)
+}
+
+func (s *backgroundRefreshingCredentialsSource) ForgetForHost(host svchost.Hostname) error {
+	return s.source.ForgetForHost(host)
+}
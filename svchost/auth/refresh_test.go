@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform/svchost"
+)
+
+type countingCredentialsSource struct {
+	count int32
+}
+
+func (s *countingCredentialsSource) ForHost(host svchost.Hostname, service string) (HostCredentials, error) {
+	atomic.AddInt32(&s.count, 1)
+	return expiringHostCredentialsToken{
+		HostCredentialsToken: HostCredentialsToken("token"),
+		expiresAt:            time.Now().Add(60 * time.Millisecond),
+		refreshHint:          40 * time.Millisecond,
+	}, nil
+}
+
+func (s *countingCredentialsSource) StoreForHost(host svchost.Hostname, credentials HostCredentialsWritable) error {
+	return nil
+}
+
+func (s *countingCredentialsSource) ForgetForHost(host svchost.Hostname) error {
+	return nil
+}
+
+func TestBackgroundRefresh(t *testing.T) {
+	underlying := &countingCredentialsSource{}
+	source, stop := BackgroundRefresh(underlying)
+	defer stop()
+
+	if _, err := source.ForHost(svchost.Hostname("example.com"), ""); err != nil {
+		t.Fatal(err)
+	}
+	if got := atomic.LoadInt32(&underlying.count); got != 1 {
+		t.Fatalf("expected exactly 1 call before refresh, got %d", got)
+	}
+
+	// The scheduled refresh fires ~20ms after the first call, since the
+	// expiry is 60ms out and the refresh hint is 40ms.
+	time.Sleep(200 * time.Millisecond)
+
+	if got := atomic.LoadInt32(&underlying.count); got < 2 {
+		t.Errorf("expected at least 2 calls after background refresh, got %d", got)
+	}
+
+	stop()
+	// A refresh that was already in flight when stop() was called is allowed
+	// to complete, but stop() must prevent any further ones from being
+	// scheduled, so the count should stabilize within one more refresh
+	// interval.
+	countAfterStop := atomic.LoadInt32(&underlying.count)
+	time.Sleep(200 * time.Millisecond)
+	countSettled := atomic.LoadInt32(&underlying.count)
+	time.Sleep(200 * time.Millisecond)
+	if got := atomic.LoadInt32(&underlying.count); got != countSettled {
+		t.Errorf("refreshing continued after stop: count went from %d to %d", countSettled, got)
+	}
+	if countSettled > countAfterStop+1 {
+		t.Errorf("more than one refresh completed after stop: count went from %d to %d", countAfterStop, countSettled)
+	}
+}
@@ -10,6 +10,11 @@ import (
 // from the provided map. It returns nil if a requested hostname is not
 // present in the map.
 //
+// A credentials map may include a "services" key giving a list of service
+// identifiers (such as "modules.v1") that the credentials are scoped to; if
+// present, the credentials are returned only when ForHost is asked about one
+// of those services, or is not asking on behalf of any particular service.
+//
 // The caller should not modify the given map after passing it to this function.
 func StaticCredentialsSource(creds map[svchost.Hostname]map[string]interface{}) CredentialsSource {
 	return staticCredentialsSource(creds)
@@ -17,16 +22,20 @@ func StaticCredentialsSource(creds map[svchost.Hostname]map[string]interface{})
 
 type staticCredentialsSource map[svchost.Hostname]map[string]interface{}
 
-func (s staticCredentialsSource) ForHost(host svchost.Hostname) (HostCredentials, error) {
+func (s staticCredentialsSource) ForHost(host svchost.Hostname, service string) (HostCredentials, error) {
 	if s == nil {
 		return nil, nil
 	}
 
-	if m, exists := s[host]; exists {
-		return HostCredentialsFromMap(m), nil
+	m, exists := s[host]
+	if !exists {
+		return nil, nil
+	}
+	if service != "" && !credentialsAllowService(m, service) {
+		return nil, nil
 	}
 
-	return nil, nil
+	return HostCredentialsFromMap(m), nil
 }
 
 func (s staticCredentialsSource) StoreForHost(host svchost.Hostname, credentials HostCredentialsWritable) error {
@@ -14,7 +14,7 @@ func TestStaticCredentialsSource(t *testing.T) {
 	})
 
 	t.Run("exists", func(t *testing.T) {
-		creds, err := src.ForHost(svchost.Hostname("example.com"))
+		creds, err := src.ForHost(svchost.Hostname("example.com"), "")
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -27,7 +27,7 @@ func TestStaticCredentialsSource(t *testing.T) {
 		}
 	})
 	t.Run("does not exist", func(t *testing.T) {
-		creds, err := src.ForHost(svchost.Hostname("example.net"))
+		creds, err := src.ForHost(svchost.Hostname("example.net"), "")
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -36,3 +36,40 @@ func TestStaticCredentialsSource(t *testing.T) {
 		}
 	})
 }
+
+func TestStaticCredentialsSourceScopedToService(t *testing.T) {
+	src := StaticCredentialsSource(map[svchost.Hostname]map[string]interface{}{
+		svchost.Hostname("example.com"): map[string]interface{}{
+			"token":    "abc123",
+			"services": []string{"modules.v1"},
+		},
+	})
+
+	t.Run("matching service", func(t *testing.T) {
+		creds, err := src.ForHost(svchost.Hostname("example.com"), "modules.v1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if creds == nil {
+			t.Errorf("expected credentials for a matching service")
+		}
+	})
+	t.Run("non-matching service", func(t *testing.T) {
+		creds, err := src.ForHost(svchost.Hostname("example.com"), "providers.v1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if creds != nil {
+			t.Errorf("expected no credentials for a service outside the configured scope, got %#v", creds)
+		}
+	})
+	t.Run("unscoped request", func(t *testing.T) {
+		creds, err := src.ForHost(svchost.Hostname("example.com"), "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if creds == nil {
+			t.Errorf("expected credentials when the caller doesn't care about service scoping")
+		}
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"time"
 )
 
 // This is a simple program that implements the "helper program" protocol
@@ -13,6 +14,11 @@ import (
 func main() {
 	args := os.Args
 
+	if len(args) >= 2 && args[1] == "handshake" {
+		fmt.Print(`{"protocol_versions":[1,2]}`)
+		return
+	}
+
 	if len(args) < 3 {
 		die("not enough arguments\n")
 	}
@@ -27,6 +33,11 @@ func main() {
 			fmt.Print(`{"username":"alfred"}`) // unrecognized by main program
 		case "fail.example.com":
 			die("failing because you told me to fail\n")
+		case "diag-fail.example.com":
+			dieDiag("invalid credentials", "the configured token was rejected by the server")
+		case "hang.example.com":
+			time.Sleep(10 * time.Second)
+			fmt.Print("{}")
 		default:
 			fmt.Print("{}") // no credentials available
 		}
@@ -62,3 +73,17 @@ func die(f string, args ...interface{}) {
 	fmt.Fprintf(os.Stderr, fmt.Sprintf(f, args...))
 	os.Exit(1)
 }
+
+// dieDiag reports failure using the protocol 2 structured diagnostics
+// format, as a JSON array on stderr, instead of die's plain text.
+func dieDiag(summary, detail string) {
+	diags := []map[string]string{
+		{"severity": "error", "summary": summary, "detail": detail},
+	}
+	enc, err := json.Marshal(diags)
+	if err != nil {
+		die("%s", err)
+	}
+	os.Stderr.Write(enc)
+	os.Exit(1)
+}
@@ -2,6 +2,7 @@ package auth
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/zclconf/go-cty/cty"
 )
@@ -41,3 +42,42 @@ func (tc HostCredentialsToken) ToStore() cty.Value {
 		"token": cty.StringVal(string(tc)),
 	})
 }
+
+// defaultRefreshHint is the RefreshHint used for an expiring token when the
+// source didn't specify one of its own.
+const defaultRefreshHint = 30 * time.Second
+
+// expiringHostCredentialsToken is a HostCredentialsToken that also knows
+// when it expires, for sources such as a credentials helper that can report
+// the lifetime of the token it returned.
+type expiringHostCredentialsToken struct {
+	HostCredentialsToken
+	expiresAt   time.Time
+	refreshHint time.Duration
+}
+
+var _ HostCredentials = expiringHostCredentialsToken{}
+var _ HostCredentialsWritable = expiringHostCredentialsToken{}
+var _ HostCredentialsExpiring = expiringHostCredentialsToken{}
+
+// ExpiresAt returns the time at which the token is expected to become
+// invalid.
+func (tc expiringHostCredentialsToken) ExpiresAt() (time.Time, bool) {
+	return tc.expiresAt, true
+}
+
+// RefreshHint returns how long before ExpiresAt a caller should try to
+// obtain a replacement token.
+func (tc expiringHostCredentialsToken) RefreshHint() time.Duration {
+	return tc.refreshHint
+}
+
+// ToStore returns a credentials object like HostCredentialsToken.ToStore
+// but with an additional "expires_at" attribute so that the expiry survives
+// a round trip through persistent storage.
+func (tc expiringHostCredentialsToken) ToStore() cty.Value {
+	return cty.ObjectVal(map[string]cty.Value{
+		"token":      cty.StringVal(string(tc.HostCredentialsToken)),
+		"expires_at": cty.StringVal(tc.expiresAt.Format(time.RFC3339)),
+	})
+}
@@ -47,6 +47,11 @@ type Disco struct {
 	hostCache map[svchost.Hostname]*Host
 	credsSrc  auth.CredentialsSource
 
+	// servicePins records, for each hostname with at least one pinned
+	// service, a map from bare service name to the version pinned for it.
+	// See SetServiceVersionPins.
+	servicePins map[svchost.Hostname]map[string]string
+
 	// Transport is a custom http.RoundTripper to use.
 	Transport http.RoundTripper
 }
@@ -66,6 +71,29 @@ func NewWithCredentialsSource(credsSrc auth.CredentialsSource) *Disco {
 	}
 }
 
+// SetServiceVersionPins records, for the given hostname, a map from bare
+// service name (e.g. "providers") to the version Terraform should accept
+// for it (e.g. "v1"), overriding whatever version the host's discovery
+// document actually advertises the service under.
+//
+// This exists for a self-hosted registry that's upgraded to a newer,
+// wire-compatible service version but whose discovery document no longer
+// lists the older version id that this Terraform release asks for; rather
+// than wait for every client to add support for the new id, an operator
+// can pin the old one back on until they upgrade.
+//
+// Pins set after the first Discover call for the given hostname, or after
+// ForceHostServices for it, are also applied to the already-cached Host.
+func (d *Disco) SetServiceVersionPins(hostname svchost.Hostname, pins map[string]string) {
+	if d.servicePins == nil {
+		d.servicePins = make(map[svchost.Hostname]map[string]string)
+	}
+	d.servicePins[hostname] = pins
+	if host, cached := d.hostCache[hostname]; cached {
+		host.servicePins = pins
+	}
+}
+
 // SetCredentialsSource provides a credentials source that will be used to
 // add credentials to outgoing discovery requests, where available.
 //
@@ -90,10 +118,18 @@ func (d *Disco) CredentialsSource() auth.CredentialsSource {
 // CredentialsForHost returns a non-nil HostCredentials if the embedded source has
 // credentials available for the host, and a nil HostCredentials if it does not.
 func (d *Disco) CredentialsForHost(hostname svchost.Hostname) (auth.HostCredentials, error) {
+	return d.CredentialsForHostService(hostname, "")
+}
+
+// CredentialsForHostService is like CredentialsForHost but scopes the
+// request to a particular service on the host, such as "modules.v1", so
+// that a credentials source which scopes its credentials to particular
+// services can decline to return them for others.
+func (d *Disco) CredentialsForHostService(hostname svchost.Hostname, service string) (auth.HostCredentials, error) {
 	if d.credsSrc == nil {
 		return nil, nil
 	}
-	return d.credsSrc.ForHost(hostname)
+	return d.credsSrc.ForHost(hostname, service)
 }
 
 // ForceHostServices provides a pre-defined set of services for a given
@@ -117,9 +153,10 @@ func (d *Disco) ForceHostServices(hostname svchost.Hostname, services map[string
 			Host:   string(hostname),
 			Path:   discoPath,
 		},
-		hostname:  hostname.ForDisplay(),
-		services:  services,
-		transport: d.Transport,
+		hostname:    hostname.ForDisplay(),
+		services:    services,
+		transport:   d.Transport,
+		servicePins: d.servicePins[hostname],
 	}
 }
 
@@ -207,9 +244,10 @@ func (d *Disco) discover(hostname svchost.Hostname) (*Host, error) {
 	host := &Host{
 		// Use the discovery URL from resp.Request in
 		// case the client followed any redirects.
-		discoURL:  resp.Request.URL,
-		hostname:  hostname.ForDisplay(),
-		transport: d.Transport,
+		discoURL:    resp.Request.URL,
+		hostname:    hostname.ForDisplay(),
+		transport:   d.Transport,
+		servicePins: d.servicePins[hostname],
 	}
 
 	// Return the host without any services.
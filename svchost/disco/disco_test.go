@@ -176,6 +176,35 @@ func TestDiscover(t *testing.T) {
 			}
 		}
 	})
+	t.Run("forced services override, host with a port", func(t *testing.T) {
+		// Self-hosted registries commonly run on a non-default port, so a
+		// relative service URL must still resolve against that port rather
+		// than silently falling back to the default HTTPS port.
+		forced := map[string]interface{}{
+			"thingy.v1": "/foo",
+		}
+
+		d := New()
+		d.ForceHostServices(svchost.Hostname("example.com:8443"), forced)
+
+		host, err := svchost.ForComparison("example.com:8443")
+		if err != nil {
+			t.Fatalf("test server hostname is invalid: %s", err)
+		}
+
+		discovered, err := d.Discover(host)
+		if err != nil {
+			t.Fatalf("unexpected discovery error: %s", err)
+		}
+
+		gotURL, err := discovered.ServiceURL("thingy.v1")
+		if err != nil {
+			t.Fatalf("unexpected service URL error: %s", err)
+		}
+		if got, want := gotURL.String(), "https://example.com:8443/foo"; got != want {
+			t.Fatalf("wrong result %q; want %q", got, want)
+		}
+	})
 	t.Run("not JSON", func(t *testing.T) {
 		portStr, close := testServer(func(w http.ResponseWriter, r *http.Request) {
 			resp := []byte(`{"thingy.v1": "http://example.com/foo"}`)
@@ -327,6 +356,60 @@ func TestDiscover(t *testing.T) {
 	})
 }
 
+func TestSetServiceVersionPins(t *testing.T) {
+	t.Run("set before Discover", func(t *testing.T) {
+		portStr, close := testServer(func(w http.ResponseWriter, r *http.Request) {
+			resp := []byte(`{"thingy.v2": "http://example.com/foo"}`)
+			w.Header().Add("Content-Type", "application/json")
+			w.Header().Add("Content-Length", strconv.Itoa(len(resp)))
+			w.Write(resp)
+		})
+		defer close()
+
+		givenHost := "localhost" + portStr
+		host, err := svchost.ForComparison(givenHost)
+		if err != nil {
+			t.Fatalf("test server hostname is invalid: %s", err)
+		}
+
+		d := New()
+		d.SetServiceVersionPins(host, map[string]string{"thingy": "v1"})
+		discovered, err := d.Discover(host)
+		if err != nil {
+			t.Fatalf("unexpected discovery error: %s", err)
+		}
+
+		gotURL, err := discovered.ServiceURL("thingy.v1")
+		if err != nil {
+			t.Fatalf("unexpected service URL error: %s", err)
+		}
+		if got, want := gotURL.String(), "http://example.com/foo"; got != want {
+			t.Fatalf("wrong result %q; want %q", got, want)
+		}
+	})
+
+	t.Run("set on an already-cached Host", func(t *testing.T) {
+		host, err := svchost.ForComparison("example.com")
+		if err != nil {
+			t.Fatalf("test hostname is invalid: %s", err)
+		}
+
+		d := New()
+		d.ForceHostServices(host, map[string]interface{}{
+			"thingy.v2": "http://example.com/foo",
+		})
+		d.SetServiceVersionPins(host, map[string]string{"thingy": "v1"})
+
+		discovered, err := d.Discover(host)
+		if err != nil {
+			t.Fatalf("unexpected discovery error: %s", err)
+		}
+		if _, err := discovered.ServiceURL("thingy.v1"); err != nil {
+			t.Fatalf("unexpected service URL error: %s", err)
+		}
+	})
+}
+
 func testServer(h func(w http.ResponseWriter, r *http.Request)) (portStr string, close func()) {
 	server := httptest.NewTLSServer(http.HandlerFunc(
 		func(w http.ResponseWriter, r *http.Request) {
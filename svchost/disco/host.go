@@ -23,6 +23,16 @@ type Host struct {
 	hostname  string
 	services  map[string]interface{}
 	transport http.RoundTripper
+
+	// servicePins maps a bare service name (e.g. "providers") to the
+	// version Terraform should accept for it (e.g. "v1"), from a "host"
+	// block's pin_service_version setting. It lets a lookup for the
+	// pinned id succeed by falling back to whatever version the
+	// discovery document actually advertises, for a self-hosted registry
+	// that's moved to a newer protocol version Terraform doesn't yet ask
+	// for, as long as the newer version is wire-compatible with the one
+	// pinned.
+	servicePins map[string]string
 }
 
 // Constraints represents the version constraints of a service.
@@ -93,7 +103,7 @@ func (h *Host) ServiceURL(id string) (*url.URL, error) {
 		return nil, &ErrServiceNotProvided{service: svc}
 	}
 
-	urlStr, ok := h.services[id].(string)
+	raw, ok := h.resolveServiceID(id, svc, ver)
 	if !ok {
 		// See if we have a matching service as that would indicate
 		// the service is supported, but not the requested version.
@@ -111,6 +121,15 @@ func (h *Host) ServiceURL(id string) (*url.URL, error) {
 		return nil, &ErrServiceNotProvided{hostname: h.hostname, service: svc}
 	}
 
+	urlStr, ok := raw.(string)
+	if !ok {
+		return nil, &ErrVersionNotSupported{
+			hostname: h.hostname,
+			service:  svc,
+			version:  ver.Original(),
+		}
+	}
+
 	u, err := h.parseURL(urlStr)
 	if err != nil {
 		return nil, fmt.Errorf("Failed to parse service URL: %v", err)
@@ -136,7 +155,8 @@ func (h *Host) ServiceOAuthClient(id string) (*OAuthClient, error) {
 		return nil, &ErrServiceNotProvided{service: svc}
 	}
 
-	if _, ok := h.services[id]; !ok {
+	rawEntry, ok := h.resolveServiceID(id, svc, ver)
+	if !ok {
 		// See if we have a matching service as that would indicate
 		// the service is supported, but not the requested version.
 		for serviceID := range h.services {
@@ -154,7 +174,7 @@ func (h *Host) ServiceOAuthClient(id string) (*OAuthClient, error) {
 	}
 
 	var raw map[string]interface{}
-	switch v := h.services[id].(type) {
+	switch v := rawEntry.(type) {
 	case map[string]interface{}:
 		raw = v // Great!
 	case []map[string]interface{}:
@@ -162,7 +182,7 @@ func (h *Host) ServiceOAuthClient(id string) (*OAuthClient, error) {
 		raw = v[0]
 	default:
 		// Debug message because raw Go types don't belong in our UI.
-		log.Printf("[DEBUG] The definition for %s has Go type %T", id, h.services[id])
+		log.Printf("[DEBUG] The definition for %s has Go type %T", id, rawEntry)
 		return nil, fmt.Errorf("Service %s must be declared with an object value in the service discovery document", id)
 	}
 
@@ -399,6 +419,32 @@ func (h *Host) VersionConstraints(id, product string) (*Constraints, error) {
 	return result, nil
 }
 
+// resolveServiceID returns the raw discovery document entry for the given
+// service id, trying the id directly first and, if that's absent, falling
+// back to whatever version of svc the discovery document actually
+// advertises, but only when servicePins names ver as the pinned version
+// for svc. This is what lets pin_service_version work even though the
+// document itself was never rewritten to use the pinned id.
+func (h *Host) resolveServiceID(id, svc string, ver *version.Version) (interface{}, bool) {
+	if raw, ok := h.services[id]; ok {
+		return raw, true
+	}
+
+	if h.servicePins[svc] != ver.Original() {
+		return nil, false
+	}
+
+	for otherID, raw := range h.services {
+		otherSvc, _, err := parseServiceID(otherID)
+		if err != nil || otherSvc != svc {
+			continue
+		}
+		return raw, true
+	}
+
+	return nil, false
+}
+
 func parseServiceID(id string) (string, *version.Version, error) {
 	parts := strings.SplitN(id, ".", 2)
 	if len(parts) != 2 {
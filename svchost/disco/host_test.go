@@ -71,6 +71,46 @@ func TestHostServiceURL(t *testing.T) {
 	}
 }
 
+func TestHostServicePinnedVersion(t *testing.T) {
+	baseURL, _ := url.Parse("https://example.com/disco/foo.json")
+	host := Host{
+		discoURL: baseURL,
+		hostname: "test-server",
+		services: map[string]interface{}{
+			"modules.v2": "https://example.net/modules-v2/",
+		},
+		servicePins: map[string]string{
+			"modules": "v1",
+		},
+	}
+
+	t.Run("pinned version resolves to the advertised version", func(t *testing.T) {
+		got, err := host.ServiceURL("modules.v1")
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if want := "https://example.net/modules-v2/"; got.String() != want {
+			t.Errorf("wrong result\ngot:  %s\nwant: %s", got.String(), want)
+		}
+	})
+
+	t.Run("an unpinned mismatched version is still unsupported", func(t *testing.T) {
+		if _, err := host.ServiceURL("modules.v3"); err == nil {
+			t.Fatal("expected an error")
+		} else if _, ok := err.(*ErrVersionNotSupported); !ok {
+			t.Errorf("wrong error type %T; want *ErrVersionNotSupported", err)
+		}
+	})
+
+	t.Run("a service with no pin at all is unaffected", func(t *testing.T) {
+		if _, err := host.ServiceURL("providers.v1"); err == nil {
+			t.Fatal("expected an error")
+		} else if _, ok := err.(*ErrServiceNotProvided); !ok {
+			t.Errorf("wrong error type %T; want *ErrServiceNotProvided", err)
+		}
+	})
+}
+
 func TestHostServiceOAuthClient(t *testing.T) {
 	baseURL, _ := url.Parse("https://example.com/disco/foo.json")
 	host := Host{
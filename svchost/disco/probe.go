@@ -0,0 +1,144 @@
+package disco
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+)
+
+// probeTimeout bounds how long Probe will wait for a single service URL to
+// respond, so that one unreachable host can't stall a pre-flight check
+// indefinitely.
+const probeTimeout = 10 * time.Second
+
+// ProbeResult describes the outcome of checking a single service URL
+// declared in a Host's discovery document, as part of Probe.
+type ProbeResult struct {
+	// Service is the service identifier the probed URL was declared under,
+	// such as "modules.v1".
+	Service string
+
+	// URL is the absolute URL that was probed, the same one ServiceURL
+	// would return for Service. It's nil if Err was produced by ServiceURL
+	// itself, before there was a URL to probe.
+	URL *url.URL
+
+	// Reachable is true if an HTTP request to URL received any response at
+	// all, regardless of status code: Probe checks basic network and
+	// TLS-handshake reachability, not whether the service considers the
+	// request valid.
+	Reachable bool
+
+	// TLSValid is true if URL uses HTTPS and the server presented a
+	// certificate the client trusts. It's always false for an "http" URL,
+	// since there's no certificate to validate, and it's meaningless when
+	// Err is set, since a failed TLS handshake is reported there instead.
+	TLSValid bool
+
+	// Err is set if the request could not be completed at all, such as a
+	// DNS failure, a connection refusal, or a TLS handshake failure. It's
+	// nil for any response that reached the HTTP layer, even one with an
+	// error status code.
+	Err error
+}
+
+// ProbeReport is the result of a call to Probe: one ProbeResult per service
+// URL declared in the host's discovery document.
+type ProbeReport struct {
+	Results []ProbeResult
+}
+
+// OK reports whether every probed service was reachable with, for an HTTPS
+// URL, a valid TLS certificate.
+func (r *ProbeReport) OK() bool {
+	for _, result := range r.Results {
+		if result.Err != nil || !result.Reachable {
+			return false
+		}
+		if result.URL != nil && result.URL.Scheme == "https" && !result.TLSValid {
+			return false
+		}
+	}
+	return true
+}
+
+// Probe checks each service URL declared in the host's discovery document
+// for basic reachability and, for HTTPS URLs, TLS certificate validity,
+// returning a structured report. This is intended to power pre-flight
+// health checks -- such as a "doctor"-style command, or a check run in CI
+// before a long plan or apply starts -- that want to fail fast with a clear
+// diagnosis rather than midway through the operation with a raw network
+// error.
+//
+// Probe makes one HTTP HEAD request per declared service URL. A service
+// that responds to HEAD in an unexpected way, including with an error
+// status code, is still considered reachable: Probe only cares whether the
+// network path and any TLS handshake succeeded, not whether the service
+// accepts the specific request.
+//
+// Service definitions that aren't simple URL strings, such as an OAuth
+// client block, are skipped: Probe only checks the URLs that ServiceURL
+// itself would return.
+//
+// Probe respects ctx for cancellation, but each individual request is also
+// bounded by its own fixed timeout so that a single unreachable host cannot
+// stall the whole report.
+func (h *Host) Probe(ctx context.Context) *ProbeReport {
+	report := &ProbeReport{}
+	if h == nil || h.services == nil {
+		return report
+	}
+
+	var ids []string
+	for id, raw := range h.services {
+		if _, ok := raw.(string); ok {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		u, err := h.ServiceURL(id)
+		if err != nil {
+			// This would mean the discovery document changed out from
+			// under us since we listed h.services, which should be rare,
+			// but we'll still report it rather than silently dropping it.
+			report.Results = append(report.Results, ProbeResult{Service: id, Err: err})
+			continue
+		}
+		report.Results = append(report.Results, h.probeOne(ctx, id, u))
+	}
+	return report
+}
+
+// probeOne performs the single HTTP request behind one ProbeResult.
+func (h *Host) probeOne(ctx context.Context, service string, u *url.URL) ProbeResult {
+	result := ProbeResult{Service: service, URL: u}
+
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "HEAD", u.String(), nil)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	client := &http.Client{Transport: h.transport}
+	resp, err := client.Do(req)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	defer resp.Body.Close()
+
+	result.Reachable = true
+	// Reaching here with no error already implies a trusted certificate
+	// chain for an HTTPS URL, since Go's HTTP client fails the request
+	// outright on a certificate validation failure.
+	result.TLSValid = u.Scheme == "https" && resp.TLS != nil
+
+	return result
+}
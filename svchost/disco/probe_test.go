@@ -0,0 +1,118 @@
+package disco
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestHostProbe(t *testing.T) {
+	baseURL, _ := url.Parse("https://example.com/disco/foo.json")
+
+	t.Run("reachable https service", func(t *testing.T) {
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(200)
+		}))
+		defer server.Close()
+
+		host := Host{
+			discoURL:  baseURL,
+			hostname:  "test-server",
+			transport: httpTransport,
+			services: map[string]interface{}{
+				"thingy.v1": server.URL,
+			},
+		}
+
+		report := host.Probe(context.Background())
+		if len(report.Results) != 1 {
+			t.Fatalf("got %d results; want 1", len(report.Results))
+		}
+		result := report.Results[0]
+		if result.Service != "thingy.v1" {
+			t.Errorf("wrong service %q", result.Service)
+		}
+		if !result.Reachable {
+			t.Errorf("got unreachable; want reachable")
+		}
+		if !result.TLSValid {
+			t.Errorf("got invalid TLS; want valid")
+		}
+		if result.Err != nil {
+			t.Errorf("unexpected error: %s", result.Err)
+		}
+		if !report.OK() {
+			t.Errorf("report.OK() returned false; want true")
+		}
+	})
+
+	t.Run("unreachable service", func(t *testing.T) {
+		host := Host{
+			discoURL:  baseURL,
+			hostname:  "test-server",
+			transport: httpTransport,
+			services: map[string]interface{}{
+				// Port 0 on localhost is never listening, so this connection
+				// should fail immediately rather than actually reaching the
+				// network.
+				"thingy.v1": "https://localhost:0/",
+			},
+		}
+
+		report := host.Probe(context.Background())
+		if len(report.Results) != 1 {
+			t.Fatalf("got %d results; want 1", len(report.Results))
+		}
+		result := report.Results[0]
+		if result.Reachable {
+			t.Errorf("got reachable; want unreachable")
+		}
+		if result.Err == nil {
+			t.Errorf("got no error; want one")
+		}
+		if report.OK() {
+			t.Errorf("report.OK() returned true; want false")
+		}
+	})
+
+	t.Run("skips non-URL service definitions", func(t *testing.T) {
+		host := Host{
+			discoURL:  baseURL,
+			hostname:  "test-server",
+			transport: httpTransport,
+			services: map[string]interface{}{
+				"login.v1": map[string]interface{}{
+					"client": "abc123",
+					"authz":  "https://example.com/authz",
+					"token":  "https://example.com/token",
+				},
+			},
+		}
+
+		report := host.Probe(context.Background())
+		if len(report.Results) != 0 {
+			t.Fatalf("got %d results; want 0", len(report.Results))
+		}
+	})
+
+	t.Run("no services", func(t *testing.T) {
+		host := Host{discoURL: baseURL, hostname: "test-server"}
+		report := host.Probe(context.Background())
+		if len(report.Results) != 0 {
+			t.Fatalf("got %d results; want 0", len(report.Results))
+		}
+		if !report.OK() {
+			t.Errorf("report.OK() returned false; want true for an empty report")
+		}
+	})
+
+	t.Run("nil host", func(t *testing.T) {
+		var host *Host
+		report := host.Probe(context.Background())
+		if len(report.Results) != 0 {
+			t.Fatalf("got %d results; want 0", len(report.Results))
+		}
+	})
+}
@@ -0,0 +1,66 @@
+package disco
+
+import (
+	"net/http"
+	"sync"
+
+	"golang.org/x/time/rate"
+)
+
+// SetRateLimit configures the receiver to limit outgoing HTTP requests to
+// at most requestsPerSecond for any single host, wrapping whatever
+// Transport is already configured. This is primarily useful to avoid
+// tripping a self-hosted registry's rate-limiting or WAF rules during a
+// large "terraform init" run.
+//
+// A requestsPerSecond of zero or less disables rate limiting, which is
+// the default.
+func (d *Disco) SetRateLimit(requestsPerSecond float64) {
+	if requestsPerSecond <= 0 {
+		return
+	}
+	inner := d.Transport
+	if inner == nil {
+		inner = http.DefaultTransport
+	}
+	d.Transport = &rateLimitRoundTripper{
+		inner:     inner,
+		perSecond: requestsPerSecond,
+		limiters:  make(map[string]*rate.Limiter),
+	}
+}
+
+// rateLimitRoundTripper wraps another http.RoundTripper to cap the number
+// of requests per second sent to any single host, giving each distinct
+// request host its own token bucket.
+type rateLimitRoundTripper struct {
+	inner     http.RoundTripper
+	perSecond float64
+
+	mu       sync.Mutex
+	limiters map[string]*rate.Limiter
+}
+
+func (rt *rateLimitRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	limiter := rt.limiterFor(req.URL.Host)
+	if err := limiter.Wait(req.Context()); err != nil {
+		return nil, err
+	}
+	return rt.inner.RoundTrip(req)
+}
+
+func (rt *rateLimitRoundTripper) limiterFor(host string) *rate.Limiter {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	limiter, ok := rt.limiters[host]
+	if !ok {
+		burst := int(rt.perSecond)
+		if burst < 1 {
+			burst = 1
+		}
+		limiter = rate.NewLimiter(rate.Limit(rt.perSecond), burst)
+		rt.limiters[host] = limiter
+	}
+	return limiter
+}
@@ -0,0 +1,58 @@
+package disco
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSetRateLimit(t *testing.T) {
+	var served int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		served++
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	d := New()
+	d.SetRateLimit(2) // 2 requests/second, burst of 2
+
+	client := &http.Client{Transport: d.Transport}
+
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		req, err := http.NewRequest("GET", server.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	elapsed := time.Since(start)
+
+	if served != 4 {
+		t.Fatalf("wrong number of requests served: got %d, want 4", served)
+	}
+	// The first two requests consume the burst immediately, so the
+	// remaining two must wait for the limiter to refill at 2/sec, meaning
+	// the whole batch of four can't complete in under half a second.
+	if elapsed < 500*time.Millisecond {
+		t.Fatalf("requests completed too quickly for the configured rate limit: %s", elapsed)
+	}
+}
+
+func TestSetRateLimit_disabledByDefault(t *testing.T) {
+	d := New()
+	if _, ok := d.Transport.(*rateLimitRoundTripper); ok {
+		t.Fatal("rate limiting should not be enabled by default")
+	}
+
+	d.SetRateLimit(0)
+	if _, ok := d.Transport.(*rateLimitRoundTripper); ok {
+		t.Fatal("SetRateLimit(0) should not enable rate limiting")
+	}
+}
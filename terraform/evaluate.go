@@ -74,6 +74,20 @@ func (e *Evaluator) Scope(data lang.Data, self addrs.Referenceable) *lang.Scope
 		SelfAddr: self,
 		PureOnly: e.Operation != walkApply && e.Operation != walkDestroy,
 		BaseDir:  ".", // Always current working directory for now.
+
+		// EnableEnvInterpolation is a whole-configuration setting declared
+		// in the root module, in the same way as the backend configuration,
+		// so it applies equally regardless of which module is currently
+		// being evaluated.
+		EnableEnvInterpolation: e.Config != nil && e.Config.Module.EnableEnvInterpolation,
+
+		// EnableExternalFunction is a whole-configuration setting, for the
+		// same reason as EnableEnvInterpolation above. ExternalPrograms
+		// itself is left unset here: it's sourced from the CLI
+		// configuration's "external_programs" allowlist, which isn't
+		// available to the core Evaluator, so whatever constructs a Scope
+		// for an actual CLI invocation is responsible for setting it.
+		EnableExternalFunction: e.Config != nil && e.Config.Module.EnableExternalFunction,
 	}
 }
 
@@ -1,6 +1,8 @@
 package terraform
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/davecgh/go-spew/spew"
@@ -34,6 +36,15 @@ func TestEvaluatorGetTerraformAttr(t *testing.T) {
 			t.Errorf("wrong result %q; want %q", got, want)
 		}
 	})
+
+	t.Run("unsupported attribute", func(t *testing.T) {
+		_, diags := scope.Data.GetTerraformAttr(addrs.TerraformAttr{
+			Name: "bloop",
+		}, tfdiags.SourceRange{})
+		if !diags.HasErrors() {
+			t.Fatal("expected an error for an unsupported attribute")
+		}
+	})
 }
 
 func TestEvaluatorGetPathAttr(t *testing.T) {
@@ -77,4 +88,30 @@ func TestEvaluatorGetPathAttr(t *testing.T) {
 			t.Errorf("wrong result %#v; want %#v", got, want)
 		}
 	})
+
+	t.Run("cwd", func(t *testing.T) {
+		wd, err := os.Getwd()
+		if err != nil {
+			t.Fatalf("failed to get working directory: %s", err)
+		}
+		want := cty.StringVal(filepath.ToSlash(wd))
+		got, diags := scope.Data.GetPathAttr(addrs.PathAttr{
+			Name: "cwd",
+		}, tfdiags.SourceRange{})
+		if len(diags) != 0 {
+			t.Errorf("unexpected diagnostics %s", spew.Sdump(diags))
+		}
+		if !got.RawEquals(want) {
+			t.Errorf("wrong result %#v; want %#v", got, want)
+		}
+	})
+
+	t.Run("unsupported attribute", func(t *testing.T) {
+		_, diags := scope.Data.GetPathAttr(addrs.PathAttr{
+			Name: "bloop",
+		}, tfdiags.SourceRange{})
+		if !diags.HasErrors() {
+			t.Fatal("expected an error for an unsupported attribute")
+		}
+	})
 }
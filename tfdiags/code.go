@@ -0,0 +1,37 @@
+package tfdiags
+
+// Coded is implemented by diagnostics that carry a stable, machine-readable
+// code (such as "CLICONFIG_W001") in addition to their human-oriented
+// summary and detail, so that a diagnostic can be identified reliably even
+// if its text changes between releases.
+//
+// Not all diagnostics have a code. Use Code, rather than a type assertion,
+// to safely retrieve one.
+type Coded interface {
+	Diagnostic
+	Code() string
+}
+
+// Code returns the code associated with d, or an empty string if d does
+// not implement Coded.
+func Code(d Diagnostic) string {
+	if coded, ok := d.(Coded); ok {
+		return coded.Code()
+	}
+	return ""
+}
+
+// WithCode returns a diagnostic equivalent to d except that it also carries
+// the given code, for later identification by Code or FilterDiagnostics.
+func WithCode(d Diagnostic, code string) Diagnostic {
+	return codedDiagnostic{Diagnostic: d, code: code}
+}
+
+type codedDiagnostic struct {
+	Diagnostic
+	code string
+}
+
+func (d codedDiagnostic) Code() string {
+	return d.code
+}
@@ -0,0 +1,92 @@
+package tfdiags
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Consolidate groups together any diagnostics in diags that share the same
+// severity, summary and detail but have different source locations -- a
+// common situation when, for example, every file in a module repeats the
+// same deprecated construct -- into a single diagnostic that reports how
+// many times it occurred and where.
+//
+// Diagnostics with no source location, and diagnostics that turn out not to
+// share a group with any other diagnostic, are returned unchanged. The
+// relative order of the first occurrence of each group is preserved.
+func Consolidate(diags Diagnostics) Diagnostics {
+	type key struct {
+		severity Severity
+		summary  string
+		detail   string
+	}
+
+	groups := make(map[key]*consolidatedDiagnostic)
+	result := make(Diagnostics, 0, len(diags))
+
+	for _, diag := range diags {
+		source := diag.Source()
+		if source.Subject == nil {
+			// Nothing to consolidate without a location to compare.
+			result = append(result, diag)
+			continue
+		}
+
+		desc := diag.Description()
+		k := key{diag.Severity(), desc.Summary, desc.Detail}
+
+		if g, exists := groups[k]; exists {
+			g.locations = append(g.locations, *source.Subject)
+			continue
+		}
+
+		g := &consolidatedDiagnostic{
+			Diagnostic: diag,
+			locations:  []SourceRange{*source.Subject},
+		}
+		groups[k] = g
+		// Appending the group itself (rather than a copy) here means that
+		// later occurrences found above can still update its locations in
+		// place, since result holds the same pointer.
+		result = append(result, g)
+	}
+
+	// A group that only ever saw one occurrence is returned as the original
+	// diagnostic rather than its wrapper.
+	for i, diag := range result {
+		if g, ok := diag.(*consolidatedDiagnostic); ok && len(g.locations) == 1 {
+			result[i] = g.Diagnostic
+		}
+	}
+
+	return result
+}
+
+// consolidatedDiagnostic wraps another diagnostic to report, once more than
+// one occurrence has been recorded, how many times it occurred and at what
+// locations, while otherwise behaving just like the wrapped diagnostic.
+type consolidatedDiagnostic struct {
+	Diagnostic
+	locations []SourceRange
+}
+
+func (d *consolidatedDiagnostic) Description() Description {
+	desc := d.Diagnostic.Description()
+	if len(d.locations) <= 1 {
+		return desc
+	}
+
+	lines := make([]string, len(d.locations))
+	for i, loc := range d.locations {
+		lines[i] = fmt.Sprintf("  - %s", loc.StartString())
+	}
+
+	if desc.Detail != "" {
+		desc.Detail += "\n\n"
+	}
+	desc.Detail += fmt.Sprintf(
+		"This was reported %d times, at the following locations:\n%s",
+		len(d.locations), strings.Join(lines, "\n"),
+	)
+	return desc
+}
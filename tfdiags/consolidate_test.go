@@ -0,0 +1,53 @@
+package tfdiags
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/hcl2/hcl"
+)
+
+func TestConsolidate(t *testing.T) {
+	mkDiag := func(summary, filename string) *hcl.Diagnostic {
+		return &hcl.Diagnostic{
+			Severity: hcl.DiagWarning,
+			Summary:  summary,
+			Detail:   "this is deprecated",
+			Subject: &hcl.Range{
+				Filename: filename,
+				Start:    hcl.Pos{Line: 1, Column: 1},
+				End:      hcl.Pos{Line: 1, Column: 2},
+			},
+		}
+	}
+
+	var diags Diagnostics
+	diags = diags.Append(mkDiag("deprecated construct", "a.tf"))
+	diags = diags.Append(mkDiag("deprecated construct", "b.tf"))
+	diags = diags.Append(mkDiag("deprecated construct", "c.tf"))
+	diags = diags.Append(mkDiag("unrelated warning", "a.tf"))
+	diags = diags.Append(SimpleWarning("no source location"))
+
+	got := Consolidate(diags)
+	if len(got) != 3 {
+		t.Fatalf("wrong number of diagnostics: got %d, want 3\n%s", len(got), got.Err())
+	}
+
+	detail := got[0].Description().Detail
+	if !strings.Contains(detail, "reported 3 times") {
+		t.Errorf("expected consolidated detail to mention 3 occurrences, got: %s", detail)
+	}
+	for _, filename := range []string{"a.tf", "b.tf", "c.tf"} {
+		if !strings.Contains(detail, filename) {
+			t.Errorf("expected consolidated detail to mention %s, got: %s", filename, detail)
+		}
+	}
+
+	if strings.Contains(got[1].Description().Detail, "reported") {
+		t.Errorf("unrelated warning should not have been consolidated: %s", got[1].Description().Detail)
+	}
+
+	if got[2].Description().Summary != "no source location" {
+		t.Errorf("expected the sourceless diagnostic to pass through unchanged, got: %#v", got[2].Description())
+	}
+}
@@ -0,0 +1,49 @@
+package tfdiags
+
+// FilterDiagnostics returns a copy of diags with warnings whose code appears
+// in suppress removed entirely, and warnings whose code appears in
+// promoteToError re-reported with Error severity instead of Warning.
+//
+// This allows an operator to centrally silence or escalate known, coded
+// diagnostics -- such as a warning that's noisy in a particular
+// environment -- without the part of Terraform that originally produced
+// the diagnostic needing to know about that decision. Diagnostics with no
+// code, and diagnostics that are already errors, are never affected.
+func FilterDiagnostics(diags Diagnostics, suppress []string, promoteToError []string) Diagnostics {
+	if len(suppress) == 0 && len(promoteToError) == 0 {
+		return diags
+	}
+
+	suppressSet := make(map[string]bool, len(suppress))
+	for _, code := range suppress {
+		suppressSet[code] = true
+	}
+	promoteSet := make(map[string]bool, len(promoteToError))
+	for _, code := range promoteToError {
+		promoteSet[code] = true
+	}
+
+	var result Diagnostics
+	for _, diag := range diags {
+		if diag.Severity() == Warning {
+			code := Code(diag)
+			if code != "" && suppressSet[code] {
+				continue
+			}
+			if code != "" && promoteSet[code] {
+				diag = severityOverride{Diagnostic: diag, severity: Error}
+			}
+		}
+		result = append(result, diag)
+	}
+	return result
+}
+
+type severityOverride struct {
+	Diagnostic
+	severity Severity
+}
+
+func (d severityOverride) Severity() Severity {
+	return d.severity
+}
@@ -0,0 +1,35 @@
+package tfdiags
+
+import (
+	"testing"
+)
+
+func TestFilterDiagnostics(t *testing.T) {
+	var diags Diagnostics
+	diags = diags.Append(WithCode(SimpleWarning("noisy warning"), "TEST_W001"))
+	diags = diags.Append(WithCode(SimpleWarning("actionable warning"), "TEST_W002"))
+	diags = diags.Append(SimpleWarning("uncoded warning"))
+	diags = diags.Append(assertErr("a real error"))
+
+	got := FilterDiagnostics(diags, []string{"TEST_W001"}, []string{"TEST_W002"})
+
+	if len(got) != 3 {
+		t.Fatalf("wrong number of diagnostics: got %d, want 3\n%s", len(got), got.Err())
+	}
+
+	if got[0].Severity() != Error {
+		t.Errorf("expected TEST_W002 to be promoted to an error, got severity %s", got[0].Severity())
+	}
+	if got[1].Severity() != Warning {
+		t.Errorf("expected the uncoded warning to remain a warning, got severity %s", got[1].Severity())
+	}
+	if got[2].Severity() != Error {
+		t.Errorf("expected the unrelated error to remain an error, got severity %s", got[2].Severity())
+	}
+}
+
+type assertErr string
+
+func (e assertErr) Error() string {
+	return string(e)
+}
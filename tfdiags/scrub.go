@@ -0,0 +1,53 @@
+package tfdiags
+
+import "strings"
+
+// scrubbedSecretPlaceholder is substituted for each occurrence of a known
+// secret found by ScrubSecrets.
+const scrubbedSecretPlaceholder = "(sensitive value)"
+
+// ScrubSecrets returns a copy of diags with every occurrence of any of the
+// given knownSecrets, in either the summary or detail of each diagnostic's
+// description, replaced with a placeholder.
+//
+// This exists because some diagnostics embed verbatim file contents or
+// subprocess output -- for example, a malformed state file or the error
+// output of a credentials helper -- which may happen to contain a secret
+// that the caller already knows about (such as a configured token) even
+// though whatever produced the diagnostic had no way to recognize it as
+// sensitive. It is not a substitute for marking values sensitive nearer
+// their source: it can only redact secrets the caller is able to name in
+// knownSecrets, and empty strings in knownSecrets are ignored so that an
+// unset credential can't cause every diagnostic to be scrubbed down to
+// nothing.
+func ScrubSecrets(diags Diagnostics, knownSecrets []string) Diagnostics {
+	var secrets []string
+	for _, s := range knownSecrets {
+		if s != "" {
+			secrets = append(secrets, s)
+		}
+	}
+	if len(secrets) == 0 {
+		return diags
+	}
+
+	var result Diagnostics
+	for _, diag := range diags {
+		result = append(result, scrubbedDiagnostic{Diagnostic: diag, knownSecrets: secrets})
+	}
+	return result
+}
+
+type scrubbedDiagnostic struct {
+	Diagnostic
+	knownSecrets []string
+}
+
+func (d scrubbedDiagnostic) Description() Description {
+	desc := d.Diagnostic.Description()
+	for _, secret := range d.knownSecrets {
+		desc.Summary = strings.Replace(desc.Summary, secret, scrubbedSecretPlaceholder, -1)
+		desc.Detail = strings.Replace(desc.Detail, secret, scrubbedSecretPlaceholder, -1)
+	}
+	return desc
+}
@@ -0,0 +1,39 @@
+package tfdiags
+
+import (
+	"testing"
+)
+
+func TestScrubSecrets(t *testing.T) {
+	var diags Diagnostics
+	diags = diags.Append(Sourceless(Error, "invalid credentials", "the server rejected token s3kr1t"))
+	diags = diags.Append(SimpleWarning("unrelated warning"))
+
+	got := ScrubSecrets(diags, []string{"s3kr1t"})
+
+	if len(got) != 2 {
+		t.Fatalf("wrong number of diagnostics: got %d, want 2\n%s", len(got), got.Err())
+	}
+
+	if got, want := got[0].Description().Detail, "the server rejected token (sensitive value)"; got != want {
+		t.Errorf("secret was not scrubbed\ngot:  %s\nwant: %s", got, want)
+	}
+	if got, want := got[1].Description().Summary, "unrelated warning"; got != want {
+		t.Errorf("unrelated diagnostic was modified\ngot:  %s\nwant: %s", got, want)
+	}
+}
+
+func TestScrubSecrets_empty(t *testing.T) {
+	var diags Diagnostics
+	diags = diags.Append(Sourceless(Error, "summary", "detail"))
+
+	got := ScrubSecrets(diags, nil)
+	if len(got) != 1 || got[0].Description().Detail != "detail" {
+		t.Errorf("diagnostics were modified even though no known secrets were given: %#v", got)
+	}
+
+	got = ScrubSecrets(diags, []string{""})
+	if len(got) != 1 || got[0].Description().Detail != "detail" {
+		t.Errorf("diagnostics were modified by an empty known secret: %#v", got)
+	}
+}